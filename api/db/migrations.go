@@ -0,0 +1,33 @@
+// Package db embeds the SQL migrations, applied with golang-migrate, so the
+// server can tell which schema version it expects.
+package db
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+//go:embed migrations/*.up.sql
+var migrations embed.FS
+
+// LatestMigration returns the version of the newest migration
+func LatestMigration() (uint, error) {
+	names, err := fs.Glob(migrations, "migrations/*.up.sql")
+	if err != nil {
+		return 0, err
+	}
+
+	var latest uint
+	for _, name := range names {
+		prefix, _, _ := strings.Cut(strings.TrimPrefix(name, "migrations/"), "_")
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("migration %s: invalid version", name)
+		}
+		latest = max(latest, uint(version))
+	}
+	return latest, nil
+}
@@ -0,0 +1 @@
+ALTER TABLE "verification" ALTER COLUMN value TYPE VARCHAR(255);
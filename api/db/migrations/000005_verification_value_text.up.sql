@@ -0,0 +1,2 @@
+-- OAuth state holds the PKCE verifier, nonce and redirect URLs
+ALTER TABLE "verification" ALTER COLUMN value TYPE TEXT;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "twoFactor";
+
+ALTER TABLE "user" DROP COLUMN IF EXISTS "twoFactorEnabled";
@@ -0,0 +1,11 @@
+ALTER TABLE "user"
+ADD COLUMN "twoFactorEnabled" BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Two factor table (better-auth twoFactor plugin)
+CREATE TABLE "twoFactor" (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    "userId" UUID NOT NULL REFERENCES "user" (id) ON DELETE CASCADE,
+    secret TEXT NOT NULL,
+    "backupCodes" TEXT NOT NULL,
+    UNIQUE ("userId")
+);
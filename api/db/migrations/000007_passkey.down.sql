@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_passkey_user_id;
+
+DROP TABLE IF EXISTS "passkey";
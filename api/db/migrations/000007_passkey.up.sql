@@ -0,0 +1,17 @@
+-- Passkey table (better-auth passkey plugin)
+CREATE TABLE "passkey" (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    name TEXT,
+    "publicKey" TEXT NOT NULL,
+    "userId" UUID NOT NULL REFERENCES "user" (id) ON DELETE CASCADE,
+    "credentialID" TEXT UNIQUE NOT NULL,
+    counter INTEGER NOT NULL DEFAULT 0,
+    "deviceType" TEXT NOT NULL,
+    "backedUp" BOOLEAN NOT NULL DEFAULT FALSE,
+    transports TEXT,
+    aaguid TEXT,
+    "createdAt" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Indexes
+CREATE INDEX idx_passkey_user_id ON "passkey" ("userId");
@@ -0,0 +1,16 @@
+DROP INDEX IF EXISTS idx_project_organization_slug;
+DROP INDEX IF EXISTS idx_project_organization_id;
+
+-- Organization projects have no user to fall back to
+DELETE FROM "project" WHERE "userId" IS NULL;
+
+ALTER TABLE "project"
+DROP CONSTRAINT IF EXISTS project_owner_check,
+ALTER COLUMN "userId" SET NOT NULL,
+DROP COLUMN IF EXISTS "organizationId";
+
+ALTER TABLE "session" DROP COLUMN IF EXISTS "activeOrganizationId";
+
+DROP TABLE IF EXISTS "invitation";
+DROP TABLE IF EXISTS "member";
+DROP TABLE IF EXISTS "organization";
@@ -0,0 +1,48 @@
+-- Organization tables (better-auth organization plugin)
+CREATE TABLE "organization" (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    name VARCHAR(255) NOT NULL,
+    slug VARCHAR(255) UNIQUE NOT NULL,
+    logo TEXT,
+    metadata TEXT,
+    "createdAt" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE "member" (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    "organizationId" UUID NOT NULL REFERENCES "organization" (id) ON DELETE CASCADE,
+    "userId" UUID NOT NULL REFERENCES "user" (id) ON DELETE CASCADE,
+    role VARCHAR(50) NOT NULL DEFAULT 'member',
+    "createdAt" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE ("organizationId", "userId")
+);
+
+CREATE TABLE "invitation" (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    "organizationId" UUID NOT NULL REFERENCES "organization" (id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    role VARCHAR(50) NOT NULL,
+    status VARCHAR(50) NOT NULL DEFAULT 'pending',
+    "expiresAt" TIMESTAMPTZ NOT NULL,
+    "inviterId" UUID NOT NULL REFERENCES "user" (id) ON DELETE CASCADE,
+    "createdAt" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE "session"
+ADD COLUMN "activeOrganizationId" UUID REFERENCES "organization" (id) ON DELETE SET NULL;
+
+-- Projects are owned by a user or an organization. Existing rows keep their
+-- user owner and can be moved into an organization later.
+ALTER TABLE "project"
+ADD COLUMN "organizationId" UUID REFERENCES "organization" (id) ON DELETE CASCADE,
+ALTER COLUMN "userId" DROP NOT NULL,
+ADD CONSTRAINT project_owner_check CHECK (
+    ("userId" IS NULL) <> ("organizationId" IS NULL)
+);
+
+-- Indexes
+CREATE INDEX idx_member_user_id ON "member" ("userId");
+CREATE INDEX idx_invitation_organization_id ON "invitation" ("organizationId");
+CREATE INDEX idx_invitation_email ON "invitation" (email);
+CREATE INDEX idx_project_organization_id ON "project" ("organizationId");
+CREATE UNIQUE INDEX idx_project_organization_slug ON "project" ("organizationId", slug);
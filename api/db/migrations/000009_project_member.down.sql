@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_project_member_user_id;
+
+DROP TABLE IF EXISTS "projectMember";
@@ -0,0 +1,13 @@
+-- Per-project role grants. They sit on top of the role a user gets from
+-- organization membership; the higher of the two applies.
+CREATE TABLE "projectMember" (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    "projectId" UUID NOT NULL REFERENCES "project" (id) ON DELETE CASCADE,
+    "userId" UUID NOT NULL REFERENCES "user" (id) ON DELETE CASCADE,
+    role VARCHAR(50) NOT NULL,
+    "createdAt" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE ("projectId", "userId")
+);
+
+-- Indexes
+CREATE INDEX idx_project_member_user_id ON "projectMember" ("userId");
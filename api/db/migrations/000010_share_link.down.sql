@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_share_link_project_id;
+
+DROP TABLE IF EXISTS "shareLink";
@@ -0,0 +1,19 @@
+-- Links that give anonymous reviewers access to a project, or to one
+-- document of it, with a viewer or commenter role
+CREATE TABLE "shareLink" (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    "projectId" UUID NOT NULL REFERENCES "project" (id) ON DELETE CASCADE,
+    "documentId" UUID,
+    token VARCHAR(255) UNIQUE NOT NULL,
+    role VARCHAR(50) NOT NULL,
+    "passwordHash" TEXT,
+    "expiresAt" TIMESTAMPTZ,
+    "maxUses" INTEGER,
+    "useCount" INTEGER NOT NULL DEFAULT 0,
+    "createdBy" UUID NOT NULL REFERENCES "user" (id) ON DELETE CASCADE,
+    "revokedAt" TIMESTAMPTZ,
+    "createdAt" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Indexes
+CREATE INDEX idx_share_link_project_id ON "shareLink" ("projectId");
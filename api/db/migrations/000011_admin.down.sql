@@ -0,0 +1,7 @@
+ALTER TABLE "session" DROP COLUMN IF EXISTS "impersonatedBy";
+
+ALTER TABLE "user"
+DROP COLUMN IF EXISTS "banExpires",
+DROP COLUMN IF EXISTS "banReason",
+DROP COLUMN IF EXISTS banned,
+DROP COLUMN IF EXISTS role;
@@ -0,0 +1,9 @@
+-- Admin plugin columns (better-auth admin plugin)
+ALTER TABLE "user"
+ADD COLUMN role VARCHAR(255) NOT NULL DEFAULT 'user',
+ADD COLUMN banned BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN "banReason" TEXT,
+ADD COLUMN "banExpires" TIMESTAMPTZ;
+
+ALTER TABLE "session"
+ADD COLUMN "impersonatedBy" UUID REFERENCES "user" (id) ON DELETE CASCADE;
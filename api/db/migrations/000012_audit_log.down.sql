@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_audit_log_target;
+DROP INDEX IF EXISTS idx_audit_log_actor_id;
+DROP INDEX IF EXISTS idx_audit_log_created_at;
+
+DROP TRIGGER IF EXISTS audit_log_append_only ON "auditLog";
+DROP FUNCTION IF EXISTS audit_log_append_only ();
+
+DROP TABLE IF EXISTS "auditLog";
@@ -0,0 +1,30 @@
+-- Append-only audit log of security relevant actions. Actor and target ids
+-- are not foreign keys so entries outlive the users and projects they name.
+CREATE TABLE "auditLog" (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    action VARCHAR(100) NOT NULL,
+    "actorId" UUID,
+    "targetType" VARCHAR(50),
+    "targetId" VARCHAR(255),
+    "ipAddress" VARCHAR(45),
+    "userAgent" TEXT,
+    "requestId" VARCHAR(255),
+    diff JSONB NOT NULL DEFAULT '{}',
+    "createdAt" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE FUNCTION audit_log_append_only () RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'auditLog is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only BEFORE
+UPDATE
+OR DELETE ON "auditLog" FOR EACH ROW
+EXECUTE FUNCTION audit_log_append_only ();
+
+-- Indexes
+CREATE INDEX idx_audit_log_created_at ON "auditLog" ("createdAt" DESC, id DESC);
+CREATE INDEX idx_audit_log_actor_id ON "auditLog" ("actorId", "createdAt" DESC);
+CREATE INDEX idx_audit_log_target ON "auditLog" ("targetType", "targetId", "createdAt" DESC);
@@ -0,0 +1,9 @@
+CREATE OR REPLACE FUNCTION audit_log_append_only () RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'auditLog is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP INDEX IF EXISTS idx_user_deletion_scheduled_at;
+
+ALTER TABLE "user" DROP COLUMN IF EXISTS "deletionScheduledAt";
@@ -0,0 +1,29 @@
+-- Accounts scheduled for deletion are purged once the grace period ends
+ALTER TABLE "user"
+ADD COLUMN "deletionScheduledAt" TIMESTAMPTZ;
+
+-- Audit entries stay append-only, but the personal data they hold can be
+-- erased: an update may only clear the IP address, user agent and diff.
+CREATE OR REPLACE FUNCTION audit_log_append_only () RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE'
+        AND NEW.id = OLD.id
+        AND NEW.action = OLD.action
+        AND NEW."actorId" IS NOT DISTINCT FROM OLD."actorId"
+        AND NEW."targetType" IS NOT DISTINCT FROM OLD."targetType"
+        AND NEW."targetId" IS NOT DISTINCT FROM OLD."targetId"
+        AND NEW."requestId" IS NOT DISTINCT FROM OLD."requestId"
+        AND NEW."createdAt" = OLD."createdAt"
+        AND (NEW."ipAddress" IS NULL OR NEW."ipAddress" = OLD."ipAddress")
+        AND (NEW."userAgent" IS NULL OR NEW."userAgent" = OLD."userAgent")
+        AND (NEW.diff = '{}' OR NEW.diff = OLD.diff) THEN
+        RETURN NEW;
+    END IF;
+    RAISE EXCEPTION 'auditLog is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+-- Indexes
+CREATE INDEX idx_user_deletion_scheduled_at ON "user" ("deletionScheduledAt")
+WHERE
+    "deletionScheduledAt" IS NOT NULL;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_asset_sha256;
+
+DROP INDEX IF EXISTS idx_asset_user_id_created_at;
+
+DROP TABLE IF EXISTS "asset";
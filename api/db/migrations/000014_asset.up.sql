@@ -0,0 +1,19 @@
+-- Files uploaded by users. The content is stored once per sha256 hash, so
+-- several assets may share the same blob.
+CREATE TABLE "asset" (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    "userId" UUID NOT NULL REFERENCES "user" (id) ON DELETE CASCADE,
+    purpose VARCHAR(50) NOT NULL,
+    filename VARCHAR(255) NOT NULL,
+    "contentType" VARCHAR(255) NOT NULL,
+    size BIGINT NOT NULL,
+    sha256 VARCHAR(64) NOT NULL,
+    width INTEGER,
+    height INTEGER,
+    "createdAt" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Indexes
+CREATE INDEX idx_asset_user_id_created_at ON "asset" ("userId", "createdAt" DESC);
+
+CREATE INDEX idx_asset_sha256 ON "asset" (sha256);
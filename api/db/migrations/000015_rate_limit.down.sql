@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_lockout_last_failure_at;
+
+DROP INDEX IF EXISTS idx_rate_limit_updated_at;
+
+DROP TABLE IF EXISTS "lockout";
+
+DROP TABLE IF EXISTS "rateLimit";
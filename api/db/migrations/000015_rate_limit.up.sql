@@ -0,0 +1,19 @@
+-- Token buckets of the PostgreSQL rate limit backend, shared by every
+-- instance of the API
+CREATE TABLE "rateLimit" (
+    key VARCHAR(255) PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    "updatedAt" TIMESTAMPTZ NOT NULL
+);
+
+-- Consecutive failed sign-ins by account, which lock the account out
+CREATE TABLE "lockout" (
+    key VARCHAR(255) PRIMARY KEY,
+    failures INTEGER NOT NULL,
+    "lastFailureAt" TIMESTAMPTZ NOT NULL
+);
+
+-- Indexes
+CREATE INDEX idx_rate_limit_updated_at ON "rateLimit" ("updatedAt");
+
+CREATE INDEX idx_lockout_last_failure_at ON "lockout" ("lastFailureAt");
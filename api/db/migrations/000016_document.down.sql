@@ -0,0 +1,9 @@
+ALTER TABLE "shareLink" DROP CONSTRAINT IF EXISTS "shareLink_documentId_fkey";
+
+DROP INDEX IF EXISTS idx_document_comment_document_id;
+
+DROP INDEX IF EXISTS idx_document_project_id;
+
+DROP TABLE IF EXISTS "documentComment";
+
+DROP TABLE IF EXISTS "document";
@@ -0,0 +1,34 @@
+-- Documents of a project
+CREATE TABLE "document" (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    "projectId" UUID NOT NULL REFERENCES "project" (id) ON DELETE CASCADE,
+    title VARCHAR(255) NOT NULL,
+    content TEXT NOT NULL DEFAULT '',
+    "createdBy" UUID REFERENCES "user" (id) ON DELETE SET NULL,
+    "createdAt" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Comments on a document, by a member of the project or by a reviewer
+-- through a share link
+CREATE TABLE "documentComment" (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    "documentId" UUID NOT NULL REFERENCES "document" (id) ON DELETE CASCADE,
+    "userId" UUID REFERENCES "user" (id) ON DELETE CASCADE,
+    "shareLinkId" UUID REFERENCES "shareLink" (id) ON DELETE SET NULL,
+    "authorName" VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    "createdAt" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Share links could name any document before documents existed; those
+-- links point nowhere and are dropped
+DELETE FROM "shareLink" WHERE "documentId" IS NOT NULL;
+
+ALTER TABLE "shareLink"
+ADD CONSTRAINT "shareLink_documentId_fkey" FOREIGN KEY ("documentId") REFERENCES "document" (id) ON DELETE CASCADE;
+
+-- Indexes
+CREATE INDEX idx_document_project_id ON "document" ("projectId");
+
+CREATE INDEX idx_document_comment_document_id ON "documentComment" ("documentId", "createdAt");
@@ -0,0 +1 @@
+ALTER TABLE "twoFactor" DROP COLUMN IF EXISTS "lastUsedStep";
@@ -0,0 +1,4 @@
+-- The time step of the last accepted TOTP code, so a code can't be replayed
+-- while it is still valid
+ALTER TABLE "twoFactor"
+ADD COLUMN "lastUsedStep" BIGINT NOT NULL DEFAULT 0;
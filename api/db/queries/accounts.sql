@@ -30,4 +30,54 @@ SELECT * FROM "account" WHERE "userId" = $1 AND "providerId" = $2;
 SELECT * FROM "account" WHERE id = $1;
 
 -- name: DeleteAccount :one
-DELETE FROM "account" WHERE id = $1 RETURNING *;
\ No newline at end of file
+DELETE FROM "account" WHERE id = $1 RETURNING *;
+
+-- name: GetAccountByProviderIdAndAccountId :one
+SELECT * FROM "account" WHERE "providerId" = $1 AND "accountId" = $2;
+
+-- name: ListAccountsByUserId :many
+SELECT * FROM "account" WHERE "userId" = $1 ORDER BY "createdAt";
+
+-- name: CountAccountsByUserId :one
+SELECT COUNT(*) FROM "account" WHERE "userId" = $1;
+
+-- name: CreateOAuthAccount :one
+INSERT INTO
+    "account" (
+        "userId",
+        "accountId",
+        "providerId",
+        "accessToken",
+        "refreshToken",
+        "idToken",
+        "accessTokenExpiresAt",
+        "refreshTokenExpiresAt",
+        scope
+    )
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING
+    *;
+
+-- name: UpdateAccountTokens :one
+UPDATE "account"
+SET
+    "accessToken" = $2,
+    "refreshToken" = $3,
+    "idToken" = $4,
+    "accessTokenExpiresAt" = $5,
+    "refreshTokenExpiresAt" = $6,
+    scope = $7,
+    "updatedAt" = CURRENT_TIMESTAMP
+WHERE
+    id = $1
+RETURNING
+    *;
+
+-- name: UpdateAccountIdByUserIdAndProvider :execrows
+UPDATE "account"
+SET
+    "accountId" = $3,
+    "updatedAt" = CURRENT_TIMESTAMP
+WHERE
+    "userId" = $1
+    AND "providerId" = $2;
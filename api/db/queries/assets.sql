@@ -0,0 +1,41 @@
+-- name: CreateAsset :one
+INSERT INTO
+    "asset" (
+        "userId",
+        purpose,
+        filename,
+        "contentType",
+        size,
+        sha256,
+        width,
+        height
+    )
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING
+    *;
+
+-- name: GetAssetByID :one
+SELECT * FROM "asset" WHERE id = $1;
+
+-- name: ListAssetsByUserId :many
+SELECT *
+FROM "asset"
+WHERE
+    "userId" = $1
+ORDER BY "createdAt" DESC;
+
+-- name: DeleteAsset :one
+DELETE FROM "asset"
+WHERE
+    id = $1
+    AND "userId" = $2
+RETURNING
+    *;
+
+-- name: CountAssetsBySha256 :one
+SELECT COUNT(*) FROM "asset" WHERE sha256 = $1;
+
+-- name: LockAssetSha256 :exec
+-- Serialises storing and releasing the blob of a hash until the transaction
+-- ends
+SELECT pg_advisory_xact_lock(hashtext(sqlc.arg(sha256)::text));
@@ -0,0 +1,85 @@
+-- name: CreateAuditLog :one
+INSERT INTO
+    "auditLog" (
+        action,
+        "actorId",
+        "targetType",
+        "targetId",
+        "ipAddress",
+        "userAgent",
+        "requestId",
+        diff
+    )
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING
+    *;
+
+-- name: ListAuditLogs :many
+SELECT *
+FROM "auditLog"
+WHERE (
+        sqlc.narg(action)::text IS NULL
+        OR action = sqlc.narg(action)::text
+    )
+    AND (
+        sqlc.narg(actor_id)::uuid IS NULL
+        OR "actorId" = sqlc.narg(actor_id)::uuid
+    )
+    AND (
+        sqlc.narg(target_type)::text IS NULL
+        OR "targetType" = sqlc.narg(target_type)::text
+    )
+    AND (
+        sqlc.narg(target_id)::text IS NULL
+        OR "targetId" = sqlc.narg(target_id)::text
+    )
+    AND (
+        sqlc.narg(since)::timestamptz IS NULL
+        OR "createdAt" >= sqlc.narg(since)::timestamptz
+    )
+    AND (
+        sqlc.narg(until)::timestamptz IS NULL
+        OR "createdAt" < sqlc.narg(until)::timestamptz
+    )
+    AND (
+        sqlc.narg(cursor_created_at)::timestamptz IS NULL
+        OR ("createdAt", id) < (
+            sqlc.narg(cursor_created_at)::timestamptz,
+            sqlc.narg(cursor_id)::uuid
+        )
+    )
+ORDER BY "createdAt" DESC, id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: ListAuditLogsByUser :many
+SELECT *
+FROM "auditLog"
+WHERE (
+        "actorId" = sqlc.arg(user_id)::uuid
+        OR (
+            "targetType" = 'user'
+            AND "targetId" = sqlc.arg(user_id)::uuid::text
+        )
+    )
+    AND (
+        sqlc.narg(cursor_created_at)::timestamptz IS NULL
+        OR ("createdAt", id) < (
+            sqlc.narg(cursor_created_at)::timestamptz,
+            sqlc.narg(cursor_id)::uuid
+        )
+    )
+ORDER BY "createdAt" DESC, id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: ScrubAuditLogsByUser :execrows
+UPDATE "auditLog"
+SET
+    "ipAddress" = NULL,
+    "userAgent" = NULL,
+    diff = '{}'
+WHERE
+    "actorId" = sqlc.arg(user_id)::uuid
+    OR (
+        "targetType" = 'user'
+        AND "targetId" = sqlc.arg(user_id)::uuid::text
+    );
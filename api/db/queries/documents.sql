@@ -0,0 +1,55 @@
+-- name: CreateDocument :one
+INSERT INTO
+    "document" (
+        "projectId",
+        title,
+        content,
+        "createdBy"
+    )
+VALUES ($1, $2, $3, $4)
+RETURNING
+    *;
+
+-- name: GetDocumentByIDAndProjectID :one
+SELECT * FROM "document" WHERE id = $1 AND "projectId" = $2;
+
+-- name: ListDocumentsByProjectID :many
+SELECT *
+FROM "document"
+WHERE
+    "projectId" = $1
+ORDER BY "updatedAt" DESC;
+
+-- name: UpdateDocument :one
+UPDATE "document"
+SET
+    title = $2,
+    content = $3,
+    "updatedAt" = CURRENT_TIMESTAMP
+WHERE
+    id = $1
+RETURNING
+    *;
+
+-- name: DeleteDocument :execrows
+DELETE FROM "document" WHERE id = $1;
+
+-- name: CreateDocumentComment :one
+INSERT INTO
+    "documentComment" (
+        "documentId",
+        "userId",
+        "shareLinkId",
+        "authorName",
+        body
+    )
+VALUES ($1, $2, $3, $4, $5)
+RETURNING
+    *;
+
+-- name: ListDocumentComments :many
+SELECT *
+FROM "documentComment"
+WHERE
+    "documentId" = $1
+ORDER BY "createdAt";
@@ -0,0 +1,37 @@
+-- name: CreateInvitation :one
+INSERT INTO
+    "invitation" (
+        "organizationId",
+        email,
+        role,
+        "expiresAt",
+        "inviterId"
+    )
+VALUES ($1, $2, $3, $4, $5)
+RETURNING
+    *;
+
+-- name: GetInvitationByID :one
+SELECT * FROM "invitation" WHERE id = $1;
+
+-- name: GetPendingInvitation :one
+SELECT *
+FROM "invitation"
+WHERE
+    "organizationId" = $1
+    AND email = $2
+    AND status = 'pending'
+    AND "expiresAt" > NOW();
+
+-- name: ListInvitationsByOrganizationId :many
+SELECT *
+FROM "invitation"
+WHERE
+    "organizationId" = $1
+ORDER BY "createdAt" DESC;
+
+-- name: UpdateInvitationStatus :one
+UPDATE "invitation" SET status = $2 WHERE id = $1 RETURNING *;
+
+-- name: UpdateInvitationExpiry :one
+UPDATE "invitation" SET "expiresAt" = $2 WHERE id = $1 RETURNING *;
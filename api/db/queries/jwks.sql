@@ -7,4 +7,24 @@ SELECT
 FROM jwks
 WHERE
     "expiresAt" IS NULL
-    OR "expiresAt" > NOW();
\ No newline at end of file
+    OR "expiresAt" > NOW();
+
+-- name: ListJwks :many
+SELECT *
+FROM jwks
+WHERE
+    "expiresAt" IS NULL
+    OR "expiresAt" > NOW()
+ORDER BY "createdAt" DESC;
+
+-- name: CreateJwksWithId :one
+INSERT INTO
+    jwks (
+        id,
+        "publicKey",
+        "privateKey",
+        "expiresAt"
+    )
+VALUES ($1, $2, $3, $4)
+RETURNING
+    *;
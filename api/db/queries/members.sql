@@ -0,0 +1,30 @@
+-- name: CreateMember :one
+INSERT INTO
+    "member" (
+        "organizationId",
+        "userId",
+        role
+    )
+VALUES ($1, $2, $3)
+RETURNING
+    *;
+
+-- name: GetMemberByID :one
+SELECT * FROM "member" WHERE id = $1;
+
+-- name: GetMemberByOrganizationIdAndUserId :one
+SELECT * FROM "member" WHERE "organizationId" = $1 AND "userId" = $2;
+
+-- name: ListMembersByOrganizationId :many
+SELECT m.id, m."organizationId", m."userId", m.role, m."createdAt", u.name, u.email, u.image
+FROM "member" m
+    JOIN "user" u ON u.id = m."userId"
+WHERE
+    m."organizationId" = $1
+ORDER BY m."createdAt";
+
+-- name: UpdateMemberRole :one
+UPDATE "member" SET role = $2 WHERE id = $1 RETURNING *;
+
+-- name: CountMembersByOrganizationIdAndRole :one
+SELECT COUNT(*) FROM "member" WHERE "organizationId" = $1 AND role = $2;
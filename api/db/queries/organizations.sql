@@ -0,0 +1,37 @@
+-- name: CreateOrganization :one
+INSERT INTO
+    "organization" (
+        name,
+        slug,
+        logo,
+        metadata
+    )
+VALUES ($1, $2, $3, $4)
+RETURNING
+    *;
+
+-- name: GetOrganizationByID :one
+SELECT * FROM "organization" WHERE id = $1;
+
+-- name: GetOrganizationBySlug :one
+SELECT * FROM "organization" WHERE slug = $1;
+
+-- name: ListOrganizationsByUserId :many
+SELECT o.*
+FROM "organization" o
+    JOIN "member" m ON m."organizationId" = o.id
+WHERE
+    m."userId" = $1
+ORDER BY o."createdAt";
+
+-- name: UpdateOrganization :one
+UPDATE "organization"
+SET
+    name = $2,
+    slug = $3,
+    logo = $4,
+    metadata = $5
+WHERE
+    id = $1
+RETURNING
+    *;
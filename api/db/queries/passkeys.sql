@@ -0,0 +1,38 @@
+-- name: CreatePasskey :one
+INSERT INTO
+    "passkey" (
+        name,
+        "publicKey",
+        "userId",
+        "credentialID",
+        counter,
+        "deviceType",
+        "backedUp",
+        transports,
+        aaguid
+    )
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING
+    *;
+
+-- name: ListPasskeysByUserId :many
+SELECT * FROM "passkey" WHERE "userId" = $1 ORDER BY "createdAt";
+
+-- name: GetPasskeyByCredentialId :one
+SELECT * FROM "passkey" WHERE "credentialID" = $1;
+
+-- name: UpdatePasskeyCounter :exec
+UPDATE "passkey" SET counter = $2, "backedUp" = $3 WHERE id = $1;
+
+-- name: UpdatePasskeyName :one
+UPDATE "passkey"
+SET
+    name = $3
+WHERE
+    id = $1
+    AND "userId" = $2
+RETURNING
+    *;
+
+-- name: DeletePasskey :execrows
+DELETE FROM "passkey" WHERE id = $1 AND "userId" = $2;
@@ -0,0 +1,27 @@
+-- name: GetProjectMember :one
+SELECT * FROM "projectMember" WHERE "projectId" = $1 AND "userId" = $2;
+
+-- name: ListProjectMembersByProjectId :many
+SELECT pm.id, pm."projectId", pm."userId", pm.role, pm."createdAt", u.name, u.email, u.image
+FROM "projectMember" pm
+    JOIN "user" u ON u.id = pm."userId"
+WHERE
+    pm."projectId" = $1
+ORDER BY pm."createdAt";
+
+-- name: UpsertProjectMember :one
+INSERT INTO
+    "projectMember" (
+        "projectId",
+        "userId",
+        role
+    )
+VALUES ($1, $2, $3)
+ON CONFLICT ("projectId", "userId") DO UPDATE
+SET
+    role = EXCLUDED.role
+RETURNING
+    *;
+
+-- name: DeleteProjectMember :execrows
+DELETE FROM "projectMember" WHERE "projectId" = $1 AND "userId" = $2;
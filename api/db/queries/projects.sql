@@ -52,4 +52,80 @@ RETURNING
 DELETE FROM "project" WHERE id = $1 RETURNING *;
 
 -- name: DeleteProjectsByUserID :exec
-DELETE FROM "project" WHERE "userId" = $1;
\ No newline at end of file
+DELETE FROM "project" WHERE "userId" = $1;
+
+-- name: CreateOrganizationProject :one
+INSERT INTO
+    "project" (
+        "organizationId",
+        name,
+        slug,
+        description
+    )
+VALUES ($1, $2, $3, $4)
+RETURNING
+    *;
+
+-- name: GetProjectByOrganizationIDAndSlug :one
+SELECT * FROM "project" WHERE "organizationId" = $1 AND slug = $2;
+
+-- name: ListProjectsByOrganizationID :many
+SELECT *
+FROM "project"
+WHERE
+    "organizationId" = $1
+ORDER BY "createdAt" DESC;
+
+-- name: MoveProjectToOrganization :one
+UPDATE "project"
+SET
+    "userId" = NULL,
+    "organizationId" = $2,
+    "updatedAt" = CURRENT_TIMESTAMP
+WHERE
+    id = $1
+RETURNING
+    *;
+
+-- name: CountProjectsSolelyOwnedByUser :one
+-- Organization projects on which the user is the only owner, through the
+-- organization role or a project grant. Deleting the user would leave them
+-- without one.
+SELECT COUNT(*)
+FROM "project" p
+WHERE
+    p."organizationId" IS NOT NULL
+    AND (
+        EXISTS (
+            SELECT 1
+            FROM "member" m
+            WHERE
+                m."organizationId" = p."organizationId"
+                AND m."userId" = $1
+                AND m.role = 'owner'
+        )
+        OR EXISTS (
+            SELECT 1
+            FROM "projectMember" pm
+            WHERE
+                pm."projectId" = p.id
+                AND pm."userId" = $1
+                AND pm.role = 'owner'
+        )
+    )
+    AND NOT EXISTS (
+        SELECT 1
+        FROM "member" m
+        WHERE
+            m."organizationId" = p."organizationId"
+            AND m."userId" <> $1
+            AND m.role = 'owner'
+    )
+    AND NOT EXISTS (
+        SELECT 1
+        FROM "projectMember" pm
+        WHERE
+            pm."projectId" = p.id
+            AND pm."userId" <> $1
+            AND pm.role = 'owner'
+    );
@@ -0,0 +1,41 @@
+-- name: CreateRateLimit :exec
+INSERT INTO
+    "rateLimit" (key, tokens, "updatedAt")
+VALUES ($1, $2, $3)
+ON CONFLICT (key) DO NOTHING;
+
+-- name: GetRateLimitForUpdate :one
+SELECT * FROM "rateLimit" WHERE key = $1 FOR UPDATE;
+
+-- name: UpdateRateLimit :exec
+UPDATE "rateLimit" SET tokens = $2, "updatedAt" = $3 WHERE key = $1;
+
+-- name: DeleteIdleRateLimits :execrows
+DELETE FROM "rateLimit" WHERE "updatedAt" < $1;
+
+-- name: RecordLockoutFailure :one
+INSERT INTO
+    "lockout" (key, failures, "lastFailureAt")
+VALUES (
+        sqlc.arg(key),
+        1,
+        sqlc.arg(failed_at)
+    )
+ON CONFLICT (key) DO UPDATE
+SET
+    failures = CASE
+        WHEN "lockout"."lastFailureAt" < sqlc.arg(reset_before)::timestamptz THEN 1
+        ELSE "lockout".failures + 1
+    END,
+    "lastFailureAt" = EXCLUDED."lastFailureAt"
+RETURNING
+    *;
+
+-- name: GetLockout :one
+SELECT * FROM "lockout" WHERE key = $1;
+
+-- name: DeleteLockout :exec
+DELETE FROM "lockout" WHERE key = $1;
+
+-- name: DeleteIdleLockouts :execrows
+DELETE FROM "lockout" WHERE "lastFailureAt" < $1;
@@ -68,4 +68,36 @@ DELETE FROM "session" WHERE id = $1 RETURNING *;
 DELETE FROM "session" WHERE "userId" = $1;
 
 -- name: GetSessionByToken :one
-SELECT * FROM "session" WHERE token = $1;
\ No newline at end of file
+SELECT * FROM "session" WHERE token = $1;
+
+-- name: SetSessionActiveOrganization :one
+UPDATE "session"
+SET
+    "activeOrganizationId" = $2,
+    "updatedAt" = CURRENT_TIMESTAMP
+WHERE
+    id = $1
+RETURNING
+    *;
+
+-- name: CreateImpersonationSession :one
+INSERT INTO
+    "session" (
+        token,
+        "userId",
+        "expiresAt",
+        "ipAddress",
+        "userAgent",
+        "impersonatedBy"
+    )
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING
+    *;
+
+-- name: ListSessionsByUserId :many
+SELECT *
+FROM "session"
+WHERE
+    "userId" = $1
+    AND "expiresAt" > CURRENT_TIMESTAMP
+ORDER BY "createdAt" DESC;
@@ -0,0 +1,53 @@
+-- name: CreateShareLink :one
+INSERT INTO
+    "shareLink" (
+        "projectId",
+        "documentId",
+        token,
+        role,
+        "passwordHash",
+        "expiresAt",
+        "maxUses",
+        "createdBy"
+    )
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING
+    *;
+
+-- name: GetShareLinkByID :one
+SELECT * FROM "shareLink" WHERE id = $1;
+
+-- name: GetShareLinkByToken :one
+SELECT * FROM "shareLink" WHERE token = $1;
+
+-- name: ListShareLinksByProjectId :many
+SELECT *
+FROM "shareLink"
+WHERE
+    "projectId" = $1
+ORDER BY "createdAt" DESC;
+
+-- name: UseShareLink :execrows
+UPDATE "shareLink"
+SET
+    "useCount" = "useCount" + 1
+WHERE
+    id = $1
+    AND "revokedAt" IS NULL
+    AND (
+        "expiresAt" IS NULL
+        OR "expiresAt" > CURRENT_TIMESTAMP
+    )
+    AND (
+        "maxUses" IS NULL
+        OR "useCount" < "maxUses"
+    );
+
+-- name: RevokeShareLink :execrows
+UPDATE "shareLink"
+SET
+    "revokedAt" = CURRENT_TIMESTAMP
+WHERE
+    id = $1
+    AND "projectId" = $2
+    AND "revokedAt" IS NULL;
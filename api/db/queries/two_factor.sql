@@ -0,0 +1,43 @@
+-- name: GetTwoFactorByUserId :one
+SELECT * FROM "twoFactor" WHERE "userId" = $1;
+
+-- name: UpsertTwoFactor :one
+INSERT INTO
+    "twoFactor" (
+        "userId",
+        secret,
+        "backupCodes"
+    )
+VALUES ($1, $2, $3)
+ON CONFLICT ("userId") DO UPDATE
+SET
+    secret = EXCLUDED.secret,
+    "backupCodes" = EXCLUDED."backupCodes"
+RETURNING
+    *;
+
+-- name: UpdateTwoFactorBackupCodes :exec
+UPDATE "twoFactor" SET "backupCodes" = $2 WHERE "userId" = $1;
+
+-- name: ReplaceTwoFactorBackupCodes :execrows
+-- Swaps the backup codes only if they are still the ones read, so a code
+-- can't be used by two concurrent requests
+UPDATE "twoFactor"
+SET
+    "backupCodes" = sqlc.arg(backup_codes)
+WHERE
+    "userId" = sqlc.arg(user_id)
+    AND "backupCodes" = sqlc.arg(previous_backup_codes);
+
+-- name: UseTwoFactorStep :execrows
+-- Records the time step of an accepted TOTP code only if it is newer than
+-- the last one, so a code works once even for concurrent requests
+UPDATE "twoFactor"
+SET
+    "lastUsedStep" = sqlc.arg(step)
+WHERE
+    "userId" = sqlc.arg(user_id)
+    AND "lastUsedStep" < sqlc.arg(step);
+
+-- name: DeleteTwoFactorByUserId :exec
+DELETE FROM "twoFactor" WHERE "userId" = $1;
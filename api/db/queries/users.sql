@@ -1,5 +1,20 @@
 -- name: ListUsers :many
-SELECT * FROM "user";
+SELECT *
+FROM "user"
+WHERE
+    email ILIKE sqlc.arg(email_pattern)::text
+    AND name ILIKE sqlc.arg(name_pattern)::text
+ORDER BY "createdAt" DESC
+LIMIT sqlc.arg(row_limit)
+OFFSET
+    sqlc.arg(row_offset);
+
+-- name: CountUsers :one
+SELECT COUNT(*)
+FROM "user"
+WHERE
+    email ILIKE sqlc.arg(email_pattern)::text
+    AND name ILIKE sqlc.arg(name_pattern)::text;
 
 -- name: CreateUser :one
 INSERT INTO
@@ -46,4 +61,87 @@ RETURNING
     *;
 
 -- name: DeleteUser :one
-DELETE FROM "user" WHERE id = $1 RETURNING *;
\ No newline at end of file
+DELETE FROM "user" WHERE id = $1 RETURNING *;
+
+-- name: SetUserTwoFactorEnabled :one
+UPDATE "user"
+SET
+    "twoFactorEnabled" = $2,
+    "updatedAt" = CURRENT_TIMESTAMP
+WHERE
+    id = $1
+RETURNING
+    *;
+
+-- name: SetUserRole :one
+UPDATE "user"
+SET
+    role = $2,
+    "updatedAt" = CURRENT_TIMESTAMP
+WHERE
+    id = $1
+RETURNING
+    *;
+
+-- name: BanUser :one
+UPDATE "user"
+SET
+    banned = TRUE,
+    "banReason" = $2,
+    "banExpires" = $3,
+    "updatedAt" = CURRENT_TIMESTAMP
+WHERE
+    id = $1
+RETURNING
+    *;
+
+-- name: UnbanUser :one
+UPDATE "user"
+SET
+    banned = FALSE,
+    "banReason" = NULL,
+    "banExpires" = NULL,
+    "updatedAt" = CURRENT_TIMESTAMP
+WHERE
+    id = $1
+RETURNING
+    *;
+
+-- name: ScheduleUserDeletion :one
+UPDATE "user"
+SET
+    "deletionScheduledAt" = $2,
+    "updatedAt" = CURRENT_TIMESTAMP
+WHERE
+    id = $1
+RETURNING
+    *;
+
+-- name: CancelUserDeletion :one
+UPDATE "user"
+SET
+    "deletionScheduledAt" = NULL,
+    "updatedAt" = CURRENT_TIMESTAMP
+WHERE
+    id = $1
+RETURNING
+    *;
+
+-- name: ListUsersDueForDeletion :many
+SELECT *
+FROM "user"
+WHERE
+    "deletionScheduledAt" <= CURRENT_TIMESTAMP
+ORDER BY "deletionScheduledAt"
+LIMIT $1;
+
+-- name: UpdateUserEmail :one
+UPDATE "user"
+SET
+    email = $2,
+    "emailVerified" = TRUE,
+    "updatedAt" = CURRENT_TIMESTAMP
+WHERE
+    id = $1
+RETURNING
+    *;
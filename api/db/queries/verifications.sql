@@ -0,0 +1,36 @@
+-- name: CreateVerification :one
+INSERT INTO
+    "verification" (
+        identifier,
+        value,
+        "expiresAt"
+    )
+VALUES ($1, $2, $3)
+RETURNING
+    *;
+
+-- name: ConsumeVerification :one
+DELETE FROM "verification"
+WHERE
+    identifier = $1
+    AND "expiresAt" > NOW()
+RETURNING
+    *;
+
+-- name: DeleteVerificationByIdentifier :exec
+DELETE FROM "verification" WHERE identifier = $1;
+
+-- name: GetVerificationByIdentifier :one
+SELECT * FROM "verification"
+WHERE
+    identifier = $1
+    AND "expiresAt" > NOW();
+
+-- name: UpdateVerificationValue :execrows
+UPDATE "verification"
+SET
+    value = sqlc.arg(new_value)::text,
+    "updatedAt" = CURRENT_TIMESTAMP
+WHERE
+    identifier = sqlc.arg(identifier)::text
+    AND value = sqlc.arg(old_value)::text;
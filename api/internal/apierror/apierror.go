@@ -0,0 +1,121 @@
+// Package apierror is the error model of the API. An error has a stable
+// code, an HTTP status, a message for users and optional details. It renders
+// in the better-auth JSON shape, or as RFC 9457 problem details for clients
+// that ask for application/problem+json, with the request id either way.
+package apierror
+
+import (
+	"errors"
+	"maps"
+	"net/http"
+	"strings"
+)
+
+// Common codes
+const (
+	CodeUnauthorized     = "UNAUTHORIZED"
+	CodeForbidden        = "FORBIDDEN"
+	CodeNotFound         = "NOT_FOUND"
+	CodeMethodNotAllowed = "METHOD_NOT_ALLOWED"
+	CodeInternal         = "INTERNAL_ERROR"
+)
+
+// Error is an error answered to the client
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	// Details are extra members for clients, such as the invalid field
+	Details map[string]any
+	// Err is the cause, for logs; it is never sent to clients
+	Err error
+}
+
+// New returns an error with the status, code and message
+func New(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Code + ": " + e.Err.Error()
+	}
+	return e.Code + ": " + e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// With returns a copy of the error with a detail added
+func (e *Error) With(key string, value any) *Error {
+	c := *e
+	c.Details = maps.Clone(e.Details)
+	if c.Details == nil {
+		c.Details = make(map[string]any)
+	}
+	c.Details[key] = value
+	return &c
+}
+
+// Wrap returns a copy of the error caused by err
+func (e *Error) Wrap(err error) *Error {
+	c := *e
+	c.Err = err
+	return &c
+}
+
+func Unauthorized() *Error {
+	return New(http.StatusUnauthorized, CodeUnauthorized, "Unauthorized")
+}
+
+func Forbidden() *Error {
+	return New(http.StatusForbidden, CodeForbidden, "Forbidden")
+}
+
+func NotFound() *Error {
+	return New(http.StatusNotFound, CodeNotFound, "Not found")
+}
+
+func MethodNotAllowed() *Error {
+	return New(http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+}
+
+// Internal returns a 500 error caused by err, whose message stays in logs
+func Internal(err error) *Error {
+	return New(http.StatusInternalServerError, CodeInternal, "Internal server error").Wrap(err)
+}
+
+// Invalid returns a 400 validation error of a request field. The field is
+// reported in the details, so forms can show the message next to it.
+func Invalid(code, field, message string) *Error {
+	return New(http.StatusBadRequest, code, message).With("field", field)
+}
+
+// As returns err as an Error, or an internal error caused by it
+func As(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return Internal(err)
+}
+
+// FromStatus returns the common error of an HTTP status, for middleware that
+// only decides the status
+func FromStatus(status int) *Error {
+	switch status {
+	case http.StatusUnauthorized:
+		return Unauthorized()
+	case http.StatusForbidden:
+		return Forbidden()
+	case http.StatusNotFound:
+		return NotFound()
+	case http.StatusMethodNotAllowed:
+		return MethodNotAllowed()
+	case http.StatusInternalServerError:
+		return Internal(nil)
+	}
+	text := http.StatusText(status)
+	return New(status, strings.ToUpper(strings.ReplaceAll(text, " ", "_")), text)
+}
@@ -0,0 +1,149 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	validation := Invalid("INVALID_NAME", "name", "Name must be between 1 and 255 characters")
+
+	t.Run("better-auth", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		rec.Header().Set(requestIDHeader, "req-1")
+		Write(rec, httptest.NewRequest(http.MethodPatch, "/api/user", nil), validation)
+
+		if rec.Code != http.StatusBadRequest || rec.Header().Get("Content-Type") != ContentTypeJSON {
+			t.Fatalf("status = %d, Content-Type %q", rec.Code, rec.Header().Get("Content-Type"))
+		}
+		want := `{"error":{"code":"INVALID_NAME","message":"Name must be between 1 and 255 characters","details":{"field":"name"},"requestId":"req-1"}}` + "\n"
+		if got := rec.Body.String(); got != want {
+			t.Errorf("body = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("problem details", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPatch, "/api/user", nil)
+		req.Header.Set("Accept", "application/problem+json, application/json;q=0.9")
+		Write(rec, req, validation)
+
+		if rec.Header().Get("Content-Type") != ContentTypeProblem {
+			t.Fatalf("Content-Type = %q", rec.Header().Get("Content-Type"))
+		}
+		var got map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]any{
+			"type":     "about:blank",
+			"title":    "Bad Request",
+			"status":   float64(http.StatusBadRequest),
+			"detail":   "Name must be between 1 and 255 characters",
+			"instance": "/api/user",
+			"code":     "INVALID_NAME",
+		}
+		for key, value := range want {
+			if got[key] != value {
+				t.Errorf("%s = %v, want %v", key, got[key], value)
+			}
+		}
+		if _, ok := got["requestId"]; ok {
+			t.Error("requestId sent without a request id")
+		}
+	})
+
+	t.Run("internal errors hide the cause", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		Write(rec, httptest.NewRequest(http.MethodGet, "/", nil), errors.New("connection refused"))
+
+		want := `{"error":{"code":"INTERNAL_ERROR","message":"Internal server error"}}` + "\n"
+		if rec.Code != http.StatusInternalServerError || rec.Body.String() != want {
+			t.Errorf("response = %d %s", rec.Code, rec.Body.String())
+		}
+	})
+}
+
+func TestPrefersProblem(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{"", false},
+		{"*/*", false},
+		{"application/json", false},
+		{"application/problem+json", true},
+		{"application/json, application/problem+json", true},
+		{"application/problem+json;q=0.5, application/json", false},
+		{"application/problem+json;q=0.5, */*;q=0.1", true},
+		{"application/problem+json;q=0", false},
+	}
+	for _, tt := range tests {
+		if got := PrefersProblem(tt.accept); got != tt.want {
+			t.Errorf("PrefersProblem(%q) = %v, want %v", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestErrorWith(t *testing.T) {
+	base := New(http.StatusBadRequest, "INVALID", "Invalid")
+	e := base.With("field", "name")
+	if base.Details != nil || e.Details["field"] != "name" {
+		t.Errorf("With changed the original or lost the detail: %v, %v", base.Details, e.Details)
+	}
+
+	cause := errors.New("cause")
+	if !errors.Is(Internal(cause), cause) {
+		t.Error("Internal does not wrap its cause")
+	}
+	if got := As(New(http.StatusConflict, "CONFLICT", "Conflict").Wrap(cause)); got.Status != http.StatusConflict {
+		t.Errorf("As = %d, want the wrapped error", got.Status)
+	}
+}
+
+func TestRouteErrors(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /things/{id}", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no such thing", http.StatusNotFound)
+	})
+	handler := RouteErrors(mux)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		code   string
+		allow  string
+	}{
+		{"unknown path", http.MethodGet, "/nothing", http.StatusNotFound, CodeNotFound, ""},
+		{"wrong method", http.MethodDelete, "/things/1", http.StatusMethodNotAllowed, CodeMethodNotAllowed, "GET, HEAD"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+			var body map[string]map[string]any
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("body %q: %v", rec.Body.String(), err)
+			}
+			if rec.Code != tt.status || body["error"]["code"] != tt.code {
+				t.Errorf("response = %d %v, want %d %s", rec.Code, body, tt.status, tt.code)
+			}
+			if got := rec.Header().Get("Allow"); got != tt.allow {
+				t.Errorf("Allow = %q, want %q", got, tt.allow)
+			}
+		})
+	}
+
+	// A route answering 404 itself is left alone
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/things/1", nil))
+	if rec.Code != http.StatusNotFound || rec.Body.String() != "no such thing\n" {
+		t.Errorf("route response = %d %q", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,47 @@
+package apierror
+
+import "net/http"
+
+// RouteErrors wraps a ServeMux so requests matching no route get a 404
+// error, and requests matching a route with another method a 405 error
+// listing the allowed methods, instead of the mux's plain text responses.
+// Responses of matched routes are untouched.
+func RouteErrors(mux http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &routeErrorWriter{ResponseWriter: w, r: r}
+		mux.ServeHTTP(rw, r)
+		if rw.status != 0 {
+			Write(w, r, FromStatus(rw.status))
+		}
+	})
+}
+
+// routeErrorWriter drops the mux's own 404 and 405 responses. The mux sets
+// the pattern on the request before calling a route, so an empty pattern
+// means the mux is answering itself.
+type routeErrorWriter struct {
+	http.ResponseWriter
+	r *http.Request
+	// status is the dropped status
+	status int
+}
+
+func (w *routeErrorWriter) WriteHeader(status int) {
+	if w.r.Pattern == "" && (status == http.StatusNotFound || status == http.StatusMethodNotAllowed) {
+		w.status = status
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *routeErrorWriter) Write(b []byte) (int, error) {
+	if w.status != 0 {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *routeErrorWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
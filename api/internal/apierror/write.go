@@ -0,0 +1,105 @@
+package apierror
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Media types of error responses
+const (
+	ContentTypeJSON    = "application/json"
+	ContentTypeProblem = "application/problem+json"
+)
+
+// requestIDHeader is set on the response by middleware.RequestID before any
+// handler runs, so errors can report the id without the request context
+const requestIDHeader = "X-Request-Id"
+
+// body is the better-auth error shape, {"error": {"code", "message"}}
+type body struct {
+	Code      string         `json:"code"`
+	Message   string         `json:"message"`
+	Details   map[string]any `json:"details,omitempty"`
+	RequestID string         `json:"requestId,omitempty"`
+}
+
+// problem is an RFC 9457 problem details object. The type is about:blank,
+// so the title is the status text; code, details and requestId are
+// extension members.
+type problem struct {
+	Type      string         `json:"type"`
+	Title     string         `json:"title"`
+	Status    int            `json:"status"`
+	Detail    string         `json:"detail"`
+	Instance  string         `json:"instance,omitempty"`
+	Code      string         `json:"code"`
+	Details   map[string]any `json:"details,omitempty"`
+	RequestID string         `json:"requestId,omitempty"`
+}
+
+// Write answers the request with err, which is rendered as an internal error
+// unless it is an Error. Problem details are sent when the Accept header
+// prefers them to JSON.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	e := As(err)
+	requestID := w.Header().Get(requestIDHeader)
+
+	var (
+		contentType = ContentTypeJSON
+		payload     any
+	)
+	if r != nil && PrefersProblem(r.Header.Get("Accept")) {
+		contentType = ContentTypeProblem
+		payload = problem{
+			Type:      "about:blank",
+			Title:     http.StatusText(e.Status),
+			Status:    e.Status,
+			Detail:    e.Message,
+			Instance:  r.URL.Path,
+			Code:      e.Code,
+			Details:   e.Details,
+			RequestID: requestID,
+		}
+	} else {
+		payload = map[string]body{"error": {
+			Code:      e.Code,
+			Message:   e.Message,
+			Details:   e.Details,
+			RequestID: requestID,
+		}}
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Del("Content-Length")
+	w.WriteHeader(e.Status)
+	json.NewEncoder(w).Encode(payload) //nolint:errcheck // Best-effort encoding
+}
+
+// PrefersProblem reports whether an Accept header asks for problem details
+// with at least the quality of plain JSON
+func PrefersProblem(accept string) bool {
+	var problemQ, jsonQ float64
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		switch mediaType {
+		case ContentTypeProblem:
+			problemQ = max(problemQ, q)
+		case ContentTypeJSON, "application/*", "*/*":
+			jsonQ = max(jsonQ, q)
+		}
+	}
+	return problemQ > 0 && problemQ >= jsonQ
+}
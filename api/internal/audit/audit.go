@@ -0,0 +1,152 @@
+// Package audit records security relevant actions in the append-only audit
+// log. Entries are written with the queries of the action's transaction so an
+// action and its entry are committed together.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"reflect"
+
+	"budhapp.com/internal/middleware"
+	"budhapp.com/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Actions
+const (
+	ActionSignIn        = "auth.sign_in"
+	ActionSignInFailed  = "auth.sign_in_failed"
+	ActionSignOut       = "auth.sign_out"
+	ActionSessionRevoke = "session.revoke"
+
+	ActionAccountDeletionScheduled = "account.deletion_scheduled"
+	ActionAccountDeletionCanceled  = "account.deletion_canceled"
+	ActionAccountDelete            = "account.delete"
+	ActionAccountEmailChange       = "account.email_change"
+
+	ActionSubscriptionCreate = "subscription.create"
+	ActionSubscriptionUpdate = "subscription.update"
+	ActionSubscriptionRevoke = "subscription.revoke"
+
+	ActionProjectDelete   = "project.delete"
+	ActionProjectTransfer = "project.transfer"
+
+	ActionAdminSetRole            = "admin.set_role"
+	ActionAdminBanUser            = "admin.ban_user"
+	ActionAdminUnbanUser          = "admin.unban_user"
+	ActionAdminListUserSessions   = "admin.list_user_sessions"
+	ActionAdminRevokeUserSession  = "admin.revoke_user_session"
+	ActionAdminRevokeUserSessions = "admin.revoke_user_sessions"
+	ActionAdminImpersonateUser    = "admin.impersonate_user"
+	ActionAdminStopImpersonating  = "admin.stop_impersonating"
+)
+
+// Target types
+const (
+	TargetUser         = "user"
+	TargetSession      = "session"
+	TargetProject      = "project"
+	TargetSubscription = "subscription"
+)
+
+// Entry is an action to record
+type Entry struct {
+	Action string
+	// ActorID is the user who acted, nil for anonymous callers and webhooks
+	ActorID    *uuid.UUID
+	TargetType string
+	TargetID   string
+	// Diff is stored as JSON: the Changes of the target, or any details
+	Diff any
+}
+
+// Record writes e with the client details of r, which may be nil for work
+// that is not tied to a request
+func Record(ctx context.Context, q *repository.Queries, r *http.Request, e Entry) error {
+	diff := []byte("{}")
+	if e.Diff != nil {
+		var err error
+		if diff, err = json.Marshal(e.Diff); err != nil {
+			return err
+		}
+	}
+
+	params := repository.CreateAuditLogParams{
+		Action:     e.Action,
+		ActorId:    e.ActorID,
+		TargetType: optional(e.TargetType),
+		TargetId:   optional(e.TargetID),
+		Diff:       diff,
+	}
+	if r != nil {
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			params.IpAddress = &host
+		}
+		params.UserAgent = optional(r.UserAgent())
+		params.RequestId = optional(middleware.RequestIDFromContext(r.Context()))
+	}
+
+	_, err := q.CreateAuditLog(ctx, params)
+	return err
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// Change is the old and new value of a field
+type Change struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// Changes returns the fields that differ between the JSON forms of before
+// and after. Either may be nil to describe a creation or a deletion.
+func Changes(before, after any) (map[string]Change, error) {
+	from, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	to, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]Change)
+	for name, value := range from {
+		if other, ok := to[name]; !ok || !reflect.DeepEqual(value, other) {
+			changes[name] = Change{From: value, To: to[name]}
+		}
+	}
+	for name, value := range to {
+		if _, ok := from[name]; !ok {
+			changes[name] = Change{To: value}
+		}
+	}
+	// Timestamps move with every write and say nothing about the change
+	delete(changes, "updatedAt")
+
+	return changes, nil
+}
+
+// fields decodes the JSON object form of v
+func fields(v any) (map[string]any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,58 @@
+package audit
+
+import (
+	"reflect"
+	"testing"
+)
+
+type record struct {
+	Name      string `json:"name"`
+	Role      string `json:"role"`
+	UpdatedAt string `json:"updatedAt"`
+}
+
+func TestChanges(t *testing.T) {
+	before := record{Name: "Ada", Role: "user", UpdatedAt: "2024-01-01"}
+	after := record{Name: "Ada", Role: "admin", UpdatedAt: "2024-01-02"}
+
+	tests := []struct {
+		name          string
+		before, after any
+		want          map[string]Change
+	}{
+		{
+			name:   "update",
+			before: before,
+			after:  after,
+			want:   map[string]Change{"role": {From: "user", To: "admin"}},
+		},
+		{
+			name:  "create",
+			after: before,
+			want:  map[string]Change{"name": {To: "Ada"}, "role": {To: "user"}},
+		},
+		{
+			name:   "delete",
+			before: before,
+			want:   map[string]Change{"name": {From: "Ada"}, "role": {From: "user"}},
+		},
+		{
+			name:   "unchanged",
+			before: before,
+			after:  record{Name: "Ada", Role: "user", UpdatedAt: "2024-02-01"},
+			want:   map[string]Change{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Changes(tt.before, tt.after)
+			if err != nil {
+				t.Fatalf("Changes() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Changes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+// Package authz decides what a user may do with a project. A user's role on
+// a project comes from owning it, from their organization membership or from
+// a grant on the project itself; the role maps to a fixed set of permissions.
+package authz
+
+import "slices"
+
+// Role is a user's role on a project
+type Role string
+
+const (
+	RoleOwner     Role = "owner"
+	RoleAdmin     Role = "admin"
+	RoleEditor    Role = "editor"
+	RoleCommenter Role = "commenter"
+	RoleViewer    Role = "viewer"
+)
+
+// ranks orders roles from least to most privileged
+var ranks = map[Role]int{
+	RoleViewer:    1,
+	RoleCommenter: 2,
+	RoleEditor:    3,
+	RoleAdmin:     4,
+	RoleOwner:     5,
+}
+
+// Valid reports whether r is a known role
+func (r Role) Valid() bool {
+	_, ok := ranks[r]
+	return ok
+}
+
+// AtLeast reports whether r grants everything other grants
+func (r Role) AtLeast(other Role) bool {
+	return ranks[r] >= ranks[other]
+}
+
+// OrganizationRole maps an organization member role onto a project role. The
+// better-auth default "member" role edits the organization's projects.
+func OrganizationRole(role string) (Role, bool) {
+	if role == "member" {
+		return RoleEditor, true
+	}
+	r := Role(role)
+	return r, r.Valid()
+}
+
+// Permission is a single action on a project or its documents
+type Permission string
+
+const (
+	ProjectRead   Permission = "project:read"
+	ProjectUpdate Permission = "project:update"
+	ProjectShare  Permission = "project:share"
+	ProjectDelete Permission = "project:delete"
+
+	DocumentRead    Permission = "document:read"
+	DocumentComment Permission = "document:comment"
+	DocumentCreate  Permission = "document:create"
+	DocumentUpdate  Permission = "document:update"
+	DocumentDelete  Permission = "document:delete"
+)
+
+// policy lists the permissions each role adds to the role below it
+var policy = []struct {
+	role        Role
+	permissions []Permission
+}{
+	{RoleViewer, []Permission{ProjectRead, DocumentRead}},
+	{RoleCommenter, []Permission{DocumentComment}},
+	{RoleEditor, []Permission{DocumentCreate, DocumentUpdate, DocumentDelete}},
+	{RoleAdmin, []Permission{ProjectUpdate, ProjectShare}},
+	{RoleOwner, []Permission{ProjectDelete}},
+}
+
+// PermissionSet is the resolved set of permissions of a role
+type PermissionSet struct {
+	Role        Role         `json:"role"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// Permissions returns the permission set of role. Unknown roles get nothing.
+func Permissions(role Role) PermissionSet {
+	set := PermissionSet{Role: role, Permissions: []Permission{}}
+	if !role.Valid() {
+		return set
+	}
+	for _, level := range policy {
+		if !role.AtLeast(level.role) {
+			break
+		}
+		set.Permissions = append(set.Permissions, level.permissions...)
+	}
+	return set
+}
+
+// Has reports whether the set grants p
+func (s PermissionSet) Has(p Permission) bool {
+	return slices.Contains(s.Permissions, p)
+}
+
+// Resolve returns the highest of the given roles, ignoring unknown ones. The
+// second result is false when none of them is a known role.
+func Resolve(roles ...Role) (Role, bool) {
+	var best Role
+	for _, r := range roles {
+		if r.Valid() && (best == "" || r.AtLeast(best)) {
+			best = r
+		}
+	}
+	return best, best != ""
+}
@@ -0,0 +1,88 @@
+package authz
+
+import "testing"
+
+func TestPermissionMatrix(t *testing.T) {
+	all := []Permission{
+		ProjectRead, ProjectUpdate, ProjectShare, ProjectDelete,
+		DocumentRead, DocumentComment, DocumentCreate, DocumentUpdate, DocumentDelete,
+	}
+
+	matrix := map[Role][]Permission{
+		RoleOwner: all,
+		RoleAdmin: {
+			ProjectRead, ProjectUpdate, ProjectShare,
+			DocumentRead, DocumentComment, DocumentCreate, DocumentUpdate, DocumentDelete,
+		},
+		RoleEditor:    {ProjectRead, DocumentRead, DocumentComment, DocumentCreate, DocumentUpdate, DocumentDelete},
+		RoleCommenter: {ProjectRead, DocumentRead, DocumentComment},
+		RoleViewer:    {ProjectRead, DocumentRead},
+		"guest":       {},
+		"":            {},
+	}
+
+	for role, granted := range matrix {
+		set := Permissions(role)
+		if set.Role != role {
+			t.Errorf("Permissions(%q).Role = %q", role, set.Role)
+		}
+		if len(set.Permissions) != len(granted) {
+			t.Errorf("Permissions(%q) = %v, want %v", role, set.Permissions, granted)
+		}
+
+		want := make(map[Permission]bool)
+		for _, p := range granted {
+			want[p] = true
+		}
+		for _, p := range all {
+			if got := set.Has(p); got != want[p] {
+				t.Errorf("Permissions(%q).Has(%q) = %v, want %v", role, p, got, want[p])
+			}
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		roles []Role
+		want  Role
+		ok    bool
+	}{
+		{nil, "", false},
+		{[]Role{"guest"}, "", false},
+		{[]Role{RoleViewer}, RoleViewer, true},
+		{[]Role{RoleViewer, RoleEditor}, RoleEditor, true},
+		{[]Role{RoleAdmin, RoleCommenter}, RoleAdmin, true},
+		{[]Role{"guest", RoleCommenter}, RoleCommenter, true},
+		{[]Role{RoleOwner, RoleViewer, RoleAdmin}, RoleOwner, true},
+	}
+
+	for _, tt := range tests {
+		got, ok := Resolve(tt.roles...)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Resolve(%v) = %q, %v, want %q, %v", tt.roles, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestOrganizationRole(t *testing.T) {
+	tests := []struct {
+		role string
+		want Role
+		ok   bool
+	}{
+		{"owner", RoleOwner, true},
+		{"admin", RoleAdmin, true},
+		{"member", RoleEditor, true},
+		{"commenter", RoleCommenter, true},
+		{"viewer", RoleViewer, true},
+		{"billing", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := OrganizationRole(tt.role)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Errorf("OrganizationRole(%q) = %q, %v, want %q, %v", tt.role, got, ok, tt.want, tt.ok)
+		}
+	}
+}
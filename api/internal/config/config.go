@@ -4,23 +4,341 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"net/netip"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
 	Environment string           `json:"environment"`
 	Address     string           `json:"address"`
+	HTTP        HTTPConfig       `json:"http"`
+	Health      HealthConfig     `json:"health"`
+	Metrics     MetricsConfig    `json:"metrics"`
+	Tracing     TracingConfig    `json:"tracing"`
 	Encryption  EncryptionConfig `json:"encryption"`
 	Database    DatabaseConfig   `json:"database"`
 	Polar       PolarConfig      `json:"polar"`
+	Auth        AuthConfig       `json:"auth"`
+	Email       EmailConfig      `json:"email"`
+	Storage     StorageConfig    `json:"storage"`
+	RateLimit   RateLimitConfig  `json:"rateLimit"`
+	CORS        CORSConfig       `json:"cors"`
+	CSRF        CSRFConfig       `json:"csrf"`
+	Security    SecurityConfig   `json:"security"`
+}
+
+// IsProduction reports whether the environment is "production" or "prod"
+func (c *Config) IsProduction() bool {
+	return c.Environment == "production" || c.Environment == "prod"
+}
+
+// MinSecretLength is the shortest accepted auth secret, the minimum
+// better-auth recommends
+const MinSecretLength = 32
+
+type AuthConfig struct {
+	// AppName is shown by authenticator apps as the TOTP issuer
+	AppName string `json:"appName"`
+	// Secret is the better-auth secret used to sign session cookies
+	Secret string `json:"secret"`
+	// BaseURL is the public URL of the auth server, used as JWT issuer and audience
+	BaseURL string    `json:"baseUrl"`
+	JWT     JWTConfig `json:"jwt"`
+	// TrustedOrigins are the origins allowed as OAuth callback and redirect targets
+	TrustedOrigins []string `json:"trustedOrigins"`
+	// PasskeyRPID is the WebAuthn relying party id, defaults to the base URL host
+	PasskeyRPID string `json:"passkeyRpId"`
+	// SocialProviders are the OAuth providers keyed by provider id
+	SocialProviders map[string]SocialProviderConfig `json:"socialProviders"`
+	MagicLink       PasswordlessConfig              `json:"magicLink"`
+	EmailOTP        PasswordlessConfig              `json:"emailOtp"`
+	Admin           AdminConfig                     `json:"admin"`
+	DeleteUser      DeleteUserConfig                `json:"deleteUser"`
+	ChangeEmail     ChangeEmailConfig               `json:"changeEmail"`
+}
+
+// ChangeEmailConfig configures email changes
+type ChangeEmailConfig struct {
+	// Expiration is how long the link verifying the new address can be used
+	Expiration Duration `json:"expiration"`
+}
+
+// DeleteUserConfig configures account deletion
+type DeleteUserConfig struct {
+	// SendVerification emails a confirmation link before an account is deleted
+	SendVerification bool `json:"sendVerification"`
+	// VerificationExpiration is how long the confirmation link can be used
+	VerificationExpiration Duration `json:"verificationExpiration"`
+	// FreshAge is how recent a session must be to delete the account without
+	// a password
+	FreshAge Duration `json:"freshAge"`
+	// GracePeriod delays the deletion so it can be canceled. Zero deletes the
+	// account right away.
+	GracePeriod Duration `json:"gracePeriod"`
+}
+
+// AdminConfig configures the admin API
+type AdminConfig struct {
+	// UserIDs are treated as admins whatever their role, so the first admin
+	// can be bootstrapped
+	UserIDs []string `json:"userIds"`
+	// ImpersonationDuration is the lifetime of impersonation sessions
+	ImpersonationDuration Duration `json:"impersonationDuration"`
+}
+
+// PasswordlessConfig configures a sign-in method that emails a link or code
+type PasswordlessConfig struct {
+	// Expiration is how long a link or code can be used
+	Expiration Duration `json:"expiration"`
+	// AllowedAttempts is how many times a code can be tried, or a link opened,
+	// before it is invalidated
+	AllowedAttempts int `json:"allowedAttempts"`
+	// DisableSignUp rejects unknown emails instead of creating a user
+	DisableSignUp bool `json:"disableSignUp"`
+}
+
+type SocialProviderConfig struct {
+	ClientID     string   `json:"clientId"`
+	ClientSecret string   `json:"clientSecret"`
+	Scopes       []string `json:"scopes"`
+	// Issuer is the OpenID Connect issuer used for discovery; required for
+	// providers other than github and google
+	Issuer string `json:"issuer"`
+}
+
+type JWTConfig struct {
+	// Algorithm is the signing algorithm for new keys: EdDSA, ES256 or RS256
+	Algorithm string `json:"algorithm"`
+	// Expiration is the lifetime of issued tokens
+	Expiration Duration `json:"expiration"`
+	// RotationInterval is how long a key is used for signing before a new one is generated
+	RotationInterval Duration `json:"rotationInterval"`
+	// RotationOverlap is how long a retired key stays published in the JWKS
+	RotationOverlap Duration `json:"rotationOverlap"`
+}
+
+// Duration is a time.Duration that reads from JSON as a Go duration string ("15m", "720h")
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string: %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Std returns the value as a time.Duration
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
 }
 
 type PolarConfig struct {
 	WebhookSecret string `json:"webhookSecret"`
+	// AccessToken authenticates calls to the Polar API; without it
+	// subscriptions are not canceled when an account is deleted
+	AccessToken string `json:"accessToken"`
+	// Server is "production" or "sandbox"
+	Server string `json:"server"`
 }
 
 type EncryptionConfig struct {
 	Key string `json:"key"`
+	// KeyVersion identifies Key in encrypted payloads ("v<N>:...")
+	KeyVersion int `json:"keyVersion"`
+	// PreviousKeys are retired keys by version, kept to decrypt older payloads
+	PreviousKeys map[int]string `json:"previousKeys"`
+}
+
+type EmailConfig struct {
+	// Provider is "sendgrid" or "resend". When empty emails are only logged.
+	Provider       string `json:"provider"`
+	SendGridAPIKey string `json:"sendgridApiKey"`
+	ResendAPIKey   string `json:"resendApiKey"`
+	// From is the default sender address
+	From string `json:"from"`
+}
+
+// StorageConfig configures the blob store of uploaded files
+type StorageConfig struct {
+	// Backend is "local" or "s3"
+	Backend string `json:"backend"`
+	// LocalPath is the directory of the local backend
+	LocalPath string   `json:"localPath"`
+	S3        S3Config `json:"s3"`
+	// MaxUploadSize is the largest accepted upload in bytes
+	MaxUploadSize int64 `json:"maxUploadSize"`
+	// URLExpiration is the lifetime of signed download URLs
+	URLExpiration Duration `json:"urlExpiration"`
+}
+
+// S3Config configures an S3 compatible bucket
+type S3Config struct {
+	// Endpoint defaults to AWS, https://s3.<region>.amazonaws.com
+	Endpoint        string `json:"endpoint"`
+	Region          string `json:"region"`
+	Bucket          string `json:"bucket"`
+	AccessKeyID     string `json:"accessKeyId"`
+	SecretAccessKey string `json:"secretAccessKey"`
+	// UsePathStyle addresses the bucket in the path instead of the host, as
+	// MinIO and most self-hosted stores expect
+	UsePathStyle bool `json:"usePathStyle"`
+}
+
+// RateLimitConfig configures request rate limits and the lockout of accounts
+// after failed sign-ins
+type RateLimitConfig struct {
+	// Backend is "memory", or "postgres" to share limits between instances
+	Backend string `json:"backend"`
+	// Global limits every request by client IP address
+	Global RateLimit `json:"global"`
+	// Auth limits requests to the sign-in endpoints by client IP address
+	Auth RateLimit `json:"auth"`
+	// Account limits sign-in attempts by account, whatever the IP address
+	Account RateLimit     `json:"account"`
+	Lockout LockoutConfig `json:"lockout"`
+}
+
+// RateLimit allows Requests per Window. Zero requests disables the limit.
+type RateLimit struct {
+	Requests int      `json:"requests"`
+	Window   Duration `json:"window"`
+}
+
+// LockoutConfig configures the lockout of accounts after failed sign-ins
+type LockoutConfig struct {
+	// Threshold is the number of consecutive failures that locks the
+	// account. Zero disables the lockout.
+	Threshold int `json:"threshold"`
+	// Duration is the first lockout, doubled by every further failure
+	Duration Duration `json:"duration"`
+	// MaxDuration caps the lockout
+	MaxDuration Duration `json:"maxDuration"`
+}
+
+// CORSConfig configures cross-origin requests from browsers
+type CORSConfig struct {
+	// AllowedOrigins are exact origins such as "https://app.example.com",
+	// patterns with one wildcard label such as "https://*.example.com", or
+	// "*" for any origin. Outside production it defaults to the local
+	// development servers.
+	AllowedOrigins []string `json:"allowedOrigins"`
+	AllowedMethods []string `json:"allowedMethods"`
+	// AllowedHeaders are the request headers allowed; "*" allows any
+	AllowedHeaders []string `json:"allowedHeaders"`
+	// ExposedHeaders are the response headers scripts may read
+	ExposedHeaders []string `json:"exposedHeaders"`
+	// AllowCredentials lets browsers send cookies
+	AllowCredentials bool `json:"allowCredentials"`
+	// MaxAge is how long browsers may cache a preflight response
+	MaxAge Duration `json:"maxAge"`
+}
+
+// CSRFConfig configures the protection of cookie-authenticated requests
+// against cross-site request forgery. Requests must come from the base URL,
+// the trusted origins or the CORS origins allowed with credentials.
+type CSRFConfig struct {
+	// DoubleSubmit also requires form posts to echo the CSRF token cookie in
+	// a csrf_token field or the X-CSRF-Token header
+	DoubleSubmit bool `json:"doubleSubmit"`
+	// ExemptPaths are path prefixes that are not checked, such as webhooks
+	// authenticated by a signature
+	ExemptPaths []string `json:"exemptPaths"`
+	// PathOrigins trusts more origins for the routes under a path prefix
+	PathOrigins map[string][]string `json:"pathOrigins"`
+}
+
+// SecurityConfig configures the security headers sent with every response.
+// An empty value leaves its header out.
+type SecurityConfig struct {
+	// HSTSMaxAge is the Strict-Transport-Security max age, sent in
+	// production only. Zero leaves the header out.
+	HSTSMaxAge            Duration `json:"hstsMaxAge"`
+	HSTSIncludeSubdomains bool     `json:"hstsIncludeSubdomains"`
+	HSTSPreload           bool     `json:"hstsPreload"`
+	ReferrerPolicy        string   `json:"referrerPolicy"`
+	// FrameOptions is DENY or SAMEORIGIN, also sent as the CSP
+	// frame-ancestors directive when the policy has none
+	FrameOptions      string `json:"frameOptions"`
+	PermissionsPolicy string `json:"permissionsPolicy"`
+	// ContentSecurityPolicy may use {nonce}, replaced by a per-request nonce
+	ContentSecurityPolicy string `json:"contentSecurityPolicy"`
+}
+
+// HTTPConfig configures the HTTP server timeouts and its shutdown. On
+// SIGTERM readiness fails for DrainDelay so load balancers stop routing new
+// requests, then in-flight requests get ShutdownTimeout to finish.
+type HTTPConfig struct {
+	ReadTimeout       Duration `json:"readTimeout"`
+	ReadHeaderTimeout Duration `json:"readHeaderTimeout"`
+	WriteTimeout      Duration `json:"writeTimeout"`
+	IdleTimeout       Duration `json:"idleTimeout"`
+	ShutdownTimeout   Duration `json:"shutdownTimeout"`
+	DrainDelay        Duration `json:"drainDelay"`
+	// TrustedProxies are the addresses or CIDR ranges of the reverse proxies,
+	// such as the SvelteKit server, whose X-Forwarded-For header names the
+	// client. Requests from other addresses are taken at face value.
+	TrustedProxies []string `json:"trustedProxies"`
+}
+
+// TrustedProxyPrefixes returns the trusted proxies as prefixes; single
+// addresses become prefixes of their full length
+func (c HTTPConfig) TrustedProxyPrefixes() ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(c.TrustedProxies))
+	for _, proxy := range c.TrustedProxies {
+		if prefix, err := netip.ParsePrefix(proxy); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: not an address or CIDR range", proxy)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+	return prefixes, nil
+}
+
+// HealthConfig configures the dependency checks of /readyz and the admin
+// health report
+type HealthConfig struct {
+	// CacheTTL is how long a check result is reused
+	CacheTTL Duration `json:"cacheTtl"`
+	// Timeout bounds each check
+	Timeout Duration `json:"timeout"`
+}
+
+// MetricsConfig configures the Prometheus endpoint /metrics
+type MetricsConfig struct {
+	Enabled bool `json:"enabled"`
+	// Token, when set, must be sent by scrapers as a bearer token
+	Token string `json:"token"`
+}
+
+// TracingConfig configures OpenTelemetry tracing
+type TracingConfig struct {
+	// Exporter is "otlp" to send spans to a collector over OTLP/HTTP,
+	// "stdout" to print them, or empty to disable tracing
+	Exporter string `json:"exporter"`
+	// Endpoint is the OTLP/HTTP traces URL, such as
+	// http://localhost:4318/v1/traces
+	Endpoint    string `json:"endpoint"`
+	ServiceName string `json:"serviceName"`
+	// SampleRatio is the share of new traces recorded, from 0 to 1. Traces
+	// started upstream follow the sampling decision of their parent.
+	SampleRatio float64 `json:"sampleRatio"`
 }
 
 type DatabaseConfig struct {
@@ -42,6 +360,16 @@ func Load() (*Config, error) {
 	// Override with environment variables
 	loadFromEnv(config)
 
+	// Defaults that depend on the environment
+	if config.CORS.AllowedOrigins == nil && !config.IsProduction() {
+		config.CORS.AllowedOrigins = []string{
+			"http://localhost:3000",
+			"http://localhost:5173",
+			"http://127.0.0.1:3000",
+			"http://127.0.0.1:5173",
+		}
+	}
+
 	// Validate final configuration
 	if err := validate(config); err != nil {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
@@ -69,11 +397,69 @@ func loadFromEnv(config *Config) {
 		config.Address = address
 	}
 
+	// HTTP server configuration
+	loadDurationFromEnv("HTTP_READ_TIMEOUT", &config.HTTP.ReadTimeout)
+	loadDurationFromEnv("HTTP_READ_HEADER_TIMEOUT", &config.HTTP.ReadHeaderTimeout)
+	loadDurationFromEnv("HTTP_WRITE_TIMEOUT", &config.HTTP.WriteTimeout)
+	loadDurationFromEnv("HTTP_IDLE_TIMEOUT", &config.HTTP.IdleTimeout)
+	loadDurationFromEnv("SHUTDOWN_TIMEOUT", &config.HTTP.ShutdownTimeout)
+	loadDurationFromEnv("SHUTDOWN_DRAIN_DELAY", &config.HTTP.DrainDelay)
+	if proxies := os.Getenv("TRUSTED_PROXIES"); proxies != "" {
+		config.HTTP.TrustedProxies = splitList(proxies)
+	}
+
+	// Health check configuration
+	loadDurationFromEnv("HEALTH_CACHE_TTL", &config.Health.CacheTTL)
+	loadDurationFromEnv("HEALTH_CHECK_TIMEOUT", &config.Health.Timeout)
+
+	// Metrics configuration
+	if enabled := os.Getenv("METRICS_ENABLED"); enabled != "" {
+		if b, err := strconv.ParseBool(enabled); err == nil {
+			config.Metrics.Enabled = b
+		}
+	}
+	if token := os.Getenv("METRICS_TOKEN"); token != "" {
+		config.Metrics.Token = token
+	}
+
+	// Tracing configuration
+	if exporter := os.Getenv("TRACING_EXPORTER"); exporter != "" {
+		config.Tracing.Exporter = exporter
+	}
+	if endpoint := os.Getenv("TRACING_ENDPOINT"); endpoint != "" {
+		config.Tracing.Endpoint = endpoint
+	}
+	if serviceName := os.Getenv("TRACING_SERVICE_NAME"); serviceName != "" {
+		config.Tracing.ServiceName = serviceName
+	}
+	if ratio := os.Getenv("TRACING_SAMPLE_RATIO"); ratio != "" {
+		if f, err := strconv.ParseFloat(ratio, 64); err == nil {
+			config.Tracing.SampleRatio = f
+		}
+	}
+
 	// Encryption configuration
 	if encryptionKey := os.Getenv("ENCRYPTION_KEY"); encryptionKey != "" {
 		config.Encryption.Key = encryptionKey
 	}
 
+	if keyVersion := os.Getenv("ENCRYPTION_KEY_VERSION"); keyVersion != "" {
+		if version, err := strconv.Atoi(keyVersion); err == nil {
+			config.Encryption.KeyVersion = version
+		}
+	}
+
+	// ENCRYPTION_PREVIOUS_KEYS="1:<base64 key>,2:<base64 key>"
+	if previousKeys := os.Getenv("ENCRYPTION_PREVIOUS_KEYS"); previousKeys != "" {
+		config.Encryption.PreviousKeys = make(map[int]string)
+		for _, entry := range splitList(previousKeys) {
+			version, key, found := strings.Cut(entry, ":")
+			if v, err := strconv.Atoi(version); found && err == nil {
+				config.Encryption.PreviousKeys[v] = key
+			}
+		}
+	}
+
 	// Database configuration
 	if databaseURL := os.Getenv("DATABASE_URL"); databaseURL != "" {
 		config.Database = DatabaseConfig{
@@ -85,12 +471,382 @@ func loadFromEnv(config *Config) {
 	if polarWebhookSecret := os.Getenv("POLAR_WEBHOOK_SECRET"); polarWebhookSecret != "" {
 		config.Polar.WebhookSecret = polarWebhookSecret
 	}
+
+	if polarAccessToken := os.Getenv("POLAR_ACCESS_TOKEN"); polarAccessToken != "" {
+		config.Polar.AccessToken = polarAccessToken
+	}
+
+	if polarServer := os.Getenv("POLAR_SERVER"); polarServer != "" {
+		config.Polar.Server = polarServer
+	}
+
+	// Auth configuration
+	if appName := os.Getenv("PROJECT_NAME"); appName != "" {
+		config.Auth.AppName = appName
+	}
+
+	if secret := os.Getenv("BETTER_AUTH_SECRET"); secret != "" {
+		config.Auth.Secret = secret
+	}
+
+	if baseURL := os.Getenv("BETTER_AUTH_URL"); baseURL != "" {
+		config.Auth.BaseURL = baseURL
+	}
+
+	if algorithm := os.Getenv("JWT_ALGORITHM"); algorithm != "" {
+		config.Auth.JWT.Algorithm = algorithm
+	}
+
+	if rpID := os.Getenv("PASSKEY_RP_ID"); rpID != "" {
+		config.Auth.PasskeyRPID = rpID
+	}
+
+	if trustedOrigins := os.Getenv("TRUSTED_ORIGINS"); trustedOrigins != "" {
+		config.Auth.TrustedOrigins = splitList(trustedOrigins)
+	}
+
+	loadDurationFromEnv("JWT_EXPIRATION", &config.Auth.JWT.Expiration)
+	loadDurationFromEnv("JWT_ROTATION_INTERVAL", &config.Auth.JWT.RotationInterval)
+	loadDurationFromEnv("JWT_ROTATION_OVERLAP", &config.Auth.JWT.RotationOverlap)
+
+	loadPasswordlessFromEnv("MAGIC_LINK", &config.Auth.MagicLink)
+	loadPasswordlessFromEnv("EMAIL_OTP", &config.Auth.EmailOTP)
+
+	if adminUserIDs := os.Getenv("ADMIN_USER_IDS"); adminUserIDs != "" {
+		config.Auth.Admin.UserIDs = splitList(adminUserIDs)
+	}
+	loadDurationFromEnv("ADMIN_IMPERSONATION_DURATION", &config.Auth.Admin.ImpersonationDuration)
+
+	if sendVerification := os.Getenv("DELETE_USER_SEND_VERIFICATION"); sendVerification != "" {
+		if b, err := strconv.ParseBool(sendVerification); err == nil {
+			config.Auth.DeleteUser.SendVerification = b
+		}
+	}
+	loadDurationFromEnv("DELETE_USER_VERIFICATION_EXPIRATION", &config.Auth.DeleteUser.VerificationExpiration)
+	loadDurationFromEnv("DELETE_USER_FRESH_AGE", &config.Auth.DeleteUser.FreshAge)
+	loadDurationFromEnv("DELETE_USER_GRACE_PERIOD", &config.Auth.DeleteUser.GracePeriod)
+
+	loadDurationFromEnv("CHANGE_EMAIL_EXPIRATION", &config.Auth.ChangeEmail.Expiration)
+
+	// Email configuration
+	if provider := os.Getenv("EMAIL_PROVIDER"); provider != "" {
+		config.Email.Provider = provider
+	}
+
+	if apiKey := os.Getenv("SENDGRID_API_KEY"); apiKey != "" {
+		config.Email.SendGridAPIKey = apiKey
+	}
+
+	if apiKey := os.Getenv("RESEND_API_KEY"); apiKey != "" {
+		config.Email.ResendAPIKey = apiKey
+	}
+
+	if from := os.Getenv("EMAIL_FROM"); from != "" {
+		config.Email.From = from
+	}
+
+	// Storage configuration
+	if backend := os.Getenv("STORAGE_BACKEND"); backend != "" {
+		config.Storage.Backend = backend
+	}
+
+	if localPath := os.Getenv("STORAGE_LOCAL_PATH"); localPath != "" {
+		config.Storage.LocalPath = localPath
+	}
+
+	if endpoint := os.Getenv("S3_ENDPOINT"); endpoint != "" {
+		config.Storage.S3.Endpoint = endpoint
+	}
+
+	if region := os.Getenv("S3_REGION"); region != "" {
+		config.Storage.S3.Region = region
+	}
+
+	if bucket := os.Getenv("S3_BUCKET"); bucket != "" {
+		config.Storage.S3.Bucket = bucket
+	}
+
+	if accessKeyID := os.Getenv("S3_ACCESS_KEY_ID"); accessKeyID != "" {
+		config.Storage.S3.AccessKeyID = accessKeyID
+	}
+
+	if secretAccessKey := os.Getenv("S3_SECRET_ACCESS_KEY"); secretAccessKey != "" {
+		config.Storage.S3.SecretAccessKey = secretAccessKey
+	}
+
+	if pathStyle := os.Getenv("S3_USE_PATH_STYLE"); pathStyle != "" {
+		if b, err := strconv.ParseBool(pathStyle); err == nil {
+			config.Storage.S3.UsePathStyle = b
+		}
+	}
+
+	if maxUploadSize := os.Getenv("UPLOAD_MAX_SIZE"); maxUploadSize != "" {
+		if n, err := strconv.ParseInt(maxUploadSize, 10, 64); err == nil {
+			config.Storage.MaxUploadSize = n
+		}
+	}
+	loadDurationFromEnv("UPLOAD_URL_EXPIRATION", &config.Storage.URLExpiration)
+
+	// Rate limit configuration
+	if backend := os.Getenv("RATE_LIMIT_BACKEND"); backend != "" {
+		config.RateLimit.Backend = backend
+	}
+	loadRateLimitFromEnv("RATE_LIMIT_GLOBAL", &config.RateLimit.Global)
+	loadRateLimitFromEnv("RATE_LIMIT_AUTH", &config.RateLimit.Auth)
+	loadRateLimitFromEnv("RATE_LIMIT_ACCOUNT", &config.RateLimit.Account)
+
+	if threshold := os.Getenv("LOCKOUT_THRESHOLD"); threshold != "" {
+		if n, err := strconv.Atoi(threshold); err == nil {
+			config.RateLimit.Lockout.Threshold = n
+		}
+	}
+	loadDurationFromEnv("LOCKOUT_DURATION", &config.RateLimit.Lockout.Duration)
+	loadDurationFromEnv("LOCKOUT_MAX_DURATION", &config.RateLimit.Lockout.MaxDuration)
+
+	// CORS configuration
+	if origins := os.Getenv("CORS_ALLOWED_ORIGINS"); origins != "" {
+		config.CORS.AllowedOrigins = splitList(origins)
+	}
+
+	if methods := os.Getenv("CORS_ALLOWED_METHODS"); methods != "" {
+		config.CORS.AllowedMethods = splitList(methods)
+	}
+
+	if headers := os.Getenv("CORS_ALLOWED_HEADERS"); headers != "" {
+		config.CORS.AllowedHeaders = splitList(headers)
+	}
+
+	if headers := os.Getenv("CORS_EXPOSED_HEADERS"); headers != "" {
+		config.CORS.ExposedHeaders = splitList(headers)
+	}
+
+	if credentials := os.Getenv("CORS_ALLOW_CREDENTIALS"); credentials != "" {
+		if b, err := strconv.ParseBool(credentials); err == nil {
+			config.CORS.AllowCredentials = b
+		}
+	}
+	loadDurationFromEnv("CORS_MAX_AGE", &config.CORS.MaxAge)
+
+	// CSRF configuration
+	if doubleSubmit := os.Getenv("CSRF_DOUBLE_SUBMIT"); doubleSubmit != "" {
+		if b, err := strconv.ParseBool(doubleSubmit); err == nil {
+			config.CSRF.DoubleSubmit = b
+		}
+	}
+
+	if exemptPaths := os.Getenv("CSRF_EXEMPT_PATHS"); exemptPaths != "" {
+		config.CSRF.ExemptPaths = splitList(exemptPaths)
+	}
+
+	// Security headers configuration
+	loadDurationFromEnv("HSTS_MAX_AGE", &config.Security.HSTSMaxAge)
+	if includeSubdomains := os.Getenv("HSTS_INCLUDE_SUBDOMAINS"); includeSubdomains != "" {
+		if b, err := strconv.ParseBool(includeSubdomains); err == nil {
+			config.Security.HSTSIncludeSubdomains = b
+		}
+	}
+	if preload := os.Getenv("HSTS_PRELOAD"); preload != "" {
+		if b, err := strconv.ParseBool(preload); err == nil {
+			config.Security.HSTSPreload = b
+		}
+	}
+	if referrerPolicy, ok := os.LookupEnv("REFERRER_POLICY"); ok {
+		config.Security.ReferrerPolicy = referrerPolicy
+	}
+	if frameOptions, ok := os.LookupEnv("FRAME_OPTIONS"); ok {
+		config.Security.FrameOptions = strings.ToUpper(frameOptions)
+	}
+	if permissionsPolicy, ok := os.LookupEnv("PERMISSIONS_POLICY"); ok {
+		config.Security.PermissionsPolicy = permissionsPolicy
+	}
+	if csp, ok := os.LookupEnv("CONTENT_SECURITY_POLICY"); ok {
+		config.Security.ContentSecurityPolicy = csp
+	}
+
+	// Social providers
+	loadSocialProviderFromEnv(config, "github", os.Getenv("GITHUB_CLIENT_ID"), os.Getenv("GITHUB_CLIENT_SECRET"), "")
+	loadSocialProviderFromEnv(config, "google", os.Getenv("GOOGLE_CLIENT_ID"), os.Getenv("GOOGLE_CLIENT_SECRET"), "")
+	if id := os.Getenv("OIDC_PROVIDER_ID"); id != "" {
+		loadSocialProviderFromEnv(config, id, os.Getenv("OIDC_CLIENT_ID"), os.Getenv("OIDC_CLIENT_SECRET"), os.Getenv("OIDC_ISSUER"))
+	}
+}
+
+// loadSocialProviderFromEnv registers a provider when its client id is set
+func loadSocialProviderFromEnv(config *Config, id, clientID, clientSecret, issuer string) {
+	if clientID == "" {
+		return
+	}
+	if config.Auth.SocialProviders == nil {
+		config.Auth.SocialProviders = make(map[string]SocialProviderConfig)
+	}
+	provider := config.Auth.SocialProviders[id]
+	provider.ClientID = clientID
+	provider.ClientSecret = clientSecret
+	if issuer != "" {
+		provider.Issuer = issuer
+	}
+	config.Auth.SocialProviders[id] = provider
+}
+
+// loadPasswordlessFromEnv reads <PREFIX>_EXPIRATION, <PREFIX>_ALLOWED_ATTEMPTS
+// and <PREFIX>_DISABLE_SIGN_UP
+func loadPasswordlessFromEnv(prefix string, config *PasswordlessConfig) {
+	loadDurationFromEnv(prefix+"_EXPIRATION", &config.Expiration)
+
+	if attempts := os.Getenv(prefix + "_ALLOWED_ATTEMPTS"); attempts != "" {
+		if n, err := strconv.Atoi(attempts); err == nil {
+			config.AllowedAttempts = n
+		}
+	}
+
+	if disable := os.Getenv(prefix + "_DISABLE_SIGN_UP"); disable != "" {
+		if b, err := strconv.ParseBool(disable); err == nil {
+			config.DisableSignUp = b
+		}
+	}
+}
+
+// splitList parses a comma separated list, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// loadDurationFromEnv overrides dst when the variable holds a valid duration
+func loadDurationFromEnv(key string, dst *Duration) {
+	value := os.Getenv(key)
+	if value == "" {
+		return
+	}
+	if parsed, err := time.ParseDuration(value); err == nil {
+		*dst = Duration(parsed)
+	}
+}
+
+// loadRateLimitFromEnv overrides dst when the variable holds a valid limit,
+// written "<requests>/<window>" like "20/1m", or "0" to disable it
+func loadRateLimitFromEnv(key string, dst *RateLimit) {
+	value := os.Getenv(key)
+	if value == "" {
+		return
+	}
+	if value == "0" {
+		*dst = RateLimit{}
+		return
+	}
+	requests, window, found := strings.Cut(value, "/")
+	n, err := strconv.Atoi(requests)
+	if !found || err != nil {
+		return
+	}
+	if parsed, err := time.ParseDuration(window); err == nil {
+		*dst = RateLimit{Requests: n, Window: Duration(parsed)}
+	}
 }
 
 func setDefaults() *Config {
 	return &Config{
 		Environment: "dev",
 		Address:     ":8080",
+		HTTP: HTTPConfig{
+			ReadTimeout:       Duration(30 * time.Second),
+			ReadHeaderTimeout: Duration(5 * time.Second),
+			WriteTimeout:      Duration(2 * time.Minute),
+			IdleTimeout:       Duration(2 * time.Minute),
+			ShutdownTimeout:   Duration(30 * time.Second),
+			DrainDelay:        Duration(5 * time.Second),
+		},
+		Health: HealthConfig{
+			CacheTTL: Duration(5 * time.Second),
+			Timeout:  Duration(2 * time.Second),
+		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+		},
+		Tracing: TracingConfig{
+			Endpoint:    "http://localhost:4318/v1/traces",
+			ServiceName: "api",
+			SampleRatio: 1,
+		},
+		Encryption: EncryptionConfig{
+			KeyVersion: 1,
+		},
+		Auth: AuthConfig{
+			AppName: "budhapp",
+			BaseURL: "http://localhost:3001",
+			JWT: JWTConfig{
+				Algorithm:        "EdDSA",
+				Expiration:       Duration(15 * time.Minute),
+				RotationInterval: Duration(30 * 24 * time.Hour),
+				RotationOverlap:  Duration(7 * 24 * time.Hour),
+			},
+			// better-auth defaults
+			MagicLink: PasswordlessConfig{
+				Expiration:      Duration(5 * time.Minute),
+				AllowedAttempts: 1,
+			},
+			EmailOTP: PasswordlessConfig{
+				Expiration:      Duration(5 * time.Minute),
+				AllowedAttempts: 3,
+			},
+			Admin: AdminConfig{
+				ImpersonationDuration: Duration(time.Hour),
+			},
+			DeleteUser: DeleteUserConfig{
+				VerificationExpiration: Duration(time.Hour),
+				FreshAge:               Duration(24 * time.Hour),
+				GracePeriod:            Duration(30 * 24 * time.Hour),
+			},
+			ChangeEmail: ChangeEmailConfig{
+				Expiration: Duration(time.Hour),
+			},
+		},
+		Polar: PolarConfig{
+			Server: "production",
+		},
+		Storage: StorageConfig{
+			Backend:       "local",
+			LocalPath:     "data/uploads",
+			MaxUploadSize: 10 << 20,
+			URLExpiration: Duration(15 * time.Minute),
+			S3: S3Config{
+				Region: "us-east-1",
+			},
+		},
+		RateLimit: RateLimitConfig{
+			Backend: "memory",
+			Global:  RateLimit{Requests: 300, Window: Duration(time.Minute)},
+			Auth:    RateLimit{Requests: 20, Window: Duration(time.Minute)},
+			Account: RateLimit{Requests: 10, Window: Duration(15 * time.Minute)},
+			Lockout: LockoutConfig{
+				Threshold:   5,
+				Duration:    Duration(time.Minute),
+				MaxDuration: Duration(time.Hour),
+			},
+		},
+		CORS: CORSConfig{
+			AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+			AllowedHeaders:   []string{"Content-Type", "Authorization", "X-CSRF-Token"},
+			ExposedHeaders:   []string{"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "Retry-After"},
+			AllowCredentials: true,
+			MaxAge:           Duration(2 * time.Hour),
+		},
+		CSRF: CSRFConfig{
+			ExemptPaths: []string{"/api/webhooks/"},
+		},
+		Security: SecurityConfig{
+			HSTSMaxAge:            Duration(365 * 24 * time.Hour),
+			HSTSIncludeSubdomains: true,
+			ReferrerPolicy:        "strict-origin-when-cross-origin",
+			FrameOptions:          "DENY",
+			PermissionsPolicy:     "camera=(), microphone=(), geolocation=(), payment=()",
+			ContentSecurityPolicy: "default-src 'none'; script-src 'nonce-{nonce}'; style-src 'nonce-{nonce}'; base-uri 'none'; form-action 'self'",
+		},
 	}
 }
 
@@ -103,15 +859,38 @@ func validate(config *Config) error {
 		return fmt.Errorf("address is required")
 	}
 
+	// HTTP server validation
+	for name, d := range map[string]Duration{
+		"read timeout":        config.HTTP.ReadTimeout,
+		"read header timeout": config.HTTP.ReadHeaderTimeout,
+		"write timeout":       config.HTTP.WriteTimeout,
+		"idle timeout":        config.HTTP.IdleTimeout,
+		"shutdown timeout":    config.HTTP.ShutdownTimeout,
+		"drain delay":         config.HTTP.DrainDelay,
+	} {
+		if d < 0 {
+			return fmt.Errorf("http %s must not be negative", name)
+		}
+	}
+	if _, err := config.HTTP.TrustedProxyPrefixes(); err != nil {
+		return err
+	}
+
 	// Encryption key validation
 	if config.Encryption.Key != "" {
-		// Decode base64 key and check decoded length
-		decodedKey, err := base64.StdEncoding.DecodeString(config.Encryption.Key)
-		if err != nil {
-			return fmt.Errorf("encryption key must be valid base64: %w", err)
+		if err := validateEncryptionKey(config.Encryption.Key); err != nil {
+			return err
 		}
-		if len(decodedKey) != 32 {
-			return fmt.Errorf("encryption key must decode to exactly 32 bytes (256 bits), got %d bytes", len(decodedKey))
+	}
+	if config.Encryption.KeyVersion < 1 {
+		return fmt.Errorf("encryption key version must be at least 1")
+	}
+	for version, key := range config.Encryption.PreviousKeys {
+		if version < 1 {
+			return fmt.Errorf("previous encryption key version must be at least 1")
+		}
+		if err := validateEncryptionKey(key); err != nil {
+			return fmt.Errorf("previous encryption key v%d: %w", version, err)
 		}
 	}
 
@@ -120,5 +899,204 @@ func validate(config *Config) error {
 		return fmt.Errorf("database connection string is required")
 	}
 
+	// Auth secret validation: an empty or short secret makes signed cookies
+	// and download URLs forgeable
+	if len(config.Auth.Secret) < MinSecretLength {
+		return fmt.Errorf("auth secret must be at least %d characters", MinSecretLength)
+	}
+
+	// JWT configuration validation
+	switch config.Auth.JWT.Algorithm {
+	case "EdDSA", "ES256", "RS256":
+	default:
+		return fmt.Errorf("jwt algorithm must be one of EdDSA, ES256 or RS256, got %q", config.Auth.JWT.Algorithm)
+	}
+	if config.Auth.JWT.Expiration <= 0 || config.Auth.JWT.RotationInterval <= 0 {
+		return fmt.Errorf("jwt expiration and rotation interval must be positive")
+	}
+	if config.Auth.JWT.RotationOverlap < config.Auth.JWT.Expiration {
+		return fmt.Errorf("jwt rotation overlap must be at least the token expiration")
+	}
+
+	// Passwordless validation
+	for name, passwordless := range map[string]PasswordlessConfig{"magic link": config.Auth.MagicLink, "email otp": config.Auth.EmailOTP} {
+		if passwordless.Expiration <= 0 || passwordless.AllowedAttempts < 1 {
+			return fmt.Errorf("%s expiration and allowed attempts must be positive", name)
+		}
+	}
+
+	// Admin validation
+	if config.Auth.Admin.ImpersonationDuration <= 0 {
+		return fmt.Errorf("admin impersonation duration must be positive")
+	}
+
+	// Account deletion validation
+	if config.Auth.DeleteUser.VerificationExpiration <= 0 || config.Auth.DeleteUser.FreshAge <= 0 {
+		return fmt.Errorf("delete user verification expiration and fresh age must be positive")
+	}
+	if config.Auth.DeleteUser.GracePeriod < 0 {
+		return fmt.Errorf("delete user grace period must not be negative")
+	}
+
+	if config.Auth.ChangeEmail.Expiration <= 0 {
+		return fmt.Errorf("change email expiration must be positive")
+	}
+
+	// Polar validation
+	if config.Polar.Server != "production" && config.Polar.Server != "sandbox" {
+		return fmt.Errorf("polar server must be production or sandbox, got %q", config.Polar.Server)
+	}
+
+	// Email validation
+	switch config.Email.Provider {
+	case "":
+	case "sendgrid":
+		if config.Email.SendGridAPIKey == "" {
+			return fmt.Errorf("sendgrid api key is required when email provider is sendgrid")
+		}
+	case "resend":
+		if config.Email.ResendAPIKey == "" {
+			return fmt.Errorf("resend api key is required when email provider is resend")
+		}
+	default:
+		return fmt.Errorf("email provider must be sendgrid or resend, got %q", config.Email.Provider)
+	}
+	if config.Email.Provider != "" && config.Email.From == "" {
+		return fmt.Errorf("email from address is required")
+	}
+
+	// Storage validation
+	switch config.Storage.Backend {
+	case "local":
+		if config.Storage.LocalPath == "" {
+			return fmt.Errorf("storage local path is required when storage backend is local")
+		}
+	case "s3":
+		s3 := config.Storage.S3
+		if s3.Bucket == "" || s3.Region == "" || s3.AccessKeyID == "" || s3.SecretAccessKey == "" {
+			return fmt.Errorf("s3 bucket, region, access key id and secret access key are required when storage backend is s3")
+		}
+	default:
+		return fmt.Errorf("storage backend must be local or s3, got %q", config.Storage.Backend)
+	}
+	if config.Storage.MaxUploadSize <= 0 || config.Storage.URLExpiration <= 0 {
+		return fmt.Errorf("upload max size and url expiration must be positive")
+	}
+
+	// Rate limit validation
+	if config.RateLimit.Backend != "memory" && config.RateLimit.Backend != "postgres" {
+		return fmt.Errorf("rate limit backend must be memory or postgres, got %q", config.RateLimit.Backend)
+	}
+	for name, limit := range map[string]RateLimit{
+		"global":  config.RateLimit.Global,
+		"auth":    config.RateLimit.Auth,
+		"account": config.RateLimit.Account,
+	} {
+		if limit.Requests < 0 || (limit.Requests > 0 && (limit.Window <= 0 || limit.Window.Std() > 24*time.Hour)) {
+			return fmt.Errorf("%s rate limit needs a positive window of at most 24h", name)
+		}
+	}
+	lockout := config.RateLimit.Lockout
+	if lockout.Threshold > 0 && (lockout.Duration <= 0 || lockout.MaxDuration < lockout.Duration || lockout.MaxDuration.Std() > 24*time.Hour) {
+		return fmt.Errorf("lockout duration must be positive and at most the max duration, which must be at most 24h")
+	}
+
+	// CORS validation
+	for _, origin := range config.CORS.AllowedOrigins {
+		if err := validateOrigin(origin); err != nil {
+			return err
+		}
+		if origin == "*" && config.CORS.AllowCredentials && config.IsProduction() {
+			return fmt.Errorf("cors allowed origin * can not be used with credentials in production")
+		}
+	}
+	if config.CORS.MaxAge < 0 {
+		return fmt.Errorf("cors max age must not be negative")
+	}
+
+	// CSRF validation
+	for prefix, origins := range config.CSRF.PathOrigins {
+		if !strings.HasPrefix(prefix, "/") {
+			return fmt.Errorf("csrf path %q must start with /", prefix)
+		}
+		for _, origin := range origins {
+			if origin == "*" {
+				return fmt.Errorf("csrf origins of %s can not be *", prefix)
+			}
+			if err := validateOrigin(origin); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Security headers validation
+	if config.Security.HSTSMaxAge < 0 {
+		return fmt.Errorf("hsts max age must not be negative")
+	}
+	switch config.Security.FrameOptions {
+	case "", "DENY", "SAMEORIGIN":
+	default:
+		return fmt.Errorf("frame options must be DENY or SAMEORIGIN, got %q", config.Security.FrameOptions)
+	}
+
+	// Health check validation
+	if config.Health.CacheTTL < 0 {
+		return fmt.Errorf("health cache ttl must not be negative")
+	}
+	if config.Health.Timeout <= 0 {
+		return fmt.Errorf("health check timeout must be positive")
+	}
+
+	// Tracing validation
+	switch config.Tracing.Exporter {
+	case "", "otlp", "stdout":
+	default:
+		return fmt.Errorf("unknown tracing exporter: %s", config.Tracing.Exporter)
+	}
+	if config.Tracing.Exporter == "otlp" && config.Tracing.Endpoint == "" {
+		return fmt.Errorf("tracing endpoint is required for the otlp exporter")
+	}
+	if config.Tracing.SampleRatio < 0 || config.Tracing.SampleRatio > 1 {
+		return fmt.Errorf("tracing sample ratio must be between 0 and 1")
+	}
+
+	// Social provider validation
+	for id, provider := range config.Auth.SocialProviders {
+		if provider.ClientID == "" || provider.ClientSecret == "" {
+			return fmt.Errorf("social provider %q requires a client id and secret", id)
+		}
+		if id != "github" && id != "google" && provider.Issuer == "" {
+			return fmt.Errorf("social provider %q requires an issuer", id)
+		}
+	}
+
+	return nil
+}
+
+// validateEncryptionKey checks that key is base64 and decodes to 32 bytes
+func validateEncryptionKey(key string) error {
+	decodedKey, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return fmt.Errorf("encryption key must be valid base64: %w", err)
+	}
+	if len(decodedKey) != 32 {
+		return fmt.Errorf("encryption key must decode to exactly 32 bytes (256 bits), got %d bytes", len(decodedKey))
+	}
+	return nil
+}
+
+// validateOrigin accepts "*", or a scheme and host with an optional port and
+// at most one wildcard, which must be the first label of the host
+func validateOrigin(origin string) error {
+	if origin == "*" {
+		return nil
+	}
+	scheme, host, found := strings.Cut(origin, "://")
+	if !found || (scheme != "http" && scheme != "https") || host == "" || strings.ContainsAny(host, "/?#@") {
+		return fmt.Errorf("invalid cors origin %q: must be a scheme and host like https://app.example.com", origin)
+	}
+	if strings.Contains(host, "*") && (!strings.HasPrefix(host, "*.") || strings.Count(host, "*") > 1) {
+		return fmt.Errorf("invalid cors origin %q: the wildcard must be the first label of the host", origin)
+	}
 	return nil
 }
@@ -0,0 +1,25 @@
+package email
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LogProvider writes emails to the log instead of sending them. Links and
+// codes end up in the log, so it must not be used in production.
+type LogProvider struct {
+	logger *slog.Logger
+}
+
+func NewLogProvider(logger *slog.Logger) *LogProvider {
+	return &LogProvider{logger: logger}
+}
+
+func (p *LogProvider) Send(_ context.Context, email Email) error {
+	p.logger.Info("email not sent, no email provider configured",
+		"to", email.To,
+		"subject", email.Subject,
+		"body", email.TextBody,
+	)
+	return nil
+}
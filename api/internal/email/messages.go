@@ -0,0 +1,92 @@
+package email
+
+import (
+	"fmt"
+	"html"
+	"time"
+)
+
+// MagicLink is the email with a one-click sign-in link
+func MagicLink(to, appName, link string, validFor time.Duration) Email {
+	return Email{
+		To:      to,
+		Subject: fmt.Sprintf("Sign in to %s", appName),
+		TextBody: fmt.Sprintf("Click the link below to sign in to %s:\n\n%s\n\nThe link expires in %s. If you did not request it, you can ignore this email.\n",
+			appName, link, humanize(validFor)),
+		HTMLBody: fmt.Sprintf(`<p>Click the link below to sign in to %s:</p><p><a href="%s">Sign in</a></p><p>The link expires in %s. If you did not request it, you can ignore this email.</p>`,
+			html.EscapeString(appName), html.EscapeString(link), humanize(validFor)),
+	}
+}
+
+// SignInCode is the email with a one-time sign-in code
+func SignInCode(to, appName, code string, validFor time.Duration) Email {
+	return Email{
+		To:      to,
+		Subject: fmt.Sprintf("Your %s sign-in code", appName),
+		TextBody: fmt.Sprintf("Your %s sign-in code is %s\n\nIt expires in %s. If you did not request it, you can ignore this email.\n",
+			appName, code, humanize(validFor)),
+		HTMLBody: fmt.Sprintf(`<p>Your %s sign-in code is</p><p style="font-size:24px;letter-spacing:4px"><strong>%s</strong></p><p>It expires in %s. If you did not request it, you can ignore this email.</p>`,
+			html.EscapeString(appName), html.EscapeString(code), humanize(validFor)),
+	}
+}
+
+// humanize formats a lifetime as "5 minutes" or "1 hour"
+func humanize(d time.Duration) string {
+	n, unit := int(d.Round(time.Minute)/time.Minute), "minute"
+	if n >= 60 && n%60 == 0 {
+		n, unit = n/60, "hour"
+	}
+	if n != 1 {
+		unit += "s"
+	}
+	return fmt.Sprintf("%d %s", n, unit)
+}
+
+// Invitation is the email inviting someone to join an organization
+func Invitation(to, appName, inviter, organization, link string, validFor time.Duration) Email {
+	return Email{
+		To:      to,
+		Subject: fmt.Sprintf("%s invited you to %s on %s", inviter, organization, appName),
+		TextBody: fmt.Sprintf("%s invited you to join %s on %s.\n\nAccept the invitation:\n\n%s\n\nThe invitation expires in %s.\n",
+			inviter, organization, appName, link, humanize(validFor)),
+		HTMLBody: fmt.Sprintf(`<p>%s invited you to join <strong>%s</strong> on %s.</p><p><a href="%s">Accept the invitation</a></p><p>The invitation expires in %s.</p>`,
+			html.EscapeString(inviter), html.EscapeString(organization), html.EscapeString(appName), html.EscapeString(link), humanize(validFor)),
+	}
+}
+
+// DeleteAccount is the email confirming an account deletion request
+func DeleteAccount(to, appName, link string, validFor time.Duration) Email {
+	return Email{
+		To:      to,
+		Subject: fmt.Sprintf("Confirm the deletion of your %s account", appName),
+		TextBody: fmt.Sprintf("Click the link below to delete your %s account:\n\n%s\n\nThe link expires in %s. If you did not request it, you can ignore this email and your account is kept.\n",
+			appName, link, humanize(validFor)),
+		HTMLBody: fmt.Sprintf(`<p>Click the link below to delete your %s account:</p><p><a href="%s">Delete my account</a></p><p>The link expires in %s. If you did not request it, you can ignore this email and your account is kept.</p>`,
+			html.EscapeString(appName), html.EscapeString(link), humanize(validFor)),
+	}
+}
+
+// VerifyNewEmail is the email confirming a new address for an account
+func VerifyNewEmail(to, appName, link string, validFor time.Duration) Email {
+	return Email{
+		To:      to,
+		Subject: fmt.Sprintf("Verify your new %s email address", appName),
+		TextBody: fmt.Sprintf("Click the link below to use this address for your %s account:\n\n%s\n\nThe link expires in %s. If you did not request it, you can ignore this email.\n",
+			appName, link, humanize(validFor)),
+		HTMLBody: fmt.Sprintf(`<p>Click the link below to use this address for your %s account:</p><p><a href="%s">Verify email address</a></p><p>The link expires in %s. If you did not request it, you can ignore this email.</p>`,
+			html.EscapeString(appName), html.EscapeString(link), humanize(validFor)),
+	}
+}
+
+// EmailChangeRequested warns the current address that the account email is
+// about to change
+func EmailChangeRequested(to, appName, newEmail string) Email {
+	return Email{
+		To:      to,
+		Subject: fmt.Sprintf("Your %s email address is being changed", appName),
+		TextBody: fmt.Sprintf("A change of your %s email address to %s was requested. It takes effect once the new address is verified.\n\nIf you did not request it, sign in and secure your account.\n",
+			appName, newEmail),
+		HTMLBody: fmt.Sprintf(`<p>A change of your %s email address to <strong>%s</strong> was requested. It takes effect once the new address is verified.</p><p>If you did not request it, sign in and secure your account.</p>`,
+			html.EscapeString(appName), html.EscapeString(newEmail)),
+	}
+}
@@ -0,0 +1,103 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"budhapp.com/internal/config"
+	"budhapp.com/internal/tracing"
+)
+
+// sendTimeout bounds a single call to a provider API
+const sendTimeout = 10 * time.Second
+
+type Email struct {
+	To string
+	// From defaults to the configured sender address
+	From     string
+	Subject  string
+	HTMLBody string
+	TextBody string
+}
+
+// EmailProvider delivers transactional emails
+type EmailProvider interface {
+	Send(ctx context.Context, email Email) error
+}
+
+// HealthChecker is implemented by providers whose API can be checked
+type HealthChecker interface {
+	Check(ctx context.Context) error
+}
+
+// NewEmailProvider returns the provider selected by the configuration. Without
+// a provider emails are written to the log, which is enough for development.
+func NewEmailProvider(cfg config.EmailConfig, logger *slog.Logger) (EmailProvider, error) {
+	client := &http.Client{Timeout: sendTimeout, Transport: tracing.Transport(nil)}
+
+	switch cfg.Provider {
+	case "sendgrid":
+		return NewSendGridProvider(cfg.SendGridAPIKey, cfg.From, client), nil
+	case "resend":
+		return NewResendProvider(cfg.ResendAPIKey, cfg.From, client), nil
+	case "":
+		return NewLogProvider(logger), nil
+	default:
+		return nil, fmt.Errorf("unknown email provider: %s", cfg.Provider)
+	}
+}
+
+// reachable checks that a provider API answers. Any response short of a
+// server error will do, as checking the key could need more permissions
+// than sending.
+func reachable(ctx context.Context, client *http.Client, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// postJSON sends payload to a provider API with a bearer token
+func postJSON(ctx context.Context, client *http.Client, url, apiKey string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+
+	return nil
+}
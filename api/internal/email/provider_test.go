@@ -0,0 +1,130 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"budhapp.com/internal/config"
+)
+
+// capture starts a server that records the JSON body of the last request
+func capture(t *testing.T, status int, body any) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("request = %s %s, want POST with bearer token", r.Method, r.Header.Get("Authorization"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+			t.Error(err)
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+var testEmail = Email{
+	To:       "jane@example.com",
+	Subject:  "Hello",
+	TextBody: "text",
+	HTMLBody: "<p>html</p>",
+}
+
+func TestSendGridProvider(t *testing.T) {
+	var got sendgridMessage
+	srv := capture(t, http.StatusAccepted, &got)
+
+	p := NewSendGridProvider("test-key", "noreply@example.com", srv.Client())
+	p.apiURL = srv.URL
+	if err := p.Send(context.Background(), testEmail); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if got.Personalizations[0].To[0].Email != "jane@example.com" || got.From.Email != "noreply@example.com" || got.Subject != "Hello" {
+		t.Errorf("message = %+v", got)
+	}
+	if len(got.Content) != 2 || got.Content[0].Type != "text/plain" || got.Content[1].Value != "<p>html</p>" {
+		t.Errorf("content = %+v", got.Content)
+	}
+}
+
+func TestResendProvider(t *testing.T) {
+	var got resendMessage
+	srv := capture(t, http.StatusOK, &got)
+
+	p := NewResendProvider("test-key", "noreply@example.com", srv.Client())
+	p.apiURL = srv.URL
+	e := testEmail
+	e.From = "support@example.com"
+	if err := p.Send(context.Background(), e); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if got.To[0] != "jane@example.com" || got.From != "support@example.com" || got.Text != "text" || got.HTML != "<p>html</p>" {
+		t.Errorf("message = %+v", got)
+	}
+}
+
+func TestProviderError(t *testing.T) {
+	var got resendMessage
+	srv := capture(t, http.StatusUnprocessableEntity, &got)
+
+	p := NewResendProvider("test-key", "noreply@example.com", srv.Client())
+	p.apiURL = srv.URL
+	if err := p.Send(context.Background(), testEmail); err == nil {
+		t.Error("Send() succeeded on a 422 response")
+	}
+}
+
+func TestProviderCheck(t *testing.T) {
+	status := http.StatusUnauthorized
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	p := NewSendGridProvider("test-key", "noreply@example.com", srv.Client())
+	p.apiURL = srv.URL
+	if err := p.Check(context.Background()); err != nil {
+		t.Errorf("Check() error = %v on a 401 response", err)
+	}
+
+	status = http.StatusServiceUnavailable
+	if err := p.Check(context.Background()); err == nil {
+		t.Error("Check() succeeded on a 503 response")
+	}
+
+	srv.Close()
+	if err := p.Check(context.Background()); err == nil {
+		t.Error("Check() succeeded without a server")
+	}
+}
+
+func TestNewEmailProvider(t *testing.T) {
+	tests := []struct {
+		provider string
+		want     string
+	}{
+		{"sendgrid", "*email.SendGridProvider"},
+		{"resend", "*email.ResendProvider"},
+		{"", "*email.LogProvider"},
+	}
+	for _, tt := range tests {
+		p, err := NewEmailProvider(config.EmailConfig{Provider: tt.provider}, slog.Default())
+		if err != nil {
+			t.Fatalf("NewEmailProvider(%q) error = %v", tt.provider, err)
+		}
+		if got := fmt.Sprintf("%T", p); got != tt.want {
+			t.Errorf("NewEmailProvider(%q) = %s, want %s", tt.provider, got, tt.want)
+		}
+	}
+
+	if _, err := NewEmailProvider(config.EmailConfig{Provider: "smtp"}, slog.Default()); err == nil {
+		t.Error("NewEmailProvider(smtp) succeeded")
+	}
+}
@@ -0,0 +1,61 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+const resendAPIURL = "https://api.resend.com/emails"
+
+// ResendProvider sends emails with the Resend API
+type ResendProvider struct {
+	apiKey string
+	from   string
+	client *http.Client
+	apiURL string
+}
+
+func NewResendProvider(apiKey, from string, client *http.Client) *ResendProvider {
+	return &ResendProvider{
+		apiKey: apiKey,
+		from:   from,
+		client: client,
+		apiURL: resendAPIURL,
+	}
+}
+
+type resendMessage struct {
+	From    string   `json:"from"`
+	To      []string `json:"to"`
+	Subject string   `json:"subject"`
+	HTML    string   `json:"html,omitempty"`
+	Text    string   `json:"text,omitempty"`
+}
+
+func (p *ResendProvider) Send(ctx context.Context, email Email) error {
+	from := email.From
+	if from == "" {
+		from = p.from
+	}
+
+	if err := postJSON(ctx, p.client, p.apiURL, p.apiKey, resendMessage{
+		From:    from,
+		To:      []string{email.To},
+		Subject: email.Subject,
+		HTML:    email.HTMLBody,
+		Text:    email.TextBody,
+	}); err != nil {
+		return fmt.Errorf("resend: %w", err)
+	}
+
+	return nil
+}
+
+// Check reports whether the Resend API is reachable
+func (p *ResendProvider) Check(ctx context.Context) error {
+	if err := reachable(ctx, p.client, p.apiURL); err != nil {
+		return fmt.Errorf("resend: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+const sendgridAPIURL = "https://api.sendgrid.com/v3/mail/send"
+
+// SendGridProvider sends emails with the SendGrid v3 Mail Send API
+type SendGridProvider struct {
+	apiKey string
+	from   string
+	client *http.Client
+	apiURL string
+}
+
+func NewSendGridProvider(apiKey, from string, client *http.Client) *SendGridProvider {
+	return &SendGridProvider{
+		apiKey: apiKey,
+		from:   from,
+		client: client,
+		apiURL: sendgridAPIURL,
+	}
+}
+
+type sendgridAddress struct {
+	Email string `json:"email"`
+}
+
+type sendgridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sendgridPersonalization struct {
+	To []sendgridAddress `json:"to"`
+}
+
+type sendgridMessage struct {
+	Personalizations []sendgridPersonalization `json:"personalizations"`
+	From             sendgridAddress           `json:"from"`
+	Subject          string                    `json:"subject"`
+	Content          []sendgridContent         `json:"content"`
+}
+
+func (p *SendGridProvider) Send(ctx context.Context, email Email) error {
+	from := email.From
+	if from == "" {
+		from = p.from
+	}
+
+	// SendGrid requires text/plain to come before text/html
+	var content []sendgridContent
+	if email.TextBody != "" {
+		content = append(content, sendgridContent{Type: "text/plain", Value: email.TextBody})
+	}
+	if email.HTMLBody != "" {
+		content = append(content, sendgridContent{Type: "text/html", Value: email.HTMLBody})
+	}
+
+	if err := postJSON(ctx, p.client, p.apiURL, p.apiKey, sendgridMessage{
+		Personalizations: []sendgridPersonalization{{To: []sendgridAddress{{Email: email.To}}}},
+		From:             sendgridAddress{Email: from},
+		Subject:          email.Subject,
+		Content:          content,
+	}); err != nil {
+		return fmt.Errorf("sendgrid: %w", err)
+	}
+
+	return nil
+}
+
+// Check reports whether the SendGrid API is reachable
+func (p *SendGridProvider) Check(ctx context.Context) error {
+	if err := reachable(ctx, p.client, p.apiURL); err != nil {
+		return fmt.Errorf("sendgrid: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrMissingKey       = errors.New("encryption key is not configured")
+	ErrInvalidPayload   = errors.New("invalid encrypted payload")
+	ErrDecryptionFailed = errors.New("decryption failed")
+)
+
+// Cipher encrypts values at rest with AES-256-GCM
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// New creates a Cipher from a base64 encoded 32 byte key (config.Encryption.Key)
+func New(key string) (*Cipher, error) {
+	if key == "" {
+		return nil, ErrMissingKey
+	}
+
+	decodedKey, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("encryption key must be valid base64: %w", err)
+	}
+	if len(decodedKey) != 32 {
+		return nil, fmt.Errorf("encryption key must decode to exactly 32 bytes, got %d bytes", len(decodedKey))
+	}
+
+	block, err := aes.NewCipher(decodedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cipher{aead: aead}, nil
+}
+
+// Encrypt seals plaintext and returns base64(nonce || ciphertext)
+func (c *Cipher) Encrypt(plaintext []byte) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := c.aead.Seal(nonce, nonce, plaintext, nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value produced by Encrypt
+func (c *Cipher) Decrypt(payload string) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrInvalidPayload
+	}
+
+	nonceSize := c.aead.NonceSize()
+	if len(sealed) < nonceSize+c.aead.Overhead() {
+		return nil, ErrInvalidPayload
+	}
+
+	plaintext, err := c.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+
+	return plaintext, nil
+}
+
+// EncryptString is a convenience wrapper around Encrypt for string values
+func (c *Cipher) EncryptString(plaintext string) (string, error) {
+	return c.Encrypt([]byte(plaintext))
+}
+
+// DecryptString is a convenience wrapper around Decrypt for string values
+func (c *Cipher) DecryptString(payload string) (string, error) {
+	plaintext, err := c.Decrypt(payload)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
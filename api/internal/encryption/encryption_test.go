@@ -0,0 +1,99 @@
+package encryption
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+var testKey = base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{"valid key", testKey, false},
+		{"empty key", "", true},
+		{"not base64", "not base64!", true},
+		{"short key", base64.StdEncoding.EncodeToString([]byte("short")), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEncryptDecryptRoundTrip(t *testing.T) {
+	c, err := New(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, plaintext := range []string{"", "secret", strings.Repeat("x", 4096)} {
+		payload, err := c.EncryptString(plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if plaintext != "" && strings.Contains(payload, plaintext) {
+			t.Error("payload contains plaintext")
+		}
+
+		got, err := c.DecryptString(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != plaintext {
+			t.Errorf("DecryptString() = %q, want %q", got, plaintext)
+		}
+	}
+}
+
+func TestEncryptUsesFreshNonce(t *testing.T) {
+	c, err := New(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, _ := c.EncryptString("same")
+	b, _ := c.EncryptString("same")
+	if a == b {
+		t.Error("two encryptions of the same plaintext should differ")
+	}
+}
+
+func TestDecryptRejectsTamperedPayload(t *testing.T) {
+	c, err := New(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload, _ := c.EncryptString("secret")
+	raw, _ := base64.StdEncoding.DecodeString(payload)
+	raw[len(raw)-1] ^= 0xff
+
+	tests := []struct {
+		name    string
+		payload string
+		wantErr error
+	}{
+		{"tampered", base64.StdEncoding.EncodeToString(raw), ErrDecryptionFailed},
+		{"not base64", "%%%", ErrInvalidPayload},
+		{"too short", base64.StdEncoding.EncodeToString([]byte("abc")), ErrInvalidPayload},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := c.Decrypt(tt.payload); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Decrypt() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
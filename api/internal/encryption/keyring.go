@@ -0,0 +1,115 @@
+package encryption
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"budhapp.com/internal/config"
+)
+
+var (
+	ErrUnknownKeyVersion = errors.New("unknown encryption key version")
+)
+
+// Keyring encrypts with the current key and decrypts with any configured key
+// version, so the encryption key can be rotated without rewriting stored data
+// up front. Payloads are prefixed with their key version: "v<N>:<payload>".
+type Keyring struct {
+	current int
+	ciphers map[int]*Cipher
+}
+
+// NewKeyring creates a Keyring from the encryption configuration
+func NewKeyring(cfg config.EncryptionConfig) (*Keyring, error) {
+	current, err := New(cfg.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	k := &Keyring{
+		current: cfg.KeyVersion,
+		ciphers: map[int]*Cipher{cfg.KeyVersion: current},
+	}
+
+	for version, key := range cfg.PreviousKeys {
+		if version == cfg.KeyVersion {
+			continue
+		}
+		c, err := New(key)
+		if err != nil {
+			return nil, fmt.Errorf("previous encryption key v%d: %w", version, err)
+		}
+		k.ciphers[version] = c
+	}
+
+	return k, nil
+}
+
+// Encrypt seals plaintext with the current key
+func (k *Keyring) Encrypt(plaintext []byte) (string, error) {
+	payload, err := k.ciphers[k.current].Encrypt(plaintext)
+	if err != nil {
+		return "", err
+	}
+	return "v" + strconv.Itoa(k.current) + ":" + payload, nil
+}
+
+// Decrypt opens a value produced by Encrypt with the key it was sealed with.
+// Unversioned payloads predate the keyring and are tried with every key.
+func (k *Keyring) Decrypt(payload string) ([]byte, error) {
+	version, sealed, ok := splitVersion(payload)
+	if !ok {
+		for _, c := range k.ciphers {
+			if plaintext, err := c.Decrypt(payload); err == nil {
+				return plaintext, nil
+			}
+		}
+		return nil, ErrDecryptionFailed
+	}
+
+	c, found := k.ciphers[version]
+	if !found {
+		return nil, fmt.Errorf("%w: v%d", ErrUnknownKeyVersion, version)
+	}
+	return c.Decrypt(sealed)
+}
+
+// EncryptString is a convenience wrapper around Encrypt for string values
+func (k *Keyring) EncryptString(plaintext string) (string, error) {
+	return k.Encrypt([]byte(plaintext))
+}
+
+// DecryptString is a convenience wrapper around Decrypt for string values
+func (k *Keyring) DecryptString(payload string) (string, error) {
+	plaintext, err := k.Decrypt(payload)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// NeedsRotation reports whether payload was not sealed with the current key
+func (k *Keyring) NeedsRotation(payload string) bool {
+	version, _, ok := splitVersion(payload)
+	return !ok || version != k.current
+}
+
+// IsVersioned reports whether payload carries a key version prefix
+func IsVersioned(payload string) bool {
+	_, _, ok := splitVersion(payload)
+	return ok
+}
+
+func splitVersion(payload string) (int, string, bool) {
+	prefix, sealed, found := strings.Cut(payload, ":")
+	if !found || len(prefix) < 2 || prefix[0] != 'v' {
+		return 0, "", false
+	}
+	version, err := strconv.Atoi(prefix[1:])
+	if err != nil || version < 1 {
+		return 0, "", false
+	}
+	return version, sealed, true
+}
@@ -0,0 +1,72 @@
+package encryption
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"budhapp.com/internal/config"
+)
+
+var otherKey = base64.StdEncoding.EncodeToString([]byte("fedcba9876543210fedcba9876543210"))
+
+func TestKeyringRotation(t *testing.T) {
+	old, err := NewKeyring(config.EncryptionConfig{Key: testKey, KeyVersion: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, _ := old.EncryptString("secret")
+	if !strings.HasPrefix(payload, "v1:") {
+		t.Fatalf("payload = %q, want v1 prefix", payload)
+	}
+
+	rotated, err := NewKeyring(config.EncryptionConfig{
+		Key:          otherKey,
+		KeyVersion:   2,
+		PreviousKeys: map[int]string{1: testKey},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := rotated.DecryptString(payload)
+	if err != nil || got != "secret" {
+		t.Fatalf("DecryptString() = %q, %v; want previous key to decrypt", got, err)
+	}
+	if !rotated.NeedsRotation(payload) {
+		t.Error("NeedsRotation() = false for a v1 payload with current key v2")
+	}
+
+	fresh, _ := rotated.EncryptString("secret")
+	if !strings.HasPrefix(fresh, "v2:") || rotated.NeedsRotation(fresh) {
+		t.Errorf("EncryptString() = %q, want current v2 payload", fresh)
+	}
+
+	// Once the old key is dropped its payloads can no longer be read
+	if _, err := old.DecryptString(fresh); !errors.Is(err, ErrUnknownKeyVersion) {
+		t.Errorf("DecryptString() error = %v, want %v", err, ErrUnknownKeyVersion)
+	}
+}
+
+func TestKeyringDecryptsUnversionedPayload(t *testing.T) {
+	c, _ := New(testKey)
+	legacy, _ := c.EncryptString("secret")
+
+	k, err := NewKeyring(config.EncryptionConfig{
+		Key:          otherKey,
+		KeyVersion:   2,
+		PreviousKeys: map[int]string{1: testKey},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if IsVersioned(legacy) {
+		t.Error("IsVersioned() = true for a payload without prefix")
+	}
+	got, err := k.DecryptString(legacy)
+	if err != nil || got != "secret" {
+		t.Errorf("DecryptString() = %q, %v", got, err)
+	}
+}
@@ -0,0 +1,647 @@
+package handlers
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"budhapp.com/internal/audit"
+	"budhapp.com/internal/config"
+	"budhapp.com/internal/email"
+	"budhapp.com/internal/logging"
+	"budhapp.com/internal/polar"
+	"budhapp.com/internal/repository"
+	"budhapp.com/internal/session"
+	"budhapp.com/internal/storage"
+	"budhapp.com/internal/validate"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// deleteAccountIdentifier prefixes the token in the verification
+	// identifier, as better-auth does
+	deleteAccountIdentifier = "delete-account-"
+	// purgeBatchSize bounds the accounts deleted by one purge run
+	purgeBatchSize = 100
+)
+
+// ErrSoleProjectOwner is returned when deleting a user would leave
+// organization projects without an owner
+var ErrSoleProjectOwner = errors.New("user is the only owner of organization projects")
+
+// AccountHandler deletes accounts and exports their data
+type AccountHandler struct {
+	queries        *repository.Queries
+	pool           *pgxpool.Pool
+	sessions       *sessionStore
+	emails         email.EmailProvider
+	billing        *polar.Client
+	store          storage.Store
+	appName        string
+	baseURL        string
+	trustedOrigins []string
+	deleteUser     config.DeleteUserConfig
+}
+
+func NewAccountHandler(queries *repository.Queries, pool *pgxpool.Pool, sessions *sessionStore, emails email.EmailProvider, billing *polar.Client, store storage.Store, cfg config.AuthConfig) *AccountHandler {
+	return &AccountHandler{
+		queries:        queries,
+		pool:           pool,
+		sessions:       sessions,
+		emails:         emails,
+		billing:        billing,
+		store:          store,
+		appName:        cfg.AppName,
+		baseURL:        strings.TrimSuffix(cfg.BaseURL, "/"),
+		trustedOrigins: cfg.TrustedOrigins,
+		deleteUser:     cfg.DeleteUser,
+	}
+}
+
+type deleteUserRequest struct {
+	Password    string `json:"password" validate:"max=128"`
+	CallbackURL string `json:"callbackURL"`
+}
+
+// DeleteUser deletes the current user's account. The user confirms with
+// their password or a session younger than the fresh age. When verification
+// is enabled a confirmation link is emailed instead, and when a grace period
+// is configured the deletion is only scheduled.
+// (better-auth: POST /api/auth/delete-user)
+func (h *AccountHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	sess, user, err := h.sessions.current(r)
+	if err != nil {
+		respondError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+	if sess.ImpersonatedBy != nil {
+		respondError(w, r, http.StatusForbidden, "YOU_CANNOT_DELETE_IMPERSONATED_USERS", "You cannot delete a user while impersonating them")
+		return
+	}
+
+	req, err := decodeAuthRequest[deleteUserRequest](w, r, validate.AllowEmptyBody())
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	if req.CallbackURL != "" && !isTrustedURL(req.CallbackURL, h.baseURL, h.trustedOrigins) {
+		respondError(w, r, http.StatusForbidden, "INVALID_CALLBACK_URL", "Invalid callback URL")
+		return
+	}
+
+	if req.Password != "" {
+		if !h.sessions.confirmPassword(w, r, user, req.Password) {
+			return
+		}
+	} else if time.Since(sess.CreatedAt) > h.deleteUser.FreshAge.Std() {
+		respondError(w, r, http.StatusBadRequest, "SESSION_EXPIRED", "Session expired. Re-authenticate to perform this action.")
+		return
+	}
+
+	// Checked before the confirmation email too, which couldn't be used
+	if err := checkProjectOwners(ctx, h.queries, user.ID); err != nil {
+		h.respondDeleteFailed(w, r, err)
+		return
+	}
+
+	if h.deleteUser.SendVerification {
+		token := generateToken(32)
+		if _, err := h.queries.CreateVerification(ctx, repository.CreateVerificationParams{
+			Identifier: deleteAccountIdentifier + token,
+			Value:      user.ID.String(),
+			ExpiresAt:  time.Now().Add(h.deleteUser.VerificationExpiration.Std()),
+		}); err != nil {
+			logging.FromContext(ctx).Error("failed to store deletion token", "error", err)
+			respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete user")
+			return
+		}
+
+		query := url.Values{"token": {token}}
+		if req.CallbackURL != "" {
+			query.Set("callbackURL", req.CallbackURL)
+		}
+		link := h.baseURL + "/api/auth/delete-user/callback?" + query.Encode()
+		sendEmail(logging.FromContext(ctx), h.emails, email.DeleteAccount(user.Email, h.appName, link, h.deleteUser.VerificationExpiration.Std()))
+
+		respondJSON(w, http.StatusOK, map[string]any{"success": true, "message": "Verification email sent"})
+		return
+	}
+
+	scheduledAt, err := h.delete(ctx, r, user)
+	if err != nil {
+		h.respondDeleteFailed(w, r, err)
+		return
+	}
+	h.respondDeleted(w, scheduledAt)
+}
+
+// DeleteUserCallback deletes the account from the emailed confirmation link.
+// It must be opened with the session of the same user.
+// (better-auth: GET /api/auth/delete-user/callback)
+func (h *AccountHandler) DeleteUserCallback(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	callbackURL := query.Get("callbackURL")
+	if callbackURL != "" && !isTrustedURL(callbackURL, h.baseURL, h.trustedOrigins) {
+		respondError(w, r, http.StatusForbidden, "INVALID_CALLBACK_URL", "Invalid callback URL")
+		return
+	}
+
+	_, user, err := h.sessions.current(r)
+	if err != nil {
+		respondError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	verification, err := h.queries.ConsumeVerification(ctx, deleteAccountIdentifier+query.Get("token"))
+	if err != nil || verification.Value != user.ID.String() {
+		respondError(w, r, http.StatusBadRequest, "INVALID_TOKEN", "Invalid token")
+		return
+	}
+
+	scheduledAt, err := h.delete(ctx, r, user)
+	if err != nil {
+		h.respondDeleteFailed(w, r, err)
+		return
+	}
+
+	if callbackURL != "" {
+		if scheduledAt == nil {
+			h.sessions.clearCookie(w, session.SessionCookieName)
+		}
+		http.Redirect(w, r, callbackURL, http.StatusFound)
+		return
+	}
+	h.respondDeleted(w, scheduledAt)
+}
+
+// respondDeleted answers a confirmed deletion. The session cookie is cleared
+// once the account is gone.
+func (h *AccountHandler) respondDeleted(w http.ResponseWriter, scheduledAt *time.Time) {
+	if scheduledAt != nil {
+		respondJSON(w, http.StatusOK, map[string]any{
+			"success":             true,
+			"message":             "User deletion scheduled",
+			"deletionScheduledAt": scheduledAt,
+		})
+		return
+	}
+
+	h.sessions.clearCookie(w, session.SessionCookieName)
+	respondJSON(w, http.StatusOK, map[string]any{"success": true, "message": "User deleted"})
+}
+
+// respondDeleteFailed answers a deletion that was refused or failed
+func (h *AccountHandler) respondDeleteFailed(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, ErrSoleProjectOwner) {
+		respondError(w, r, http.StatusBadRequest, "YOU_ARE_THE_ONLY_OWNER_OF_ORGANIZATION_PROJECTS", "Transfer the ownership of your organization projects before deleting your account")
+		return
+	}
+	logging.FromContext(r.Context()).Error("failed to delete user", "error", err)
+	respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete user")
+}
+
+// checkProjectOwners refuses the deletion of a user who is the only owner of
+// organization projects. Their personal projects go with them, but
+// organization projects would be left without an owner.
+func checkProjectOwners(ctx context.Context, q *repository.Queries, userID uuid.UUID) error {
+	count, err := q.CountProjectsSolelyOwnedByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrSoleProjectOwner
+	}
+	return nil
+}
+
+// delete deletes the account now, or schedules its deletion when there is a
+// grace period and returns the time it will be deleted
+func (h *AccountHandler) delete(ctx context.Context, r *http.Request, user repository.User) (*time.Time, error) {
+	if h.deleteUser.GracePeriod == 0 {
+		return nil, h.purge(ctx, user, &user.ID)
+	}
+
+	// A deletion that is already scheduled keeps its date
+	if user.DeletionScheduledAt != nil {
+		return user.DeletionScheduledAt, nil
+	}
+	if err := checkProjectOwners(ctx, h.queries, user.ID); err != nil {
+		return nil, err
+	}
+
+	scheduledAt := time.Now().Add(h.deleteUser.GracePeriod.Std())
+	err := withTx(ctx, h.pool, h.queries, func(q *repository.Queries) error {
+		if _, err := q.ScheduleUserDeletion(ctx, repository.ScheduleUserDeletionParams{
+			ID:                  user.ID,
+			DeletionScheduledAt: &scheduledAt,
+		}); err != nil {
+			return err
+		}
+		return audit.Record(ctx, q, r, audit.Entry{
+			Action:     audit.ActionAccountDeletionScheduled,
+			ActorID:    &user.ID,
+			TargetType: audit.TargetUser,
+			TargetID:   user.ID.String(),
+			Diff:       map[string]audit.Change{"deletionScheduledAt": {To: scheduledAt}},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &scheduledAt, nil
+}
+
+// CancelDeletion cancels the scheduled deletion of the current user's account
+func (h *AccountHandler) CancelDeletion(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	_, user, err := h.sessions.current(r)
+	if err != nil {
+		respondError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+	if user.DeletionScheduledAt == nil {
+		respondError(w, r, http.StatusBadRequest, "DELETION_NOT_SCHEDULED", "Account deletion is not scheduled")
+		return
+	}
+
+	err = withTx(ctx, h.pool, h.queries, func(q *repository.Queries) error {
+		if _, err := q.CancelUserDeletion(ctx, user.ID); err != nil {
+			return err
+		}
+		return audit.Record(ctx, q, r, audit.Entry{
+			Action:     audit.ActionAccountDeletionCanceled,
+			ActorID:    &user.ID,
+			TargetType: audit.TargetUser,
+			TargetID:   user.ID.String(),
+			Diff:       map[string]audit.Change{"deletionScheduledAt": {From: user.DeletionScheduledAt}},
+		})
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to cancel account deletion", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to cancel account deletion")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// purge deletes the user with everything they own: sessions, accounts,
+// personal projects and memberships cascade from the user row. The Polar
+// subscription is revoked first, so a failure there leaves the account in
+// place to be retried. Audit entries are kept with their personal data
+// erased. actorID is nil when the deletion ran after the grace period.
+// It is refused with ErrSoleProjectOwner while the user is the only owner
+// of organization projects, checked again in the transaction.
+func (h *AccountHandler) purge(ctx context.Context, user repository.User, actorID *uuid.UUID) error {
+	if err := checkProjectOwners(ctx, h.queries, user.ID); err != nil {
+		return err
+	}
+
+	var revoked *string
+	subscription, err := h.queries.GetSubscriptionByUserID(ctx, user.ID)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+	case err != nil:
+		return err
+	case subscription.PolarSubscriptionId != nil && subscription.Status != SubscriptionStatusCanceled:
+		if !h.billing.Enabled() {
+			logging.FromContext(ctx).Warn("polar access token not configured, subscription not revoked",
+				"user_id", user.ID, "subscription_id", *subscription.PolarSubscriptionId)
+			break
+		}
+		if err := h.billing.RevokeSubscription(ctx, *subscription.PolarSubscriptionId); err != nil {
+			return fmt.Errorf("revoke subscription: %w", err)
+		}
+		revoked = subscription.PolarSubscriptionId
+	}
+
+	// The assets are deleted with the user, their files afterwards
+	assets, err := h.queries.ListAssetsByUserId(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	err = withTx(ctx, h.pool, h.queries, func(q *repository.Queries) error {
+		if err := checkProjectOwners(ctx, q, user.ID); err != nil {
+			return err
+		}
+		if _, err := q.ScrubAuditLogsByUser(ctx, user.ID); err != nil {
+			return err
+		}
+		if _, err := q.DeleteUser(ctx, user.ID); err != nil {
+			return err
+		}
+
+		// Written without the request so no client details are kept
+		if revoked != nil {
+			if err := audit.Record(ctx, q, nil, audit.Entry{
+				Action:     audit.ActionSubscriptionRevoke,
+				ActorID:    actorID,
+				TargetType: audit.TargetSubscription,
+				TargetID:   *revoked,
+			}); err != nil {
+				return err
+			}
+		}
+		return audit.Record(ctx, q, nil, audit.Entry{
+			Action:     audit.ActionAccountDelete,
+			ActorID:    actorID,
+			TargetType: audit.TargetUser,
+			TargetID:   user.ID.String(),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	released := make(map[string]bool, len(assets))
+	for _, asset := range assets {
+		if !released[asset.Sha256] {
+			released[asset.Sha256] = true
+			releaseBlobs(ctx, h.pool, h.queries, h.store, asset.Sha256)
+		}
+	}
+	return nil
+}
+
+// PurgeScheduled deletes the accounts whose grace period has ended. A
+// failed deletion is logged and retried on the next run.
+func (h *AccountHandler) PurgeScheduled(ctx context.Context) error {
+	users, err := h.queries.ListUsersDueForDeletion(ctx, purgeBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		err := h.purge(ctx, user, nil)
+		if errors.Is(err, ErrSoleProjectOwner) {
+			logging.FromContext(ctx).Warn("account deletion waits for organization projects to get another owner", "user_id", user.ID)
+			continue
+		}
+		if err != nil {
+			logging.FromContext(ctx).Error("failed to delete account", "user_id", user.ID, "error", err)
+			continue
+		}
+		logging.FromContext(ctx).Info("account deleted", "user_id", user.ID)
+	}
+	return nil
+}
+
+// Run purges scheduled deletions every interval until ctx is done
+func (h *AccountHandler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := h.PurgeScheduled(ctx); err != nil {
+				logging.FromContext(ctx).Error("failed to purge deleted accounts", "error", err)
+			}
+		}
+	}
+}
+
+// exportAccount is a linked sign-in method without its tokens and password
+type exportAccount struct {
+	ID         uuid.UUID `json:"id"`
+	ProviderID string    `json:"providerId"`
+	AccountID  string    `json:"accountId"`
+	Scope      *string   `json:"scope"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// sessionView is a session without its token, as shown in exports and to
+// admins
+type sessionView struct {
+	ID                   uuid.UUID  `json:"id"`
+	IPAddress            *string    `json:"ipAddress"`
+	UserAgent            *string    `json:"userAgent"`
+	ActiveOrganizationID *uuid.UUID `json:"activeOrganizationId"`
+	ImpersonatedBy       *uuid.UUID `json:"impersonatedBy"`
+	ExpiresAt            time.Time  `json:"expiresAt"`
+	CreatedAt            time.Time  `json:"createdAt"`
+}
+
+func newSessionView(sess repository.Session) sessionView {
+	return sessionView{
+		ID:                   sess.ID,
+		IPAddress:            sess.IpAddress,
+		UserAgent:            sess.UserAgent,
+		ActiveOrganizationID: sess.ActiveOrganizationId,
+		ImpersonatedBy:       sess.ImpersonatedBy,
+		ExpiresAt:            sess.ExpiresAt,
+		CreatedAt:            sess.CreatedAt,
+	}
+}
+
+type exportBilling struct {
+	Subscription *repository.Subscription `json:"subscription"`
+	// History is the audit trail of the subscription, oldest last
+	History []auditLogEntry `json:"history"`
+}
+
+// exportReadme describes the files of an export
+const exportReadme = `# Your %s data
+
+Exported on %s.
+
+- profile.json: your user profile
+- accounts.json: the sign-in methods linked to your account, without tokens or passwords
+- sessions.json: your active sessions
+- projects.json: your personal projects, and projects/<slug>.md for each of them
+- billing.json: your subscription and its history
+- uploads.json: the files you uploaded, without their content
+- security-activity.json: sign-ins, sign-outs and other security events of your account
+`
+
+// Export returns a ZIP of the current user's data as JSON and markdown
+func (h *AccountHandler) Export(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	_, user, err := h.sessions.current(r)
+	if err != nil {
+		respondError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	// Everything is loaded before the response starts so a failure can still
+	// be reported
+	files, projects, err := h.collectExport(ctx, user)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to export account", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to export account")
+		return
+	}
+
+	now := time.Now().UTC()
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-export-%s.zip"`, h.appName, now.Format("2006-01-02")))
+	w.WriteHeader(http.StatusOK)
+
+	zw := zip.NewWriter(w)
+	err = writeZipFile(zw, "README.md", []byte(fmt.Sprintf(exportReadme, h.appName, now.Format(time.RFC3339))))
+	for _, name := range []string{"profile.json", "accounts.json", "sessions.json", "projects.json", "billing.json", "uploads.json", "security-activity.json"} {
+		if err != nil {
+			break
+		}
+		var data []byte
+		if data, err = json.MarshalIndent(files[name], "", "  "); err == nil {
+			err = writeZipFile(zw, name, data)
+		}
+	}
+	for _, project := range projects {
+		if err != nil {
+			break
+		}
+		err = writeZipFile(zw, "projects/"+project.Slug+".md", []byte(projectMarkdown(project)))
+	}
+	if err == nil {
+		err = zw.Close()
+	}
+	if err != nil {
+		// The status is already sent; the client sees a truncated archive
+		logging.FromContext(ctx).Error("failed to write account export", "error", err)
+	}
+}
+
+// collectExport loads the contents of the export files by name
+func (h *AccountHandler) collectExport(ctx context.Context, user repository.User) (map[string]any, []repository.Project, error) {
+	accounts, err := h.queries.ListAccountsByUserId(ctx, user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	exportAccounts := make([]exportAccount, 0, len(accounts))
+	for _, account := range accounts {
+		exportAccounts = append(exportAccounts, exportAccount{
+			ID:         account.ID,
+			ProviderID: account.ProviderId,
+			AccountID:  account.AccountId,
+			Scope:      account.Scope,
+			CreatedAt:  account.CreatedAt,
+			UpdatedAt:  account.UpdatedAt,
+		})
+	}
+
+	sessions, err := h.queries.ListSessionsByUserId(ctx, user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	exportSessions := make([]sessionView, 0, len(sessions))
+	for _, sess := range sessions {
+		exportSessions = append(exportSessions, newSessionView(sess))
+	}
+
+	projects, err := h.queries.ListProjectsByUserID(ctx, &user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	billing := exportBilling{History: []auditLogEntry{}}
+	subscription, err := h.queries.GetSubscriptionByUserID(ctx, user.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, err
+	}
+	if err == nil {
+		billing.Subscription = &subscription
+		if subscription.PolarSubscriptionId != nil {
+			targetType := audit.TargetSubscription
+			billing.History, err = allAuditLogs(func(createdAt *time.Time, id *uuid.UUID) ([]repository.AuditLog, error) {
+				return h.queries.ListAuditLogs(ctx, repository.ListAuditLogsParams{
+					TargetType:      &targetType,
+					TargetID:        subscription.PolarSubscriptionId,
+					CursorCreatedAt: createdAt,
+					CursorID:        id,
+					RowLimit:        maxAuditLogLimit,
+				})
+			})
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	uploads, err := h.queries.ListAssetsByUserId(ctx, user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	activity, err := allAuditLogs(func(createdAt *time.Time, id *uuid.UUID) ([]repository.AuditLog, error) {
+		return h.queries.ListAuditLogsByUser(ctx, repository.ListAuditLogsByUserParams{
+			UserID:          user.ID,
+			CursorCreatedAt: createdAt,
+			CursorID:        id,
+			RowLimit:        maxAuditLogLimit,
+		})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return map[string]any{
+		"profile.json":           user,
+		"accounts.json":          exportAccounts,
+		"sessions.json":          exportSessions,
+		"projects.json":          projects,
+		"billing.json":           billing,
+		"uploads.json":           uploads,
+		"security-activity.json": activity,
+	}, projects, nil
+}
+
+// allAuditLogs reads every page of an audit log listing
+func allAuditLogs(list func(createdAt *time.Time, id *uuid.UUID) ([]repository.AuditLog, error)) ([]auditLogEntry, error) {
+	entries := []auditLogEntry{}
+	var createdAt *time.Time
+	var id *uuid.UUID
+	for {
+		rows, err := list(createdAt, id)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, auditLogEntries(rows)...)
+		if len(rows) < maxAuditLogLimit {
+			return entries, nil
+		}
+		last := rows[len(rows)-1]
+		createdAt, id = &last.CreatedAt, &last.ID
+	}
+}
+
+func writeZipFile(zw *zip.Writer, name string, data []byte) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return err
+}
+
+// projectMarkdown renders a project as a markdown document
+func projectMarkdown(project repository.Project) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", project.Name)
+	if project.Description != nil && *project.Description != "" {
+		fmt.Fprintf(&b, "%s\n\n", *project.Description)
+	}
+	fmt.Fprintf(&b, "- Slug: %s\n", project.Slug)
+	fmt.Fprintf(&b, "- Created: %s\n", project.CreatedAt.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "- Updated: %s\n", project.UpdatedAt.UTC().Format(time.RFC3339))
+	return b.String()
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"budhapp.com/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestProjectMarkdown(t *testing.T) {
+	description := "Notes for the launch"
+	project := repository.Project{
+		Name:        "Launch",
+		Slug:        "launch",
+		Description: &description,
+		CreatedAt:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		UpdatedAt:   time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC),
+	}
+
+	got := projectMarkdown(project)
+	for _, want := range []string{"# Launch\n", "Notes for the launch\n", "- Slug: launch\n", "- Created: 2024-01-02T03:04:05Z\n", "- Updated: 2024-02-03T04:05:06Z\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("projectMarkdown() = %q, missing %q", got, want)
+		}
+	}
+}
+
+func TestAllAuditLogs(t *testing.T) {
+	rows := make([]repository.AuditLog, maxAuditLogLimit+10)
+	start := time.Now()
+	for i := range rows {
+		rows[i] = repository.AuditLog{ID: uuid.New(), Action: "auth.sign_in", CreatedAt: start.Add(-time.Duration(i) * time.Second), Diff: []byte("{}")}
+	}
+
+	var calls int
+	entries, err := allAuditLogs(func(createdAt *time.Time, id *uuid.UUID) ([]repository.AuditLog, error) {
+		calls++
+		offset := 0
+		if id != nil {
+			for i, row := range rows {
+				if row.ID == *id {
+					offset = i + 1
+				}
+			}
+		}
+		return rows[offset:min(offset+maxAuditLogLimit, len(rows))], nil
+	})
+	if err != nil {
+		t.Fatalf("allAuditLogs() error = %v", err)
+	}
+	if len(entries) != len(rows) || calls != 2 {
+		t.Errorf("allAuditLogs() = %d entries in %d calls, want %d in 2", len(entries), calls, len(rows))
+	}
+}
+
+// countDB answers every single-row query with count and fails anything else,
+// so a test notices when more than the count is run
+type countDB struct {
+	count   int64
+	queries []string
+}
+
+func (db *countDB) Exec(_ context.Context, sql string, _ ...any) (pgconn.CommandTag, error) {
+	db.queries = append(db.queries, sql)
+	return pgconn.CommandTag{}, errors.New("unexpected exec")
+}
+
+func (db *countDB) Query(_ context.Context, sql string, _ ...any) (pgx.Rows, error) {
+	db.queries = append(db.queries, sql)
+	return nil, errors.New("unexpected query")
+}
+
+func (db *countDB) QueryRow(_ context.Context, sql string, _ ...any) pgx.Row {
+	db.queries = append(db.queries, sql)
+	return countRow(db.count)
+}
+
+type countRow int64
+
+func (r countRow) Scan(dest ...any) error {
+	*dest[0].(*int64) = int64(r)
+	return nil
+}
+
+func TestPurgeRefusesSoleProjectOwner(t *testing.T) {
+	db := &countDB{count: 1}
+	h := &AccountHandler{queries: repository.New(db)}
+	user := repository.User{ID: uuid.New(), Email: "owner@example.com"}
+
+	if err := h.purge(context.Background(), user, &user.ID); !errors.Is(err, ErrSoleProjectOwner) {
+		t.Fatalf("purge() = %v, want ErrSoleProjectOwner", err)
+	}
+	if len(db.queries) != 1 {
+		t.Errorf("purge() ran %d queries, want only the owner check", len(db.queries))
+	}
+}
+
+func TestCheckProjectOwners(t *testing.T) {
+	if err := checkProjectOwners(context.Background(), repository.New(&countDB{}), uuid.New()); err != nil {
+		t.Errorf("checkProjectOwners() without sole owned projects = %v, want nil", err)
+	}
+	if err := checkProjectOwners(context.Background(), repository.New(&countDB{count: 2}), uuid.New()); !errors.Is(err, ErrSoleProjectOwner) {
+		t.Errorf("checkProjectOwners() with sole owned projects = %v, want ErrSoleProjectOwner", err)
+	}
+}
@@ -0,0 +1,591 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"budhapp.com/internal/apierror"
+	"budhapp.com/internal/audit"
+	"budhapp.com/internal/config"
+	"budhapp.com/internal/logging"
+	"budhapp.com/internal/repository"
+	"budhapp.com/internal/session"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	adminRole = "admin"
+
+	// Page size of list-users when no limit is given, and its upper bound
+	defaultUserListLimit = 100
+	maxUserListLimit     = 1000
+)
+
+// hasRole reports whether a comma separated better-auth role list contains role
+func hasRole(roles, role string) bool {
+	for _, r := range strings.Split(roles, ",") {
+		if strings.TrimSpace(r) == role {
+			return true
+		}
+	}
+	return false
+}
+
+// likePattern builds an ILIKE pattern matching value with a better-auth
+// search operator: contains, starts_with or ends_with
+func likePattern(value, operator string) string {
+	if value == "" {
+		return "%"
+	}
+	value = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+	switch operator {
+	case "starts_with":
+		return value + "%"
+	case "ends_with":
+		return "%" + value
+	default:
+		return "%" + value + "%"
+	}
+}
+
+type adminContextKey struct{}
+
+// adminSession is the signed-in admin stored in the request context by
+// RequireAdmin
+type adminSession struct {
+	session repository.Session
+	user    repository.User
+}
+
+type AdminHandler struct {
+	queries  *repository.Queries
+	pool     *pgxpool.Pool
+	sessions *sessionStore
+	cfg      config.AdminConfig
+}
+
+func NewAdminHandler(queries *repository.Queries, pool *pgxpool.Pool, sessions *sessionStore, cfg config.AdminConfig) *AdminHandler {
+	return &AdminHandler{
+		queries:  queries,
+		pool:     pool,
+		sessions: sessions,
+		cfg:      cfg,
+	}
+}
+
+// isAdmin reports whether user has the admin role or is a configured admin
+func (h *AdminHandler) isAdmin(user repository.User) bool {
+	return hasRole(user.Role, adminRole) || slices.Contains(h.cfg.UserIDs, user.ID.String())
+}
+
+// RequireAdmin is the middleware of the admin routes. It admits signed-in
+// admins, except through an impersonation session.
+func (h *AdminHandler) RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sess, user, err := h.sessions.current(r)
+		if err != nil {
+			respondError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+			return
+		}
+		if sess.ImpersonatedBy != nil || !h.isAdmin(user) {
+			respondError(w, r, http.StatusForbidden, "YOU_ARE_NOT_ALLOWED_TO_ACCESS_THIS_RESOURCE", "You are not allowed to access this resource")
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), adminContextKey{}, adminSession{session: sess, user: user})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// admin returns the admin stored by RequireAdmin
+func (h *AdminHandler) admin(w http.ResponseWriter, r *http.Request) (adminSession, bool) {
+	admin, ok := r.Context().Value(adminContextKey{}).(adminSession)
+	if !ok {
+		logging.FromContext(r.Context()).Error("admin route is missing the admin middleware", "uri", r.URL.RequestURI())
+		respondError(w, r, http.StatusForbidden, "YOU_ARE_NOT_ALLOWED_TO_ACCESS_THIS_RESOURCE", "You are not allowed to access this resource")
+	}
+	return admin, ok
+}
+
+// audit records an action of the admin on the target user
+func (h *AdminHandler) audit(ctx context.Context, q *repository.Queries, r *http.Request, adminID uuid.UUID, action string, targetID uuid.UUID, diff any) error {
+	return audit.Record(ctx, q, r, audit.Entry{
+		Action:     action,
+		ActorID:    &adminID,
+		TargetType: audit.TargetUser,
+		TargetID:   targetID.String(),
+		Diff:       diff,
+	})
+}
+
+// targetUser loads the user named by the request, responding on failure
+func (h *AdminHandler) targetUser(w http.ResponseWriter, r *http.Request, id uuid.UUID) (repository.User, bool) {
+	user, err := h.queries.GetUserByID(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondError(w, r, http.StatusNotFound, "USER_NOT_FOUND", "User not found")
+		return repository.User{}, false
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to load user", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load user")
+		return repository.User{}, false
+	}
+	return user, true
+}
+
+// ListUsers returns a page of users, optionally filtered by email or name
+// (better-auth: GET /api/auth/admin/list-users)
+func (h *AdminHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit := defaultUserListLimit
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			respondErr(w, r, apierror.Invalid("INVALID_QUERY", "limit", "limit must be a positive number"))
+			return
+		}
+		limit = min(n, maxUserListLimit)
+	}
+	offset := 0
+	if value := query.Get("offset"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			respondErr(w, r, apierror.Invalid("INVALID_QUERY", "offset", "offset must not be negative"))
+			return
+		}
+		offset = n
+	}
+
+	emailPattern, namePattern := "%", "%"
+	pattern := likePattern(query.Get("searchValue"), query.Get("searchOperator"))
+	switch query.Get("searchField") {
+	case "", "email":
+		emailPattern = pattern
+	case "name":
+		namePattern = pattern
+	default:
+		respondErr(w, r, apierror.Invalid("INVALID_QUERY", "searchField", "searchField must be email or name"))
+		return
+	}
+
+	users, err := h.queries.ListUsers(r.Context(), repository.ListUsersParams{
+		EmailPattern: emailPattern,
+		NamePattern:  namePattern,
+		RowLimit:     int32(limit),
+		RowOffset:    int32(offset),
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to list users", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list users")
+		return
+	}
+	if users == nil {
+		users = []repository.User{}
+	}
+
+	total, err := h.queries.CountUsers(r.Context(), repository.CountUsersParams{
+		EmailPattern: emailPattern,
+		NamePattern:  namePattern,
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to count users", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list users")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"users":  users,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+type setRoleRequest struct {
+	UserID uuid.UUID `json:"userId" validate:"required"`
+	Role   string    `json:"role" validate:"required"`
+}
+
+// SetRole replaces the roles of a user
+// (better-auth: POST /api/auth/admin/set-role)
+func (h *AdminHandler) SetRole(w http.ResponseWriter, r *http.Request) {
+	admin, ok := h.admin(w, r)
+	if !ok {
+		return
+	}
+
+	req, err := decodeAuthRequest[setRoleRequest](w, r)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	target, ok := h.targetUser(w, r, req.UserID)
+	if !ok {
+		return
+	}
+
+	var user repository.User
+	err = withTx(r.Context(), h.pool, h.queries, func(q *repository.Queries) error {
+		var err error
+		user, err = q.SetUserRole(r.Context(), repository.SetUserRoleParams{
+			ID:   target.ID,
+			Role: strings.TrimSpace(req.Role),
+		})
+		if err != nil {
+			return err
+		}
+		return h.audit(r.Context(), q, r, admin.user.ID, audit.ActionAdminSetRole, target.ID, map[string]audit.Change{
+			"role": {From: target.Role, To: user.Role},
+		})
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to set role", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to set role")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{"user": user})
+}
+
+type banUserRequest struct {
+	UserID    uuid.UUID `json:"userId" validate:"required"`
+	BanReason *string   `json:"banReason"`
+	// BanExpiresIn is the ban duration in seconds; zero bans indefinitely
+	BanExpiresIn int64 `json:"banExpiresIn" validate:"min=0"`
+}
+
+// BanUser bans a user and revokes their sessions
+// (better-auth: POST /api/auth/admin/ban-user)
+func (h *AdminHandler) BanUser(w http.ResponseWriter, r *http.Request) {
+	admin, ok := h.admin(w, r)
+	if !ok {
+		return
+	}
+
+	req, err := decodeAuthRequest[banUserRequest](w, r)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	if req.UserID == admin.user.ID {
+		respondError(w, r, http.StatusBadRequest, "YOU_CANNOT_BAN_YOURSELF", "You cannot ban yourself")
+		return
+	}
+	target, ok := h.targetUser(w, r, req.UserID)
+	if !ok {
+		return
+	}
+
+	params := repository.BanUserParams{
+		ID:        req.UserID,
+		BanReason: req.BanReason,
+	}
+	if req.BanExpiresIn > 0 {
+		expires := time.Now().Add(time.Duration(req.BanExpiresIn) * time.Second)
+		params.BanExpires = &expires
+	}
+
+	var user repository.User
+	err = withTx(r.Context(), h.pool, h.queries, func(q *repository.Queries) error {
+		var err error
+		if user, err = q.BanUser(r.Context(), params); err != nil {
+			return err
+		}
+		if err := q.DeleteUserSessions(r.Context(), user.ID); err != nil {
+			return err
+		}
+		changes, err := audit.Changes(target, user)
+		if err != nil {
+			return err
+		}
+		return h.audit(r.Context(), q, r, admin.user.ID, audit.ActionAdminBanUser, user.ID, changes)
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to ban user", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to ban user")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{"user": user})
+}
+
+type userIDRequest struct {
+	UserID uuid.UUID `json:"userId" validate:"required"`
+}
+
+// UnbanUser lifts a user's ban
+// (better-auth: POST /api/auth/admin/unban-user)
+func (h *AdminHandler) UnbanUser(w http.ResponseWriter, r *http.Request) {
+	admin, ok := h.admin(w, r)
+	if !ok {
+		return
+	}
+
+	req, err := decodeAuthRequest[userIDRequest](w, r)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	target, ok := h.targetUser(w, r, req.UserID)
+	if !ok {
+		return
+	}
+
+	var user repository.User
+	err = withTx(r.Context(), h.pool, h.queries, func(q *repository.Queries) error {
+		var err error
+		if user, err = q.UnbanUser(r.Context(), req.UserID); err != nil {
+			return err
+		}
+		changes, err := audit.Changes(target, user)
+		if err != nil {
+			return err
+		}
+		return h.audit(r.Context(), q, r, admin.user.ID, audit.ActionAdminUnbanUser, user.ID, changes)
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to unban user", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to unban user")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{"user": user})
+}
+
+// ListUserSessions returns the active sessions of a user, without their
+// tokens. Sessions are revoked by id with RevokeUserSession.
+// (better-auth: POST /api/auth/admin/list-user-sessions)
+func (h *AdminHandler) ListUserSessions(w http.ResponseWriter, r *http.Request) {
+	admin, ok := h.admin(w, r)
+	if !ok {
+		return
+	}
+
+	req, err := decodeAuthRequest[userIDRequest](w, r)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	if _, ok := h.targetUser(w, r, req.UserID); !ok {
+		return
+	}
+
+	sessions, err := h.queries.ListSessionsByUserId(r.Context(), req.UserID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to list sessions", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list sessions")
+		return
+	}
+	if err := h.audit(r.Context(), h.queries, r, admin.user.ID, audit.ActionAdminListUserSessions, req.UserID, nil); err != nil {
+		logging.FromContext(r.Context()).Error("failed to audit session listing", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list sessions")
+		return
+	}
+
+	views := make([]sessionView, 0, len(sessions))
+	for _, sess := range sessions {
+		views = append(views, newSessionView(sess))
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{"sessions": views})
+}
+
+type sessionIDRequest struct {
+	SessionID uuid.UUID `json:"sessionId" validate:"required"`
+}
+
+// RevokeUserSession signs a user out of one session, named by its id
+// (better-auth: POST /api/auth/admin/revoke-user-session)
+func (h *AdminHandler) RevokeUserSession(w http.ResponseWriter, r *http.Request) {
+	admin, ok := h.admin(w, r)
+	if !ok {
+		return
+	}
+
+	req, err := decodeAuthRequest[sessionIDRequest](w, r)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+
+	err = withTx(r.Context(), h.pool, h.queries, func(q *repository.Queries) error {
+		sess, err := q.DeleteSession(r.Context(), req.SessionID)
+		if err != nil {
+			return err
+		}
+		return h.audit(r.Context(), q, r, admin.user.ID, audit.ActionAdminRevokeUserSession, sess.UserId, map[string]string{"sessionId": sess.ID.String()})
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondError(w, r, http.StatusNotFound, "SESSION_NOT_FOUND", "Session not found")
+		return
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to revoke session", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to revoke session")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// RevokeUserSessions signs a user out everywhere
+// (better-auth: POST /api/auth/admin/revoke-user-sessions)
+func (h *AdminHandler) RevokeUserSessions(w http.ResponseWriter, r *http.Request) {
+	admin, ok := h.admin(w, r)
+	if !ok {
+		return
+	}
+
+	req, err := decodeAuthRequest[userIDRequest](w, r)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	if _, ok := h.targetUser(w, r, req.UserID); !ok {
+		return
+	}
+
+	err = withTx(r.Context(), h.pool, h.queries, func(q *repository.Queries) error {
+		if err := q.DeleteUserSessions(r.Context(), req.UserID); err != nil {
+			return err
+		}
+		return h.audit(r.Context(), q, r, admin.user.ID, audit.ActionAdminRevokeUserSessions, req.UserID, nil)
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to revoke sessions", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to revoke sessions")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// ImpersonateUser signs the admin in as another user. The impersonation
+// session records the admin in impersonatedBy and expires after the
+// configured duration; the admin's own session is kept in the admin session
+// cookie for StopImpersonating.
+// (better-auth: POST /api/auth/admin/impersonate-user)
+func (h *AdminHandler) ImpersonateUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	admin, ok := h.admin(w, r)
+	if !ok {
+		return
+	}
+
+	req, err := decodeAuthRequest[userIDRequest](w, r)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	target, ok := h.targetUser(w, r, req.UserID)
+	if !ok {
+		return
+	}
+	if target.ID == admin.user.ID || h.isAdmin(target) {
+		respondError(w, r, http.StatusForbidden, "YOU_CANNOT_IMPERSONATE_ADMINS", "You cannot impersonate admins")
+		return
+	}
+
+	var ipAddress *string
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		ipAddress = &host
+	}
+	var userAgent *string
+	if ua := r.UserAgent(); ua != "" {
+		userAgent = &ua
+	}
+
+	var sess repository.Session
+	err = withTx(ctx, h.pool, h.queries, func(q *repository.Queries) error {
+		var err error
+		sess, err = q.CreateImpersonationSession(ctx, repository.CreateImpersonationSessionParams{
+			Token:          generateToken(32),
+			UserId:         target.ID,
+			ExpiresAt:      time.Now().Add(h.cfg.ImpersonationDuration.Std()),
+			IpAddress:      ipAddress,
+			UserAgent:      userAgent,
+			ImpersonatedBy: &admin.user.ID,
+		})
+		if err != nil {
+			return err
+		}
+		return h.audit(ctx, q, r, admin.user.ID, audit.ActionAdminImpersonateUser, target.ID, map[string]any{
+			"sessionId": sess.ID,
+			"expiresAt": sess.ExpiresAt,
+		})
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to create impersonation session", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to impersonate user")
+		return
+	}
+
+	h.sessions.setCookie(w, session.AdminSessionCookieName, admin.session.Token, admin.session.ExpiresAt)
+	h.sessions.setCookie(w, session.SessionCookieName, sess.Token, sess.ExpiresAt)
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"session": sess,
+		"user":    target,
+	})
+}
+
+// StopImpersonating ends the current impersonation session and restores the
+// admin's session. It runs outside RequireAdmin since the caller is signed in
+// as the impersonated user.
+// (better-auth: POST /api/auth/admin/stop-impersonating)
+func (h *AdminHandler) StopImpersonating(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	sess, _, err := h.sessions.current(r)
+	if err != nil {
+		respondError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+	if sess.ImpersonatedBy == nil {
+		respondError(w, r, http.StatusBadRequest, "NOT_IMPERSONATING", "You are not impersonating anyone")
+		return
+	}
+
+	token, ok := session.SignedCookieFromRequest(r, session.AdminSessionCookieName, h.sessions.secret)
+	if !ok {
+		respondError(w, r, http.StatusBadRequest, "FAILED_TO_FIND_ADMIN_SESSION", "Failed to find admin session")
+		return
+	}
+	adminSess, admin, err := h.sessions.lookup(ctx, token)
+	if err != nil || admin.ID != *sess.ImpersonatedBy {
+		respondError(w, r, http.StatusBadRequest, "FAILED_TO_FIND_ADMIN_SESSION", "Failed to find admin session")
+		return
+	}
+
+	err = withTx(ctx, h.pool, h.queries, func(q *repository.Queries) error {
+		if _, err := q.DeleteSession(ctx, sess.ID); err != nil {
+			return err
+		}
+		return h.audit(ctx, q, r, admin.ID, audit.ActionAdminStopImpersonating, sess.UserId, map[string]any{
+			"sessionId": sess.ID,
+		})
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to stop impersonating", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to stop impersonating")
+		return
+	}
+
+	h.sessions.clearCookie(w, session.AdminSessionCookieName)
+	h.sessions.setCookie(w, session.SessionCookieName, adminSess.Token, adminSess.ExpiresAt)
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"session": adminSess,
+		"user":    admin,
+	})
+}
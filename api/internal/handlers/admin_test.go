@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"budhapp.com/internal/repository"
+	"github.com/google/uuid"
+)
+
+func TestLikePattern(t *testing.T) {
+	tests := []struct {
+		value, operator, want string
+	}{
+		{"", "contains", "%"},
+		{"jane", "", "%jane%"},
+		{"jane", "contains", "%jane%"},
+		{"jane", "starts_with", "jane%"},
+		{"example.com", "ends_with", "%example.com"},
+		{"50%_off\\", "contains", `%50\%\_off\\%`},
+	}
+
+	for _, tt := range tests {
+		if got := likePattern(tt.value, tt.operator); got != tt.want {
+			t.Errorf("likePattern(%q, %q) = %q, want %q", tt.value, tt.operator, got, tt.want)
+		}
+	}
+}
+
+func TestHasRole(t *testing.T) {
+	tests := []struct {
+		roles string
+		want  bool
+	}{
+		{"admin", true},
+		{"user", false},
+		{"user, admin", true},
+		{"superadmin", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := hasRole(tt.roles, adminRole); got != tt.want {
+			t.Errorf("hasRole(%q) = %v, want %v", tt.roles, got, tt.want)
+		}
+	}
+}
+
+func TestSessionViewHidesToken(t *testing.T) {
+	sess := repository.Session{ID: uuid.New(), UserId: uuid.New(), Token: "bearer-secret"}
+	data, err := json.Marshal(newSessionView(sess))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("bearer-secret")) || bytes.Contains(data, []byte(`"token"`)) {
+		t.Errorf("session view %s holds the token", data)
+	}
+	if !bytes.Contains(data, []byte(sess.ID.String())) {
+		t.Errorf("session view %s is missing the session id", data)
+	}
+}
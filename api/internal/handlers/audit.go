@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"budhapp.com/internal/apierror"
+	"budhapp.com/internal/logging"
+	"budhapp.com/internal/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	// Page size of audit log listings when no limit is given, and its upper bound
+	defaultAuditLogLimit = 50
+	maxAuditLogLimit     = 200
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// auditLogEntry is an audit log row with its diff as JSON
+type auditLogEntry struct {
+	ID         uuid.UUID       `json:"id"`
+	Action     string          `json:"action"`
+	ActorID    *uuid.UUID      `json:"actorId"`
+	TargetType *string         `json:"targetType"`
+	TargetID   *string         `json:"targetId"`
+	IPAddress  *string         `json:"ipAddress"`
+	UserAgent  *string         `json:"userAgent"`
+	RequestID  *string         `json:"requestId"`
+	Diff       json.RawMessage `json:"diff"`
+	CreatedAt  time.Time       `json:"createdAt"`
+}
+
+// auditLogPage is a page of entries, newest first. NextCursor is set when
+// more entries follow.
+type auditLogPage struct {
+	Entries    []auditLogEntry `json:"entries"`
+	NextCursor *string         `json:"nextCursor"`
+}
+
+func newAuditLogPage(rows []repository.AuditLog, limit int) auditLogPage {
+	page := auditLogPage{Entries: auditLogEntries(rows)}
+	if len(rows) == limit {
+		last := rows[len(rows)-1]
+		cursor := encodeAuditCursor(last.CreatedAt, last.ID)
+		page.NextCursor = &cursor
+	}
+	return page
+}
+
+func auditLogEntries(rows []repository.AuditLog) []auditLogEntry {
+	entries := make([]auditLogEntry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, auditLogEntry{
+			ID:         row.ID,
+			Action:     row.Action,
+			ActorID:    row.ActorId,
+			TargetType: row.TargetType,
+			TargetID:   row.TargetId,
+			IPAddress:  row.IpAddress,
+			UserAgent:  row.UserAgent,
+			RequestID:  row.RequestId,
+			Diff:       json.RawMessage(row.Diff),
+			CreatedAt:  row.CreatedAt,
+		})
+	}
+	return entries
+}
+
+// encodeAuditCursor returns an opaque cursor pointing after the entry
+func encodeAuditCursor(createdAt time.Time, id uuid.UUID) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(createdAt.UTC().Format(time.RFC3339Nano) + "," + id.String()))
+}
+
+func decodeAuditCursor(cursor string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+	ts, id, found := strings.Cut(string(raw), ",")
+	if !found {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+	return createdAt, parsed, nil
+}
+
+// auditPagination reads the limit and cursor query parameters
+func auditPagination(query url.Values) (limit int, createdAt *time.Time, id *uuid.UUID, err error) {
+	limit = defaultAuditLogLimit
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return 0, nil, nil, apierror.Invalid("INVALID_QUERY", "limit", "limit must be a positive number")
+		}
+		limit = min(n, maxAuditLogLimit)
+	}
+	if cursor := query.Get("cursor"); cursor != "" {
+		ts, cursorID, err := decodeAuditCursor(cursor)
+		if err != nil {
+			return 0, nil, nil, apierror.Invalid("INVALID_QUERY", "cursor", err.Error())
+		}
+		createdAt, id = &ts, &cursorID
+	}
+	return limit, createdAt, id, nil
+}
+
+type AuditHandler struct {
+	queries  *repository.Queries
+	sessions *sessionStore
+}
+
+func NewAuditHandler(queries *repository.Queries, sessions *sessionStore) *AuditHandler {
+	return &AuditHandler{
+		queries:  queries,
+		sessions: sessions,
+	}
+}
+
+// ListAuditLogs returns audit log entries, newest first. Runs behind the
+// admin middleware.
+func (h *AuditHandler) ListAuditLogs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit, cursorCreatedAt, cursorID, err := auditPagination(query)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+
+	params := repository.ListAuditLogsParams{
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		RowLimit:        int32(limit),
+	}
+	if value := query.Get("action"); value != "" {
+		params.Action = &value
+	}
+	if value := query.Get("actorId"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			respondErr(w, r, apierror.Invalid("INVALID_QUERY", "actorId", "actorId must be a uuid"))
+			return
+		}
+		params.ActorID = &id
+	}
+	if value := query.Get("targetType"); value != "" {
+		params.TargetType = &value
+	}
+	if value := query.Get("targetId"); value != "" {
+		params.TargetID = &value
+	}
+	for name, dst := range map[string]**time.Time{"since": &params.Since, "until": &params.Until} {
+		if value := query.Get(name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				respondErr(w, r, apierror.Invalid("INVALID_QUERY", name, name+" must be an RFC 3339 time"))
+				return
+			}
+			*dst = &t
+		}
+	}
+
+	rows, err := h.queries.ListAuditLogs(r.Context(), params)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to list audit logs", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list audit logs")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, newAuditLogPage(rows, limit))
+}
+
+// SecurityActivity returns the signed-in user's security activity: entries
+// they made and entries that target them, newest first
+func (h *AuditHandler) SecurityActivity(w http.ResponseWriter, r *http.Request) {
+	_, user, err := h.sessions.current(r)
+	if err != nil {
+		respondError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	limit, cursorCreatedAt, cursorID, err := auditPagination(r.URL.Query())
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+
+	rows, err := h.queries.ListAuditLogsByUser(r.Context(), repository.ListAuditLogsByUserParams{
+		UserID:          user.ID,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		RowLimit:        int32(limit),
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to list security activity", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list security activity")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, newAuditLogPage(rows, limit))
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestAuditCursor(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 12, 30, 0, 123456000, time.UTC)
+	id := uuid.New()
+
+	gotCreatedAt, gotID, err := decodeAuditCursor(encodeAuditCursor(createdAt, id))
+	if err != nil {
+		t.Fatalf("decodeAuditCursor() error = %v", err)
+	}
+	if !gotCreatedAt.Equal(createdAt) || gotID != id {
+		t.Errorf("decodeAuditCursor() = %v, %v, want %v, %v", gotCreatedAt, gotID, createdAt, id)
+	}
+
+	for _, cursor := range []string{"!!", "bm90LWEtY3Vyc29y", encodeAuditCursor(createdAt, id)[:10]} {
+		if _, _, err := decodeAuditCursor(cursor); err != ErrInvalidCursor {
+			t.Errorf("decodeAuditCursor(%q) error = %v, want ErrInvalidCursor", cursor, err)
+		}
+	}
+}
+
+func TestAuditPagination(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    int
+		wantErr bool
+	}{
+		{"", defaultAuditLogLimit, false},
+		{"limit=10", 10, false},
+		{"limit=5000", maxAuditLogLimit, false},
+		{"limit=0", 0, true},
+		{"limit=abc", 0, true},
+		{"cursor=invalid!", 0, true},
+	}
+
+	for _, tt := range tests {
+		query, _ := url.ParseQuery(tt.query)
+		limit, _, _, err := auditPagination(query)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("auditPagination(%q) error = %v, wantErr %v", tt.query, err, tt.wantErr)
+			continue
+		}
+		if limit != tt.want {
+			t.Errorf("auditPagination(%q) limit = %d, want %d", tt.query, limit, tt.want)
+		}
+	}
+}
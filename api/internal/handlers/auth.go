@@ -3,22 +3,30 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
-	"log/slog"
 	"net/http"
+	"strings"
 
+	"budhapp.com/internal/audit"
+	"budhapp.com/internal/jwks"
+	"budhapp.com/internal/logging"
+	"budhapp.com/internal/password"
+	"budhapp.com/internal/ratelimit"
 	"budhapp.com/internal/repository"
 	"budhapp.com/internal/session"
+	"github.com/jackc/pgx/v5"
 )
 
 type AuthHandler struct {
-	logger  *slog.Logger
-	queries *repository.Queries
+	queries  *repository.Queries
+	sessions *sessionStore
+	keys     *jwks.Manager
 }
 
-func NewAuthHandler(queries *repository.Queries, logger *slog.Logger) *AuthHandler {
+func NewAuthHandler(queries *repository.Queries, sessions *sessionStore, keys *jwks.Manager) *AuthHandler {
 	return &AuthHandler{
-		queries: queries,
-		logger:  logger,
+		queries:  queries,
+		sessions: sessions,
+		keys:     keys,
 	}
 }
 
@@ -26,20 +34,180 @@ var (
 	ErrMissingUserID = errors.New("missing user id")
 )
 
+type signInEmailRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,max=128"`
+}
+
+// SignInEmail authenticates with email and password. Users with two-factor
+// authentication enabled get a challenge instead of a session unless the
+// device is trusted (better-auth: POST /api/auth/sign-in/email)
+func (h *AuthHandler) SignInEmail(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req, err := decodeAuthRequest[signInEmailRequest](w, r)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	if wait := h.sessions.signInLocked(r, req.Email); wait > 0 {
+		ratelimit.RespondTooManyRequests(w, r, wait)
+		return
+	}
+
+	user, err := h.queries.GetUserByEmail(ctx, strings.ToLower(req.Email))
+	if err != nil {
+		// Hash anyway so response time does not reveal whether the email exists
+		password.Hash(req.Password) //nolint:errcheck // Timing only
+		if err := h.sessions.signInFailed(r, nil, strings.ToLower(req.Email), "unknown_email"); err != nil {
+			logging.FromContext(ctx).Error("failed to audit sign-in", "error", err)
+		}
+		respondError(w, r, http.StatusUnauthorized, "INVALID_CREDENTIALS", "Invalid email or password")
+		return
+	}
+
+	ok, err := checkPassword(ctx, h.queries, user.ID, req.Password)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to verify password", "error", err)
+	}
+	if !ok {
+		if err := h.sessions.signInFailed(r, &user.ID, user.Email, "invalid_password"); err != nil {
+			logging.FromContext(ctx).Error("failed to audit sign-in", "error", err)
+		}
+		respondError(w, r, http.StatusUnauthorized, "INVALID_CREDENTIALS", "Invalid email or password")
+		return
+	}
+
+	if user.TwoFactorEnabled && !h.sessions.isTrustedDevice(r, user.ID) {
+		if err := h.sessions.startTwoFactor(ctx, w, user.ID); err != nil {
+			logging.FromContext(ctx).Error("failed to start two factor challenge", "error", err)
+			respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to sign in")
+			return
+		}
+		respondJSON(w, http.StatusOK, map[string]bool{"twoFactorRedirect": true})
+		return
+	}
+
+	sess, err := h.sessions.create(ctx, w, r, user.ID)
+	if errors.Is(err, ErrUserBanned) {
+		respondBanned(w, r)
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to create session", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to sign in")
+		return
+	}
+	if user.TwoFactorEnabled {
+		// Sliding window: each sign-in from a trusted device extends it
+		h.sessions.trustDevice(w, user.ID)
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"user":    user,
+		"session": sess,
+	})
+}
+
+// SignOut deletes the current session and clears the session cookie
+// (better-auth: POST /api/auth/sign-out)
+func (h *AuthHandler) SignOut(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	sess, _, err := h.sessions.current(r)
+	if err != nil {
+		h.sessions.clearCookie(w, session.SessionCookieName)
+		respondJSON(w, http.StatusOK, map[string]bool{"success": true})
+		return
+	}
+
+	err = withTx(ctx, h.sessions.pool, h.queries, func(q *repository.Queries) error {
+		if _, err := q.DeleteSession(ctx, sess.ID); err != nil {
+			return err
+		}
+		return audit.Record(ctx, q, r, audit.Entry{
+			Action:     audit.ActionSignOut,
+			ActorID:    &sess.UserId,
+			TargetType: audit.TargetSession,
+			TargetID:   sess.ID.String(),
+		})
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to delete session", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to sign out")
+		return
+	}
+
+	h.sessions.clearCookie(w, session.SessionCookieName)
+	respondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+type revokeSessionRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// RevokeSession deletes one of the current user's sessions by its token
+// (better-auth: POST /api/auth/revoke-session)
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	_, user, err := h.sessions.current(r)
+	if err != nil {
+		respondError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	req, err := decodeAuthRequest[revokeSessionRequest](w, r)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+
+	target, err := h.queries.GetSessionByToken(ctx, req.Token)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && target.UserId != user.ID) {
+		respondError(w, r, http.StatusNotFound, "SESSION_NOT_FOUND", "Session not found")
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to get session", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to revoke session")
+		return
+	}
+
+	err = withTx(ctx, h.sessions.pool, h.queries, func(q *repository.Queries) error {
+		if _, err := q.DeleteSession(ctx, target.ID); err != nil {
+			return err
+		}
+		return audit.Record(ctx, q, r, audit.Entry{
+			Action:     audit.ActionSessionRevoke,
+			ActorID:    &user.ID,
+			TargetType: audit.TargetSession,
+			TargetID:   target.ID.String(),
+		})
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to revoke session", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to revoke session")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]bool{"status": true})
+}
+
 func (h *AuthHandler) UserFromRequest(w http.ResponseWriter, r *http.Request) {
-	h.logger.Debug("UserFromRequest called")
+	logging.FromContext(r.Context()).Debug("UserFromRequest called")
 	userPtr, ok := session.UserFromContext(r.Context())
-	h.logger.Debug("UserFromRequest", "userPtr", userPtr, "ok", ok)
+	logging.FromContext(r.Context()).Debug("UserFromRequest", "userPtr", userPtr, "ok", ok)
 	if !ok || userPtr == nil {
-		http.Error(w, "Failed to retrieve user from context", http.StatusInternalServerError)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to retrieve user from context")
 		return
 	}
 
 	// Marshal the user object to JSON
 	jsonData, err := json.Marshal(userPtr) // Marshal the pointer directly
 	if err != nil {
-		h.logger.Error("Failed to marshal user data to JSON", "error", err) // Log the error
-		http.Error(w, "Failed to encode user data", http.StatusInternalServerError)
+		logging.FromContext(r.Context()).Error("Failed to marshal user data to JSON", "error", err) // Log the error
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to encode user data")
 		return
 	}
 
@@ -47,3 +215,42 @@ func (h *AuthHandler) UserFromRequest(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	w.Write(jsonData)
 }
+
+// Token issues a JWT for the session identified by the session cookie
+// (better-auth jwt plugin: GET /api/auth/token)
+func (h *AuthHandler) Token(w http.ResponseWriter, r *http.Request) {
+	if !h.keys.CanSign() {
+		respondError(w, r, http.StatusServiceUnavailable, "JWT_UNAVAILABLE", "Token signing is not configured")
+		return
+	}
+
+	_, user, err := h.sessions.current(r)
+	if err != nil {
+		respondError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	token, err := h.keys.Issue(user.ID.String(), map[string]any{
+		"id":            user.ID.String(),
+		"email":         user.Email,
+		"name":          user.Name,
+		"emailVerified": user.EmailVerified,
+		"image":         user.Image,
+		"createdAt":     user.CreatedAt,
+		"updatedAt":     user.UpdatedAt,
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to issue jwt", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to issue token")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"token": token})
+}
+
+// JWKS publishes the public keys used to verify issued tokens
+// (better-auth jwt plugin: GET /api/auth/jwks)
+func (h *AuthHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	respondJSON(w, http.StatusOK, h.keys.PublicSet())
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"budhapp.com/internal/password"
+	"budhapp.com/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// credentialProviderID is the providerId of email and password accounts
+const credentialProviderID = "credential"
+
+// checkPassword verifies password against the user's credential account.
+// Users who only signed in with a social provider have no password.
+func checkPassword(ctx context.Context, queries *repository.Queries, userID uuid.UUID, plain string) (bool, error) {
+	account, err := queries.GetAccountByUserIdAndProvider(ctx, repository.GetAccountByUserIdAndProviderParams{
+		UserId:     userID,
+		ProviderId: credentialProviderID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if account.Password == nil {
+		return false, nil
+	}
+
+	return password.Verify(*account.Password, plain)
+}
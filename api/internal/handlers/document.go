@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"budhapp.com/internal/logging"
+	"budhapp.com/internal/repository"
+	"budhapp.com/internal/session"
+	"budhapp.com/internal/validate"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DocumentHandler serves the documents of a project and their comments. Its
+// routes run behind the project authorization middleware, or the share
+// middleware for reviewers, which checks the document permission of the
+// route. Share links of a single document only see that document.
+type DocumentHandler struct {
+	queries *repository.Queries
+	pool    *pgxpool.Pool
+}
+
+// anonymousReviewer signs comments of reviewers who give no name
+const anonymousReviewer = "Reviewer"
+
+func NewDocumentHandler(queries *repository.Queries, pool *pgxpool.Pool) *DocumentHandler {
+	return &DocumentHandler{
+		queries: queries,
+		pool:    pool,
+	}
+}
+
+// document loads the {documentId} document of the authorized project,
+// writing 404 when the project has no such document or the share link
+// doesn't open it
+func (h *DocumentHandler) document(w http.ResponseWriter, r *http.Request) (*repository.Project, repository.Document, bool) {
+	project, _, ok := authorizedProject(w, r)
+	if !ok {
+		return nil, repository.Document{}, false
+	}
+
+	id, err := uuid.Parse(r.PathValue("documentId"))
+	if scope := shareScope(r); err != nil || (scope != nil && *scope != id) {
+		respondError(w, r, http.StatusNotFound, "DOCUMENT_NOT_FOUND", "Document not found")
+		return nil, repository.Document{}, false
+	}
+	document, err := h.queries.GetDocumentByIDAndProjectID(r.Context(), repository.GetDocumentByIDAndProjectIDParams{
+		ID:        id,
+		ProjectId: project.ID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondError(w, r, http.StatusNotFound, "DOCUMENT_NOT_FOUND", "Document not found")
+		return nil, repository.Document{}, false
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to load document", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load document")
+		return nil, repository.Document{}, false
+	}
+	return project, document, true
+}
+
+// List returns the documents of the project, most recently updated first
+func (h *DocumentHandler) List(w http.ResponseWriter, r *http.Request) {
+	project, _, ok := authorizedProject(w, r)
+	if !ok {
+		return
+	}
+
+	documents, err := h.queries.ListDocumentsByProjectID(r.Context(), project.ID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to list documents", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list documents")
+		return
+	}
+	if scope := shareScope(r); scope != nil {
+		documents = slices.DeleteFunc(documents, func(d repository.Document) bool { return d.ID != *scope })
+	}
+	if documents == nil {
+		documents = []repository.Document{}
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{"documents": documents})
+}
+
+type createDocumentRequest struct {
+	Title   string `json:"title" validate:"required,max=255"`
+	Content string `json:"content"`
+}
+
+// Create adds a document to the project
+func (h *DocumentHandler) Create(w http.ResponseWriter, r *http.Request) {
+	project, _, ok := authorizedProject(w, r)
+	if !ok {
+		return
+	}
+	userID, ok := currentUserID(r)
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	req, err := validate.Decode[createDocumentRequest](w, r)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+
+	document, err := h.queries.CreateDocument(r.Context(), repository.CreateDocumentParams{
+		ProjectId: project.ID,
+		Title:     strings.TrimSpace(req.Title),
+		Content:   req.Content,
+		CreatedBy: &userID,
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to create document", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create document")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, document)
+}
+
+// Get returns a document of the project
+func (h *DocumentHandler) Get(w http.ResponseWriter, r *http.Request) {
+	_, document, ok := h.document(w, r)
+	if !ok {
+		return
+	}
+
+	respondJSON(w, http.StatusOK, document)
+}
+
+type updateDocumentRequest struct {
+	Title   *string `json:"title" validate:"max=255"`
+	Content *string `json:"content"`
+}
+
+// Update changes the title or content of a document
+func (h *DocumentHandler) Update(w http.ResponseWriter, r *http.Request) {
+	_, document, ok := h.document(w, r)
+	if !ok {
+		return
+	}
+
+	req, err := validate.Decode[updateDocumentRequest](w, r)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+
+	params := repository.UpdateDocumentParams{
+		ID:      document.ID,
+		Title:   document.Title,
+		Content: document.Content,
+	}
+	if req.Title != nil && strings.TrimSpace(*req.Title) != "" {
+		params.Title = strings.TrimSpace(*req.Title)
+	}
+	if req.Content != nil {
+		params.Content = *req.Content
+	}
+
+	document, err = h.queries.UpdateDocument(r.Context(), params)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to update document", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update document")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, document)
+}
+
+// Delete removes a document with its comments
+func (h *DocumentHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	_, document, ok := h.document(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := h.queries.DeleteDocument(r.Context(), document.ID); err != nil {
+		logging.FromContext(r.Context()).Error("failed to delete document", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete document")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]bool{"status": true})
+}
+
+// ListComments returns the comments of a document, oldest first
+func (h *DocumentHandler) ListComments(w http.ResponseWriter, r *http.Request) {
+	_, document, ok := h.document(w, r)
+	if !ok {
+		return
+	}
+
+	comments, err := h.queries.ListDocumentComments(r.Context(), document.ID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to list comments", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list comments")
+		return
+	}
+	if comments == nil {
+		comments = []repository.DocumentComment{}
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{"comments": comments})
+}
+
+type createCommentRequest struct {
+	Body string `json:"body" validate:"required,max=10000"`
+	// AuthorName signs the comments of reviewers without an account; users
+	// sign with their own name
+	AuthorName string `json:"authorName" validate:"max=255"`
+}
+
+// CreateComment adds a comment to a document, signed with the name of the
+// commenting user, or by a reviewer through a share link
+func (h *DocumentHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	_, document, ok := h.document(w, r)
+	if !ok {
+		return
+	}
+
+	req, err := validate.Decode[createCommentRequest](w, r)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+
+	params := repository.CreateDocumentCommentParams{
+		DocumentId: document.ID,
+		Body:       strings.TrimSpace(req.Body),
+	}
+	if link, ok := session.ShareLinkFromContext(ctx); ok {
+		params.ShareLinkId = &link.ID
+		params.AuthorName = strings.TrimSpace(req.AuthorName)
+		if params.AuthorName == "" {
+			params.AuthorName = anonymousReviewer
+		}
+	} else {
+		userID, ok := currentUserID(r)
+		if !ok {
+			respondError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+			return
+		}
+		user, err := h.queries.GetUserByID(ctx, userID)
+		if err != nil {
+			logging.FromContext(ctx).Error("failed to load comment author", "error", err)
+			respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create comment")
+			return
+		}
+		params.UserId = &userID
+		params.AuthorName = user.Name
+	}
+
+	comment, err := h.queries.CreateDocumentComment(ctx, params)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to create comment", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create comment")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, comment)
+}
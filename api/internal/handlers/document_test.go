@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"budhapp.com/internal/authz"
+	"budhapp.com/internal/repository"
+	"budhapp.com/internal/session"
+	"github.com/google/uuid"
+)
+
+// projectRequest returns a request authorized on project with role, as the
+// project authorization middleware leaves it
+func projectRequest(method, target, body string, project *repository.Project, role authz.Role) *http.Request {
+	ctx := context.WithValue(context.Background(), session.ProjectContextKey, project)
+	ctx = context.WithValue(ctx, session.PermissionsContextKey, authz.Permissions(role))
+	return httptest.NewRequestWithContext(ctx, method, target, strings.NewReader(body))
+}
+
+func TestDocumentRequiresMiddleware(t *testing.T) {
+	rec := httptest.NewRecorder()
+	(&DocumentHandler{}).List(rec, httptest.NewRequest(http.MethodGet, "/api/projects/launch/documents", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("List() without the middleware = %d, want 500", rec.Code)
+	}
+}
+
+func TestDocumentInvalidID(t *testing.T) {
+	project := &repository.Project{ID: uuid.New()}
+	req := projectRequest(http.MethodGet, "/api/projects/launch/documents/nope", "", project, authz.RoleViewer)
+	req.SetPathValue("documentId", "nope")
+	rec := httptest.NewRecorder()
+
+	(&DocumentHandler{}).Get(rec, req)
+	if rec.Code != http.StatusNotFound || !bytes.Contains(rec.Body.Bytes(), []byte("DOCUMENT_NOT_FOUND")) {
+		t.Errorf("Get() = %d %s, want 404 DOCUMENT_NOT_FOUND", rec.Code, rec.Body)
+	}
+}
+
+func TestCreateDocumentRequiresUser(t *testing.T) {
+	project := &repository.Project{ID: uuid.New()}
+	rec := httptest.NewRecorder()
+
+	(&DocumentHandler{}).Create(rec, projectRequest(http.MethodPost, "/api/projects/launch/documents", `{"title":"Draft"}`, project, authz.RoleEditor))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Create() without a user = %d, want 401", rec.Code)
+	}
+}
+
+func TestDocumentShareScope(t *testing.T) {
+	project := &repository.Project{ID: uuid.New()}
+	shared := uuid.New()
+	link := &repository.ShareLink{ID: uuid.New(), ProjectId: project.ID, DocumentId: &shared}
+
+	// Another document of the project stays hidden from the link
+	other := uuid.New()
+	req := projectRequest(http.MethodGet, "/api/shared/documents/"+other.String(), "", project, authz.RoleCommenter)
+	req = req.WithContext(context.WithValue(req.Context(), session.ShareLinkContextKey, link))
+	req.SetPathValue("documentId", other.String())
+	rec := httptest.NewRecorder()
+	(&DocumentHandler{}).Get(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Get() of another document = %d, want 404", rec.Code)
+	}
+
+	// So does the project itself
+	req = projectRequest(http.MethodGet, "/api/shared/project", "", project, authz.RoleCommenter)
+	req = req.WithContext(context.WithValue(req.Context(), session.ShareLinkContextKey, link))
+	rec = httptest.NewRecorder()
+	(&ProjectHandler{}).Get(rec, req)
+	if rec.Code != http.StatusForbidden || !bytes.Contains(rec.Body.Bytes(), []byte("SHARE_LINK_LIMITED_TO_DOCUMENT")) {
+		t.Errorf("Get() of the project = %d %s, want 403 SHARE_LINK_LIMITED_TO_DOCUMENT", rec.Code, rec.Body)
+	}
+}
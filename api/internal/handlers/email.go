@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"budhapp.com/internal/email"
+)
+
+// emailSendTimeout bounds background delivery of emails
+const emailSendTimeout = 30 * time.Second
+
+// emailsInFlight tracks background deliveries so shutdown can wait for them
+var emailsInFlight sync.WaitGroup
+
+// sendEmail delivers the email in the background so response time does not
+// depend on the provider or reveal whether the address has an account
+func sendEmail(logger *slog.Logger, provider email.EmailProvider, e email.Email) {
+	emailsInFlight.Add(1)
+	go func() {
+		defer emailsInFlight.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), emailSendTimeout)
+		defer cancel()
+
+		if err := provider.Send(ctx, e); err != nil {
+			logger.Error("email send failed", "error", err, "to", e.To)
+		}
+	}()
+}
+
+// WaitForEmails blocks until background email deliveries finish or the
+// context is done
+func WaitForEmails(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		emailsInFlight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
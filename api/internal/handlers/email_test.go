@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"budhapp.com/internal/email"
+)
+
+// blockingProvider holds each send until it is released
+type blockingProvider struct {
+	release chan struct{}
+}
+
+func (p blockingProvider) Send(ctx context.Context, e email.Email) error {
+	<-p.release
+	return nil
+}
+
+func TestWaitForEmails(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	provider := blockingProvider{release: make(chan struct{})}
+	sendEmail(logger, provider, email.Email{To: "jane@example.com"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := WaitForEmails(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("WaitForEmails() = %v while sending, want %v", err, context.DeadlineExceeded)
+	}
+
+	close(provider.release)
+	if err := WaitForEmails(context.Background()); err != nil {
+		t.Errorf("WaitForEmails() = %v after sending, want nil", err)
+	}
+}
@@ -1,25 +1,61 @@
 package handlers
 
 import (
-	"log/slog"
 	"net/http"
 
 	"budhapp.com/internal/config"
+	"budhapp.com/internal/email"
+	"budhapp.com/internal/encryption"
+	"budhapp.com/internal/jwks"
+	"budhapp.com/internal/oauth"
+	"budhapp.com/internal/passkey"
+	"budhapp.com/internal/polar"
+	"budhapp.com/internal/ratelimit"
 	"budhapp.com/internal/repository"
+	"budhapp.com/internal/storage"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Handlers struct {
-	queries *repository.Queries
-	Auth    *AuthHandler
-	Polar   *PolarHandler
+	queries      *repository.Queries
+	Auth         *AuthHandler
+	Social       *SocialHandler
+	TwoFactor    *TwoFactorHandler
+	Passkey      *PasskeyHandler
+	Passwordless *PasswordlessHandler
+	Organization *OrganizationHandler
+	Admin        *AdminHandler
+	Audit        *AuditHandler
+	Account      *AccountHandler
+	User         *UserHandler
+	Upload       *UploadHandler
+	Project      *ProjectHandler
+	Document     *DocumentHandler
+	ShareLink    *ShareLinkHandler
+	Polar        *PolarHandler
 }
 
 // New creates a new Handlers instance
-func New(queries *repository.Queries, logger *slog.Logger, cfg config.Config) *Handlers {
+func New(queries *repository.Queries, pool *pgxpool.Pool, cfg config.Config, keys *jwks.Manager, providers *oauth.Registry, tokens *oauth.TokenService, keyring *encryption.Keyring, passkeys *passkey.Service, emails email.EmailProvider, billing *polar.Client, store storage.Store, signer *storage.Signer, lockout *ratelimit.Lockout) *Handlers {
+	sessions := newSessionStore(queries, pool, cfg.Auth.Secret, cfg.Auth.BaseURL, lockout)
+
 	return &Handlers{
-		queries: queries,
-		Auth:    NewAuthHandler(queries, logger),
-		Polar:   NewPolarHandler(queries, logger, cfg.Polar),
+		queries:      queries,
+		Auth:         NewAuthHandler(queries, sessions, keys),
+		Social:       NewSocialHandler(queries, pool, sessions, providers, tokens, cfg.Auth.BaseURL, cfg.Auth.TrustedOrigins),
+		TwoFactor:    NewTwoFactorHandler(queries, pool, sessions, keyring, cfg.Auth.AppName),
+		Passkey:      NewPasskeyHandler(queries, sessions, passkeys),
+		Passwordless: NewPasswordlessHandler(queries, pool, sessions, emails, cfg.Auth),
+		Organization: NewOrganizationHandler(queries, pool, sessions, emails, cfg.Auth.AppName, cfg.Auth.BaseURL),
+		Admin:        NewAdminHandler(queries, pool, sessions, cfg.Auth.Admin),
+		Audit:        NewAuditHandler(queries, sessions),
+		Account:      NewAccountHandler(queries, pool, sessions, emails, billing, store, cfg.Auth),
+		User:         NewUserHandler(queries, pool, sessions, emails, cfg.Auth),
+		Upload:       NewUploadHandler(queries, pool, store, signer, cfg.Storage, cfg.Auth.BaseURL),
+		Project:      NewProjectHandler(queries, pool),
+		Document:     NewDocumentHandler(queries, pool),
+		ShareLink:    NewShareLinkHandler(queries, keys, cfg.Auth.BaseURL, lockout),
+		Polar:        NewPolarHandler(queries, cfg.Polar),
 	}
 }
 
@@ -0,0 +1,728 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"budhapp.com/internal/authz"
+	"budhapp.com/internal/email"
+	"budhapp.com/internal/logging"
+	"budhapp.com/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// invitationExpiration matches the better-auth invitation lifetime
+const invitationExpiration = 48 * time.Hour
+
+// Organization roles, as in the better-auth organization plugin. Members may
+// also hold the editor, commenter and viewer project roles from authz, which
+// then apply to every project of the organization.
+const (
+	roleOwner  = "owner"
+	roleAdmin  = "admin"
+	roleMember = "member"
+)
+
+// Invitation statuses
+const (
+	invitationPending  = "pending"
+	invitationAccepted = "accepted"
+	invitationRejected = "rejected"
+	invitationCanceled = "canceled"
+)
+
+func isRole(role string) bool {
+	return role == roleMember || authz.Role(role).Valid()
+}
+
+// canManage reports whether the role may update the organization, invite
+// users and change roles
+func canManage(role string) bool {
+	return role == roleOwner || role == roleAdmin
+}
+
+type OrganizationHandler struct {
+	queries  *repository.Queries
+	pool     *pgxpool.Pool
+	sessions *sessionStore
+	emails   email.EmailProvider
+	appName  string
+	baseURL  string
+}
+
+func NewOrganizationHandler(queries *repository.Queries, pool *pgxpool.Pool, sessions *sessionStore, emails email.EmailProvider, appName, baseURL string) *OrganizationHandler {
+	return &OrganizationHandler{
+		queries:  queries,
+		pool:     pool,
+		sessions: sessions,
+		emails:   emails,
+		appName:  appName,
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+type organizationMember struct {
+	repository.Member
+	User *memberUser `json:"user,omitempty"`
+}
+
+type memberUser struct {
+	ID    uuid.UUID `json:"id"`
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+	Image *string   `json:"image"`
+}
+
+type fullOrganization struct {
+	repository.Organization
+	Members     []organizationMember    `json:"members"`
+	Invitations []repository.Invitation `json:"invitations,omitempty"`
+}
+
+type createOrganizationRequest struct {
+	Name                          string          `json:"name" validate:"required"`
+	Slug                          string          `json:"slug" validate:"required,slug"`
+	Logo                          *string         `json:"logo"`
+	Metadata                      json.RawMessage `json:"metadata"`
+	KeepCurrentActiveOrganization bool            `json:"keepCurrentActiveOrganization"`
+}
+
+// Create creates an organization with the signed-in user as owner and makes
+// it the active organization of the session
+// (better-auth: POST /api/auth/organization/create)
+func (h *OrganizationHandler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	sess, user, err := h.sessions.current(r)
+	if err != nil {
+		respondError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	req, err := decodeAuthRequest[createOrganizationRequest](w, r)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+
+	var org fullOrganization
+	err = withTx(ctx, h.pool, h.queries, func(q *repository.Queries) error {
+		created, err := q.CreateOrganization(ctx, repository.CreateOrganizationParams{
+			Name:     req.Name,
+			Slug:     req.Slug,
+			Logo:     req.Logo,
+			Metadata: metadataString(req.Metadata),
+		})
+		if err != nil {
+			return err
+		}
+
+		owner, err := q.CreateMember(ctx, repository.CreateMemberParams{
+			OrganizationId: created.ID,
+			UserId:         user.ID,
+			Role:           roleOwner,
+		})
+		if err != nil {
+			return err
+		}
+
+		if !req.KeepCurrentActiveOrganization {
+			if _, err := q.SetSessionActiveOrganization(ctx, repository.SetSessionActiveOrganizationParams{
+				ID:                   sess.ID,
+				ActiveOrganizationId: &created.ID,
+			}); err != nil {
+				return err
+			}
+		}
+
+		org = fullOrganization{Organization: created, Members: []organizationMember{{Member: owner}}}
+		return nil
+	})
+	if isUniqueViolation(err) {
+		respondError(w, r, http.StatusBadRequest, "ORGANIZATION_ALREADY_EXISTS", "Organization already exists")
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to create organization", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create organization")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, org)
+}
+
+// List returns the organizations the signed-in user is a member of
+// (better-auth: GET /api/auth/organization/list)
+func (h *OrganizationHandler) List(w http.ResponseWriter, r *http.Request) {
+	_, user, err := h.sessions.current(r)
+	if err != nil {
+		respondError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	orgs, err := h.queries.ListOrganizationsByUserId(r.Context(), user.ID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to list organizations", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list organizations")
+		return
+	}
+	if orgs == nil {
+		orgs = []repository.Organization{}
+	}
+
+	respondJSON(w, http.StatusOK, orgs)
+}
+
+type updateOrganizationRequest struct {
+	OrganizationID *uuid.UUID `json:"organizationId"`
+	Data           struct {
+		Name     *string         `json:"name"`
+		Slug     *string         `json:"slug" validate:"slug"`
+		Logo     *string         `json:"logo"`
+		Metadata json.RawMessage `json:"metadata"`
+	} `json:"data"`
+}
+
+// Update changes the name, slug, logo or metadata of an organization. Only
+// owners and admins may update it.
+// (better-auth: POST /api/auth/organization/update)
+func (h *OrganizationHandler) Update(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req, sess, user, ok := authorize[updateOrganizationRequest](h, w, r)
+	if !ok {
+		return
+	}
+
+	orgID, ok := activeOrganization(w, r, req.OrganizationID, sess)
+	if !ok {
+		return
+	}
+	member, ok := h.member(w, r, orgID, user.ID)
+	if !ok {
+		return
+	}
+	if !canManage(member.Role) {
+		respondError(w, r, http.StatusForbidden, "YOU_ARE_NOT_ALLOWED_TO_UPDATE_THIS_ORGANIZATION", "You are not allowed to update this organization")
+		return
+	}
+
+	org, err := h.queries.GetOrganizationByID(ctx, orgID)
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "ORGANIZATION_NOT_FOUND", "Organization not found")
+		return
+	}
+
+	params := repository.UpdateOrganizationParams{
+		ID:       org.ID,
+		Name:     org.Name,
+		Slug:     org.Slug,
+		Logo:     org.Logo,
+		Metadata: org.Metadata,
+	}
+	if req.Data.Name != nil && strings.TrimSpace(*req.Data.Name) != "" {
+		params.Name = strings.TrimSpace(*req.Data.Name)
+	}
+	if req.Data.Slug != nil {
+		params.Slug = *req.Data.Slug
+	}
+	if req.Data.Logo != nil {
+		params.Logo = req.Data.Logo
+	}
+	if len(req.Data.Metadata) > 0 {
+		params.Metadata = metadataString(req.Data.Metadata)
+	}
+
+	org, err = h.queries.UpdateOrganization(ctx, params)
+	if isUniqueViolation(err) {
+		respondError(w, r, http.StatusBadRequest, "ORGANIZATION_SLUG_ALREADY_TAKEN", "Organization slug already taken")
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to update organization", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update organization")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, org)
+}
+
+type setActiveOrganizationRequest struct {
+	OrganizationID *uuid.UUID `json:"organizationId"`
+}
+
+// SetActive sets the active organization of the session, or clears it when
+// organizationId is null (better-auth: POST /api/auth/organization/set-active)
+func (h *OrganizationHandler) SetActive(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req, sess, user, ok := authorize[setActiveOrganizationRequest](h, w, r)
+	if !ok {
+		return
+	}
+
+	var org *repository.Organization
+	if req.OrganizationID != nil {
+		if _, ok := h.member(w, r, *req.OrganizationID, user.ID); !ok {
+			return
+		}
+		found, err := h.queries.GetOrganizationByID(ctx, *req.OrganizationID)
+		if err != nil {
+			respondError(w, r, http.StatusBadRequest, "ORGANIZATION_NOT_FOUND", "Organization not found")
+			return
+		}
+		org = &found
+	}
+
+	if _, err := h.queries.SetSessionActiveOrganization(ctx, repository.SetSessionActiveOrganizationParams{
+		ID:                   sess.ID,
+		ActiveOrganizationId: req.OrganizationID,
+	}); err != nil {
+		logging.FromContext(ctx).Error("failed to set active organization", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to set active organization")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, org)
+}
+
+// GetFull returns an organization with its members and invitations. It
+// defaults to the active organization and returns null when there is none.
+// (better-auth: GET /api/auth/organization/get-full-organization)
+func (h *OrganizationHandler) GetFull(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	sess, user, err := h.sessions.current(r)
+	if err != nil {
+		respondError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	orgID := sess.ActiveOrganizationId
+	if raw := r.URL.Query().Get("organizationId"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			respondError(w, r, http.StatusBadRequest, "ORGANIZATION_NOT_FOUND", "Organization not found")
+			return
+		}
+		orgID = &id
+	}
+	if orgID == nil {
+		respondJSON(w, http.StatusOK, nil)
+		return
+	}
+
+	if _, ok := h.member(w, r, *orgID, user.ID); !ok {
+		return
+	}
+
+	org, err := h.queries.GetOrganizationByID(ctx, *orgID)
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "ORGANIZATION_NOT_FOUND", "Organization not found")
+		return
+	}
+	rows, err := h.queries.ListMembersByOrganizationId(ctx, org.ID)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to list members", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load organization")
+		return
+	}
+	invitations, err := h.queries.ListInvitationsByOrganizationId(ctx, org.ID)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to list invitations", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load organization")
+		return
+	}
+
+	full := fullOrganization{
+		Organization: org,
+		Members:      make([]organizationMember, len(rows)),
+		Invitations:  invitations,
+	}
+	if full.Invitations == nil {
+		full.Invitations = []repository.Invitation{}
+	}
+	for i, row := range rows {
+		full.Members[i] = organizationMember{
+			Member: repository.Member{
+				ID:             row.ID,
+				OrganizationId: row.OrganizationId,
+				UserId:         row.UserId,
+				Role:           row.Role,
+				CreatedAt:      row.CreatedAt,
+			},
+			User: &memberUser{ID: row.UserId, Name: row.Name, Email: row.Email, Image: row.Image},
+		}
+	}
+
+	respondJSON(w, http.StatusOK, full)
+}
+
+type inviteMemberRequest struct {
+	Email          string     `json:"email" validate:"required,email"`
+	Role           string     `json:"role" validate:"required"`
+	OrganizationID *uuid.UUID `json:"organizationId"`
+	Resend         bool       `json:"resend"`
+}
+
+// InviteMember emails an invitation to join the organization. Owners and
+// admins may invite; only owners may invite another owner.
+// (better-auth: POST /api/auth/organization/invite-member)
+func (h *OrganizationHandler) InviteMember(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req, sess, user, ok := authorize[inviteMemberRequest](h, w, r)
+	if !ok {
+		return
+	}
+	req.Email = strings.ToLower(req.Email)
+	if !isRole(req.Role) {
+		respondError(w, r, http.StatusBadRequest, "ROLE_NOT_FOUND", "Role not found")
+		return
+	}
+
+	orgID, ok := activeOrganization(w, r, req.OrganizationID, sess)
+	if !ok {
+		return
+	}
+	inviter, ok := h.member(w, r, orgID, user.ID)
+	if !ok {
+		return
+	}
+	if !canManage(inviter.Role) {
+		respondError(w, r, http.StatusForbidden, "YOU_ARE_NOT_ALLOWED_TO_INVITE_USERS_TO_THIS_ORGANIZATION", "You are not allowed to invite users to this organization")
+		return
+	}
+	if req.Role == roleOwner && inviter.Role != roleOwner {
+		respondError(w, r, http.StatusForbidden, "YOU_ARE_NOT_ALLOWED_TO_INVITE_USER_WITH_THIS_ROLE", "You are not allowed to invite a user with this role")
+		return
+	}
+
+	if invitee, err := h.queries.GetUserByEmail(ctx, req.Email); err == nil {
+		if _, err := h.queries.GetMemberByOrganizationIdAndUserId(ctx, repository.GetMemberByOrganizationIdAndUserIdParams{
+			OrganizationId: orgID,
+			UserId:         invitee.ID,
+		}); err == nil {
+			respondError(w, r, http.StatusBadRequest, "USER_IS_ALREADY_A_MEMBER_OF_THIS_ORGANIZATION", "User is already a member of this organization")
+			return
+		}
+	}
+
+	org, err := h.queries.GetOrganizationByID(ctx, orgID)
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "ORGANIZATION_NOT_FOUND", "Organization not found")
+		return
+	}
+
+	expiresAt := time.Now().Add(invitationExpiration)
+	invitation, err := h.queries.GetPendingInvitation(ctx, repository.GetPendingInvitationParams{
+		OrganizationId: orgID,
+		Email:          req.Email,
+	})
+	switch {
+	case err == nil && !req.Resend:
+		respondError(w, r, http.StatusBadRequest, "USER_IS_ALREADY_INVITED_TO_THIS_ORGANIZATION", "User is already invited to this organization")
+		return
+	case err == nil:
+		invitation, err = h.queries.UpdateInvitationExpiry(ctx, repository.UpdateInvitationExpiryParams{
+			ID:        invitation.ID,
+			ExpiresAt: expiresAt,
+		})
+	case errors.Is(err, pgx.ErrNoRows):
+		invitation, err = h.queries.CreateInvitation(ctx, repository.CreateInvitationParams{
+			OrganizationId: orgID,
+			Email:          req.Email,
+			Role:           req.Role,
+			ExpiresAt:      expiresAt,
+			InviterId:      user.ID,
+		})
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to create invitation", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to invite member")
+		return
+	}
+
+	link := h.baseURL + "/accept-invitation/" + invitation.ID.String()
+	sendEmail(logging.FromContext(ctx), h.emails, email.Invitation(req.Email, h.appName, user.Name, org.Name, link, invitationExpiration))
+
+	respondJSON(w, http.StatusOK, invitation)
+}
+
+type invitationRequest struct {
+	InvitationID uuid.UUID `json:"invitationId" validate:"required"`
+}
+
+// AcceptInvitation adds the signed-in user to the organization and makes it
+// active. The invitation must be addressed to the user's email.
+// (better-auth: POST /api/auth/organization/accept-invitation)
+func (h *OrganizationHandler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req, sess, user, ok := authorize[invitationRequest](h, w, r)
+	if !ok {
+		return
+	}
+
+	invitation, ok := h.recipientInvitation(w, r, req.InvitationID, user)
+	if !ok {
+		return
+	}
+
+	var member repository.Member
+	err := withTx(ctx, h.pool, h.queries, func(q *repository.Queries) error {
+		var err error
+		if invitation, err = q.UpdateInvitationStatus(ctx, repository.UpdateInvitationStatusParams{
+			ID:     invitation.ID,
+			Status: invitationAccepted,
+		}); err != nil {
+			return err
+		}
+		if member, err = q.CreateMember(ctx, repository.CreateMemberParams{
+			OrganizationId: invitation.OrganizationId,
+			UserId:         user.ID,
+			Role:           invitation.Role,
+		}); err != nil {
+			return err
+		}
+		_, err = q.SetSessionActiveOrganization(ctx, repository.SetSessionActiveOrganizationParams{
+			ID:                   sess.ID,
+			ActiveOrganizationId: &invitation.OrganizationId,
+		})
+		return err
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to accept invitation", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to accept invitation")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"invitation": invitation,
+		"member":     member,
+	})
+}
+
+// RejectInvitation declines an invitation addressed to the signed-in user
+// (better-auth: POST /api/auth/organization/reject-invitation)
+func (h *OrganizationHandler) RejectInvitation(w http.ResponseWriter, r *http.Request) {
+	req, _, user, ok := authorize[invitationRequest](h, w, r)
+	if !ok {
+		return
+	}
+
+	invitation, ok := h.recipientInvitation(w, r, req.InvitationID, user)
+	if !ok {
+		return
+	}
+
+	invitation, err := h.queries.UpdateInvitationStatus(r.Context(), repository.UpdateInvitationStatusParams{
+		ID:     invitation.ID,
+		Status: invitationRejected,
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to reject invitation", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to reject invitation")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"invitation": invitation,
+		"member":     nil,
+	})
+}
+
+// CancelInvitation withdraws a pending invitation. Owners and admins of the
+// organization may cancel.
+// (better-auth: POST /api/auth/organization/cancel-invitation)
+func (h *OrganizationHandler) CancelInvitation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req, _, user, ok := authorize[invitationRequest](h, w, r)
+	if !ok {
+		return
+	}
+
+	invitation, err := h.queries.GetInvitationByID(ctx, req.InvitationID)
+	if err != nil || invitation.Status != invitationPending {
+		respondError(w, r, http.StatusBadRequest, "INVITATION_NOT_FOUND", "Invitation not found")
+		return
+	}
+	member, ok := h.member(w, r, invitation.OrganizationId, user.ID)
+	if !ok {
+		return
+	}
+	if !canManage(member.Role) {
+		respondError(w, r, http.StatusForbidden, "YOU_ARE_NOT_ALLOWED_TO_CANCEL_THIS_INVITATION", "You are not allowed to cancel this invitation")
+		return
+	}
+
+	invitation, err = h.queries.UpdateInvitationStatus(ctx, repository.UpdateInvitationStatusParams{
+		ID:     invitation.ID,
+		Status: invitationCanceled,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to cancel invitation", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to cancel invitation")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, invitation)
+}
+
+type updateMemberRoleRequest struct {
+	MemberID       uuid.UUID  `json:"memberId" validate:"required"`
+	Role           string     `json:"role" validate:"required"`
+	OrganizationID *uuid.UUID `json:"organizationId"`
+}
+
+// UpdateMemberRole changes the role of a member. Owners and admins may change
+// roles; only owners may grant or revoke the owner role, and the last owner
+// cannot be demoted.
+// (better-auth: POST /api/auth/organization/update-member-role)
+func (h *OrganizationHandler) UpdateMemberRole(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req, sess, user, ok := authorize[updateMemberRoleRequest](h, w, r)
+	if !ok {
+		return
+	}
+	if !isRole(req.Role) {
+		respondError(w, r, http.StatusBadRequest, "ROLE_NOT_FOUND", "Role not found")
+		return
+	}
+
+	orgID, ok := activeOrganization(w, r, req.OrganizationID, sess)
+	if !ok {
+		return
+	}
+	actor, ok := h.member(w, r, orgID, user.ID)
+	if !ok {
+		return
+	}
+
+	target, err := h.queries.GetMemberByID(ctx, req.MemberID)
+	if err != nil || target.OrganizationId != orgID {
+		respondError(w, r, http.StatusBadRequest, "MEMBER_NOT_FOUND", "Member not found")
+		return
+	}
+
+	touchesOwner := req.Role == roleOwner || target.Role == roleOwner
+	if !canManage(actor.Role) || (touchesOwner && actor.Role != roleOwner) {
+		respondError(w, r, http.StatusForbidden, "YOU_ARE_NOT_ALLOWED_TO_UPDATE_THIS_MEMBER", "You are not allowed to update this member")
+		return
+	}
+
+	if target.Role == roleOwner && req.Role != roleOwner {
+		owners, err := h.queries.CountMembersByOrganizationIdAndRole(ctx, repository.CountMembersByOrganizationIdAndRoleParams{
+			OrganizationId: orgID,
+			Role:           roleOwner,
+		})
+		if err != nil {
+			logging.FromContext(ctx).Error("failed to count owners", "error", err)
+			respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update member")
+			return
+		}
+		if owners <= 1 {
+			respondError(w, r, http.StatusBadRequest, "YOU_CANNOT_LEAVE_THE_ORGANIZATION_WITHOUT_AN_OWNER", "You cannot leave the organization without an owner")
+			return
+		}
+	}
+
+	member, err := h.queries.UpdateMemberRole(ctx, repository.UpdateMemberRoleParams{
+		ID:   target.ID,
+		Role: req.Role,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to update member role", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update member")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, member)
+}
+
+// authorize returns the signed-in session and user and the decoded request
+// body. It writes the error response and returns false on failure.
+func authorize[T any](h *OrganizationHandler, w http.ResponseWriter, r *http.Request) (T, repository.Session, repository.User, bool) {
+	var req T
+	sess, user, err := h.sessions.current(r)
+	if err != nil {
+		respondError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return req, repository.Session{}, repository.User{}, false
+	}
+
+	req, err = decodeAuthRequest[T](w, r)
+	if err != nil {
+		respondErr(w, r, err)
+		return req, repository.Session{}, repository.User{}, false
+	}
+
+	return req, sess, user, true
+}
+
+// member returns the user's membership of the organization, writing the
+// error response when they are not a member
+func (h *OrganizationHandler) member(w http.ResponseWriter, r *http.Request, orgID, userID uuid.UUID) (repository.Member, bool) {
+	member, err := h.queries.GetMemberByOrganizationIdAndUserId(r.Context(), repository.GetMemberByOrganizationIdAndUserIdParams{
+		OrganizationId: orgID,
+		UserId:         userID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondError(w, r, http.StatusForbidden, "USER_IS_NOT_A_MEMBER_OF_THE_ORGANIZATION", "User is not a member of the organization")
+		return repository.Member{}, false
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to look up member", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to look up member")
+		return repository.Member{}, false
+	}
+
+	return member, true
+}
+
+// recipientInvitation returns a pending, unexpired invitation addressed to
+// the user, writing the error response otherwise
+func (h *OrganizationHandler) recipientInvitation(w http.ResponseWriter, r *http.Request, id uuid.UUID, user repository.User) (repository.Invitation, bool) {
+	invitation, err := h.queries.GetInvitationByID(r.Context(), id)
+	if err != nil || invitation.Status != invitationPending || invitation.ExpiresAt.Before(time.Now()) {
+		respondError(w, r, http.StatusBadRequest, "INVITATION_NOT_FOUND", "Invitation not found")
+		return repository.Invitation{}, false
+	}
+	if !strings.EqualFold(invitation.Email, user.Email) {
+		respondError(w, r, http.StatusForbidden, "YOU_ARE_NOT_THE_RECIPIENT_OF_THE_INVITATION", "You are not the recipient of the invitation")
+		return repository.Invitation{}, false
+	}
+
+	return invitation, true
+}
+
+// activeOrganization returns the requested organization, falling back to the
+// active organization of the session
+func activeOrganization(w http.ResponseWriter, r *http.Request, requested *uuid.UUID, sess repository.Session) (uuid.UUID, bool) {
+	if requested != nil {
+		return *requested, true
+	}
+	if sess.ActiveOrganizationId != nil {
+		return *sess.ActiveOrganizationId, true
+	}
+
+	respondError(w, r, http.StatusBadRequest, "NO_ACTIVE_ORGANIZATION", "No active organization")
+	return uuid.Nil, false
+}
+
+// metadataString stores organization metadata as a JSON string, like
+// better-auth; null or missing metadata is stored as NULL
+func metadataString(raw json.RawMessage) *string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	s := string(raw)
+	return &s
+}
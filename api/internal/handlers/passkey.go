@@ -0,0 +1,325 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"budhapp.com/internal/logging"
+	"budhapp.com/internal/passkey"
+	"budhapp.com/internal/repository"
+	"budhapp.com/internal/session"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// passkeyChallengeExpiration matches the better-auth passkey challenge lifetime
+	passkeyChallengeExpiration = 5 * time.Minute
+	passkeyIdentifier          = "passkey-"
+)
+
+var (
+	ErrNoPasskeyChallenge = errors.New("no pending passkey challenge")
+)
+
+// startPasskeyChallenge stores the ceremony state and sets the passkey cookie
+// that identifies it
+func (s *sessionStore) startPasskeyChallenge(ctx context.Context, w http.ResponseWriter, state []byte) error {
+	identifier := passkeyIdentifier + generateToken(32)
+	expiresAt := time.Now().Add(passkeyChallengeExpiration)
+
+	if _, err := s.queries.CreateVerification(ctx, repository.CreateVerificationParams{
+		Identifier: identifier,
+		Value:      string(state),
+		ExpiresAt:  expiresAt,
+	}); err != nil {
+		return err
+	}
+
+	s.setCookie(w, session.PasskeyCookieName, identifier, expiresAt)
+	return nil
+}
+
+// consumePasskeyChallenge returns the ceremony state of the request. Each
+// challenge can only be answered once.
+func (s *sessionStore) consumePasskeyChallenge(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	identifier, ok := session.SignedCookieFromRequest(r, session.PasskeyCookieName, s.secret)
+	if !ok || !strings.HasPrefix(identifier, passkeyIdentifier) {
+		return nil, ErrNoPasskeyChallenge
+	}
+	s.clearCookie(w, session.PasskeyCookieName)
+
+	verification, err := s.queries.ConsumeVerification(r.Context(), identifier)
+	if err != nil {
+		return nil, ErrNoPasskeyChallenge
+	}
+
+	return []byte(verification.Value), nil
+}
+
+type PasskeyHandler struct {
+	queries  *repository.Queries
+	sessions *sessionStore
+	passkeys *passkey.Service
+}
+
+func NewPasskeyHandler(queries *repository.Queries, sessions *sessionStore, passkeys *passkey.Service) *PasskeyHandler {
+	return &PasskeyHandler{
+		queries:  queries,
+		sessions: sessions,
+		passkeys: passkeys,
+	}
+}
+
+// GenerateRegisterOptions starts registering a passkey for the signed-in user
+// (better-auth: GET /api/auth/passkey/generate-register-options)
+func (h *PasskeyHandler) GenerateRegisterOptions(w http.ResponseWriter, r *http.Request) {
+	_, user, err := h.sessions.current(r)
+	if err != nil {
+		respondError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	attachment := protocol.AuthenticatorAttachment(r.URL.Query().Get("authenticatorAttachment"))
+	if attachment != "" && attachment != protocol.Platform && attachment != protocol.CrossPlatform {
+		respondError(w, r, http.StatusBadRequest, "INVALID_AUTHENTICATOR_ATTACHMENT", "Invalid authenticator attachment")
+		return
+	}
+
+	creation, state, err := h.passkeys.BeginRegistration(r.Context(), user, attachment)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to generate passkey registration options", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to generate registration options")
+		return
+	}
+
+	if err := h.sessions.startPasskeyChallenge(r.Context(), w, state); err != nil {
+		logging.FromContext(r.Context()).Error("failed to store passkey challenge", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to generate registration options")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, creation.Response)
+}
+
+type verifyRegistrationRequest struct {
+	Response json.RawMessage `json:"response" validate:"required"`
+	Name     string          `json:"name"`
+}
+
+// VerifyRegistration checks the authenticator's attestation and stores the passkey
+// (better-auth: POST /api/auth/passkey/verify-registration)
+func (h *PasskeyHandler) VerifyRegistration(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	_, user, err := h.sessions.current(r)
+	if err != nil {
+		respondError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	req, err := decodeAuthRequest[verifyRegistrationRequest](w, r)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+
+	state, err := h.sessions.consumePasskeyChallenge(w, r)
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "CHALLENGE_NOT_FOUND", "Challenge not found")
+		return
+	}
+
+	params, err := h.passkeys.FinishRegistration(ctx, user, state, req.Response, req.Name)
+	if err != nil {
+		logging.FromContext(ctx).Info("passkey registration failed", "user_id", user.ID, "error", err)
+		respondError(w, r, http.StatusBadRequest, "FAILED_TO_VERIFY_REGISTRATION", "Failed to verify registration")
+		return
+	}
+
+	p, err := h.queries.CreatePasskey(ctx, params)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to store passkey", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to store passkey")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, p)
+}
+
+// GenerateAuthenticateOptions starts a passkey sign-in. Any passkey
+// registered on this site may answer, so no email is needed.
+// (better-auth: POST /api/auth/passkey/generate-authenticate-options)
+func (h *PasskeyHandler) GenerateAuthenticateOptions(w http.ResponseWriter, r *http.Request) {
+	assertion, state, err := h.passkeys.BeginLogin()
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to generate passkey authentication options", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to generate authentication options")
+		return
+	}
+
+	if err := h.sessions.startPasskeyChallenge(r.Context(), w, state); err != nil {
+		logging.FromContext(r.Context()).Error("failed to store passkey challenge", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to generate authentication options")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, assertion.Response)
+}
+
+type verifyAuthenticationRequest struct {
+	Response json.RawMessage `json:"response" validate:"required"`
+}
+
+// VerifyAuthentication checks the assertion and signs the passkey's user in
+// (better-auth: POST /api/auth/passkey/verify-authentication)
+func (h *PasskeyHandler) VerifyAuthentication(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req, err := decodeAuthRequest[verifyAuthenticationRequest](w, r)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+
+	state, err := h.sessions.consumePasskeyChallenge(w, r)
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "CHALLENGE_NOT_FOUND", "Challenge not found")
+		return
+	}
+
+	p, err := h.passkeys.FinishLogin(ctx, state, req.Response)
+	if errors.Is(err, passkey.ErrPasskeyNotFound) {
+		respondError(w, r, http.StatusUnauthorized, "PASSKEY_NOT_FOUND", "Passkey not found")
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).Info("passkey authentication failed", "error", err)
+		respondError(w, r, http.StatusBadRequest, "AUTHENTICATION_FAILED", "Authentication failed")
+		return
+	}
+
+	user, err := h.queries.GetUserByID(ctx, p.UserId)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to load passkey user", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to sign in")
+		return
+	}
+
+	sess, err := h.sessions.create(ctx, w, r, user.ID)
+	if errors.Is(err, ErrUserBanned) {
+		respondBanned(w, r)
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to create session", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to sign in")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"user":    user,
+		"session": sess,
+	})
+}
+
+// ListUserPasskeys returns the passkeys of the signed-in user
+// (better-auth: GET /api/auth/passkey/list-user-passkeys)
+func (h *PasskeyHandler) ListUserPasskeys(w http.ResponseWriter, r *http.Request) {
+	_, user, err := h.sessions.current(r)
+	if err != nil {
+		respondError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	passkeys, err := h.queries.ListPasskeysByUserId(r.Context(), user.ID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to list passkeys", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list passkeys")
+		return
+	}
+	if passkeys == nil {
+		passkeys = []repository.Passkey{}
+	}
+
+	respondJSON(w, http.StatusOK, passkeys)
+}
+
+type updatePasskeyRequest struct {
+	ID   uuid.UUID `json:"id" validate:"required"`
+	Name string    `json:"name"`
+}
+
+// UpdatePasskey renames one of the signed-in user's passkeys
+// (better-auth: POST /api/auth/passkey/update-passkey)
+func (h *PasskeyHandler) UpdatePasskey(w http.ResponseWriter, r *http.Request) {
+	_, user, err := h.sessions.current(r)
+	if err != nil {
+		respondError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	req, err := decodeAuthRequest[updatePasskeyRequest](w, r)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+
+	p, err := h.queries.UpdatePasskeyName(r.Context(), repository.UpdatePasskeyNameParams{
+		ID:     req.ID,
+		UserId: user.ID,
+		Name:   &req.Name,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondError(w, r, http.StatusNotFound, "PASSKEY_NOT_FOUND", "Passkey not found")
+		return
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to update passkey", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update passkey")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{"passkey": p})
+}
+
+type deletePasskeyRequest struct {
+	ID uuid.UUID `json:"id" validate:"required"`
+}
+
+// DeletePasskey removes one of the signed-in user's passkeys
+// (better-auth: POST /api/auth/passkey/delete-passkey)
+func (h *PasskeyHandler) DeletePasskey(w http.ResponseWriter, r *http.Request) {
+	_, user, err := h.sessions.current(r)
+	if err != nil {
+		respondError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	req, err := decodeAuthRequest[deletePasskeyRequest](w, r)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+
+	deleted, err := h.queries.DeletePasskey(r.Context(), repository.DeletePasskeyParams{
+		ID:     req.ID,
+		UserId: user.ID,
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to delete passkey", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete passkey")
+		return
+	}
+	if deleted == 0 {
+		respondError(w, r, http.StatusNotFound, "PASSKEY_NOT_FOUND", "Passkey not found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]bool{"status": true})
+}
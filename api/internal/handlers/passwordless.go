@@ -0,0 +1,445 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"budhapp.com/internal/config"
+	"budhapp.com/internal/email"
+	"budhapp.com/internal/logging"
+	"budhapp.com/internal/ratelimit"
+	"budhapp.com/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	otpLength = 6
+	// otpSignInIdentifier prefixes the email in the verification identifier,
+	// as the better-auth emailOTP plugin does
+	otpSignInIdentifier = "sign-in-otp-"
+	otpTypeSignIn       = "sign-in"
+)
+
+// Error codes passed to the magic link error callback URL, matching better-auth
+const (
+	errorInvalidToken          = "INVALID_TOKEN"
+	errorAttemptsExceeded      = "ATTEMPTS_EXCEEDED"
+	errorSignUpDisabled        = "new_user_signup_disabled"
+	errorFailedToCreateSession = "failed_to_create_session"
+	errorFailedToCreateUser    = "failed_to_create_user"
+)
+
+var (
+	ErrSignUpDisabled = errors.New("sign up is disabled")
+)
+
+// magicLinkValue is stored in the verification table, keyed by the link token
+type magicLinkValue struct {
+	Email   string `json:"email"`
+	Name    string `json:"name"`
+	Attempt int    `json:"attempt"`
+}
+
+// PasswordlessHandler signs users in with a link or a one-time code sent to
+// their email address
+type PasswordlessHandler struct {
+	queries        *repository.Queries
+	pool           *pgxpool.Pool
+	sessions       *sessionStore
+	emails         email.EmailProvider
+	appName        string
+	baseURL        string
+	trustedOrigins []string
+	magicLink      config.PasswordlessConfig
+	emailOTP       config.PasswordlessConfig
+}
+
+func NewPasswordlessHandler(queries *repository.Queries, pool *pgxpool.Pool, sessions *sessionStore, emails email.EmailProvider, cfg config.AuthConfig) *PasswordlessHandler {
+	return &PasswordlessHandler{
+		queries:        queries,
+		pool:           pool,
+		sessions:       sessions,
+		emails:         emails,
+		appName:        cfg.AppName,
+		baseURL:        strings.TrimSuffix(cfg.BaseURL, "/"),
+		trustedOrigins: cfg.TrustedOrigins,
+		magicLink:      cfg.MagicLink,
+		emailOTP:       cfg.EmailOTP,
+	}
+}
+
+type magicLinkRequest struct {
+	Email              string `json:"email" validate:"required,email"`
+	Name               string `json:"name"`
+	CallbackURL        string `json:"callbackURL"`
+	NewUserCallbackURL string `json:"newUserCallbackURL"`
+	ErrorCallbackURL   string `json:"errorCallbackURL"`
+}
+
+// SignInMagicLink emails a sign-in link. The response does not reveal whether
+// the email belongs to an account.
+// (better-auth: POST /api/auth/sign-in/magic-link)
+func (h *PasswordlessHandler) SignInMagicLink(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeAuthRequest[magicLinkRequest](w, r)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	req.Email = strings.ToLower(req.Email)
+
+	for _, u := range []string{req.CallbackURL, req.NewUserCallbackURL, req.ErrorCallbackURL} {
+		if u != "" && !isTrustedURL(u, h.baseURL, h.trustedOrigins) {
+			respondError(w, r, http.StatusForbidden, "INVALID_CALLBACK_URL", "Invalid callback URL")
+			return
+		}
+	}
+
+	token := generateToken(32)
+	value, err := json.Marshal(magicLinkValue{Email: req.Email, Name: req.Name})
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to send magic link")
+		return
+	}
+	if _, err := h.queries.CreateVerification(r.Context(), repository.CreateVerificationParams{
+		Identifier: token,
+		Value:      string(value),
+		ExpiresAt:  time.Now().Add(h.magicLink.Expiration.Std()),
+	}); err != nil {
+		logging.FromContext(r.Context()).Error("failed to store magic link", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to send magic link")
+		return
+	}
+
+	query := url.Values{"token": {token}}
+	if req.CallbackURL != "" {
+		query.Set("callbackURL", req.CallbackURL)
+	}
+	if req.NewUserCallbackURL != "" {
+		query.Set("newUserCallbackURL", req.NewUserCallbackURL)
+	}
+	if req.ErrorCallbackURL != "" {
+		query.Set("errorCallbackURL", req.ErrorCallbackURL)
+	}
+	link := h.baseURL + "/api/auth/magic-link/verify?" + query.Encode()
+
+	sendEmail(logging.FromContext(r.Context()), h.emails, email.MagicLink(req.Email, h.appName, link, h.magicLink.Expiration.Std()))
+
+	respondJSON(w, http.StatusOK, map[string]bool{"status": true})
+}
+
+// VerifyMagicLink signs the user in from the emailed link and redirects to
+// the callback URL. A link can be opened AllowedAttempts times.
+// (better-auth: GET /api/auth/magic-link/verify)
+func (h *PasswordlessHandler) VerifyMagicLink(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	// The link may have been altered, so redirect targets are checked again
+	target := func(key, fallback string) string {
+		if u := query.Get(key); u != "" && isTrustedURL(u, h.baseURL, h.trustedOrigins) {
+			return u
+		}
+		return fallback
+	}
+	callbackURL := target("callbackURL", "/")
+	errorURL := target("errorCallbackURL", callbackURL)
+	newUserURL := target("newUserCallbackURL", callbackURL)
+
+	token := query.Get("token")
+	verification, err := h.queries.GetVerificationByIdentifier(ctx, token)
+	if err != nil {
+		redirectError(w, r, errorURL, errorInvalidToken)
+		return
+	}
+
+	var value magicLinkValue
+	if err := json.Unmarshal([]byte(verification.Value), &value); err != nil {
+		redirectError(w, r, errorURL, errorInvalidToken)
+		return
+	}
+
+	if !h.useAttempt(ctx, verification, value, h.magicLink.AllowedAttempts) {
+		redirectError(w, r, errorURL, errorAttemptsExceeded)
+		return
+	}
+
+	user, isNew, err := h.findOrCreateUser(ctx, value.Email, value.Name, h.magicLink.DisableSignUp)
+	if errors.Is(err, ErrSignUpDisabled) {
+		redirectError(w, r, errorURL, errorSignUpDisabled)
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to create user", "error", err)
+		redirectError(w, r, errorURL, errorFailedToCreateUser)
+		return
+	}
+
+	if _, err := h.sessions.create(ctx, w, r, user.ID); err != nil {
+		if errors.Is(err, ErrUserBanned) {
+			redirectError(w, r, errorURL, errorBanned)
+			return
+		}
+		logging.FromContext(ctx).Error("failed to create session", "error", err)
+		redirectError(w, r, errorURL, errorFailedToCreateSession)
+		return
+	}
+
+	if isNew {
+		callbackURL = newUserURL
+	}
+	http.Redirect(w, r, callbackURL, http.StatusFound)
+}
+
+// useAttempt counts one use of a magic link and reports whether it was
+// allowed. The last allowed use deletes the link.
+func (h *PasswordlessHandler) useAttempt(ctx context.Context, verification repository.Verification, value magicLinkValue, allowed int) bool {
+	if value.Attempt+1 >= allowed {
+		_, err := h.queries.ConsumeVerification(ctx, verification.Identifier)
+		return err == nil && value.Attempt < allowed
+	}
+
+	value.Attempt++
+	next, err := json.Marshal(value)
+	if err != nil {
+		return false
+	}
+	// Compare-and-swap on the old value so concurrent opens are each counted
+	updated, err := h.queries.UpdateVerificationValue(ctx, repository.UpdateVerificationValueParams{
+		NewValue:   string(next),
+		Identifier: verification.Identifier,
+		OldValue:   verification.Value,
+	})
+	return err == nil && updated == 1
+}
+
+type sendOTPRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Type  string `json:"type"`
+}
+
+// SendVerificationOTP emails a one-time sign-in code, replacing any earlier
+// code for the address. Only the "sign-in" type is supported.
+// (better-auth: POST /api/auth/email-otp/send-verification-otp)
+func (h *PasswordlessHandler) SendVerificationOTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req, err := decodeAuthRequest[sendOTPRequest](w, r)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	req.Email = strings.ToLower(req.Email)
+	if req.Type != otpTypeSignIn {
+		respondError(w, r, http.StatusBadRequest, "INVALID_OTP_TYPE", "Invalid OTP type")
+		return
+	}
+
+	// Unknown emails get the same response, so addresses cannot be enumerated
+	if h.emailOTP.DisableSignUp {
+		if _, err := h.queries.GetUserByEmail(ctx, req.Email); err != nil {
+			respondJSON(w, http.StatusOK, map[string]bool{"success": true})
+			return
+		}
+	}
+
+	otp, err := generateOTP(otpLength)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to send OTP")
+		return
+	}
+
+	identifier := otpSignInIdentifier + req.Email
+	err = withTx(ctx, h.pool, h.queries, func(q *repository.Queries) error {
+		if err := q.DeleteVerificationByIdentifier(ctx, identifier); err != nil {
+			return err
+		}
+		_, err := q.CreateVerification(ctx, repository.CreateVerificationParams{
+			Identifier: identifier,
+			Value:      otpValue(otp, 0),
+			ExpiresAt:  time.Now().Add(h.emailOTP.Expiration.Std()),
+		})
+		return err
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to store otp", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to send OTP")
+		return
+	}
+
+	sendEmail(logging.FromContext(ctx), h.emails, email.SignInCode(req.Email, h.appName, otp, h.emailOTP.Expiration.Std()))
+
+	respondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+type signInOTPRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	OTP   string `json:"otp" validate:"required"`
+}
+
+// SignInEmailOTP signs the user in with an emailed code. After AllowedAttempts
+// wrong codes the code is invalidated and a new one must be requested.
+// (better-auth: POST /api/auth/sign-in/email-otp)
+func (h *PasswordlessHandler) SignInEmailOTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req, err := decodeAuthRequest[signInOTPRequest](w, r)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	req.Email = strings.ToLower(req.Email)
+	identifier := otpSignInIdentifier + req.Email
+	if wait := h.sessions.signInLocked(r, req.Email); wait > 0 {
+		ratelimit.RespondTooManyRequests(w, r, wait)
+		return
+	}
+
+	verification, err := h.queries.GetVerificationByIdentifier(ctx, identifier)
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "OTP_EXPIRED", "OTP expired")
+		return
+	}
+
+	otp, attempts, ok := parseOTPValue(verification.Value)
+	if !ok {
+		respondError(w, r, http.StatusBadRequest, "INVALID_OTP", "Invalid OTP")
+		return
+	}
+	if attempts >= h.emailOTP.AllowedAttempts {
+		h.queries.DeleteVerificationByIdentifier(ctx, identifier) //nolint:errcheck // Expires on its own
+		respondError(w, r, http.StatusForbidden, "TOO_MANY_ATTEMPTS", "Too many attempts")
+		return
+	}
+
+	// The attempt is counted before the code is compared, with a
+	// compare-and-swap on the old value. Of concurrent guesses only one sees
+	// the count it read, so no more than AllowedAttempts codes are compared.
+	counted, err := h.queries.UpdateVerificationValue(ctx, repository.UpdateVerificationValueParams{
+		NewValue:   otpValue(otp, attempts+1),
+		Identifier: identifier,
+		OldValue:   verification.Value,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to count otp attempt", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to sign in")
+		return
+	}
+	if counted == 0 || subtle.ConstantTimeCompare([]byte(otp), []byte(strings.TrimSpace(req.OTP))) != 1 {
+		var userID *uuid.UUID
+		if user, err := h.queries.GetUserByEmail(ctx, req.Email); err == nil {
+			userID = &user.ID
+		}
+		if err := h.sessions.signInFailed(r, userID, req.Email, "invalid_otp"); err != nil {
+			logging.FromContext(ctx).Error("failed to audit sign-in", "error", err)
+		}
+		respondError(w, r, http.StatusBadRequest, "INVALID_OTP", "Invalid OTP")
+		return
+	}
+
+	// Consuming the code makes a concurrent request with the same code fail
+	if _, err := h.queries.ConsumeVerification(ctx, identifier); err != nil {
+		respondError(w, r, http.StatusBadRequest, "OTP_EXPIRED", "OTP expired")
+		return
+	}
+
+	user, _, err := h.findOrCreateUser(ctx, req.Email, "", h.emailOTP.DisableSignUp)
+	if errors.Is(err, ErrSignUpDisabled) {
+		respondError(w, r, http.StatusBadRequest, "USER_NOT_FOUND", "User not found")
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to create user", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to sign in")
+		return
+	}
+
+	sess, err := h.sessions.create(ctx, w, r, user.ID)
+	if errors.Is(err, ErrUserBanned) {
+		respondBanned(w, r)
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to create session", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to sign in")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"user":    user,
+		"session": sess,
+	})
+}
+
+// findOrCreateUser returns the user with the email, creating one unless sign
+// up is disabled. Receiving the email proves the address, so it is marked
+// verified.
+func (h *PasswordlessHandler) findOrCreateUser(ctx context.Context, address, name string, disableSignUp bool) (repository.User, bool, error) {
+	user, err := h.queries.GetUserByEmail(ctx, address)
+	switch {
+	case err == nil:
+		if !user.EmailVerified {
+			user, err = h.queries.UpdateUser(ctx, repository.UpdateUserParams{
+				ID:            user.ID,
+				Name:          user.Name,
+				Email:         user.Email,
+				EmailVerified: true,
+				Image:         user.Image,
+			})
+		}
+		return user, false, err
+
+	case errors.Is(err, pgx.ErrNoRows):
+		if disableSignUp {
+			return repository.User{}, false, ErrSignUpDisabled
+		}
+		user, err = h.queries.CreateUser(ctx, repository.CreateUserParams{
+			Name:          name,
+			Email:         address,
+			EmailVerified: true,
+		})
+		return user, err == nil, err
+
+	default:
+		return repository.User{}, false, err
+	}
+}
+
+// generateOTP returns n random decimal digits
+func generateOTP(n int) (string, error) {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+	v, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", n, v), nil
+}
+
+// otpValue encodes a code and its failed attempts as "<otp>:<attempts>", the
+// verification value format of the better-auth emailOTP plugin
+func otpValue(otp string, attempts int) string {
+	return otp + ":" + strconv.Itoa(attempts)
+}
+
+func parseOTPValue(value string) (string, int, bool) {
+	otp, count, found := strings.Cut(value, ":")
+	if !found {
+		return "", 0, false
+	}
+	attempts, err := strconv.Atoi(count)
+	if err != nil {
+		return "", 0, false
+	}
+	return otp, attempts, true
+}
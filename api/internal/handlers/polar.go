@@ -7,15 +7,17 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"io"
-	"log/slog"
 	"math"
 	"net/http"
 	"strings"
 	"time"
 
+	"budhapp.com/internal/audit"
 	"budhapp.com/internal/config"
+	"budhapp.com/internal/logging"
+	"budhapp.com/internal/metrics"
 	"budhapp.com/internal/repository"
+	"budhapp.com/internal/validate"
 	"github.com/google/uuid"
 )
 
@@ -83,7 +85,7 @@ const (
 // WebhookEvent represents the base webhook event structure from Polar
 // Following Standard Webhooks specification
 type WebhookEvent struct {
-	Type      string          `json:"type"`
+	Type      string          `json:"type" validate:"required"`
 	Timestamp time.Time       `json:"timestamp"`
 	Data      json.RawMessage `json:"data"`
 }
@@ -230,62 +232,70 @@ const (
 
 	// Signature tolerance: 5 minutes (Standard Webhooks recommends this)
 	signatureToleranceSecs = 300
+
+	// unknownWebhookEvent labels the metrics of requests rejected before
+	// their event type is known
+	unknownWebhookEvent = "unknown"
 )
 
 type PolarHandler struct {
-	logger        *slog.Logger
 	queries       *repository.Queries
 	webhookSecret string
 }
 
-func NewPolarHandler(queries *repository.Queries, logger *slog.Logger, cfg config.PolarConfig) *PolarHandler {
+func NewPolarHandler(queries *repository.Queries, cfg config.PolarConfig) *PolarHandler {
 	return &PolarHandler{
 		queries:       queries,
-		logger:        logger,
 		webhookSecret: cfg.WebhookSecret,
 	}
 }
 
 // Webhook handles incoming Polar webhook events
 func (h *PolarHandler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
-	h.logger.Debug("Polar webhook received")
+	logging.FromContext(r.Context()).Debug("Polar webhook received")
 
-	// Read the raw body for signature verification
-	body, err := io.ReadAll(r.Body)
+	// Read the raw body for signature verification, up to the route limit
+	body, err := validate.ReadBody(w, r)
 	if err != nil {
-		h.logger.Error("failed to read request body", "error", err)
-		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "Failed to read request body")
+		logging.FromContext(r.Context()).Error("failed to read request body", "error", err)
+		metrics.WebhookEvents.Inc("polar", unknownWebhookEvent, "invalid_request")
+		respondErr(w, r, err)
 		return
 	}
 
 	// Verify webhook signature if secret is configured
 	if h.webhookSecret != "" {
 		if err := h.verifySignature(r, body); err != nil {
-			h.logger.Warn("webhook signature verification failed", "error", err)
-			respondError(w, http.StatusUnauthorized, "INVALID_SIGNATURE", "Webhook signature verification failed")
+			logging.FromContext(r.Context()).Warn("webhook signature verification failed", "error", err)
+			metrics.WebhookEvents.Inc("polar", unknownWebhookEvent, "invalid_signature")
+			respondError(w, r, http.StatusUnauthorized, "INVALID_SIGNATURE", "Webhook signature verification failed")
 			return
 		}
 	}
 
-	// Parse the webhook event
-	var event WebhookEvent
-	if err := json.Unmarshal(body, &event); err != nil {
-		h.logger.Error("failed to parse webhook event", "error", err)
-		respondError(w, http.StatusBadRequest, "INVALID_PAYLOAD", "Failed to parse webhook payload")
+	// Parse the webhook event. Polar adds fields over time, so unknown ones
+	// are accepted.
+	event, err := validate.Unmarshal[WebhookEvent](body, validate.AllowUnknownFields())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to parse webhook event", "error", err)
+		metrics.WebhookEvents.Inc("polar", unknownWebhookEvent, "invalid_payload")
+		respondErr(w, r, err)
 		return
 	}
 
-	h.logger.Info("polar webhook event received", "type", event.Type, "timestamp", event.Timestamp)
+	logging.FromContext(r.Context()).Info("polar webhook event received", "type", event.Type, "timestamp", event.Timestamp)
 
 	// Handle the event based on type
 	if err := h.handleEvent(r.Context(), event); err != nil {
-		h.logger.Error("failed to handle webhook event", "type", event.Type, "error", err)
+		logging.FromContext(r.Context()).Error("failed to handle webhook event", "type", event.Type, "error", err)
+		metrics.WebhookEvents.Inc("polar", event.Type, "failed")
 		// Return 200 OK to prevent retries for business logic errors
 		// Polar will retry on 4xx/5xx errors
 		w.WriteHeader(http.StatusOK)
 		return
 	}
 
+	metrics.WebhookEvents.Inc("polar", event.Type, "processed")
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -390,25 +400,29 @@ func (h *PolarHandler) handleEvent(ctx context.Context, event WebhookEvent) erro
 				Data:   event.Data,
 			},
 		)
-		h.logger.Debug("unhandled webhook event type", "type", event.Type)
+		logging.FromContext(ctx).Debug("unhandled webhook event type", "type", event.Type)
 		return nil
 	}
 }
 
 // handleSubscriptionCreated handles new subscription creation
-func (h *PolarHandler) handleSubscriptionCreated(_ context.Context, data json.RawMessage) error {
+func (h *PolarHandler) handleSubscriptionCreated(ctx context.Context, data json.RawMessage) error {
 	var subscription PolarSubscription
 	if err := json.Unmarshal(data, &subscription); err != nil {
 		return fmt.Errorf("failed to parse subscription data: %w", err)
 	}
 
-	h.logger.Info("subscription created",
+	logging.FromContext(ctx).Info("subscription created",
 		"subscription_id", subscription.ID,
 		"customer_id", subscription.CustomerID,
 		"product_id", subscription.ProductID,
 		"status", subscription.Status,
 	)
 
+	if err := h.auditSubscription(ctx, audit.ActionSubscriptionCreate, subscription); err != nil {
+		return fmt.Errorf("failed to audit subscription: %w", err)
+	}
+
 	// TODO: Implement subscription creation logic
 	// - Look up user by customer external_id
 	// - Create or update subscription record in database
@@ -417,18 +431,22 @@ func (h *PolarHandler) handleSubscriptionCreated(_ context.Context, data json.Ra
 }
 
 // handleSubscriptionUpdated handles subscription updates (active, canceled, etc.)
-func (h *PolarHandler) handleSubscriptionUpdated(_ context.Context, data json.RawMessage) error {
+func (h *PolarHandler) handleSubscriptionUpdated(ctx context.Context, data json.RawMessage) error {
 	var subscription PolarSubscription
 	if err := json.Unmarshal(data, &subscription); err != nil {
 		return fmt.Errorf("failed to parse subscription data: %w", err)
 	}
 
-	h.logger.Info("subscription updated",
+	logging.FromContext(ctx).Info("subscription updated",
 		"subscription_id", subscription.ID,
 		"status", subscription.Status,
 		"cancel_at_period_end", subscription.CancelAtPeriodEnd,
 	)
 
+	if err := h.auditSubscription(ctx, audit.ActionSubscriptionUpdate, subscription); err != nil {
+		return fmt.Errorf("failed to audit subscription: %w", err)
+	}
+
 	// TODO: Implement subscription update logic
 	// - Update subscription status in database
 	// - Handle tier changes, cancellations, etc.
@@ -436,14 +454,30 @@ func (h *PolarHandler) handleSubscriptionUpdated(_ context.Context, data json.Ra
 	return nil
 }
 
+// auditSubscription records a subscription change reported by Polar. There
+// is no actor: the change comes from the payment provider.
+func (h *PolarHandler) auditSubscription(ctx context.Context, action string, subscription PolarSubscription) error {
+	return audit.Record(ctx, h.queries, nil, audit.Entry{
+		Action:     action,
+		TargetType: audit.TargetSubscription,
+		TargetID:   subscription.ID,
+		Diff: map[string]any{
+			"customerId":        subscription.CustomerID,
+			"productId":         subscription.ProductID,
+			"status":            subscription.Status,
+			"cancelAtPeriodEnd": subscription.CancelAtPeriodEnd,
+		},
+	})
+}
+
 // handleOrderCreated handles new order creation
-func (h *PolarHandler) handleOrderCreated(_ context.Context, data json.RawMessage) error {
+func (h *PolarHandler) handleOrderCreated(ctx context.Context, data json.RawMessage) error {
 	var order PolarOrder
 	if err := json.Unmarshal(data, &order); err != nil {
 		return fmt.Errorf("failed to parse order data: %w", err)
 	}
 
-	h.logger.Info("order created",
+	logging.FromContext(ctx).Info("order created",
 		"order_id", order.ID,
 		"customer_id", order.CustomerID,
 		"billing_reason", order.BillingReason,
@@ -458,13 +492,13 @@ func (h *PolarHandler) handleOrderCreated(_ context.Context, data json.RawMessag
 }
 
 // handleOrderUpdated handles order updates (refunds, etc.)
-func (h *PolarHandler) handleOrderUpdated(_ context.Context, data json.RawMessage) error {
+func (h *PolarHandler) handleOrderUpdated(ctx context.Context, data json.RawMessage) error {
 	var order PolarOrder
 	if err := json.Unmarshal(data, &order); err != nil {
 		return fmt.Errorf("failed to parse order data: %w", err)
 	}
 
-	h.logger.Info("order updated",
+	logging.FromContext(ctx).Info("order updated",
 		"order_id", order.ID,
 		"billing_reason", order.BillingReason,
 	)
@@ -476,13 +510,13 @@ func (h *PolarHandler) handleOrderUpdated(_ context.Context, data json.RawMessag
 }
 
 // handleCustomerUpdated handles customer creation and updates
-func (h *PolarHandler) handleCustomerUpdated(_ context.Context, data json.RawMessage) error {
+func (h *PolarHandler) handleCustomerUpdated(ctx context.Context, data json.RawMessage) error {
 	var customer PolarCustomer
 	if err := json.Unmarshal(data, &customer); err != nil {
 		return fmt.Errorf("failed to parse customer data: %w", err)
 	}
 
-	h.logger.Info("customer updated",
+	logging.FromContext(ctx).Info("customer updated",
 		"customer_id", customer.ID,
 		"external_id", customer.ExternalID,
 		"email", customer.Email,
@@ -495,13 +529,13 @@ func (h *PolarHandler) handleCustomerUpdated(_ context.Context, data json.RawMes
 }
 
 // handleCustomerDeleted handles customer deletion
-func (h *PolarHandler) handleCustomerDeleted(_ context.Context, data json.RawMessage) error {
+func (h *PolarHandler) handleCustomerDeleted(ctx context.Context, data json.RawMessage) error {
 	var customer PolarCustomer
 	if err := json.Unmarshal(data, &customer); err != nil {
 		return fmt.Errorf("failed to parse customer data: %w", err)
 	}
 
-	h.logger.Info("customer deleted",
+	logging.FromContext(ctx).Info("customer deleted",
 		"customer_id", customer.ID,
 		"external_id", customer.ExternalID,
 	)
@@ -512,13 +546,13 @@ func (h *PolarHandler) handleCustomerDeleted(_ context.Context, data json.RawMes
 }
 
 // handleCheckoutUpdated handles checkout session updates
-func (h *PolarHandler) handleCheckoutUpdated(_ context.Context, data json.RawMessage) error {
+func (h *PolarHandler) handleCheckoutUpdated(ctx context.Context, data json.RawMessage) error {
 	var checkout PolarCheckout
 	if err := json.Unmarshal(data, &checkout); err != nil {
 		return fmt.Errorf("failed to parse checkout data: %w", err)
 	}
 
-	h.logger.Info("checkout updated",
+	logging.FromContext(ctx).Info("checkout updated",
 		"checkout_id", checkout.ID,
 		"status", checkout.Status,
 		"customer_external_id", checkout.CustomerExternalID,
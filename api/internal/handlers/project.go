@@ -0,0 +1,448 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"budhapp.com/internal/audit"
+	"budhapp.com/internal/authz"
+	"budhapp.com/internal/logging"
+	"budhapp.com/internal/repository"
+	"budhapp.com/internal/session"
+	"budhapp.com/internal/validate"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ProjectHandler serves projects. Routes of a single project run behind the
+// server's project authorization middleware, which loads the project and the
+// caller's permissions into the request context; the organization project
+// list and create routes check the caller's membership themselves.
+type ProjectHandler struct {
+	queries *repository.Queries
+	pool    *pgxpool.Pool
+}
+
+func NewProjectHandler(queries *repository.Queries, pool *pgxpool.Pool) *ProjectHandler {
+	return &ProjectHandler{
+		queries: queries,
+		pool:    pool,
+	}
+}
+
+// authorized returns the project and permissions stored by the middleware
+func (h *ProjectHandler) authorized(w http.ResponseWriter, r *http.Request) (*repository.Project, authz.PermissionSet, bool) {
+	return authorizedProject(w, r)
+}
+
+// authorizedProject returns the project and permissions stored by the
+// project authorization middleware, writing 500 when the route lacks it
+func authorizedProject(w http.ResponseWriter, r *http.Request) (*repository.Project, authz.PermissionSet, bool) {
+	project, ok := session.ProjectFromContext(r.Context())
+	permissions, hasPermissions := session.PermissionsFromContext(r.Context())
+	if !ok || !hasPermissions {
+		logging.FromContext(r.Context()).Error("project route is missing the authorization middleware", "uri", r.URL.RequestURI())
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load project")
+		return nil, authz.PermissionSet{}, false
+	}
+	return project, permissions, true
+}
+
+// Get returns the project with the caller's role and permissions on it.
+// Share links of a single document don't open the project.
+func (h *ProjectHandler) Get(w http.ResponseWriter, r *http.Request) {
+	project, permissions, ok := h.authorized(w, r)
+	if !ok {
+		return
+	}
+	if shareScope(r) != nil {
+		respondError(w, r, http.StatusForbidden, "SHARE_LINK_LIMITED_TO_DOCUMENT", "This share link only opens one document")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"project":     project,
+		"permissions": permissions,
+	})
+}
+
+type updateProjectRequest struct {
+	Name        *string `json:"name" validate:"max=255"`
+	Slug        *string `json:"slug" validate:"slug,max=255"`
+	Description *string `json:"description"`
+}
+
+// Update changes the project's name, slug or description
+func (h *ProjectHandler) Update(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	project, _, ok := h.authorized(w, r)
+	if !ok {
+		return
+	}
+
+	req, err := validate.Decode[updateProjectRequest](w, r)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+
+	params := repository.UpdateProjectParams{
+		ID:          project.ID,
+		Name:        project.Name,
+		Slug:        project.Slug,
+		Description: project.Description,
+	}
+	if req.Name != nil && strings.TrimSpace(*req.Name) != "" {
+		params.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Slug != nil {
+		params.Slug = *req.Slug
+	}
+	if req.Description != nil {
+		params.Description = req.Description
+	}
+
+	updated, err := h.queries.UpdateProject(ctx, params)
+	if isUniqueViolation(err) {
+		respondSlugTaken(w, r)
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to update project", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update project")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, updated)
+}
+
+// respondSlugTaken writes the error of a slug the project's owner already
+// uses for another project
+func respondSlugTaken(w http.ResponseWriter, r *http.Request) {
+	respondError(w, r, http.StatusBadRequest, "PROJECT_SLUG_ALREADY_TAKEN", "Project slug already taken")
+}
+
+// Delete removes the project
+func (h *ProjectHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	project, _, ok := h.authorized(w, r)
+	if !ok {
+		return
+	}
+
+	var actorID *uuid.UUID
+	if user, ok := session.UserFromContext(r.Context()); ok {
+		if id, err := uuid.Parse(user.ID); err == nil {
+			actorID = &id
+		}
+	}
+
+	err := withTx(r.Context(), h.pool, h.queries, func(q *repository.Queries) error {
+		deleted, err := q.DeleteProject(r.Context(), project.ID)
+		if err != nil {
+			return err
+		}
+		changes, err := audit.Changes(deleted, nil)
+		if err != nil {
+			return err
+		}
+		return audit.Record(r.Context(), q, r, audit.Entry{
+			Action:     audit.ActionProjectDelete,
+			ActorID:    actorID,
+			TargetType: audit.TargetProject,
+			TargetID:   deleted.ID.String(),
+			Diff:       changes,
+		})
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to delete project", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete project")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]bool{"status": true})
+}
+
+// ListMembers returns the users granted a role on the project itself.
+// Organization members are listed by the organization endpoints.
+func (h *ProjectHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	project, _, ok := h.authorized(w, r)
+	if !ok {
+		return
+	}
+
+	members, err := h.queries.ListProjectMembersByProjectId(r.Context(), project.ID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to list project members", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list project members")
+		return
+	}
+	if members == nil {
+		members = []repository.ListProjectMembersByProjectIdRow{}
+	}
+
+	respondJSON(w, http.StatusOK, members)
+}
+
+type setProjectMemberRequest struct {
+	Email string     `json:"email" validate:"required,email"`
+	Role  authz.Role `json:"role" validate:"required,oneof=owner admin editor commenter viewer"`
+}
+
+// SetMember grants a user a role on the project, replacing any earlier grant.
+// Only organization projects can be shared, and nobody can grant a role above
+// their own.
+func (h *ProjectHandler) SetMember(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	project, permissions, ok := h.authorized(w, r)
+	if !ok {
+		return
+	}
+
+	req, err := validate.Decode[setProjectMemberRequest](w, r)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	if project.OrganizationId == nil {
+		respondError(w, r, http.StatusBadRequest, "PROJECT_NOT_SHAREABLE", "Only organization projects can be shared")
+		return
+	}
+	if !permissions.Role.AtLeast(req.Role) {
+		respondError(w, r, http.StatusForbidden, "YOU_ARE_NOT_ALLOWED_TO_GRANT_THIS_ROLE", "You are not allowed to grant this role")
+		return
+	}
+
+	user, err := h.queries.GetUserByEmail(ctx, strings.ToLower(req.Email))
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondError(w, r, http.StatusBadRequest, "USER_NOT_FOUND", "User not found")
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to load user", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to share project")
+		return
+	}
+
+	member, err := h.queries.UpsertProjectMember(ctx, repository.UpsertProjectMemberParams{
+		ProjectId: project.ID,
+		UserId:    user.ID,
+		Role:      string(req.Role),
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to store project member", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to share project")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, member)
+}
+
+// RemoveMember revokes the project role granted to the {userId} user. Roles
+// that come from organization membership are unaffected.
+func (h *ProjectHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	project, _, ok := h.authorized(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := uuid.Parse(r.PathValue("userId"))
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "INVALID_USER_ID", "Invalid user id")
+		return
+	}
+
+	deleted, err := h.queries.DeleteProjectMember(r.Context(), repository.DeleteProjectMemberParams{
+		ProjectId: project.ID,
+		UserId:    userID,
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to delete project member", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to remove project member")
+		return
+	}
+	if deleted == 0 {
+		respondError(w, r, http.StatusNotFound, "MEMBER_NOT_FOUND", "Member not found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]bool{"status": true})
+}
+
+// organizationMember loads the organization with the slug and the signed-in
+// user's membership of it, writing the error response when the user is not
+// a member
+func (h *ProjectHandler) organizationMember(w http.ResponseWriter, r *http.Request, slug string) (repository.Organization, repository.Member, bool) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return repository.Organization{}, repository.Member{}, false
+	}
+
+	org, err := h.queries.GetOrganizationBySlug(r.Context(), slug)
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondError(w, r, http.StatusNotFound, "ORGANIZATION_NOT_FOUND", "Organization not found")
+		return repository.Organization{}, repository.Member{}, false
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to load organization", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load organization")
+		return repository.Organization{}, repository.Member{}, false
+	}
+
+	member, err := h.queries.GetMemberByOrganizationIdAndUserId(r.Context(), repository.GetMemberByOrganizationIdAndUserIdParams{
+		OrganizationId: org.ID,
+		UserId:         userID,
+	})
+	// Non-members can't tell organizations apart from missing ones
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondError(w, r, http.StatusNotFound, "ORGANIZATION_NOT_FOUND", "Organization not found")
+		return repository.Organization{}, repository.Member{}, false
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to look up member", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load organization")
+		return repository.Organization{}, repository.Member{}, false
+	}
+
+	return org, member, true
+}
+
+// ListOrganizationProjects returns the projects of the organization to its
+// members
+func (h *ProjectHandler) ListOrganizationProjects(w http.ResponseWriter, r *http.Request) {
+	org, _, ok := h.organizationMember(w, r, r.PathValue("organization"))
+	if !ok {
+		return
+	}
+
+	projects, err := h.queries.ListProjectsByOrganizationID(r.Context(), &org.ID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to list projects", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list projects")
+		return
+	}
+	if projects == nil {
+		projects = []repository.Project{}
+	}
+
+	respondJSON(w, http.StatusOK, projects)
+}
+
+type createProjectRequest struct {
+	Name        string  `json:"name" validate:"required,max=255"`
+	Slug        string  `json:"slug" validate:"required,slug,max=255"`
+	Description *string `json:"description"`
+}
+
+// CreateOrganizationProject creates a project owned by the organization.
+// Only owners and admins of the organization may create projects.
+func (h *ProjectHandler) CreateOrganizationProject(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	org, member, ok := h.organizationMember(w, r, r.PathValue("organization"))
+	if !ok {
+		return
+	}
+	if !canManage(member.Role) {
+		respondError(w, r, http.StatusForbidden, "YOU_ARE_NOT_ALLOWED_TO_CREATE_A_PROJECT", "You are not allowed to create a project in this organization")
+		return
+	}
+
+	req, err := validate.Decode[createProjectRequest](w, r)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+
+	project, err := h.queries.CreateOrganizationProject(ctx, repository.CreateOrganizationProjectParams{
+		OrganizationId: &org.ID,
+		Name:           strings.TrimSpace(req.Name),
+		Slug:           req.Slug,
+		Description:    req.Description,
+	})
+	if isUniqueViolation(err) {
+		respondSlugTaken(w, r)
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to create project", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create project")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, project)
+}
+
+type transferProjectRequest struct {
+	OrganizationSlug string `json:"organizationSlug" validate:"required,slug"`
+}
+
+// Transfer moves a personal project into an organization the owner manages,
+// which is how projects created before organizations existed join a team.
+// The project keeps its slug, so it must be free in the organization.
+func (h *ProjectHandler) Transfer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	project, _, ok := h.authorized(w, r)
+	if !ok {
+		return
+	}
+	if project.OrganizationId != nil {
+		respondError(w, r, http.StatusBadRequest, "PROJECT_ALREADY_IN_ORGANIZATION", "Project already belongs to an organization")
+		return
+	}
+
+	req, err := validate.Decode[transferProjectRequest](w, r)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+
+	org, member, ok := h.organizationMember(w, r, req.OrganizationSlug)
+	if !ok {
+		return
+	}
+	if !canManage(member.Role) {
+		respondError(w, r, http.StatusForbidden, "YOU_ARE_NOT_ALLOWED_TO_CREATE_A_PROJECT", "You are not allowed to create a project in this organization")
+		return
+	}
+
+	actorID := member.UserId
+	var moved repository.Project
+	err = withTx(ctx, h.pool, h.queries, func(q *repository.Queries) error {
+		var err error
+		moved, err = q.MoveProjectToOrganization(ctx, repository.MoveProjectToOrganizationParams{
+			ID:             project.ID,
+			OrganizationId: &org.ID,
+		})
+		if err != nil {
+			return err
+		}
+		changes, err := audit.Changes(*project, moved)
+		if err != nil {
+			return err
+		}
+		return audit.Record(ctx, q, r, audit.Entry{
+			Action:     audit.ActionProjectTransfer,
+			ActorID:    &actorID,
+			TargetType: audit.TargetProject,
+			TargetID:   moved.ID.String(),
+			Diff:       changes,
+		})
+	})
+	if isUniqueViolation(err) {
+		respondSlugTaken(w, r)
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to transfer project", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to transfer project")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, moved)
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"budhapp.com/internal/authz"
+	"budhapp.com/internal/repository"
+	"budhapp.com/internal/session"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestOrganizationProjectsRequireUser(t *testing.T) {
+	h := &ProjectHandler{}
+	for name, handler := range map[string]http.HandlerFunc{
+		"list":   h.ListOrganizationProjects,
+		"create": h.CreateOrganizationProject,
+	} {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodPost, "/api/organizations/acme/projects", strings.NewReader(`{}`)))
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s without a user = %d, want 401", name, rec.Code)
+		}
+	}
+}
+
+func TestTransfer(t *testing.T) {
+	userID := uuid.New()
+	orgID := uuid.New()
+
+	tests := []struct {
+		name     string
+		project  repository.Project
+		body     string
+		wantCode string
+	}{
+		{"organization project", repository.Project{OrganizationId: &orgID}, `{"organizationSlug":"acme"}`, "PROJECT_ALREADY_IN_ORGANIZATION"},
+		{"missing organization", repository.Project{UserId: &userID}, `{}`, "VALIDATION_ERROR"},
+		{"invalid organization", repository.Project{UserId: &userID}, `{"organizationSlug":"Acme Inc"}`, "VALIDATION_ERROR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), session.ProjectContextKey, &tt.project)
+			ctx = context.WithValue(ctx, session.PermissionsContextKey, authz.Permissions(authz.RoleOwner))
+			req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/api/projects/launch/transfer", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+
+			(&ProjectHandler{}).Transfer(rec, req)
+			if rec.Code != http.StatusBadRequest || !bytes.Contains(rec.Body.Bytes(), []byte(tt.wantCode)) {
+				t.Errorf("Transfer() = %d %s, want 400 %s", rec.Code, rec.Body, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestIsUniqueViolation(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"unique violation", &pgconn.PgError{Code: "23505", ConstraintName: "idx_project_organization_slug"}, true},
+		{"wrapped", fmt.Errorf("transfer: %w", &pgconn.PgError{Code: "23505"}), true},
+		{"foreign key violation", &pgconn.PgError{Code: "23503"}, false},
+		{"no rows", pgx.ErrNoRows, false},
+		{"nil", nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := isUniqueViolation(tt.err); got != tt.want {
+			t.Errorf("isUniqueViolation(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// isTrustedURL accepts relative paths and absolute URLs on the base URL or a
+// trusted origin, so callback URLs cannot be used as an open redirect
+func isTrustedURL(raw, baseURL string, trustedOrigins []string) bool {
+	if strings.HasPrefix(raw, "/") {
+		return !strings.HasPrefix(raw, "//") && !strings.HasPrefix(raw, "/\\")
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return false
+	}
+	origin := u.Scheme + "://" + u.Host
+
+	for _, trusted := range append([]string{baseURL}, trustedOrigins...) {
+		if t, err := url.Parse(trusted); err == nil && t.Scheme+"://"+t.Host == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// redirectError sends the user to target with the error code in the query string
+func redirectError(w http.ResponseWriter, r *http.Request, target, code string) {
+	if target == "" {
+		target = "/"
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		u = &url.URL{Path: "/"}
+	}
+	q := u.Query()
+	q.Set("error", code)
+	u.RawQuery = q.Encode()
+
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
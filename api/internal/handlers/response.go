@@ -3,6 +3,9 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+
+	"budhapp.com/internal/apierror"
+	"budhapp.com/internal/validate"
 )
 
 // respondJSON writes a JSON response with the given status code and data
@@ -12,12 +15,21 @@ func respondJSON(w http.ResponseWriter, status int, data any) {
 	json.NewEncoder(w).Encode(data) //nolint:errcheck // Best-effort encoding
 }
 
-// respondError writes a JSON error response in better-auth format
-func respondError(w http.ResponseWriter, status int, code, message string) {
-	respondJSON(w, status, map[string]any{
-		"error": map[string]string{
-			"code":    code,
-			"message": message,
-		},
-	})
+// respondError writes an error response in better-auth format, or as
+// problem details when the client asks for them
+func respondError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	apierror.Write(w, r, apierror.New(status, code, message))
+}
+
+// respondErr writes an apierror.Error; other errors are answered as
+// internal errors
+func respondErr(w http.ResponseWriter, r *http.Request, err error) {
+	apierror.Write(w, r, err)
+}
+
+// decodeAuthRequest decodes and validates the body of a better-auth
+// endpoint. better-auth clients send options of features the API doesn't
+// implement, so unknown fields are ignored rather than rejected.
+func decodeAuthRequest[T any](w http.ResponseWriter, r *http.Request, opts ...validate.Option) (T, error) {
+	return validate.Decode[T](w, r, append(opts, validate.AllowUnknownFields())...)
 }
@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"budhapp.com/internal/audit"
+	"budhapp.com/internal/logging"
+	"budhapp.com/internal/metrics"
+	"budhapp.com/internal/ratelimit"
+	"budhapp.com/internal/repository"
+	"budhapp.com/internal/session"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrNoSession  = errors.New("no valid session")
+	ErrUserBanned = errors.New("user is banned")
+)
+
+// errorBanned is the error redirected to when a banned user signs in
+const errorBanned = "banned"
+
+// respondBanned rejects a sign-in by a banned user the way better-auth does
+func respondBanned(w http.ResponseWriter, r *http.Request) {
+	respondError(w, r, http.StatusForbidden, "BANNED_USER", "You have been banned from this application")
+}
+
+// sessionExpiration matches the better-auth default session lifetime
+const sessionExpiration = 7 * 24 * time.Hour
+
+// sessionStore resolves better-auth sessions from the signed session cookie
+type sessionStore struct {
+	queries *repository.Queries
+	pool    *pgxpool.Pool
+	secret  string
+	// secure marks cookies Secure and adds the __Secure- prefix, as better-auth
+	// does when its base URL is served over HTTPS
+	secure bool
+	// lockout limits sign-in attempts by email; nil disables it
+	lockout *ratelimit.Lockout
+}
+
+func newSessionStore(queries *repository.Queries, pool *pgxpool.Pool, secret, baseURL string, lockout *ratelimit.Lockout) *sessionStore {
+	return &sessionStore{
+		queries: queries,
+		pool:    pool,
+		secret:  secret,
+		secure:  strings.HasPrefix(baseURL, "https://"),
+		lockout: lockout,
+	}
+}
+
+// create starts a new session for the user and sets the session cookie.
+// Banned users get ErrUserBanned; a ban that has expired is lifted.
+func (s *sessionStore) create(ctx context.Context, w http.ResponseWriter, r *http.Request, userID uuid.UUID) (repository.Session, error) {
+	user, err := s.queries.GetUserByID(ctx, userID)
+	if err != nil {
+		return repository.Session{}, err
+	}
+	if user.Banned {
+		if user.BanExpires == nil || user.BanExpires.After(time.Now()) {
+			metrics.SignIns.Inc(r.Pattern, "user_banned")
+			return repository.Session{}, ErrUserBanned
+		}
+		if _, err := s.queries.UnbanUser(ctx, userID); err != nil {
+			return repository.Session{}, err
+		}
+	}
+
+	var ipAddress *string
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		ipAddress = &host
+	}
+	var userAgent *string
+	if ua := r.UserAgent(); ua != "" {
+		userAgent = &ua
+	}
+
+	var sess repository.Session
+	err = withTx(ctx, s.pool, s.queries, func(q *repository.Queries) error {
+		var err error
+		sess, err = q.CreateSession(ctx, repository.CreateSessionParams{
+			Token:     generateToken(32),
+			UserId:    userID,
+			ExpiresAt: time.Now().Add(sessionExpiration),
+			IpAddress: ipAddress,
+			UserAgent: userAgent,
+		})
+		if err != nil {
+			return err
+		}
+		return audit.Record(ctx, q, r, audit.Entry{
+			Action:     audit.ActionSignIn,
+			ActorID:    &userID,
+			TargetType: audit.TargetSession,
+			TargetID:   sess.ID.String(),
+			Diff:       map[string]string{"path": r.URL.Path},
+		})
+	})
+	if err != nil {
+		return repository.Session{}, err
+	}
+
+	s.setCookie(w, session.SessionCookieName, sess.Token, sess.ExpiresAt)
+	if s.lockout != nil {
+		s.lockout.Succeed(ctx, user.Email)
+	}
+	metrics.SignIns.Inc(r.Pattern, "success")
+
+	return sess, nil
+}
+
+// signInLocked takes a sign-in attempt for the email and returns how long
+// the account must wait, zero when the attempt may proceed. Unknown emails
+// are limited the same way so the response doesn't reveal them.
+func (s *sessionStore) signInLocked(r *http.Request, email string) time.Duration {
+	if s.lockout == nil {
+		return 0
+	}
+	retryAfter := s.lockout.Check(r.Context(), strings.ToLower(email), time.Now())
+	if retryAfter > 0 {
+		metrics.SignIns.Inc(r.Pattern, "locked_out")
+	}
+	return retryAfter
+}
+
+// signInFailed records a rejected sign-in and counts it towards the lockout
+// of the email. userID is nil when the email is unknown.
+func (s *sessionStore) signInFailed(r *http.Request, userID *uuid.UUID, email, reason string) error {
+	metrics.SignIns.Inc(r.Pattern, reason)
+	if s.lockout != nil {
+		s.lockout.Fail(r.Context(), strings.ToLower(email), time.Now())
+	}
+
+	entry := audit.Entry{
+		Action:  audit.ActionSignInFailed,
+		ActorID: userID,
+		Diff:    map[string]string{"email": email, "reason": reason, "path": r.URL.Path},
+	}
+	if userID != nil {
+		entry.TargetType = audit.TargetUser
+		entry.TargetID = userID.String()
+	}
+	return audit.Record(r.Context(), s.queries, r, entry)
+}
+
+// confirmPassword checks the password a signed-in user gives to confirm a
+// sensitive change. Wrong passwords count towards the lockout like failed
+// sign-ins, so a stolen session can't be used to guess the password. It
+// writes the error response and returns false on failure.
+func (s *sessionStore) confirmPassword(w http.ResponseWriter, r *http.Request, user repository.User, plain string) bool {
+	ctx := r.Context()
+
+	if wait := s.signInLocked(r, user.Email); wait > 0 {
+		ratelimit.RespondTooManyRequests(w, r, wait)
+		return false
+	}
+
+	ok, err := checkPassword(ctx, s.queries, user.ID, plain)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to verify password", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to verify password")
+		return false
+	}
+	if !ok {
+		if err := s.signInFailed(r, &user.ID, user.Email, "invalid_password"); err != nil {
+			logging.FromContext(ctx).Error("failed to audit password check", "error", err)
+		}
+		respondError(w, r, http.StatusBadRequest, "INVALID_PASSWORD", "Invalid password")
+		return false
+	}
+	return true
+}
+
+// setCookie sets a signed, HttpOnly cookie the way better-auth names them
+func (s *sessionStore) setCookie(w http.ResponseWriter, name, value string, expires time.Time) {
+	if s.secure {
+		name = session.SecureCookiePrefix + name
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    session.SignCookieValue(value, s.secret),
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   s.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// clearCookie expires a cookie set by setCookie
+func (s *sessionStore) clearCookie(w http.ResponseWriter, name string) {
+	if s.secure {
+		name = session.SecureCookiePrefix + name
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   s.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// current returns the unexpired session and user for the request cookie
+func (s *sessionStore) current(r *http.Request) (repository.Session, repository.User, error) {
+	token, ok := session.TokenFromRequest(r, s.secret)
+	if !ok {
+		return repository.Session{}, repository.User{}, ErrNoSession
+	}
+
+	return s.lookup(r.Context(), token)
+}
+
+func (s *sessionStore) lookup(ctx context.Context, token string) (repository.Session, repository.User, error) {
+	sess, err := s.queries.GetSessionByToken(ctx, token)
+	if err != nil {
+		return repository.Session{}, repository.User{}, ErrNoSession
+	}
+	if sess.ExpiresAt.Before(time.Now()) {
+		return repository.Session{}, repository.User{}, ErrNoSession
+	}
+
+	user, err := s.queries.GetUserByID(ctx, sess.UserId)
+	if err != nil {
+		return repository.Session{}, repository.User{}, err
+	}
+	logging.AddAttrs(ctx, "userId", user.ID.String())
+
+	return sess, user, nil
+}
+
+const tokenAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// generateToken returns a random alphanumeric string, like better-auth's generateId
+func generateToken(n int) string {
+	// Bytes above the largest multiple of the alphabet size are rejected to
+	// keep the distribution uniform
+	limit := 256 - 256%len(tokenAlphabet)
+	out := make([]byte, 0, n)
+	buf := make([]byte, n)
+	for len(out) < n {
+		rand.Read(buf) //nolint:errcheck // crypto/rand.Read never fails
+		for _, c := range buf {
+			if int(c) < limit && len(out) < n {
+				out = append(out, tokenAlphabet[int(c)%len(tokenAlphabet)])
+			}
+		}
+	}
+	return string(out)
+}
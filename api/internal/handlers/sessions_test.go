@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"budhapp.com/internal/config"
+	"budhapp.com/internal/ratelimit"
+	"budhapp.com/internal/repository"
+	"github.com/google/uuid"
+)
+
+func TestConfirmPasswordLockedOut(t *testing.T) {
+	lockout := ratelimit.NewLockout(ratelimit.NewMemoryStore(), slog.New(slog.NewTextHandler(io.Discard, nil)), config.RateLimitConfig{
+		Lockout: config.LockoutConfig{
+			Threshold:   2,
+			Duration:    config.Duration(time.Minute),
+			MaxDuration: config.Duration(time.Hour),
+		},
+	})
+	user := repository.User{ID: uuid.New(), Email: "user@example.com"}
+	for range 2 {
+		lockout.Fail(context.Background(), user.Email, time.Now())
+	}
+
+	// The lockout is checked before the password, which needs the database
+	s := &sessionStore{lockout: lockout}
+	rec := httptest.NewRecorder()
+	if s.confirmPassword(rec, httptest.NewRequest(http.MethodPost, "/api/auth/two-factor/disable", nil), user, "guess") {
+		t.Fatal("confirmPassword() = true for a locked out account")
+	}
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("confirmPassword() = %d, want 429 with Retry-After", rec.Code)
+	}
+}
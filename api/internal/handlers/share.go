@@ -0,0 +1,388 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"budhapp.com/internal/authz"
+	"budhapp.com/internal/jwks"
+	"budhapp.com/internal/logging"
+	"budhapp.com/internal/password"
+	"budhapp.com/internal/ratelimit"
+	"budhapp.com/internal/repository"
+	"budhapp.com/internal/session"
+	"budhapp.com/internal/validate"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Event types recorded for accesses through share links
+const (
+	EventShareLinkResolved = "share_link.resolved"
+	EventShareLinkAccessed = "share_link.accessed"
+)
+
+// ShareLinkClaim is the JWT claim that marks an anonymous share session
+const ShareLinkClaim = "shareLinkId"
+
+// ShareAudience is the JWT audience of share sessions, so their tokens are
+// only accepted by the /api/shared routes
+const ShareAudience = "share"
+
+// isShareRole reports whether anonymous reviewers may hold the role
+func isShareRole(role authz.Role) bool {
+	return role == authz.RoleViewer || role == authz.RoleCommenter
+}
+
+// ShareAccess is the data of a share link event. It is recorded for the user
+// who created the link, since anonymous reviewers have no account.
+type ShareAccess struct {
+	ShareLinkID uuid.UUID  `json:"shareLinkId"`
+	ProjectID   uuid.UUID  `json:"projectId"`
+	DocumentID  *uuid.UUID `json:"documentId,omitempty"`
+	Method      string     `json:"method,omitempty"`
+	Path        string     `json:"path,omitempty"`
+	IPAddress   string     `json:"ipAddress,omitempty"`
+	UserAgent   string     `json:"userAgent,omitempty"`
+}
+
+// RecordShareAccess stores an event for an access through link
+func RecordShareAccess(r *http.Request, queries *repository.Queries, link *repository.ShareLink, eventType string) error {
+	access := ShareAccess{
+		ShareLinkID: link.ID,
+		ProjectID:   link.ProjectId,
+		DocumentID:  link.DocumentId,
+		Method:      r.Method,
+		Path:        r.URL.Path,
+		UserAgent:   r.UserAgent(),
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		access.IPAddress = host
+	}
+
+	data, err := json.Marshal(access)
+	if err != nil {
+		return err
+	}
+
+	_, err = queries.CreateEvent(r.Context(), repository.CreateEventParams{
+		UserId: link.CreatedBy,
+		Type:   eventType,
+		Data:   data,
+	})
+	return err
+}
+
+type ShareLinkHandler struct {
+	queries *repository.Queries
+	keys    *jwks.Manager
+	baseURL string
+	// lockout limits password guesses per link, so they can't be spread
+	// over many addresses
+	lockout *ratelimit.Lockout
+}
+
+func NewShareLinkHandler(queries *repository.Queries, keys *jwks.Manager, baseURL string, lockout *ratelimit.Lockout) *ShareLinkHandler {
+	return &ShareLinkHandler{
+		queries: queries,
+		keys:    keys,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		lockout: lockout,
+	}
+}
+
+// shareLinkResponse is a share link as shown to the project's owners. The
+// password hash stays on the server.
+type shareLinkResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	ProjectID   uuid.UUID  `json:"projectId"`
+	DocumentID  *uuid.UUID `json:"documentId"`
+	Token       string     `json:"token"`
+	URL         string     `json:"url"`
+	Role        string     `json:"role"`
+	HasPassword bool       `json:"hasPassword"`
+	ExpiresAt   *time.Time `json:"expiresAt"`
+	MaxUses     *int32     `json:"maxUses"`
+	UseCount    int32      `json:"useCount"`
+	CreatedBy   uuid.UUID  `json:"createdBy"`
+	RevokedAt   *time.Time `json:"revokedAt"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+func (h *ShareLinkHandler) response(link repository.ShareLink) shareLinkResponse {
+	return shareLinkResponse{
+		ID:          link.ID,
+		ProjectID:   link.ProjectId,
+		DocumentID:  link.DocumentId,
+		Token:       link.Token,
+		URL:         h.baseURL + "/share/" + link.Token,
+		Role:        link.Role,
+		HasPassword: link.PasswordHash != nil,
+		ExpiresAt:   link.ExpiresAt,
+		MaxUses:     link.MaxUses,
+		UseCount:    link.UseCount,
+		CreatedBy:   link.CreatedBy,
+		RevokedAt:   link.RevokedAt,
+		CreatedAt:   link.CreatedAt,
+	}
+}
+
+type createShareLinkRequest struct {
+	Role       authz.Role `json:"role"`
+	DocumentID *uuid.UUID `json:"documentId"`
+	Password   string     `json:"password" validate:"password"`
+	// ExpiresIn is the lifetime of the link in seconds; zero never expires
+	ExpiresIn int64  `json:"expiresIn" validate:"min=0"`
+	MaxUses   *int32 `json:"maxUses" validate:"min=1"`
+}
+
+// Create creates a share link for the project, or for one of its documents
+func (h *ShareLinkHandler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	project, ok := session.ProjectFromContext(ctx)
+	user, hasUser := session.UserFromContext(ctx)
+	if !ok || !hasUser {
+		respondError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+	userID, err := uuid.Parse(user.ID)
+	if err != nil {
+		respondError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	req, err := validate.Decode[createShareLinkRequest](w, r)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	if !isShareRole(req.Role) {
+		respondError(w, r, http.StatusBadRequest, "INVALID_ROLE", "Share links can only grant the viewer or commenter role")
+		return
+	}
+
+	if req.DocumentID != nil {
+		_, err := h.queries.GetDocumentByIDAndProjectID(ctx, repository.GetDocumentByIDAndProjectIDParams{
+			ID:        *req.DocumentID,
+			ProjectId: project.ID,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			respondError(w, r, http.StatusBadRequest, "DOCUMENT_NOT_FOUND", "Document not found in this project")
+			return
+		}
+		if err != nil {
+			logging.FromContext(ctx).Error("failed to load shared document", "error", err)
+			respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create share link")
+			return
+		}
+	}
+
+	params := repository.CreateShareLinkParams{
+		ProjectId:  project.ID,
+		DocumentId: req.DocumentID,
+		Token:      generateToken(32),
+		Role:       string(req.Role),
+		MaxUses:    req.MaxUses,
+		CreatedBy:  userID,
+	}
+	if req.ExpiresIn > 0 {
+		expiresAt := time.Now().Add(time.Duration(req.ExpiresIn) * time.Second)
+		params.ExpiresAt = &expiresAt
+	}
+	if req.Password != "" {
+		hash, err := password.Hash(req.Password)
+		if err != nil {
+			logging.FromContext(ctx).Error("failed to hash share link password", "error", err)
+			respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create share link")
+			return
+		}
+		params.PasswordHash = &hash
+	}
+
+	link, err := h.queries.CreateShareLink(ctx, params)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to create share link", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create share link")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, h.response(link))
+}
+
+// List returns the project's share links, including revoked and expired ones
+func (h *ShareLinkHandler) List(w http.ResponseWriter, r *http.Request) {
+	project, ok := session.ProjectFromContext(r.Context())
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	links, err := h.queries.ListShareLinksByProjectId(r.Context(), project.ID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to list share links", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list share links")
+		return
+	}
+
+	out := make([]shareLinkResponse, 0, len(links))
+	for _, link := range links {
+		out = append(out, h.response(link))
+	}
+
+	respondJSON(w, http.StatusOK, out)
+}
+
+// Revoke disables the {id} share link. Sessions resolved from it stop
+// working on their next request.
+func (h *ShareLinkHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	project, ok := session.ProjectFromContext(r.Context())
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "INVALID_SHARE_LINK_ID", "Invalid share link id")
+		return
+	}
+
+	revoked, err := h.queries.RevokeShareLink(r.Context(), repository.RevokeShareLinkParams{
+		ID:        id,
+		ProjectId: project.ID,
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to revoke share link", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to revoke share link")
+		return
+	}
+	if revoked == 0 {
+		respondError(w, r, http.StatusNotFound, "SHARE_LINK_NOT_FOUND", "Share link not found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]bool{"status": true})
+}
+
+type resolveShareLinkRequest struct {
+	Password string `json:"password" validate:"max=128"`
+}
+
+// checkPassword verifies the password of a protected link, writing the
+// error when it's missing or wrong. Failures count towards a lockout of the
+// link, which is checked before the password.
+func (h *ShareLinkHandler) checkPassword(w http.ResponseWriter, r *http.Request, link repository.ShareLink, given string) bool {
+	if link.PasswordHash == nil {
+		return true
+	}
+	if given == "" {
+		respondError(w, r, http.StatusUnauthorized, "PASSWORD_REQUIRED", "Password required")
+		return false
+	}
+
+	key := "share-link:" + link.ID.String()
+	if h.lockout != nil {
+		if wait := h.lockout.Check(r.Context(), key, time.Now()); wait > 0 {
+			ratelimit.RespondTooManyRequests(w, r, wait)
+			return false
+		}
+	}
+	valid, err := password.Verify(*link.PasswordHash, given)
+	if err != nil || !valid {
+		if h.lockout != nil {
+			h.lockout.Fail(r.Context(), key, time.Now())
+		}
+		respondError(w, r, http.StatusUnauthorized, "INVALID_PASSWORD", "Invalid password")
+		return false
+	}
+	if h.lockout != nil {
+		h.lockout.Succeed(r.Context(), key)
+	}
+	return true
+}
+
+// Resolve exchanges the {token} of a share link for an anonymous session: a
+// short-lived JWT that only opens the /api/shared routes. Each call counts as
+// one use of the link.
+func (h *ShareLinkHandler) Resolve(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if !h.keys.CanSign() {
+		respondError(w, r, http.StatusServiceUnavailable, "JWT_UNAVAILABLE", "Token signing is not configured")
+		return
+	}
+
+	req, err := validate.Decode[resolveShareLinkRequest](w, r, validate.AllowEmptyBody())
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+
+	link, err := h.queries.GetShareLinkByToken(ctx, r.PathValue("token"))
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondError(w, r, http.StatusNotFound, "SHARE_LINK_NOT_FOUND", "Share link not found")
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to load share link", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to open share link")
+		return
+	}
+
+	if !h.checkPassword(w, r, link, req.Password) {
+		return
+	}
+
+	// Revocation, expiry and the use limit are checked by the update itself
+	// so concurrent resolutions can't exceed maxUses
+	used, err := h.queries.UseShareLink(ctx, link.ID)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to use share link", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to open share link")
+		return
+	}
+	if used == 0 {
+		respondError(w, r, http.StatusGone, "SHARE_LINK_EXPIRED", "Share link expired")
+		return
+	}
+
+	claims := map[string]any{
+		ShareLinkClaim: link.ID.String(),
+		"projectId":    link.ProjectId.String(),
+		"role":         link.Role,
+	}
+	if link.DocumentId != nil {
+		claims["documentId"] = link.DocumentId.String()
+	}
+	token, err := h.keys.IssueFor(ShareAudience, "share:"+link.ID.String(), claims)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to issue share token", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to open share link")
+		return
+	}
+
+	if err := RecordShareAccess(r, h.queries, &link, EventShareLinkResolved); err != nil {
+		logging.FromContext(ctx).Error("failed to record share link access", "error", err)
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"token":       token,
+		"projectId":   link.ProjectId,
+		"documentId":  link.DocumentId,
+		"permissions": authz.Permissions(authz.Role(link.Role)),
+	})
+}
+
+// shareScope returns the document a share session is limited to, or nil for
+// user sessions and share links of a whole project
+func shareScope(r *http.Request) *uuid.UUID {
+	if link, ok := session.ShareLinkFromContext(r.Context()); ok {
+		return link.DocumentId
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"budhapp.com/internal/config"
+	"budhapp.com/internal/password"
+	"budhapp.com/internal/ratelimit"
+	"budhapp.com/internal/repository"
+	"github.com/google/uuid"
+)
+
+func TestShareLinkPasswordLockout(t *testing.T) {
+	lockout := ratelimit.NewLockout(ratelimit.NewMemoryStore(), slog.New(slog.NewTextHandler(io.Discard, nil)), config.RateLimitConfig{
+		Lockout: config.LockoutConfig{
+			Threshold:   2,
+			Duration:    config.Duration(time.Minute),
+			MaxDuration: config.Duration(time.Hour),
+		},
+	})
+	hash, err := password.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	link := repository.ShareLink{ID: uuid.New(), PasswordHash: &hash}
+	h := &ShareLinkHandler{lockout: lockout}
+
+	check := func(given string) int {
+		rec := httptest.NewRecorder()
+		h.checkPassword(rec, httptest.NewRequest(http.MethodPost, "/api/share/token", nil), link, given)
+		return rec.Code
+	}
+
+	for range 2 {
+		if code := check("guess"); code != http.StatusUnauthorized {
+			t.Fatalf("checkPassword() of a wrong password = %d, want 401", code)
+		}
+	}
+	// The link stays locked even for the right password
+	if code := check("correct horse"); code != http.StatusTooManyRequests {
+		t.Errorf("checkPassword() of a locked link = %d, want 429", code)
+	}
+
+	// Other links are not affected
+	other := repository.ShareLink{ID: uuid.New(), PasswordHash: &hash}
+	rec := httptest.NewRecorder()
+	if !h.checkPassword(rec, httptest.NewRequest(http.MethodPost, "/api/share/token", nil), other, "correct horse") {
+		t.Errorf("checkPassword() of another link = %d, want it accepted", rec.Code)
+	}
+}
@@ -0,0 +1,485 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"budhapp.com/internal/logging"
+	"budhapp.com/internal/oauth"
+	"budhapp.com/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// oauthStateExpiration is how long a sign-in attempt stays valid
+const oauthStateExpiration = 10 * time.Minute
+
+// Error codes passed to the error callback URL, matching better-auth
+const (
+	errorStateMismatch      = "state_mismatch"
+	errorOAuthFailed        = "oauth_provider_error"
+	errorAccountNotLinked   = "account_not_linked"
+	errorAccountLinkedOther = "account_already_linked_to_different_user"
+	errorUnableToCreateUser = "unable_to_create_user"
+)
+
+// oauthState is stored in the verification table, keyed by the state parameter
+type oauthState struct {
+	Provider     string     `json:"provider"`
+	CodeVerifier string     `json:"codeVerifier"`
+	Nonce        string     `json:"nonce"`
+	CallbackURL  string     `json:"callbackURL"`
+	ErrorURL     string     `json:"errorURL"`
+	NewUserURL   string     `json:"newUserURL"`
+	Link         *uuid.UUID `json:"link,omitempty"`
+}
+
+type SocialHandler struct {
+	queries        *repository.Queries
+	pool           *pgxpool.Pool
+	sessions       *sessionStore
+	providers      *oauth.Registry
+	tokens         *oauth.TokenService
+	baseURL        string
+	trustedOrigins []string
+}
+
+func NewSocialHandler(queries *repository.Queries, pool *pgxpool.Pool, sessions *sessionStore, providers *oauth.Registry, tokens *oauth.TokenService, baseURL string, trustedOrigins []string) *SocialHandler {
+	return &SocialHandler{
+		queries:        queries,
+		pool:           pool,
+		sessions:       sessions,
+		providers:      providers,
+		tokens:         tokens,
+		baseURL:        baseURL,
+		trustedOrigins: trustedOrigins,
+	}
+}
+
+type socialSignInRequest struct {
+	Provider           string `json:"provider" validate:"required"`
+	CallbackURL        string `json:"callbackURL"`
+	ErrorCallbackURL   string `json:"errorCallbackURL"`
+	NewUserCallbackURL string `json:"newUserCallbackURL"`
+	DisableRedirect    bool   `json:"disableRedirect"`
+}
+
+// SignIn starts the authorization code flow
+// (better-auth: POST /api/auth/sign-in/social)
+func (h *SocialHandler) SignIn(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeAuthRequest[socialSignInRequest](w, r)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+
+	h.authorize(w, r, req, nil)
+}
+
+type linkSocialRequest struct {
+	Provider    string `json:"provider" validate:"required"`
+	CallbackURL string `json:"callbackURL"`
+}
+
+// LinkSocial starts the authorization code flow to attach a provider account
+// to the signed-in user (better-auth: POST /api/auth/link-social)
+func (h *SocialHandler) LinkSocial(w http.ResponseWriter, r *http.Request) {
+	_, user, err := h.sessions.current(r)
+	if err != nil {
+		respondError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	req, err := decodeAuthRequest[linkSocialRequest](w, r)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+
+	h.authorize(w, r, socialSignInRequest{
+		Provider:    req.Provider,
+		CallbackURL: req.CallbackURL,
+	}, &user.ID)
+}
+
+func (h *SocialHandler) authorize(w http.ResponseWriter, r *http.Request, req socialSignInRequest, link *uuid.UUID) {
+	provider, err := h.providers.Get(req.Provider)
+	if err != nil {
+		respondError(w, r, http.StatusNotFound, "PROVIDER_NOT_FOUND", "Provider not found")
+		return
+	}
+
+	for _, u := range []string{req.CallbackURL, req.ErrorCallbackURL, req.NewUserCallbackURL} {
+		if u != "" && !isTrustedURL(u, h.baseURL, h.trustedOrigins) {
+			respondError(w, r, http.StatusForbidden, "INVALID_CALLBACK_URL", "Invalid callback URL")
+			return
+		}
+	}
+
+	state := oauthState{
+		Provider:     provider.ID(),
+		CodeVerifier: oauth.GenerateVerifier(),
+		Nonce:        generateToken(32),
+		CallbackURL:  req.CallbackURL,
+		ErrorURL:     req.ErrorCallbackURL,
+		NewUserURL:   req.NewUserCallbackURL,
+		Link:         link,
+	}
+	if state.CallbackURL == "" {
+		state.CallbackURL = "/"
+	}
+
+	stateID := generateToken(32)
+	authURL, err := provider.AuthCodeURL(r.Context(), stateID, state.CodeVerifier, state.Nonce)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to build authorization url", "provider", provider.ID(), "error", err)
+		respondError(w, r, http.StatusBadGateway, "PROVIDER_UNAVAILABLE", "Provider unavailable")
+		return
+	}
+
+	value, err := json.Marshal(state)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to start sign in")
+		return
+	}
+	if _, err := h.queries.CreateVerification(r.Context(), repository.CreateVerificationParams{
+		Identifier: stateID,
+		Value:      string(value),
+		ExpiresAt:  time.Now().Add(oauthStateExpiration),
+	}); err != nil {
+		logging.FromContext(r.Context()).Error("failed to store oauth state", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to start sign in")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"url":      authURL,
+		"redirect": !req.DisableRedirect,
+	})
+}
+
+// Callback completes the authorization code flow, signing the user in or
+// linking the account (better-auth: GET /api/auth/callback/{provider})
+func (h *SocialHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	verification, err := h.queries.ConsumeVerification(ctx, query.Get("state"))
+	if err != nil {
+		redirectError(w, r, "", errorStateMismatch)
+		return
+	}
+
+	var state oauthState
+	if err := json.Unmarshal([]byte(verification.Value), &state); err != nil || state.Provider != r.PathValue("provider") {
+		redirectError(w, r, "", errorStateMismatch)
+		return
+	}
+
+	errorURL := state.ErrorURL
+	if errorURL == "" {
+		errorURL = state.CallbackURL
+	}
+
+	if providerErr := query.Get("error"); providerErr != "" {
+		redirectError(w, r, errorURL, providerErr)
+		return
+	}
+
+	provider, err := h.providers.Get(state.Provider)
+	if err != nil {
+		redirectError(w, r, errorURL, errorOAuthFailed)
+		return
+	}
+
+	tokens, err := provider.Exchange(ctx, query.Get("code"), state.CodeVerifier)
+	if err != nil {
+		logging.FromContext(ctx).Warn("oauth code exchange failed", "provider", provider.ID(), "error", err)
+		redirectError(w, r, errorURL, errorOAuthFailed)
+		return
+	}
+
+	info, err := provider.UserInfo(ctx, tokens, state.Nonce)
+	if err != nil {
+		logging.FromContext(ctx).Warn("oauth user info failed", "provider", provider.ID(), "error", err)
+		redirectError(w, r, errorURL, errorOAuthFailed)
+		return
+	}
+	info.Email = strings.ToLower(info.Email)
+
+	stored, err := h.tokens.Seal(tokens)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to encrypt oauth tokens", "error", err)
+		redirectError(w, r, errorURL, errorOAuthFailed)
+		return
+	}
+
+	if state.Link != nil {
+		h.linkAccount(w, r, *state.Link, provider.ID(), info, stored, state.CallbackURL, errorURL)
+		return
+	}
+
+	userID, isNew, code := h.resolveUser(ctx, provider.ID(), info, stored)
+	if code != "" {
+		redirectError(w, r, errorURL, code)
+		return
+	}
+
+	if _, err := h.sessions.create(ctx, w, r, userID); err != nil {
+		if errors.Is(err, ErrUserBanned) {
+			redirectError(w, r, errorURL, errorBanned)
+			return
+		}
+		logging.FromContext(ctx).Error("failed to create session", "error", err)
+		redirectError(w, r, errorURL, errorUnableToCreateUser)
+		return
+	}
+
+	target := state.CallbackURL
+	if isNew && state.NewUserURL != "" {
+		target = state.NewUserURL
+	}
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// resolveUser finds or creates the user for a provider identity. Existing
+// users are only linked by email when the provider has verified it; otherwise
+// anyone controlling an unverified address could take over the account.
+func (h *SocialHandler) resolveUser(ctx context.Context, providerID string, info *oauth.UserInfo, tokens oauth.StoredTokens) (uuid.UUID, bool, string) {
+	account, err := h.queries.GetAccountByProviderIdAndAccountId(ctx, repository.GetAccountByProviderIdAndAccountIdParams{
+		ProviderId: providerID,
+		AccountId:  info.ID,
+	})
+	if err == nil {
+		if _, err := h.queries.UpdateAccountTokens(ctx, accountTokensParams(account, tokens)); err != nil {
+			logging.FromContext(ctx).Error("failed to update account tokens", "error", err)
+		}
+		return account.UserId, false, ""
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		logging.FromContext(ctx).Error("failed to look up account", "error", err)
+		return uuid.Nil, false, errorOAuthFailed
+	}
+
+	user, err := h.queries.GetUserByEmail(ctx, info.Email)
+	switch {
+	case err == nil:
+		if !info.EmailVerified {
+			return uuid.Nil, false, errorAccountNotLinked
+		}
+		err = withTx(ctx, h.pool, h.queries, func(q *repository.Queries) error {
+			if _, err := q.CreateOAuthAccount(ctx, oauthAccountParams(user.ID, providerID, info.ID, tokens)); err != nil {
+				return err
+			}
+			if !user.EmailVerified {
+				_, err := q.UpdateUser(ctx, repository.UpdateUserParams{
+					ID:            user.ID,
+					Name:          user.Name,
+					Email:         user.Email,
+					EmailVerified: true,
+					Image:         user.Image,
+				})
+				return err
+			}
+			return nil
+		})
+		if err != nil {
+			logging.FromContext(ctx).Error("failed to link account", "error", err)
+			return uuid.Nil, false, errorOAuthFailed
+		}
+		return user.ID, false, ""
+
+	case errors.Is(err, pgx.ErrNoRows):
+		err = withTx(ctx, h.pool, h.queries, func(q *repository.Queries) error {
+			var image *string
+			if info.Image != "" {
+				image = &info.Image
+			}
+			user, err = q.CreateUser(ctx, repository.CreateUserParams{
+				Name:          info.Name,
+				Email:         info.Email,
+				EmailVerified: info.EmailVerified,
+				Image:         image,
+			})
+			if err != nil {
+				return err
+			}
+			_, err = q.CreateOAuthAccount(ctx, oauthAccountParams(user.ID, providerID, info.ID, tokens))
+			return err
+		})
+		if err != nil {
+			logging.FromContext(ctx).Error("failed to create user", "error", err)
+			return uuid.Nil, false, errorUnableToCreateUser
+		}
+		return user.ID, true, ""
+
+	default:
+		logging.FromContext(ctx).Error("failed to look up user", "error", err)
+		return uuid.Nil, false, errorOAuthFailed
+	}
+}
+
+// linkAccount attaches the provider identity to the user who started the link
+func (h *SocialHandler) linkAccount(w http.ResponseWriter, r *http.Request, userID uuid.UUID, providerID string, info *oauth.UserInfo, tokens oauth.StoredTokens, callbackURL, errorURL string) {
+	ctx := r.Context()
+
+	account, err := h.queries.GetAccountByProviderIdAndAccountId(ctx, repository.GetAccountByProviderIdAndAccountIdParams{
+		ProviderId: providerID,
+		AccountId:  info.ID,
+	})
+	switch {
+	case err == nil && account.UserId != userID:
+		redirectError(w, r, errorURL, errorAccountLinkedOther)
+		return
+	case err == nil:
+		_, err = h.queries.UpdateAccountTokens(ctx, accountTokensParams(account, tokens))
+	case errors.Is(err, pgx.ErrNoRows):
+		_, err = h.queries.CreateOAuthAccount(ctx, oauthAccountParams(userID, providerID, info.ID, tokens))
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to link account", "error", err)
+		redirectError(w, r, errorURL, errorOAuthFailed)
+		return
+	}
+
+	http.Redirect(w, r, callbackURL, http.StatusFound)
+}
+
+type accountResponse struct {
+	ID         uuid.UUID `json:"id"`
+	ProviderID string    `json:"providerId"`
+	AccountID  string    `json:"accountId"`
+	UserID     uuid.UUID `json:"userId"`
+	Scopes     []string  `json:"scopes"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// ListAccounts returns the accounts linked to the signed-in user
+// (better-auth: GET /api/auth/list-accounts)
+func (h *SocialHandler) ListAccounts(w http.ResponseWriter, r *http.Request) {
+	_, user, err := h.sessions.current(r)
+	if err != nil {
+		respondError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	accounts, err := h.queries.ListAccountsByUserId(r.Context(), user.ID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to list accounts", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list accounts")
+		return
+	}
+
+	response := make([]accountResponse, 0, len(accounts))
+	for _, a := range accounts {
+		scopes := []string{}
+		if a.Scope != nil && *a.Scope != "" {
+			scopes = strings.FieldsFunc(*a.Scope, func(c rune) bool { return c == ',' || c == ' ' })
+		}
+		response = append(response, accountResponse{
+			ID:         a.ID,
+			ProviderID: a.ProviderId,
+			AccountID:  a.AccountId,
+			UserID:     a.UserId,
+			Scopes:     scopes,
+			CreatedAt:  a.CreatedAt,
+			UpdatedAt:  a.UpdatedAt,
+		})
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+type unlinkAccountRequest struct {
+	ProviderID string `json:"providerId" validate:"required"`
+	AccountID  string `json:"accountId"`
+}
+
+// UnlinkAccount removes a provider account from the signed-in user. The last
+// account cannot be removed, the user would have no way to sign in.
+// (better-auth: POST /api/auth/unlink-account)
+func (h *SocialHandler) UnlinkAccount(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	_, user, err := h.sessions.current(r)
+	if err != nil {
+		respondError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	req, err := decodeAuthRequest[unlinkAccountRequest](w, r)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+
+	accounts, err := h.queries.ListAccountsByUserId(ctx, user.ID)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to list accounts", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to unlink account")
+		return
+	}
+
+	var target *repository.Account
+	for i, a := range accounts {
+		if a.ProviderId == req.ProviderID && (req.AccountID == "" || a.AccountId == req.AccountID) {
+			target = &accounts[i]
+			break
+		}
+	}
+	if target == nil {
+		respondError(w, r, http.StatusBadRequest, "ACCOUNT_NOT_FOUND", "Account not found")
+		return
+	}
+	if len(accounts) <= 1 {
+		respondError(w, r, http.StatusBadRequest, "FAILED_TO_UNLINK_LAST_ACCOUNT", "You can't unlink your last account")
+		return
+	}
+
+	if _, err := h.queries.DeleteAccount(ctx, target.ID); err != nil {
+		logging.FromContext(ctx).Error("failed to unlink account", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to unlink account")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]bool{"status": true})
+}
+
+func oauthAccountParams(userID uuid.UUID, providerID, accountID string, tokens oauth.StoredTokens) repository.CreateOAuthAccountParams {
+	return repository.CreateOAuthAccountParams{
+		UserId:                userID,
+		AccountId:             accountID,
+		ProviderId:            providerID,
+		AccessToken:           tokens.AccessToken,
+		RefreshToken:          tokens.RefreshToken,
+		IdToken:               tokens.IDToken,
+		AccessTokenExpiresAt:  tokens.AccessTokenExpiresAt,
+		RefreshTokenExpiresAt: tokens.RefreshTokenExpiresAt,
+		Scope:                 tokens.Scope,
+	}
+}
+
+// accountTokensParams keeps the stored refresh token when the provider does
+// not return a new one, which most do on subsequent sign-ins
+func accountTokensParams(account repository.Account, tokens oauth.StoredTokens) repository.UpdateAccountTokensParams {
+	params := repository.UpdateAccountTokensParams{
+		ID:                    account.ID,
+		AccessToken:           tokens.AccessToken,
+		RefreshToken:          tokens.RefreshToken,
+		IdToken:               tokens.IDToken,
+		AccessTokenExpiresAt:  tokens.AccessTokenExpiresAt,
+		RefreshTokenExpiresAt: tokens.RefreshTokenExpiresAt,
+		Scope:                 tokens.Scope,
+	}
+	if params.RefreshToken == nil {
+		params.RefreshToken = account.RefreshToken
+		params.RefreshTokenExpiresAt = account.RefreshTokenExpiresAt
+	}
+	return params
+}
@@ -0,0 +1,481 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"budhapp.com/internal/encryption"
+	"budhapp.com/internal/logging"
+	"budhapp.com/internal/ratelimit"
+	"budhapp.com/internal/repository"
+	"budhapp.com/internal/session"
+	"budhapp.com/internal/totp"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// twoFactorChallengeExpiration is how long the user has to enter a code after the password
+	twoFactorChallengeExpiration = 10 * time.Minute
+	// trustDeviceExpiration matches the better-auth trusted device lifetime
+	trustDeviceExpiration = 30 * 24 * time.Hour
+	backupCodeCount       = 10
+	twoFactorIdentifier   = "2fa-"
+)
+
+var (
+	ErrNoTwoFactorChallenge = errors.New("no pending two factor challenge")
+)
+
+// startTwoFactor records a pending sign-in and sets the two factor cookie
+// that identifies it, instead of creating a session
+func (s *sessionStore) startTwoFactor(ctx context.Context, w http.ResponseWriter, userID uuid.UUID) error {
+	identifier := twoFactorIdentifier + generateToken(32)
+	expiresAt := time.Now().Add(twoFactorChallengeExpiration)
+
+	if _, err := s.queries.CreateVerification(ctx, repository.CreateVerificationParams{
+		Identifier: identifier,
+		Value:      userID.String(),
+		ExpiresAt:  expiresAt,
+	}); err != nil {
+		return err
+	}
+
+	s.setCookie(w, session.TwoFactorCookieName, identifier, expiresAt)
+	return nil
+}
+
+// consumeTwoFactor takes the pending challenge of the request, deleting it
+// so concurrent verifications can't both use it. It is put back with
+// restoreTwoFactor when the code is refused.
+func (s *sessionStore) consumeTwoFactor(r *http.Request) (repository.Verification, uuid.UUID, error) {
+	identifier, ok := session.SignedCookieFromRequest(r, session.TwoFactorCookieName, s.secret)
+	if !ok || !strings.HasPrefix(identifier, twoFactorIdentifier) {
+		return repository.Verification{}, uuid.Nil, ErrNoTwoFactorChallenge
+	}
+
+	verification, err := s.queries.ConsumeVerification(r.Context(), identifier)
+	if err != nil {
+		return repository.Verification{}, uuid.Nil, ErrNoTwoFactorChallenge
+	}
+
+	userID, err := uuid.Parse(verification.Value)
+	if err != nil {
+		return repository.Verification{}, uuid.Nil, ErrNoTwoFactorChallenge
+	}
+
+	return verification, userID, nil
+}
+
+// restoreTwoFactor puts a consumed challenge back with its expiry, so the
+// user can try another code until the lockout stops them
+func (s *sessionStore) restoreTwoFactor(ctx context.Context, challenge repository.Verification) {
+	if _, err := s.queries.CreateVerification(ctx, repository.CreateVerificationParams{
+		Identifier: challenge.Identifier,
+		Value:      challenge.Value,
+		ExpiresAt:  challenge.ExpiresAt,
+	}); err != nil {
+		logging.FromContext(ctx).Error("failed to restore two factor challenge", "error", err)
+	}
+}
+
+// trustDevice sets a signed cookie that skips the two factor challenge for
+// the user on this device
+func (s *sessionStore) trustDevice(w http.ResponseWriter, userID uuid.UUID) {
+	expiresAt := time.Now().Add(trustDeviceExpiration)
+	value := userID.String() + "!" + strconv.FormatInt(expiresAt.Unix(), 10)
+	s.setCookie(w, session.TrustDeviceCookieName, value, expiresAt)
+}
+
+func (s *sessionStore) isTrustedDevice(r *http.Request, userID uuid.UUID) bool {
+	value, ok := session.SignedCookieFromRequest(r, session.TrustDeviceCookieName, s.secret)
+	if !ok {
+		return false
+	}
+
+	id, expires, found := strings.Cut(value, "!")
+	if !found || id != userID.String() {
+		return false
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	return err == nil && time.Now().Before(time.Unix(unix, 0))
+}
+
+type TwoFactorHandler struct {
+	queries  *repository.Queries
+	pool     *pgxpool.Pool
+	sessions *sessionStore
+	keyring  *encryption.Keyring
+	issuer   string
+}
+
+func NewTwoFactorHandler(queries *repository.Queries, pool *pgxpool.Pool, sessions *sessionStore, keyring *encryption.Keyring, issuer string) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		queries:  queries,
+		pool:     pool,
+		sessions: sessions,
+		keyring:  keyring,
+		issuer:   issuer,
+	}
+}
+
+type passwordRequest struct {
+	Password string `json:"password" validate:"required,max=128"`
+}
+
+// authorize returns the signed-in user after checking their password, as
+// better-auth requires for every change to two factor settings. It writes the
+// error response and returns false on failure.
+func (h *TwoFactorHandler) authorize(w http.ResponseWriter, r *http.Request) (repository.User, bool) {
+	if h.keyring == nil {
+		respondError(w, r, http.StatusServiceUnavailable, "TWO_FACTOR_UNAVAILABLE", "Two factor authentication is not configured")
+		return repository.User{}, false
+	}
+
+	_, user, err := h.sessions.current(r)
+	if err != nil {
+		respondError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return repository.User{}, false
+	}
+
+	req, err := decodeAuthRequest[passwordRequest](w, r)
+	if err != nil {
+		respondErr(w, r, err)
+		return repository.User{}, false
+	}
+
+	if !h.sessions.confirmPassword(w, r, user, req.Password) {
+		return repository.User{}, false
+	}
+
+	return user, true
+}
+
+// Enable generates a TOTP secret and backup codes. Two factor authentication
+// is only turned on once a code from the authenticator has been verified.
+// (better-auth: POST /api/auth/two-factor/enable)
+func (h *TwoFactorHandler) Enable(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.authorize(w, r)
+	if !ok {
+		return
+	}
+	// Replacing the secret of an active enrollment would lock the user out
+	// of their authenticator; it has to be disabled first
+	if user.TwoFactorEnabled {
+		respondError(w, r, http.StatusBadRequest, "TWO_FACTOR_ALREADY_ENABLED", "Two factor is already enabled")
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to enable two factor")
+		return
+	}
+	backupCodes := generateBackupCodes()
+
+	sealedSecret, err := h.keyring.EncryptString(secret)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to enable two factor")
+		return
+	}
+	sealedCodes, err := h.sealBackupCodes(backupCodes)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to enable two factor")
+		return
+	}
+
+	if _, err := h.queries.UpsertTwoFactor(r.Context(), repository.UpsertTwoFactorParams{
+		UserId:      user.ID,
+		Secret:      sealedSecret,
+		BackupCodes: sealedCodes,
+	}); err != nil {
+		logging.FromContext(r.Context()).Error("failed to store two factor secret", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to enable two factor")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"totpURI":     totp.URI(secret, h.issuer, user.Email),
+		"backupCodes": backupCodes,
+	})
+}
+
+// TOTPURI returns the otpauth URI of the current secret
+// (better-auth: POST /api/auth/two-factor/get-totp-uri)
+func (h *TwoFactorHandler) TOTPURI(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	tf, err := h.queries.GetTwoFactorByUserId(r.Context(), user.ID)
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "TOTP_NOT_ENABLED", "TOTP not enabled")
+		return
+	}
+	secret, err := h.keyring.DecryptString(tf.Secret)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to decrypt totp secret", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to read two factor secret")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"totpURI": totp.URI(secret, h.issuer, user.Email)})
+}
+
+// Disable turns off two factor authentication and removes the secret
+// (better-auth: POST /api/auth/two-factor/disable)
+func (h *TwoFactorHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	err := withTx(r.Context(), h.pool, h.queries, func(q *repository.Queries) error {
+		if err := q.DeleteTwoFactorByUserId(r.Context(), user.ID); err != nil {
+			return err
+		}
+		_, err := q.SetUserTwoFactorEnabled(r.Context(), repository.SetUserTwoFactorEnabledParams{
+			ID:               user.ID,
+			TwoFactorEnabled: false,
+		})
+		return err
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to disable two factor", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to disable two factor")
+		return
+	}
+
+	h.sessions.clearCookie(w, session.TrustDeviceCookieName)
+	respondJSON(w, http.StatusOK, map[string]bool{"status": true})
+}
+
+// GenerateBackupCodes replaces the backup codes
+// (better-auth: POST /api/auth/two-factor/generate-backup-codes)
+func (h *TwoFactorHandler) GenerateBackupCodes(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := h.queries.GetTwoFactorByUserId(r.Context(), user.ID); err != nil {
+		respondError(w, r, http.StatusBadRequest, "TWO_FACTOR_NOT_ENABLED", "Two factor isn't enabled")
+		return
+	}
+
+	backupCodes := generateBackupCodes()
+	sealed, err := h.sealBackupCodes(backupCodes)
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to generate backup codes")
+		return
+	}
+	if err := h.queries.UpdateTwoFactorBackupCodes(r.Context(), repository.UpdateTwoFactorBackupCodesParams{
+		UserId:      user.ID,
+		BackupCodes: sealed,
+	}); err != nil {
+		logging.FromContext(r.Context()).Error("failed to store backup codes", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to generate backup codes")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"status":      true,
+		"backupCodes": backupCodes,
+	})
+}
+
+type verifyCodeRequest struct {
+	Code        string `json:"code" validate:"required"`
+	TrustDevice bool   `json:"trustDevice"`
+}
+
+// VerifyTOTP checks an authenticator code. It completes a pending sign-in, or
+// for a signed-in user confirms enrollment and turns two factor on.
+// (better-auth: POST /api/auth/two-factor/verify-totp)
+func (h *TwoFactorHandler) VerifyTOTP(w http.ResponseWriter, r *http.Request) {
+	h.verify(w, r, func(ctx context.Context, tf repository.TwoFactor, code string) (bool, error) {
+		secret, err := h.keyring.DecryptString(tf.Secret)
+		if err != nil {
+			return false, err
+		}
+		step, ok := totp.Step(secret, code, time.Now())
+		if !ok {
+			return false, nil
+		}
+		// A code is refused once its step, or a later one, was used
+		used, err := h.queries.UseTwoFactorStep(ctx, repository.UseTwoFactorStepParams{
+			Step:   step,
+			UserID: tf.UserId,
+		})
+		return used == 1, err
+	})
+}
+
+// VerifyBackupCode accepts a backup code in place of a TOTP code. Each code
+// can only be used once. (better-auth: POST /api/auth/two-factor/verify-backup-code)
+func (h *TwoFactorHandler) VerifyBackupCode(w http.ResponseWriter, r *http.Request) {
+	h.verify(w, r, func(ctx context.Context, tf repository.TwoFactor, code string) (bool, error) {
+		codes, err := h.openBackupCodes(tf.BackupCodes)
+		if err != nil {
+			return false, err
+		}
+
+		for i, c := range codes {
+			if c != code {
+				continue
+			}
+			remaining := append(codes[:i:i], codes[i+1:]...)
+			sealed, err := h.sealBackupCodes(remaining)
+			if err != nil {
+				return false, err
+			}
+			// A concurrent request using a code changes the sealed codes,
+			// so only one of them removes this code
+			replaced, err := h.queries.ReplaceTwoFactorBackupCodes(ctx, repository.ReplaceTwoFactorBackupCodesParams{
+				BackupCodes:         sealed,
+				UserID:              tf.UserId,
+				PreviousBackupCodes: tf.BackupCodes,
+			})
+			return replaced == 1, err
+		}
+		return false, nil
+	})
+}
+
+func (h *TwoFactorHandler) verify(w http.ResponseWriter, r *http.Request, check func(ctx context.Context, tf repository.TwoFactor, code string) (bool, error)) {
+	ctx := r.Context()
+
+	if h.keyring == nil {
+		respondError(w, r, http.StatusServiceUnavailable, "TWO_FACTOR_UNAVAILABLE", "Two factor authentication is not configured")
+		return
+	}
+
+	req, err := decodeAuthRequest[verifyCodeRequest](w, r)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+
+	// A pending sign-in takes precedence over an existing session. Its
+	// challenge is consumed before the code is checked, so only one request
+	// can verify it, and put back if the code is refused.
+	challenge, userID, err := h.sessions.consumeTwoFactor(r)
+	pending := err == nil
+	var sess repository.Session
+	var user repository.User
+	if pending {
+		user, err = h.queries.GetUserByID(ctx, userID)
+	} else {
+		sess, user, err = h.sessions.current(r)
+	}
+	if err != nil {
+		respondError(w, r, http.StatusUnauthorized, "INVALID_TWO_FACTOR_COOKIE", "Invalid two factor cookie")
+		return
+	}
+	if wait := h.sessions.signInLocked(r, user.Email); wait > 0 {
+		if pending {
+			h.sessions.restoreTwoFactor(ctx, challenge)
+		}
+		ratelimit.RespondTooManyRequests(w, r, wait)
+		return
+	}
+
+	tf, err := h.queries.GetTwoFactorByUserId(ctx, user.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondError(w, r, http.StatusBadRequest, "TOTP_NOT_ENABLED", "TOTP not enabled")
+		return
+	}
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to verify code")
+		return
+	}
+
+	valid, err := check(ctx, tf, strings.TrimSpace(req.Code))
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to verify two factor code", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to verify code")
+		return
+	}
+	if !valid {
+		if pending {
+			h.sessions.restoreTwoFactor(ctx, challenge)
+		}
+		if err := h.sessions.signInFailed(r, &user.ID, user.Email, "invalid_two_factor_code"); err != nil {
+			logging.FromContext(ctx).Error("failed to audit sign-in", "error", err)
+		}
+		respondError(w, r, http.StatusUnauthorized, "INVALID_CODE", "Invalid code")
+		return
+	}
+
+	if pending {
+		h.sessions.clearCookie(w, session.TwoFactorCookieName)
+
+		sess, err = h.sessions.create(ctx, w, r, user.ID)
+		if errors.Is(err, ErrUserBanned) {
+			respondBanned(w, r)
+			return
+		}
+		if err != nil {
+			logging.FromContext(ctx).Error("failed to create session", "error", err)
+			respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to sign in")
+			return
+		}
+	}
+
+	if !user.TwoFactorEnabled {
+		if user, err = h.queries.SetUserTwoFactorEnabled(ctx, repository.SetUserTwoFactorEnabledParams{
+			ID:               user.ID,
+			TwoFactorEnabled: true,
+		}); err != nil {
+			logging.FromContext(ctx).Error("failed to enable two factor", "error", err)
+			respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to enable two factor")
+			return
+		}
+	}
+
+	if req.TrustDevice {
+		h.sessions.trustDevice(w, user.ID)
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"token": sess.Token,
+		"user":  user,
+	})
+}
+
+func (h *TwoFactorHandler) sealBackupCodes(codes []string) (string, error) {
+	data, err := json.Marshal(codes)
+	if err != nil {
+		return "", err
+	}
+	return h.keyring.Encrypt(data)
+}
+
+func (h *TwoFactorHandler) openBackupCodes(sealed string) ([]string, error) {
+	data, err := h.keyring.Decrypt(sealed)
+	if err != nil {
+		return nil, err
+	}
+	var codes []string
+	if err := json.Unmarshal(data, &codes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// generateBackupCodes returns codes in the better-auth "xxxxx-xxxxx" format
+func generateBackupCodes() []string {
+	codes := make([]string, backupCodeCount)
+	for i := range codes {
+		code := generateToken(10)
+		codes[i] = code[:5] + "-" + code[5:]
+	}
+	return codes
+}
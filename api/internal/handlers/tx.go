@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"budhapp.com/internal/repository"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// uniqueViolation is the SQLSTATE of a unique constraint violation
+const uniqueViolation = "23505"
+
+// withTx runs fn in a transaction, committing when it returns nil
+func withTx(ctx context.Context, pool *pgxpool.Pool, queries *repository.Queries, fn func(q *repository.Queries) error) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // No-op after commit
+
+	if err := fn(queries.WithTx(tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// isUniqueViolation reports whether err is a unique constraint violation.
+// Slugs are claimed by the insert or update itself, as a lookup beforehand
+// can't stop a concurrent request from taking the slug first.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
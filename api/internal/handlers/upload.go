@@ -0,0 +1,602 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"budhapp.com/internal/config"
+	"budhapp.com/internal/imaging"
+	"budhapp.com/internal/logging"
+	"budhapp.com/internal/repository"
+	"budhapp.com/internal/session"
+	"budhapp.com/internal/storage"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// Purposes of an upload. Avatars replace the user's image.
+	PurposeAvatar     = "avatar"
+	PurposeAttachment = "attachment"
+
+	// variantOriginal names the uploaded file in download URLs. Thumbnails
+	// are named by their size in pixels.
+	variantOriginal = "original"
+
+	// avatarSize is the thumbnail served as the avatar
+	avatarSize = 256
+
+	// multipartOverhead allows for the form boundaries and fields around
+	// the file
+	multipartOverhead = 1 << 20
+
+	maxFilenameLength = 255
+)
+
+// thumbnailSizes are generated for every decodable image
+var thumbnailSizes = []int{64, avatarSize}
+
+// uploadContentTypes are the sniffed types accepted as uploads
+var uploadContentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+	"text/plain":      true,
+}
+
+// thumbnailContentTypes are the types imaging can decode
+var thumbnailContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// UploadHandler stores files uploaded by users and serves them through
+// signed, short-lived URLs. Identical files are stored once, keyed by their
+// sha256 hash.
+type UploadHandler struct {
+	queries *repository.Queries
+	pool    *pgxpool.Pool
+	store   storage.Store
+	signer  *storage.Signer
+	baseURL string
+	cfg     config.StorageConfig
+}
+
+func NewUploadHandler(queries *repository.Queries, pool *pgxpool.Pool, store storage.Store, signer *storage.Signer, cfg config.StorageConfig, baseURL string) *UploadHandler {
+	return &UploadHandler{
+		queries: queries,
+		pool:    pool,
+		store:   store,
+		signer:  signer,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		cfg:     cfg,
+	}
+}
+
+// assetView is an asset with signed URLs to download it
+type assetView struct {
+	repository.Asset
+	URL        string            `json:"url"`
+	Thumbnails map[string]string `json:"thumbnails,omitempty"`
+	ExpiresAt  time.Time         `json:"expiresAt"`
+}
+
+func (h *UploadHandler) view(asset repository.Asset) assetView {
+	expires := time.Now().Add(h.cfg.URLExpiration.Std())
+	v := assetView{
+		Asset:     asset,
+		URL:       h.signedURL(asset.ID, variantOriginal, expires),
+		ExpiresAt: expires,
+	}
+	if hasThumbnails(asset) {
+		v.Thumbnails = make(map[string]string, len(thumbnailSizes))
+		for _, size := range thumbnailSizes {
+			variant := strconv.Itoa(size)
+			v.Thumbnails[variant] = h.signedURL(asset.ID, variant, expires)
+		}
+	}
+	return v
+}
+
+func (h *UploadHandler) signedURL(id uuid.UUID, variant string, expires time.Time) string {
+	p := filePath(id, variant)
+	return h.baseURL + p + "?" + h.signer.Sign(p, expires).Encode()
+}
+
+// filePath is the download route of a variant, which is also what the URL
+// signature covers
+func filePath(id uuid.UUID, variant string) string {
+	return "/api/files/" + id.String() + "/" + variant
+}
+
+// MaxBodySize is the body limit of uploads: the largest file and the
+// multipart form around it
+func (h *UploadHandler) MaxBodySize() int64 {
+	return h.cfg.MaxUploadSize + multipartOverhead
+}
+
+// Upload stores the multipart "file" field. The "purpose" field is
+// "attachment", the default, or "avatar", which must be an image and
+// becomes the user's image.
+func (h *UploadHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := currentUserID(r)
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.MaxBodySize())
+	if err := r.ParseMultipartForm(h.cfg.MaxUploadSize); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(w, r, http.StatusRequestEntityTooLarge, "FILE_TOO_LARGE", "File is too large")
+			return
+		}
+		respondError(w, r, http.StatusBadRequest, "INVALID_BODY", "Invalid multipart form")
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	purpose := r.FormValue("purpose")
+	if purpose == "" {
+		purpose = PurposeAttachment
+	}
+	if purpose != PurposeAttachment && purpose != PurposeAvatar {
+		respondError(w, r, http.StatusBadRequest, "INVALID_PURPOSE", "Purpose must be attachment or avatar")
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "FILE_REQUIRED", "File is required")
+		return
+	}
+	defer file.Close()
+	if header.Size > h.cfg.MaxUploadSize {
+		respondError(w, r, http.StatusRequestEntityTooLarge, "FILE_TOO_LARGE", "File is too large")
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(file, h.cfg.MaxUploadSize+1))
+	if err != nil {
+		respondError(w, r, http.StatusBadRequest, "INVALID_BODY", "Failed to read file")
+		return
+	}
+	if int64(len(data)) > h.cfg.MaxUploadSize {
+		respondError(w, r, http.StatusRequestEntityTooLarge, "FILE_TOO_LARGE", "File is too large")
+		return
+	}
+	if len(data) == 0 {
+		respondError(w, r, http.StatusBadRequest, "FILE_REQUIRED", "File is empty")
+		return
+	}
+
+	// The client's content type is ignored, the type is sniffed from the
+	// content
+	contentType, ok := sniffContentType(data)
+	if !ok {
+		respondError(w, r, http.StatusUnsupportedMediaType, "UNSUPPORTED_FILE_TYPE", "File type is not supported")
+		return
+	}
+	if purpose == PurposeAvatar && !thumbnailContentTypes[contentType] {
+		respondError(w, r, http.StatusUnsupportedMediaType, "UNSUPPORTED_FILE_TYPE", "Avatar must be a JPEG, PNG or GIF image")
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	params := repository.CreateAssetParams{
+		UserId:      userID,
+		Purpose:     purpose,
+		Filename:    sanitizeFilename(header.Filename),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Sha256:      hex.EncodeToString(sum[:]),
+	}
+
+	var thumbnails map[int][]byte
+	if thumbnailContentTypes[contentType] {
+		img, format, err := imaging.Decode(data)
+		if err != nil {
+			respondError(w, r, http.StatusBadRequest, "INVALID_IMAGE", "Image could not be decoded")
+			return
+		}
+		width, height := int32(img.Bounds().Dx()), int32(img.Bounds().Dy())
+		params.Width, params.Height = &width, &height
+
+		thumbnails = make(map[int][]byte, len(thumbnailSizes))
+		for _, size := range thumbnailSizes {
+			var buf bytes.Buffer
+			if _, err := imaging.Encode(&buf, imaging.Thumbnail(img, size), format); err != nil {
+				logging.FromContext(ctx).Error("failed to encode thumbnail", "error", err)
+				respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to store file")
+				return
+			}
+			thumbnails[size] = buf.Bytes()
+		}
+	}
+
+	// The blobs are stored outside the transaction so the hash isn't locked
+	// while they are written
+	if err := h.putBlobs(ctx, params.Sha256, contentType, data, thumbnails); err != nil {
+		logging.FromContext(ctx).Error("failed to store blobs", "error", err)
+		releaseBlobs(context.WithoutCancel(ctx), h.pool, h.queries, h.store, params.Sha256)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to store file")
+		return
+	}
+
+	var asset repository.Asset
+	err = withTx(ctx, h.pool, h.queries, func(q *repository.Queries) error {
+		// Under the lock the blobs can't be released before the asset
+		// referring to them is committed. A release that ran since they were
+		// stored removed them, so they are stored again.
+		if err := q.LockAssetSha256(ctx, params.Sha256); err != nil {
+			return err
+		}
+		exists, err := h.store.Exists(ctx, blobKey(params.Sha256))
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if err := h.putBlobs(ctx, params.Sha256, contentType, data, thumbnails); err != nil {
+				return err
+			}
+		}
+
+		if asset, err = q.CreateAsset(ctx, params); err != nil {
+			return err
+		}
+		if purpose != PurposeAvatar {
+			return nil
+		}
+
+		user, err := q.GetUserByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		image := h.avatarURL(asset.ID)
+		_, err = q.UpdateUser(ctx, repository.UpdateUserParams{
+			ID:            user.ID,
+			Name:          user.Name,
+			Email:         user.Email,
+			EmailVerified: user.EmailVerified,
+			Image:         &image,
+		})
+		return err
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to create asset", "error", err)
+		releaseBlobs(context.WithoutCancel(ctx), h.pool, h.queries, h.store, params.Sha256)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to store file")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, h.view(asset))
+}
+
+// putBlobs stores the file and its thumbnails unless a file with the same
+// hash is already stored
+func (h *UploadHandler) putBlobs(ctx context.Context, sha string, contentType string, data []byte, thumbnails map[int][]byte) error {
+	exists, err := h.store.Exists(ctx, blobKey(sha))
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if err := h.store.Put(ctx, blobKey(sha), bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+			return err
+		}
+	}
+
+	for size, thumbnail := range thumbnails {
+		key := thumbnailKey(sha, size)
+		exists, err := h.store.Exists(ctx, key)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if err := h.store.Put(ctx, key, bytes.NewReader(thumbnail), int64(len(thumbnail)), thumbnailContentType(contentType)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List returns the current user's uploads, newest first, with signed URLs
+func (h *UploadHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(r)
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	assets, err := h.queries.ListAssetsByUserId(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to list assets", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list uploads")
+		return
+	}
+
+	views := make([]assetView, 0, len(assets))
+	for _, asset := range assets {
+		views = append(views, h.view(asset))
+	}
+	respondJSON(w, http.StatusOK, views)
+}
+
+// Delete removes one of the current user's uploads. The stored file is
+// deleted once no other upload has the same content. Deleting the current
+// avatar removes the user's image.
+func (h *UploadHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := currentUserID(r)
+	if !ok {
+		respondError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondError(w, r, http.StatusNotFound, "ASSET_NOT_FOUND", "Upload not found")
+		return
+	}
+
+	var asset repository.Asset
+	err = withTx(ctx, h.pool, h.queries, func(q *repository.Queries) error {
+		var err error
+		if asset, err = q.DeleteAsset(ctx, repository.DeleteAssetParams{ID: id, UserId: userID}); err != nil {
+			return err
+		}
+		if asset.Purpose != PurposeAvatar {
+			return nil
+		}
+
+		user, err := q.GetUserByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if user.Image == nil || *user.Image != h.avatarURL(asset.ID) {
+			return nil
+		}
+		_, err = q.UpdateUser(ctx, repository.UpdateUserParams{
+			ID:            user.ID,
+			Name:          user.Name,
+			Email:         user.Email,
+			EmailVerified: user.EmailVerified,
+		})
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondError(w, r, http.StatusNotFound, "ASSET_NOT_FOUND", "Upload not found")
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to delete asset", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete upload")
+		return
+	}
+
+	releaseBlobs(ctx, h.pool, h.queries, h.store, asset.Sha256)
+
+	respondJSON(w, http.StatusOK, map[string]bool{"status": true})
+}
+
+// Download serves a file or thumbnail from a URL signed by List or Upload.
+// No session is needed, the signature grants access until it expires.
+func (h *UploadHandler) Download(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+
+	if err := h.signer.Verify(r.URL.Path, r.URL.Query(), now); err != nil {
+		if errors.Is(err, storage.ErrExpiredSignature) {
+			respondError(w, r, http.StatusForbidden, "EXPIRED_SIGNATURE", "Download link has expired")
+			return
+		}
+		respondError(w, r, http.StatusForbidden, "INVALID_SIGNATURE", "Invalid download link")
+		return
+	}
+
+	asset, ok := h.asset(w, r)
+	if !ok {
+		return
+	}
+
+	variant := r.PathValue("variant")
+	expires, _ := strconv.ParseInt(r.URL.Query().Get("expires"), 10, 64)
+	maxAge := max(expires-now.Unix(), 0)
+	w.Header().Set("Cache-Control", "private, max-age="+strconv.FormatInt(maxAge, 10))
+
+	if variant == variantOriginal {
+		// Images are shown inline, anything else is downloaded
+		disposition := "attachment"
+		if thumbnailContentTypes[asset.ContentType] || asset.ContentType == "image/webp" {
+			disposition = "inline"
+		}
+		w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": asset.Filename}))
+		h.serve(w, r, blobKey(asset.Sha256), asset.ContentType)
+		return
+	}
+
+	size, err := strconv.Atoi(variant)
+	if err != nil || !hasThumbnails(asset) || !slices.Contains(thumbnailSizes, size) {
+		respondError(w, r, http.StatusNotFound, "ASSET_NOT_FOUND", "File not found")
+		return
+	}
+	w.Header().Set("Content-Disposition", "inline")
+	h.serve(w, r, thumbnailKey(asset.Sha256, size), thumbnailContentType(asset.ContentType))
+}
+
+// Avatar serves the avatar thumbnail of an upload made with the avatar
+// purpose. Avatars are public, like the image URL of the user.
+func (h *UploadHandler) Avatar(w http.ResponseWriter, r *http.Request) {
+	asset, ok := h.asset(w, r)
+	if !ok {
+		return
+	}
+	if asset.Purpose != PurposeAvatar {
+		respondError(w, r, http.StatusNotFound, "ASSET_NOT_FOUND", "Avatar not found")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Header().Set("Content-Disposition", "inline")
+	h.serve(w, r, thumbnailKey(asset.Sha256, avatarSize), thumbnailContentType(asset.ContentType))
+}
+
+// asset loads the asset of the {id} route parameter
+func (h *UploadHandler) asset(w http.ResponseWriter, r *http.Request) (repository.Asset, bool) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondError(w, r, http.StatusNotFound, "ASSET_NOT_FOUND", "File not found")
+		return repository.Asset{}, false
+	}
+	asset, err := h.queries.GetAssetByID(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondError(w, r, http.StatusNotFound, "ASSET_NOT_FOUND", "File not found")
+		return repository.Asset{}, false
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to get asset", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load file")
+		return repository.Asset{}, false
+	}
+	return asset, true
+}
+
+// serve copies a blob to the response. The headers keep browsers from
+// sniffing the content or running it as a page.
+func (h *UploadHandler) serve(w http.ResponseWriter, r *http.Request, key, contentType string) {
+	blob, err := h.store.Get(r.Context(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		respondError(w, r, http.StatusNotFound, "ASSET_NOT_FOUND", "File not found")
+		return
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to read blob", "key", key, "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load file")
+		return
+	}
+	defer blob.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if _, err := io.Copy(w, blob); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to send blob", "key", key, "error", err)
+	}
+}
+
+func (h *UploadHandler) avatarURL(id uuid.UUID) string {
+	return h.baseURL + "/api/avatars/" + id.String()
+}
+
+// releaseBlobs deletes the file stored under sha and its thumbnails when no
+// asset refers to it anymore. It holds the lock of the hash, so an upload of
+// the same content either commits its asset first or stores the file again
+// afterwards. Failures are logged, as the asset itself is already gone.
+func releaseBlobs(ctx context.Context, pool *pgxpool.Pool, queries *repository.Queries, store storage.Store, sha string) {
+	err := withTx(ctx, pool, queries, func(q *repository.Queries) error {
+		if err := q.LockAssetSha256(ctx, sha); err != nil {
+			return err
+		}
+		count, err := q.CountAssetsBySha256(ctx, sha)
+		if err != nil || count > 0 {
+			return err
+		}
+
+		keys := []string{blobKey(sha)}
+		for _, size := range thumbnailSizes {
+			keys = append(keys, thumbnailKey(sha, size))
+		}
+		for _, key := range keys {
+			if err := store.Delete(ctx, key); err != nil {
+				logging.FromContext(ctx).Error("failed to delete blob", "key", key, "error", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to release blobs", "sha256", sha, "error", err)
+	}
+}
+
+// currentUserID returns the id of the user authenticated by the JWT
+// middleware
+func currentUserID(r *http.Request) (uuid.UUID, bool) {
+	user, ok := session.UserFromContext(r.Context())
+	if !ok || user == nil {
+		return uuid.UUID{}, false
+	}
+	id, err := uuid.Parse(user.ID)
+	if err != nil {
+		return uuid.UUID{}, false
+	}
+	return id, true
+}
+
+// sniffContentType returns the media type detected from the content when it
+// is accepted as an upload
+func sniffContentType(data []byte) (string, bool) {
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(data))
+	if err != nil || !uploadContentTypes[mediaType] {
+		return "", false
+	}
+	return mediaType, true
+}
+
+// sanitizeFilename keeps the base name of the client's file name, which is
+// only used for the Content-Disposition of downloads
+func sanitizeFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == ".." || name == "/" || !utf8.ValidString(name) {
+		return "upload"
+	}
+	for len(name) > maxFilenameLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
+
+func blobKey(sha string) string {
+	return "blobs/" + sha[:2] + "/" + sha
+}
+
+func thumbnailKey(sha string, size int) string {
+	return "thumbnails/" + sha[:2] + "/" + sha + "/" + strconv.Itoa(size)
+}
+
+// thumbnailContentType is the type imaging.Encode writes for an image,
+// PNG to keep the transparency of PNG and GIF images, JPEG otherwise
+func thumbnailContentType(contentType string) string {
+	if contentType == "image/png" || contentType == "image/gif" {
+		return "image/png"
+	}
+	return "image/jpeg"
+}
+
+func hasThumbnails(asset repository.Asset) bool {
+	return asset.Width != nil && thumbnailContentTypes[asset.ContentType]
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"budhapp.com/internal/storage"
+	"github.com/google/uuid"
+)
+
+func TestSniffContentType(t *testing.T) {
+	var pngData bytes.Buffer
+	if err := png.Encode(&pngData, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		data   []byte
+		want   string
+		wantOK bool
+	}{
+		{"png", pngData.Bytes(), "image/png", true},
+		{"pdf", []byte("%PDF-1.7\n"), "application/pdf", true},
+		{"text", []byte("hello"), "text/plain", true},
+		{"html", []byte("<!DOCTYPE html><script>alert(1)</script>"), "", false},
+		{"binary", []byte{0x7f, 'E', 'L', 'F', 0, 1}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := sniffContentType(tt.data)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("sniffContentType() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"photo.png", "photo.png"},
+		{"../../etc/passwd", "passwd"},
+		{`C:\Users\me\report.pdf`, "report.pdf"},
+		{"bad\"name\r\n.txt", "badname.txt"},
+		{"", "upload"},
+		{"..", "upload"},
+		{string(bytes.Repeat([]byte("é"), 200)), string(bytes.Repeat([]byte("é"), 127))},
+	}
+	for _, tt := range tests {
+		if got := sanitizeFilename(tt.name); got != tt.want {
+			t.Errorf("sanitizeFilename(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDownloadRejectsBadSignature(t *testing.T) {
+	signer, err := storage.NewSigner("secret")
+	if err != nil {
+		t.Fatalf("NewSigner() error = %v", err)
+	}
+	h := &UploadHandler{signer: signer}
+	id := uuid.New()
+	path := filePath(id, variantOriginal)
+
+	tests := []struct {
+		name     string
+		url      string
+		wantCode string
+	}{
+		{"unsigned", path, "INVALID_SIGNATURE"},
+		{"other file", filePath(uuid.New(), variantOriginal) + "?" + signer.Sign(path, time.Now().Add(time.Minute)).Encode(), "INVALID_SIGNATURE"},
+		{"expired", path + "?" + signer.Sign(path, time.Now().Add(-time.Minute)).Encode(), "EXPIRED_SIGNATURE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			rec := httptest.NewRecorder()
+			h.Download(rec, req)
+			if rec.Code != http.StatusForbidden || !bytes.Contains(rec.Body.Bytes(), []byte(tt.wantCode)) {
+				t.Errorf("Download() = %d %s, want 403 %s", rec.Code, rec.Body, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestUploadRequiresUser(t *testing.T) {
+	h := &UploadHandler{}
+	rec := httptest.NewRecorder()
+	h.Upload(rec, httptest.NewRequest(http.MethodPost, "/api/uploads", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Upload() without a user = %d, want 401", rec.Code)
+	}
+}
@@ -0,0 +1,327 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"budhapp.com/internal/apierror"
+	"budhapp.com/internal/audit"
+	"budhapp.com/internal/config"
+	"budhapp.com/internal/email"
+	"budhapp.com/internal/logging"
+	"budhapp.com/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// changeEmailIdentifier prefixes the token in the verification identifier
+	changeEmailIdentifier = "change-email-"
+
+	// Limits of the profile fields, matching the user table
+	maxNameLength  = 255
+	maxImageLength = 2048
+)
+
+var ErrEmailTaken = errors.New("email already in use")
+
+// changeEmailValue is stored in the verification table, keyed by the token
+type changeEmailValue struct {
+	UserID   uuid.UUID `json:"userId"`
+	NewEmail string    `json:"newEmail"`
+}
+
+// UserHandler updates the profile and email address of the current user
+type UserHandler struct {
+	queries        *repository.Queries
+	pool           *pgxpool.Pool
+	sessions       *sessionStore
+	emails         email.EmailProvider
+	appName        string
+	baseURL        string
+	trustedOrigins []string
+	changeEmail    config.ChangeEmailConfig
+}
+
+func NewUserHandler(queries *repository.Queries, pool *pgxpool.Pool, sessions *sessionStore, emails email.EmailProvider, cfg config.AuthConfig) *UserHandler {
+	return &UserHandler{
+		queries:        queries,
+		pool:           pool,
+		sessions:       sessions,
+		emails:         emails,
+		appName:        cfg.AppName,
+		baseURL:        strings.TrimSuffix(cfg.BaseURL, "/"),
+		trustedOrigins: cfg.TrustedOrigins,
+		changeEmail:    cfg.ChangeEmail,
+	}
+}
+
+type updateUserRequest struct {
+	Name *string `json:"name"`
+	// Image is kept raw to tell a null, which removes the image, from an
+	// absent field
+	Image json.RawMessage `json:"image"`
+	Email *string         `json:"email"`
+}
+
+// UpdateUser changes the name and image of the current user. The email is
+// changed with ChangeEmail. (better-auth: POST /api/auth/update-user)
+func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	_, user, err := h.sessions.current(r)
+	if err != nil {
+		respondError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	req, err := decodeAuthRequest[updateUserRequest](w, r)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	if req.Email != nil {
+		respondError(w, r, http.StatusBadRequest, "EMAIL_CAN_NOT_BE_UPDATED", "Email can not be updated. Use changeEmail instead")
+		return
+	}
+	if req.Name == nil && req.Image == nil {
+		respondError(w, r, http.StatusBadRequest, "NO_FIELDS_TO_UPDATE", "No fields to update")
+		return
+	}
+
+	params := repository.UpdateUserParams{
+		ID:            user.ID,
+		Name:          user.Name,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		Image:         user.Image,
+	}
+	if req.Name != nil {
+		name, err := validateName(*req.Name)
+		if err != nil {
+			respondErr(w, r, err)
+			return
+		}
+		params.Name = name
+	}
+	if req.Image != nil {
+		image, err := validateImage(req.Image)
+		if err != nil {
+			respondErr(w, r, err)
+			return
+		}
+		params.Image = image
+	}
+
+	if _, err := h.queries.UpdateUser(ctx, params); err != nil {
+		logging.FromContext(ctx).Error("failed to update user", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update user")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]bool{"status": true})
+}
+
+// validateName returns the trimmed name, which must not be empty
+func validateName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxNameLength {
+		return "", apierror.Invalid("INVALID_NAME", "name", "Name must be between 1 and 255 characters")
+	}
+	return name, nil
+}
+
+// validateImage accepts null, which removes the image, or an absolute http
+// or https URL
+func validateImage(raw json.RawMessage) (*string, error) {
+	if bytes.Equal(raw, []byte("null")) {
+		return nil, nil
+	}
+
+	invalid := apierror.Invalid("INVALID_IMAGE", "image", "Image must be an http or https URL")
+	var image string
+	if err := json.Unmarshal(raw, &image); err != nil {
+		return nil, invalid
+	}
+	if image = strings.TrimSpace(image); image == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(image)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(image) > maxImageLength {
+		return nil, invalid
+	}
+	return &image, nil
+}
+
+type changeEmailRequest struct {
+	NewEmail    string `json:"newEmail" validate:"required,email"`
+	CallbackURL string `json:"callbackURL"`
+}
+
+// ChangeEmail emails a verification link to the new address. The change
+// takes effect once the link is opened. When the current address is
+// verified it is told about the request.
+// (better-auth: POST /api/auth/change-email)
+func (h *UserHandler) ChangeEmail(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	_, user, err := h.sessions.current(r)
+	if err != nil {
+		respondError(w, r, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	req, err := decodeAuthRequest[changeEmailRequest](w, r)
+	if err != nil {
+		respondErr(w, r, err)
+		return
+	}
+	newEmail := strings.ToLower(req.NewEmail)
+	if newEmail == user.Email {
+		respondError(w, r, http.StatusBadRequest, "EMAIL_IS_THE_SAME", "Email is the same")
+		return
+	}
+	if req.CallbackURL != "" && !isTrustedURL(req.CallbackURL, h.baseURL, h.trustedOrigins) {
+		respondError(w, r, http.StatusForbidden, "INVALID_CALLBACK_URL", "Invalid callback URL")
+		return
+	}
+
+	if err := h.checkEmailAvailable(ctx, h.queries, newEmail); err != nil {
+		if errors.Is(err, ErrEmailTaken) {
+			respondError(w, r, http.StatusBadRequest, "USER_ALREADY_EXISTS", "User already exists. Use another email.")
+			return
+		}
+		logging.FromContext(ctx).Error("failed to check email", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to change email")
+		return
+	}
+
+	token := generateToken(32)
+	value, err := json.Marshal(changeEmailValue{UserID: user.ID, NewEmail: newEmail})
+	if err != nil {
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to change email")
+		return
+	}
+	if _, err := h.queries.CreateVerification(ctx, repository.CreateVerificationParams{
+		Identifier: changeEmailIdentifier + token,
+		Value:      string(value),
+		ExpiresAt:  time.Now().Add(h.changeEmail.Expiration.Std()),
+	}); err != nil {
+		logging.FromContext(ctx).Error("failed to store email change", "error", err)
+		respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to change email")
+		return
+	}
+
+	query := url.Values{"token": {token}}
+	if req.CallbackURL != "" {
+		query.Set("callbackURL", req.CallbackURL)
+	}
+	link := h.baseURL + "/api/auth/verify-email?" + query.Encode()
+
+	sendEmail(logging.FromContext(ctx), h.emails, email.VerifyNewEmail(newEmail, h.appName, link, h.changeEmail.Expiration.Std()))
+	if user.EmailVerified {
+		sendEmail(logging.FromContext(ctx), h.emails, email.EmailChangeRequested(user.Email, h.appName, newEmail))
+	}
+
+	respondJSON(w, http.StatusOK, map[string]bool{"status": true})
+}
+
+// VerifyEmail applies an email change from the link sent to the new address
+// and redirects to the callback URL when one was given. The new address is
+// marked verified and becomes the id of the credential account.
+// (better-auth: GET /api/auth/verify-email)
+func (h *UserHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	callbackURL := query.Get("callbackURL")
+	if callbackURL != "" && !isTrustedURL(callbackURL, h.baseURL, h.trustedOrigins) {
+		callbackURL = ""
+	}
+	fail := func(status int, code, message string) {
+		if callbackURL != "" {
+			redirectError(w, r, callbackURL, code)
+			return
+		}
+		respondError(w, r, status, code, message)
+	}
+
+	verification, err := h.queries.ConsumeVerification(ctx, changeEmailIdentifier+query.Get("token"))
+	if err != nil {
+		fail(http.StatusBadRequest, errorInvalidToken, "Invalid token")
+		return
+	}
+	var value changeEmailValue
+	if err := json.Unmarshal([]byte(verification.Value), &value); err != nil {
+		fail(http.StatusBadRequest, errorInvalidToken, "Invalid token")
+		return
+	}
+
+	var user repository.User
+	err = withTx(ctx, h.pool, h.queries, func(q *repository.Queries) error {
+		// The address may have been taken since the link was sent
+		if err := h.checkEmailAvailable(ctx, q, value.NewEmail); err != nil {
+			return err
+		}
+
+		before, err := q.GetUserByID(ctx, value.UserID)
+		if err != nil {
+			return err
+		}
+		if user, err = q.UpdateUserEmail(ctx, repository.UpdateUserEmailParams{ID: before.ID, Email: value.NewEmail}); err != nil {
+			return err
+		}
+		if _, err := q.UpdateAccountIdByUserIdAndProvider(ctx, repository.UpdateAccountIdByUserIdAndProviderParams{
+			UserId:     user.ID,
+			ProviderId: credentialProviderID,
+			AccountId:  user.Email,
+		}); err != nil {
+			return err
+		}
+		return audit.Record(ctx, q, r, audit.Entry{
+			Action:     audit.ActionAccountEmailChange,
+			ActorID:    &user.ID,
+			TargetType: audit.TargetUser,
+			TargetID:   user.ID.String(),
+			Diff:       map[string]audit.Change{"email": {From: before.Email, To: user.Email}},
+		})
+	})
+	switch {
+	case errors.Is(err, ErrEmailTaken):
+		fail(http.StatusBadRequest, "USER_ALREADY_EXISTS", "User already exists. Use another email.")
+		return
+	case errors.Is(err, pgx.ErrNoRows):
+		fail(http.StatusBadRequest, errorInvalidToken, "Invalid token")
+		return
+	case err != nil:
+		logging.FromContext(ctx).Error("failed to change email", "error", err)
+		fail(http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to change email")
+		return
+	}
+
+	if callbackURL != "" {
+		http.Redirect(w, r, callbackURL, http.StatusFound)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"status": true, "user": user})
+}
+
+// checkEmailAvailable returns ErrEmailTaken when a user has the address
+func (h *UserHandler) checkEmailAvailable(ctx context.Context, q *repository.Queries, address string) error {
+	_, err := q.GetUserByEmail(ctx, address)
+	switch {
+	case err == nil:
+		return ErrEmailTaken
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil
+	default:
+		return err
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestValidateName(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{"Jane Doe", "Jane Doe", false},
+		{"  Jane  ", "Jane", false},
+		{"", "", true},
+		{"   ", "", true},
+		{strings.Repeat("a", maxNameLength+1), "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := validateName(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("validateName(%q) = %q, %v, want %q, wantErr %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestUpdateUserRequestImage(t *testing.T) {
+	tests := []struct {
+		body    string
+		want    *string
+		wantErr bool
+	}{
+		{`{"image": null}`, nil, false},
+		{`{"image": ""}`, nil, false},
+		{`{"image": "https://example.com/a.png"}`, ptr("https://example.com/a.png"), false},
+		{`{"image": "javascript:alert(1)"}`, nil, true},
+		{`{"image": "/relative.png"}`, nil, true},
+		{`{"image": 42}`, nil, true},
+		{`{"image": "https://example.com/` + strings.Repeat("a", maxImageLength) + `"}`, nil, true},
+	}
+
+	for _, tt := range tests {
+		var req updateUserRequest
+		if err := json.Unmarshal([]byte(tt.body), &req); err != nil {
+			t.Fatalf("Unmarshal(%s) error = %v", tt.body, err)
+		}
+		if req.Image == nil {
+			t.Fatalf("Unmarshal(%s) lost the image field", tt.body)
+		}
+
+		got, err := validateImage(req.Image)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateImage(%s) error = %v, wantErr %v", req.Image, err, tt.wantErr)
+			continue
+		}
+		if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+			t.Errorf("validateImage(%s) = %v, want %v", req.Image, got, tt.want)
+		}
+	}
+
+	var req updateUserRequest
+	if err := json.Unmarshal([]byte(`{"name": "Jane"}`), &req); err != nil || req.Image != nil {
+		t.Errorf("absent image = %s, %v, want nil", req.Image, err)
+	}
+}
+
+func ptr(s string) *string {
+	return &s
+}
@@ -0,0 +1,137 @@
+// Package health runs the dependency checks behind the readiness probe and
+// the admin health report. Subsystems register their checks at startup;
+// results are cached so frequent probes don't load the dependencies.
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Status of a check or a report
+type Status string
+
+const (
+	StatusOK Status = "ok"
+	// StatusDegraded means only non-critical checks fail
+	StatusDegraded Status = "degraded"
+	StatusFail     Status = "fail"
+)
+
+// Check reports whether a dependency works
+type Check func(ctx context.Context) error
+
+// Result is the outcome of a check
+type Result struct {
+	Name     string `json:"name"`
+	Status   Status `json:"status"`
+	Critical bool   `json:"critical"`
+	Error    string `json:"error,omitempty"`
+	// DurationMs is how long the check took, in milliseconds
+	DurationMs float64   `json:"durationMs"`
+	CheckedAt  time.Time `json:"checkedAt"`
+}
+
+// Report is the outcome of all checks
+type Report struct {
+	Status Status   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Ready reports whether the critical checks pass
+func (r Report) Ready() bool {
+	return r.Status != StatusFail
+}
+
+// Registry holds the registered checks and their cached results
+type Registry struct {
+	ttl     time.Duration
+	timeout time.Duration
+	now     func() time.Time
+
+	mu     sync.RWMutex
+	checks []*check
+}
+
+type check struct {
+	name     string
+	critical bool
+	run      Check
+
+	// mu is held while the check runs, so concurrent probes share one run
+	mu     sync.Mutex
+	result Result
+}
+
+// NewRegistry creates a registry caching results for ttl and giving each
+// check timeout to complete
+func NewRegistry(ttl, timeout time.Duration) *Registry {
+	return &Registry{ttl: ttl, timeout: timeout, now: time.Now}
+}
+
+// Register adds a check. Failing critical checks fail readiness; others only
+// degrade the report.
+func (r *Registry) Register(name string, critical bool, run Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checks = append(r.checks, &check{name: name, critical: critical, run: run})
+}
+
+// Check runs the checks whose cached result expired, concurrently, and
+// returns the report sorted by name
+func (r *Registry) Check(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := r.checks
+	r.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = r.result(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	report := Report{Status: StatusOK, Checks: results}
+	for _, result := range results {
+		switch {
+		case result.Status == StatusOK:
+		case result.Critical:
+			report.Status = StatusFail
+		case report.Status == StatusOK:
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+// result returns the cached result of the check, running it when expired
+func (r *Registry) result(ctx context.Context, c *check) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := r.now()
+	if !c.result.CheckedAt.IsZero() && now.Sub(c.result.CheckedAt) < r.ttl {
+		return c.result
+	}
+
+	// The result is shared, so it must not depend on the caller going away
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.timeout)
+	defer cancel()
+
+	result := Result{Name: c.name, Status: StatusOK, Critical: c.critical, CheckedAt: now}
+	if err := c.run(ctx); err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	result.DurationMs = float64(r.now().Sub(now).Microseconds()) / 1000
+	c.result = result
+	return result
+}
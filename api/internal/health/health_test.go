@@ -0,0 +1,107 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRegistryStatus(t *testing.T) {
+	failing := func(ctx context.Context) error { return errors.New("down") }
+	passing := func(ctx context.Context) error { return nil }
+
+	tests := []struct {
+		name     string
+		critical Check
+		optional Check
+		want     Status
+	}{
+		{"all pass", passing, passing, StatusOK},
+		{"optional fails", passing, failing, StatusDegraded},
+		{"critical fails", failing, passing, StatusFail},
+		{"all fail", failing, failing, StatusFail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry(time.Minute, time.Second)
+			r.Register("email", false, tt.optional)
+			r.Register("database", true, tt.critical)
+
+			report := r.Check(context.Background())
+			if report.Status != tt.want || report.Ready() != (tt.want != StatusFail) {
+				t.Errorf("status = %s, ready %v, want %s", report.Status, report.Ready(), tt.want)
+			}
+			if len(report.Checks) != 2 || report.Checks[0].Name != "database" || !report.Checks[0].Critical {
+				t.Errorf("checks = %+v, want database first", report.Checks)
+			}
+		})
+	}
+}
+
+func TestRegistryCache(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := NewRegistry(5*time.Second, time.Second)
+	r.now = func() time.Time { return now }
+
+	var runs atomic.Int32
+	r.Register("database", true, func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	})
+
+	r.Check(context.Background())
+	now = now.Add(4 * time.Second)
+	r.Check(context.Background())
+	if got := runs.Load(); got != 1 {
+		t.Errorf("runs = %d within the ttl, want 1", got)
+	}
+
+	now = now.Add(time.Second)
+	r.Check(context.Background())
+	if got := runs.Load(); got != 2 {
+		t.Errorf("runs = %d after the ttl, want 2", got)
+	}
+}
+
+func TestRegistryConcurrentProbes(t *testing.T) {
+	r := NewRegistry(time.Minute, time.Second)
+	var runs atomic.Int32
+	r.Register("database", true, func(ctx context.Context) error {
+		runs.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.Check(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	if got := runs.Load(); got != 1 {
+		t.Errorf("runs = %d, want 1", got)
+	}
+}
+
+func TestRegistryTimeout(t *testing.T) {
+	r := NewRegistry(time.Minute, 10*time.Millisecond)
+	r.Register("email", false, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	// A probe that gives up must not cancel the shared check
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report := r.Check(ctx)
+	if got := report.Checks[0]; got.Status != StatusFail || got.Error != context.DeadlineExceeded.Error() {
+		t.Errorf("result = %+v, want a deadline failure", got)
+	}
+}
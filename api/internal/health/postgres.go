@@ -0,0 +1,36 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Database checks that the database answers
+func Database(pool *pgxpool.Pool) Check {
+	return pool.Ping
+}
+
+// Migrations checks that the migrations up to version are applied. The
+// schema_migrations table belongs to golang-migrate, not to the sqlc schema,
+// so it is queried directly. A newer schema passes, as during a rolling
+// deploy the previous release runs against it.
+func Migrations(pool *pgxpool.Pool, version uint) Check {
+	return func(ctx context.Context) error {
+		var (
+			current int64
+			dirty   bool
+		)
+		if err := pool.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&current, &dirty); err != nil {
+			return fmt.Errorf("read migration version: %w", err)
+		}
+		if dirty {
+			return fmt.Errorf("migration %d failed and left the schema dirty", current)
+		}
+		if current < int64(version) {
+			return fmt.Errorf("schema is at version %d, want %d", current, version)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,86 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecode(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	got, format, err := Decode(encodePNG(t, img))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if format != "png" || got.Bounds().Dx() != 4 || got.Bounds().Dy() != 2 {
+		t.Errorf("Decode() = %s %v, want png 4x2", format, got.Bounds())
+	}
+
+	if _, _, err := Decode([]byte("not an image")); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Decode() of text error = %v, want ErrUnsupportedFormat", err)
+	}
+
+	// A PNG header claiming 10000x10000 pixels is rejected before decoding
+	huge := encodePNG(t, image.NewGray(image.Rect(0, 0, 1, 1)))
+	binary.BigEndian.PutUint32(huge[16:], 10000)
+	binary.BigEndian.PutUint32(huge[20:], 10000)
+	binary.BigEndian.PutUint32(huge[29:], crc32.ChecksumIEEE(huge[12:29]))
+	if _, _, err := Decode(huge); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Decode() of huge image error = %v, want ErrTooLarge", err)
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		size          int
+		wantW, wantH  int
+	}{
+		{"landscape", 400, 200, 100, 100, 50},
+		{"portrait", 200, 400, 100, 50, 100},
+		{"already fits", 40, 30, 100, 40, 30},
+		{"thin", 1000, 2, 100, 100, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := image.NewRGBA(image.Rect(0, 0, tt.width, tt.height))
+			got := Thumbnail(img, tt.size).Bounds()
+			if got.Dx() != tt.wantW || got.Dy() != tt.wantH {
+				t.Errorf("Thumbnail() = %dx%d, want %dx%d", got.Dx(), got.Dy(), tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestThumbnailAverages(t *testing.T) {
+	// Alternating black and white columns average to grey
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for y := range 4 {
+		for x := range 4 {
+			if x%2 == 0 {
+				img.Set(x, y, color.White)
+			} else {
+				img.Set(x, y, color.Black)
+			}
+		}
+	}
+	got := Thumbnail(img, 2).RGBAAt(0, 0)
+	if got.R != 127 || got.A != 255 {
+		t.Errorf("Thumbnail() pixel = %v, want grey", got)
+	}
+}
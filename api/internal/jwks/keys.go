@@ -0,0 +1,106 @@
+package jwks
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+
+	"github.com/lestrrat-go/jwx/v3/jwa"
+	"github.com/lestrrat-go/jwx/v3/jwk"
+)
+
+// generateKey creates a private JWK for the given algorithm, tagged with kid and alg
+func generateKey(algorithm, kid string) (jwk.Key, error) {
+	alg, ok := jwa.LookupSignatureAlgorithm(algorithm)
+	if !ok {
+		return nil, fmt.Errorf("unsupported algorithm %q", algorithm)
+	}
+
+	var raw any
+	var err error
+	switch alg {
+	case jwa.EdDSA():
+		_, raw, err = ed25519.GenerateKey(rand.Reader)
+	case jwa.ES256():
+		raw, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case jwa.RS256():
+		raw, err = rsa.GenerateKey(rand.Reader, 2048)
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", algorithm)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate %s key: %w", algorithm, err)
+	}
+
+	key, err := jwk.Import(raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := key.Set(jwk.KeyIDKey, kid); err != nil {
+		return nil, err
+	}
+	if err := key.Set(jwk.AlgorithmKey, alg); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// inferAlgorithm returns the signing algorithm for keys stored without an "alg"
+// member, as better-auth does for its default keys
+func inferAlgorithm(key jwk.Key) (jwa.SignatureAlgorithm, error) {
+	if alg, ok := key.Algorithm(); ok {
+		if sig, ok := jwa.LookupSignatureAlgorithm(alg.String()); ok {
+			return sig, nil
+		}
+	}
+
+	switch key.KeyType() {
+	case jwa.OKP():
+		return jwa.EdDSA(), nil
+	case jwa.EC():
+		var crv jwa.EllipticCurveAlgorithm
+		if err := key.Get(jwk.ECDSACrvKey, &crv); err != nil {
+			return jwa.EmptySignatureAlgorithm(), err
+		}
+		switch crv {
+		case jwa.P256():
+			return jwa.ES256(), nil
+		case jwa.P384():
+			return jwa.ES384(), nil
+		case jwa.P521():
+			return jwa.ES512(), nil
+		}
+	case jwa.RSA():
+		return jwa.RS256(), nil
+	}
+
+	return jwa.EmptySignatureAlgorithm(), fmt.Errorf("cannot infer algorithm for key type %s", key.KeyType())
+}
+
+// parseStoredKey parses a JWK stored in the jwks table and normalizes kid and alg
+func parseStoredKey(data []byte, kid string) (jwk.Key, error) {
+	key, err := jwk.ParseKey(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := key.KeyID(); !ok {
+		if err := key.Set(jwk.KeyIDKey, kid); err != nil {
+			return nil, err
+		}
+	}
+
+	alg, err := inferAlgorithm(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := key.Set(jwk.AlgorithmKey, alg); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
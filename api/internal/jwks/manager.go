@@ -0,0 +1,336 @@
+package jwks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"budhapp.com/internal/config"
+	"budhapp.com/internal/encryption"
+	"budhapp.com/internal/repository"
+	"github.com/google/uuid"
+	"github.com/lestrrat-go/jwx/v3/jwa"
+	"github.com/lestrrat-go/jwx/v3/jwk"
+	"github.com/lestrrat-go/jwx/v3/jwt"
+)
+
+var (
+	ErrSigningUnavailable = errors.New("no signing key available")
+)
+
+// minRefreshInterval bounds how often an unknown key id triggers a reload
+const minRefreshInterval = 30 * time.Second
+
+// Store is the subset of repository.Queries used by the Manager
+type Store interface {
+	ListJwks(ctx context.Context) ([]repository.Jwk, error)
+	CreateJwksWithId(ctx context.Context, arg repository.CreateJwksWithIdParams) (repository.Jwk, error)
+}
+
+// Manager owns the key pairs stored in the jwks table. It publishes the public
+// keys of every non-expired row, signs tokens with the newest key it can
+// decrypt and rotates that key once it is older than the rotation interval.
+//
+// Retired keys stay published until their expiresAt, which is set to
+// rotation interval + overlap, so tokens signed just before a rotation
+// remain verifiable by other services.
+type Manager struct {
+	store   Store
+	cipher  *encryption.Keyring
+	cfg     config.JWTConfig
+	issuer  string
+	logger  *slog.Logger
+	now     func() time.Time
+	refresh sync.Mutex
+
+	mu          sync.RWMutex
+	publicSet   jwk.Set
+	signingKey  jwk.Key
+	signingAlg  jwa.SignatureAlgorithm
+	lastRefresh time.Time
+}
+
+// NewManager creates a key manager. A nil cipher puts the manager in
+// verification-only mode: keys are published and tokens verified, but
+// nothing is signed or generated.
+func NewManager(store Store, cipher *encryption.Keyring, cfg config.AuthConfig, logger *slog.Logger) *Manager {
+	return &Manager{
+		store:     store,
+		cipher:    cipher,
+		cfg:       cfg.JWT,
+		issuer:    cfg.BaseURL,
+		logger:    logger,
+		now:       time.Now,
+		publicSet: jwk.NewSet(),
+	}
+}
+
+// Refresh reloads the key set from the database
+func (m *Manager) Refresh(ctx context.Context) error {
+	m.refresh.Lock()
+	defer m.refresh.Unlock()
+
+	return m.load(ctx)
+}
+
+func (m *Manager) load(ctx context.Context) error {
+	rows, err := m.store.ListJwks(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list jwks: %w", err)
+	}
+
+	now := m.now()
+	publicSet := jwk.NewSet()
+	var signingKey jwk.Key
+	var signingAlg jwa.SignatureAlgorithm
+
+	// Rows are ordered newest first
+	for _, row := range rows {
+		kid := row.ID.String()
+
+		publicKey, err := parseStoredKey([]byte(row.PublicKey), kid)
+		if err != nil {
+			m.logger.Warn("skipping unparseable jwks public key", "kid", kid, "error", err)
+			continue
+		}
+		if err := publicSet.AddKey(publicKey); err != nil {
+			return err
+		}
+
+		if signingKey != nil || m.cipher == nil || row.CreatedAt.Add(m.cfg.RotationInterval.Std()).Before(now) {
+			continue
+		}
+
+		// Keys written by other issuers are encrypted with their own secret
+		plaintext, err := m.cipher.Decrypt(row.PrivateKey)
+		if err != nil {
+			continue
+		}
+		privateKey, err := parseStoredKey(plaintext, kid)
+		if err != nil {
+			m.logger.Warn("skipping unparseable jwks private key", "kid", kid, "error", err)
+			continue
+		}
+		alg, err := inferAlgorithm(privateKey)
+		if err != nil {
+			continue
+		}
+		signingKey, signingAlg = privateKey, alg
+	}
+
+	m.mu.Lock()
+	m.publicSet = publicSet
+	m.signingKey = signingKey
+	m.signingAlg = signingAlg
+	m.lastRefresh = now
+	m.mu.Unlock()
+
+	return nil
+}
+
+// Rotate generates a new key pair, stores it and makes it the signing key
+func (m *Manager) Rotate(ctx context.Context) error {
+	m.refresh.Lock()
+	defer m.refresh.Unlock()
+
+	return m.rotate(ctx)
+}
+
+func (m *Manager) rotate(ctx context.Context) error {
+	if m.cipher == nil {
+		return ErrSigningUnavailable
+	}
+
+	id := uuid.New()
+	privateKey, err := generateKey(m.cfg.Algorithm, id.String())
+	if err != nil {
+		return err
+	}
+	publicKey, err := privateKey.PublicKey()
+	if err != nil {
+		return err
+	}
+
+	publicJSON, err := json.Marshal(publicKey)
+	if err != nil {
+		return err
+	}
+	privateJSON, err := json.Marshal(privateKey)
+	if err != nil {
+		return err
+	}
+	encryptedPrivate, err := m.cipher.Encrypt(privateJSON)
+	if err != nil {
+		return err
+	}
+
+	expiresAt := m.now().Add(m.cfg.RotationInterval.Std() + m.cfg.RotationOverlap.Std())
+	if _, err := m.store.CreateJwksWithId(ctx, repository.CreateJwksWithIdParams{
+		ID:         id,
+		PublicKey:  string(publicJSON),
+		PrivateKey: encryptedPrivate,
+		ExpiresAt:  &expiresAt,
+	}); err != nil {
+		return fmt.Errorf("failed to store jwks key: %w", err)
+	}
+
+	m.logger.Info("generated jwks signing key", "kid", id.String(), "algorithm", m.cfg.Algorithm, "expires_at", expiresAt)
+
+	return m.load(ctx)
+}
+
+// EnsureSigningKey reloads the key set and rotates when no current signing key exists
+func (m *Manager) EnsureSigningKey(ctx context.Context) error {
+	m.refresh.Lock()
+	defer m.refresh.Unlock()
+
+	if err := m.load(ctx); err != nil {
+		return err
+	}
+
+	if m.cipher == nil || m.CanSign() {
+		return nil
+	}
+
+	return m.rotate(ctx)
+}
+
+// Run keeps the key set fresh and rotates keys until ctx is cancelled
+func (m *Manager) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.EnsureSigningKey(ctx); err != nil {
+				m.logger.Error("failed to refresh jwks", "error", err)
+			}
+		}
+	}
+}
+
+// CanSign reports whether the manager holds a current signing key
+func (m *Manager) CanSign() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.signingKey != nil
+}
+
+// Check reports an error when no key is published, or when the manager
+// could sign but holds no current signing key
+func (m *Manager) Check(ctx context.Context) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.publicSet.Len() == 0 {
+		return errors.New("no keys loaded")
+	}
+	if m.cipher != nil && m.signingKey == nil {
+		return errors.New("no current signing key")
+	}
+	return nil
+}
+
+// PublicSet returns the published public keys
+func (m *Manager) PublicSet() jwk.Set {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.publicSet
+}
+
+// Issue signs a token for subject with the given extra claims, for the
+// issuer's own API
+func (m *Manager) Issue(subject string, claims map[string]any) (string, error) {
+	return m.IssueFor("", subject, claims)
+}
+
+// IssueFor signs a token accepted only by ParseRequestFor with the same
+// audience, such as share sessions limited to their own routes. An empty
+// audience is the issuer itself.
+func (m *Manager) IssueFor(audience, subject string, claims map[string]any) (string, error) {
+	m.mu.RLock()
+	key, alg := m.signingKey, m.signingAlg
+	m.mu.RUnlock()
+
+	if key == nil {
+		return "", ErrSigningUnavailable
+	}
+
+	now := m.now()
+	builder := jwt.NewBuilder().
+		Subject(subject).
+		IssuedAt(now).
+		Expiration(now.Add(m.cfg.Expiration.Std()))
+	if m.issuer != "" {
+		builder = builder.Issuer(m.issuer).Audience([]string{m.audience(audience)})
+	}
+	for name, value := range claims {
+		builder = builder.Claim(name, value)
+	}
+
+	token, err := builder.Build()
+	if err != nil {
+		return "", err
+	}
+
+	signed, err := jwt.Sign(token, jwt.WithKey(alg, key))
+	if err != nil {
+		return "", err
+	}
+
+	return string(signed), nil
+}
+
+// audience returns the aud claim of tokens for audience
+func (m *Manager) audience(audience string) string {
+	if audience == "" {
+		return m.issuer
+	}
+	return audience
+}
+
+// ParseRequest verifies the bearer token of r against the published keys,
+// accepting only tokens issued by Issue.
+// A token signed with an unknown key triggers one rate-limited reload, so keys
+// created by other issuers are picked up without waiting for Run.
+func (m *Manager) ParseRequest(r *http.Request) (jwt.Token, error) {
+	return m.ParseRequestFor(r, "")
+}
+
+// ParseRequestFor verifies the bearer token of r like ParseRequest, for
+// tokens issued by IssueFor with audience
+func (m *Manager) ParseRequestFor(r *http.Request, audience string) (jwt.Token, error) {
+	options := []jwt.ParseOption{jwt.WithKeySet(m.PublicSet())}
+	if m.issuer != "" {
+		options = append(options, jwt.WithIssuer(m.issuer), jwt.WithAudience(m.audience(audience)))
+	}
+
+	token, err := jwt.ParseRequest(r, options...)
+	if err == nil {
+		return token, nil
+	}
+
+	m.mu.RLock()
+	stale := m.now().Sub(m.lastRefresh) > minRefreshInterval
+	m.mu.RUnlock()
+	if !stale {
+		return nil, err
+	}
+
+	if refreshErr := m.Refresh(r.Context()); refreshErr != nil {
+		return nil, err
+	}
+
+	options[0] = jwt.WithKeySet(m.PublicSet())
+	return jwt.ParseRequest(r, options...)
+}
@@ -0,0 +1,229 @@
+package jwks
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"testing"
+	"time"
+
+	"budhapp.com/internal/config"
+	"budhapp.com/internal/encryption"
+	"budhapp.com/internal/repository"
+	"github.com/lestrrat-go/jwx/v3/jwt"
+)
+
+type fakeStore struct {
+	rows []repository.Jwk
+	now  func() time.Time
+}
+
+func (s *fakeStore) ListJwks(_ context.Context) ([]repository.Jwk, error) {
+	var out []repository.Jwk
+	for _, row := range s.rows {
+		if row.ExpiresAt == nil || row.ExpiresAt.After(s.now()) {
+			out = append(out, row)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (s *fakeStore) CreateJwksWithId(_ context.Context, arg repository.CreateJwksWithIdParams) (repository.Jwk, error) {
+	row := repository.Jwk{
+		ID:         arg.ID,
+		PublicKey:  arg.PublicKey,
+		PrivateKey: arg.PrivateKey,
+		CreatedAt:  s.now(),
+		ExpiresAt:  arg.ExpiresAt,
+	}
+	s.rows = append(s.rows, row)
+	return row, nil
+}
+
+func newTestManager(t *testing.T, algorithm string) (*Manager, *fakeStore, *time.Time) {
+	t.Helper()
+
+	cipher, err := encryption.NewKeyring(config.EncryptionConfig{
+		Key:        base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef")),
+		KeyVersion: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Token expiry is validated against the wall clock, so start from it
+	clock := time.Now()
+	store := &fakeStore{now: func() time.Time { return clock }}
+	m := NewManager(store, cipher, config.AuthConfig{
+		BaseURL: "http://localhost:3001",
+		JWT: config.JWTConfig{
+			Algorithm:        algorithm,
+			Expiration:       config.Duration(15 * time.Minute),
+			RotationInterval: config.Duration(24 * time.Hour),
+			RotationOverlap:  config.Duration(time.Hour),
+		},
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	m.now = store.now
+
+	return m, store, &clock
+}
+
+func bearerRequest(token string) *http.Request {
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	return r
+}
+
+func TestIssueAndVerify(t *testing.T) {
+	for _, algorithm := range []string{"EdDSA", "ES256", "RS256"} {
+		t.Run(algorithm, func(t *testing.T) {
+			m, _, _ := newTestManager(t, algorithm)
+			if err := m.EnsureSigningKey(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			signed, err := m.Issue("user-1", map[string]any{"email": "a@example.com"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			token, err := m.ParseRequest(bearerRequest(signed))
+			if err != nil {
+				t.Fatalf("token does not verify: %v", err)
+			}
+			if sub, _ := token.Subject(); sub != "user-1" {
+				t.Errorf("subject = %q, want user-1", sub)
+			}
+		})
+	}
+}
+
+func TestAudience(t *testing.T) {
+	m, _, clock := newTestManager(t, "EdDSA")
+	if err := m.EnsureSigningKey(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	share, err := m.IssueFor("share", "share:1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.ParseRequest(bearerRequest(share)); err == nil {
+		t.Error("token for another audience verifies with ParseRequest")
+	}
+	if _, err := m.ParseRequestFor(bearerRequest(share), "share"); err != nil {
+		t.Errorf("token does not verify for its audience: %v", err)
+	}
+
+	user, err := m.Issue("user-1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.ParseRequestFor(bearerRequest(user), "share"); err == nil {
+		t.Error("user token verifies for the share audience")
+	}
+
+	// Tokens of another issuer are rejected even when signed with a known key
+	token, err := jwt.NewBuilder().
+		Subject("user-1").
+		Issuer("https://other.example.com").
+		Audience([]string{"http://localhost:3001"}).
+		Expiration(clock.Add(time.Minute)).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	foreign, err := jwt.Sign(token, jwt.WithKey(m.signingAlg, m.signingKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.ParseRequest(bearerRequest(string(foreign))); err == nil {
+		t.Error("token of another issuer verifies")
+	}
+}
+
+func TestPrivateKeyIsEncryptedAtRest(t *testing.T) {
+	m, store, _ := newTestManager(t, "EdDSA")
+	if err := m.EnsureSigningKey(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(store.rows) != 1 {
+		t.Fatalf("expected 1 stored key, got %d", len(store.rows))
+	}
+	if _, err := parseStoredKey([]byte(store.rows[0].PrivateKey), "x"); err == nil {
+		t.Error("private key is stored in plaintext")
+	}
+}
+
+func TestRotationKeepsOldKeyPublished(t *testing.T) {
+	m, store, clock := newTestManager(t, "EdDSA")
+	ctx := context.Background()
+
+	if err := m.EnsureSigningKey(ctx); err != nil {
+		t.Fatal(err)
+	}
+	oldToken, err := m.Issue("user-1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Past the rotation interval but inside the overlap window
+	*clock = clock.Add(24*time.Hour + time.Minute)
+	if err := m.EnsureSigningKey(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		advance  time.Duration
+		keys     int
+		oldValid bool
+	}{
+		{"during overlap", 0, 2, true},
+		{"after overlap", time.Hour, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			*clock = clock.Add(tt.advance)
+			if err := m.Refresh(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := m.PublicSet().Len(); got != tt.keys {
+				t.Errorf("published keys = %d, want %d", got, tt.keys)
+			}
+			if len(store.rows) != 2 {
+				t.Errorf("stored keys = %d, want 2", len(store.rows))
+			}
+
+			_, err := m.ParseRequest(bearerRequest(oldToken))
+			if tt.oldValid && err != nil {
+				t.Errorf("old token should verify via JWKS: %v", err)
+			}
+			if !tt.oldValid && err == nil {
+				t.Error("old token should no longer verify")
+			}
+		})
+	}
+}
+
+func TestVerificationOnlyManager(t *testing.T) {
+	m, _, _ := newTestManager(t, "EdDSA")
+	m.cipher = nil
+
+	if err := m.EnsureSigningKey(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if m.CanSign() {
+		t.Error("manager without cipher should not sign")
+	}
+	if _, err := m.Issue("user-1", nil); err != ErrSigningUnavailable {
+		t.Errorf("Issue() error = %v, want ErrSigningUnavailable", err)
+	}
+}
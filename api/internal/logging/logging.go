@@ -0,0 +1,49 @@
+// Package logging scopes a slog.Logger to each request. The request logger
+// carries the request id, the trace id and, once authenticated, the user id,
+// so handlers log with the context of their request and the request log line
+// written when the response completes has all of them.
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+type contextKey struct{}
+
+// scope holds the logger of a request; attributes found while handling the
+// request replace it
+type scope struct {
+	logger atomic.Pointer[slog.Logger]
+}
+
+// NewContext returns a context carrying logger for the request
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	s := &scope{}
+	s.logger.Store(logger)
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// FromContext returns the logger of the request, or the default logger
+// outside requests
+func FromContext(ctx context.Context) *slog.Logger {
+	if s, ok := ctx.Value(contextKey{}).(*scope); ok {
+		return s.logger.Load()
+	}
+	return slog.Default()
+}
+
+// AddAttrs adds attributes to the logger of the request, for the rest of the
+// request and its log line, such as the user id once the caller is
+// authenticated. It does nothing outside requests.
+func AddAttrs(ctx context.Context, args ...any) {
+	if s, ok := ctx.Value(contextKey{}).(*scope); ok {
+		for {
+			logger := s.logger.Load()
+			if s.logger.CompareAndSwap(logger, logger.With(args...)) {
+				return
+			}
+		}
+	}
+}
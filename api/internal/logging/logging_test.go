@@ -0,0 +1,86 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"budhapp.com/internal/middleware"
+)
+
+func TestRequests(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	handler := middleware.New(middleware.RequestID, Requests(logger)).ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		AddAttrs(r.Context(), "userId", "user-1")
+		FromContext(r.Context()).Info("handled")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("missing"))
+	})
+	req := httptest.NewRequest(http.MethodGet, "/things?page=2", nil)
+	req.Header.Set(middleware.RequestIDHeader, "req-1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	dec := json.NewDecoder(&buf)
+	var handled, request map[string]any
+	if err := dec.Decode(&handled); err != nil {
+		t.Fatal(err)
+	}
+	if err := dec.Decode(&request); err != nil {
+		t.Fatal(err)
+	}
+
+	if handled["msg"] != "handled" || handled["requestId"] != "req-1" || handled["userId"] != "user-1" {
+		t.Errorf("handler log = %v, want the request and user ids", handled)
+	}
+	want := map[string]any{
+		"msg":       "request",
+		"level":     "WARN",
+		"requestId": "req-1",
+		"userId":    "user-1",
+		"uri":       "/things?page=2",
+		"status":    float64(http.StatusNotFound),
+		"bytes":     float64(len("missing")),
+	}
+	for key, value := range want {
+		if request[key] != value {
+			t.Errorf("request log %s = %v, want %v", key, request[key], value)
+		}
+	}
+}
+
+func TestRequestsPanic(t *testing.T) {
+	var buf bytes.Buffer
+	handler := Requests(slog.New(slog.NewJSONHandler(&buf, nil)))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Requests swallowed the panic")
+			}
+		}()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+
+	var request map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &request); err != nil {
+		t.Fatal(err)
+	}
+	if request["level"] != "ERROR" || request["status"] != float64(http.StatusInternalServerError) {
+		t.Errorf("request log = %v, want a 500 error", request)
+	}
+}
+
+func TestFromContextOutsideRequests(t *testing.T) {
+	ctx := t.Context()
+	AddAttrs(ctx, "userId", "user-1")
+	if FromContext(ctx) != slog.Default() {
+		t.Error("FromContext outside requests is not the default logger")
+	}
+}
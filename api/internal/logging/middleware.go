@@ -0,0 +1,94 @@
+package logging
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"budhapp.com/internal/middleware"
+	"budhapp.com/internal/tracing"
+)
+
+// Requests returns a middleware scoping a logger to each request and logging
+// the request once the response completes, with its status, size and
+// duration. Server errors are logged at error level and client errors at
+// warn level. It runs after middleware.RequestID and tracing.Middleware,
+// whose ids the logger carries, and after middleware.RealIP.
+func Requests(logger *slog.Logger) middleware.Constructor {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			attrs := []any{"requestId", middleware.RequestIDFromContext(r.Context())}
+			if traceID := tracing.TraceID(r.Context()); traceID != "" {
+				attrs = append(attrs, "traceId", traceID)
+			}
+			ctx := NewContext(r.Context(), logger.With(attrs...))
+			rec := &responseRecorder{ResponseWriter: w}
+
+			defer func() {
+				status := rec.status
+				pv := recover()
+				switch {
+				case pv != nil && status == 0:
+					// The panic is answered with 500 upstream
+					status = http.StatusInternalServerError
+				case status == 0:
+					status = http.StatusOK
+				}
+
+				level := slog.LevelInfo
+				switch {
+				case status >= http.StatusInternalServerError:
+					level = slog.LevelError
+				case status >= http.StatusBadRequest:
+					level = slog.LevelWarn
+				}
+				FromContext(ctx).Log(ctx, level, "request",
+					"method", r.Method,
+					"uri", r.URL.RequestURI(),
+					"proto", r.Proto,
+					"ip", r.RemoteAddr,
+					"userAgent", r.UserAgent(),
+					"status", status,
+					"bytes", rec.bytes,
+					"duration", time.Since(start),
+				)
+				if pv != nil {
+					panic(pv)
+				}
+			}()
+
+			next.ServeHTTP(rec, r.WithContext(ctx))
+		})
+	}
+}
+
+// responseRecorder remembers the status code and counts the body bytes
+// written by the handler
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,50 @@
+package metrics
+
+// CounterVec is a counter partitioned by labels
+type CounterVec struct {
+	name string
+	help string
+	vec  vec[float64]
+}
+
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{name: name, help: help, vec: newVec[float64](labels)}
+}
+
+// Inc adds one to the series of the label values
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds v, which must not be negative, to the series of the label values
+func (c *CounterVec) Add(v float64, values ...string) {
+	if v < 0 {
+		panic("metrics: counters can't decrease")
+	}
+
+	c.vec.mu.Lock()
+	defer c.vec.mu.Unlock()
+
+	*c.vec.with(values) += v
+}
+
+// Value returns the count of the label values
+func (c *CounterVec) Value(values ...string) float64 {
+	c.vec.mu.Lock()
+	defer c.vec.mu.Unlock()
+
+	if v, ok := c.vec.get(values); ok {
+		return *v
+	}
+	return 0
+}
+
+func (c *CounterVec) Collect(w *Writer) {
+	c.vec.mu.Lock()
+	defer c.vec.mu.Unlock()
+
+	w.Family(c.name, TypeCounter, c.help)
+	c.vec.each(func(labels []Label, v *float64) {
+		w.Sample(c.name, labels, *v)
+	})
+}
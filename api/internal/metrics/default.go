@@ -0,0 +1,47 @@
+package metrics
+
+// Default is the registry served on /metrics
+var Default = NewRegistry()
+
+// Metrics recorded across the API
+var (
+	HTTPRequests = NewCounterVec("http_requests_total",
+		"HTTP requests by route pattern, method and status code.",
+		"route", "method", "status")
+	HTTPRequestDuration = NewHistogramVec("http_request_duration_seconds",
+		"HTTP request latency by route pattern and method.",
+		DefaultBuckets, "route", "method")
+
+	WebhookEvents = NewCounterVec("webhook_events_total",
+		"Webhook events by provider, event type and outcome.",
+		"provider", "event", "outcome")
+
+	SignIns = NewCounterVec("auth_sign_ins_total",
+		"Sign-in attempts by route pattern and result: success or the reason of the failure.",
+		"route", "result")
+
+	// AITokens is recorded with RecordAITokens
+	AITokens = NewCounterVec("ai_tokens_total",
+		"AI tokens used by model and kind.",
+		"model", "kind")
+)
+
+// Kinds of AI tokens
+const (
+	AITokensInput  = "input"
+	AITokensOutput = "output"
+)
+
+// RecordAITokens counts n tokens of kind, AITokensInput or AITokensOutput,
+// used by model. AI call sites record the usage the provider reports after
+// each call.
+func RecordAITokens(model, kind string, n int) {
+	if n <= 0 {
+		return
+	}
+	AITokens.Add(float64(n), model, kind)
+}
+
+func init() {
+	Default.Register(HTTPRequests, HTTPRequestDuration, WebhookEvents, SignIns, AITokens)
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+)
+
+// DefaultBuckets are latency buckets in seconds, from 5ms to 10s
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// HistogramVec is a histogram partitioned by labels
+type HistogramVec struct {
+	name    string
+	help    string
+	buckets []float64
+	vec     vec[histogram]
+}
+
+type histogram struct {
+	// counts has one count per bucket, not cumulated, and one for +Inf
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewHistogramVec creates a histogram with the given upper bounds
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &HistogramVec{name: name, help: help, buckets: buckets, vec: newVec[histogram](labels)}
+}
+
+// Observe adds v to the series of the label values
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	h.vec.mu.Lock()
+	defer h.vec.mu.Unlock()
+
+	s := h.vec.with(values)
+	if s.counts == nil {
+		s.counts = make([]uint64, len(h.buckets)+1)
+	}
+	s.counts[sort.SearchFloat64s(h.buckets, v)]++
+	s.sum += v
+	s.count++
+}
+
+func (h *HistogramVec) Collect(w *Writer) {
+	h.vec.mu.Lock()
+	defer h.vec.mu.Unlock()
+
+	w.Family(h.name, TypeHistogram, h.help)
+	h.vec.each(func(labels []Label, s *histogram) {
+		var cumulative uint64
+		for i, count := range s.counts {
+			cumulative += count
+			le := math.Inf(1)
+			if i < len(h.buckets) {
+				le = h.buckets[i]
+			}
+			w.Sample(h.name+"_bucket", append(labels, Label{Name: "le", Value: formatValue(le)}), float64(cumulative))
+		}
+		w.Sample(h.name+"_sum", labels, s.sum)
+		w.Sample(h.name+"_count", labels, float64(s.count))
+	})
+}
+
+// Count returns the number of observations of the label values
+func (h *HistogramVec) Count(values ...string) uint64 {
+	h.vec.mu.Lock()
+	defer h.vec.mu.Unlock()
+
+	if s, ok := h.vec.get(values); ok {
+		return s.count
+	}
+	return 0
+}
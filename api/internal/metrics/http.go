@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type patternContextKey struct{}
+
+// unmatchedRoute labels requests no route matched, so unknown paths don't
+// create series
+const unmatchedRoute = "unmatched"
+
+// Instrument is a middleware counting requests and observing their latency
+// by route pattern. The pattern is recorded by RecordPattern around the mux:
+// middlewares in between copy the request, so the mux sets it on a copy.
+func Instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		pattern := new(string)
+		rec := &statusRecorder{ResponseWriter: w}
+
+		defer func() {
+			status := rec.status
+			pv := recover()
+			switch {
+			case pv != nil && status == 0:
+				// The panic is answered with 500 upstream
+				status = http.StatusInternalServerError
+			case status == 0:
+				status = http.StatusOK
+			}
+			route := *pattern
+			if route == "" {
+				route = unmatchedRoute
+			}
+			HTTPRequests.Inc(route, r.Method, strconv.Itoa(status))
+			HTTPRequestDuration.Observe(time.Since(start).Seconds(), route, r.Method)
+			if pv != nil {
+				panic(pv)
+			}
+		}()
+
+		ctx := context.WithValue(r.Context(), patternContextKey{}, pattern)
+		next.ServeHTTP(rec, r.WithContext(ctx))
+	})
+}
+
+// RecordPattern wraps the mux to report the matched pattern to Instrument
+func RecordPattern(mux http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if pattern, ok := r.Context().Value(patternContextKey{}).(*string); ok {
+				*pattern = r.Pattern
+			}
+		}()
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// statusRecorder remembers the status code written by the handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,172 @@
+// Package metrics exposes Prometheus metrics in the text exposition format.
+// It implements the few metric types the API needs instead of depending on
+// the Prometheus client library.
+package metrics
+
+import (
+	"bytes"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Metric types
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// Collector writes metric families when metrics are scraped
+type Collector interface {
+	Collect(w *Writer)
+}
+
+// CollectorFunc adapts a function to a Collector, for values read at
+// scrape time such as pool statistics
+type CollectorFunc func(w *Writer)
+
+func (f CollectorFunc) Collect(w *Writer) {
+	f(w)
+}
+
+// Registry holds the collectors served on /metrics
+type Registry struct {
+	mu         sync.RWMutex
+	collectors []Collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds collectors to the registry
+func (r *Registry) Register(collectors ...Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.collectors = append(r.collectors, collectors...)
+}
+
+// ServeHTTP writes the metrics of all collectors
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.RLock()
+	collectors := r.collectors
+	r.mu.RUnlock()
+
+	mw := &Writer{}
+	for _, c := range collectors {
+		c.Collect(mw)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(mw.buf.Bytes())
+}
+
+// Label is a label name and value of a sample
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Writer formats metric families in the text exposition format
+type Writer struct {
+	buf bytes.Buffer
+}
+
+// Family starts a metric family; its samples follow
+func (w *Writer) Family(name, typ, help string) {
+	w.buf.WriteString("# HELP " + name + " " + helpEscaper.Replace(help) + "\n")
+	w.buf.WriteString("# TYPE " + name + " " + typ + "\n")
+}
+
+// Sample writes a value of the current family
+func (w *Writer) Sample(name string, labels []Label, value float64) {
+	w.buf.WriteString(name)
+	if len(labels) > 0 {
+		w.buf.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				w.buf.WriteByte(',')
+			}
+			w.buf.WriteString(l.Name + `="` + labelEscaper.Replace(l.Value) + `"`)
+		}
+		w.buf.WriteByte('}')
+	}
+	w.buf.WriteString(" " + formatValue(value) + "\n")
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// vec holds the series of a metric by label values
+type vec[T any] struct {
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]*T
+	values map[string][]string
+}
+
+func newVec[T any](labels []string) vec[T] {
+	return vec[T]{labels: labels, series: make(map[string]*T), values: make(map[string][]string)}
+}
+
+// with returns the series of the label values, creating it on first use.
+// The caller holds mu.
+func (v *vec[T]) with(values []string) *T {
+	if len(values) != len(v.labels) {
+		panic("metrics: got " + strconv.Itoa(len(values)) + " label values, want " + strconv.Itoa(len(v.labels)))
+	}
+	key := strings.Join(values, "\xff")
+	s, ok := v.series[key]
+	if !ok {
+		s = new(T)
+		v.series[key] = s
+		v.values[key] = append([]string(nil), values...)
+	}
+	return s
+}
+
+// get returns the series of the label values if it exists. The caller
+// holds mu.
+func (v *vec[T]) get(values []string) (*T, bool) {
+	s, ok := v.series[strings.Join(values, "\xff")]
+	return s, ok
+}
+
+// each calls fn for the series sorted by label values, so the output is
+// stable. The caller holds mu.
+func (v *vec[T]) each(fn func(labels []Label, s *T)) {
+	keys := make([]string, 0, len(v.series))
+	for key := range v.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		labels := make([]Label, len(v.labels))
+		for i, name := range v.labels {
+			labels[i] = Label{Name: name, Value: v.values[key][i]}
+		}
+		fn(labels, v.series[key])
+	}
+}
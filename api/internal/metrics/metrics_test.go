@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func scrape(t *testing.T, r *Registry) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", got)
+	}
+	return rec.Body.String()
+}
+
+func TestCounterVec(t *testing.T) {
+	c := NewCounterVec("events_total", "Events by type.\nSecond line.", "type")
+	c.Inc("b")
+	c.Add(2.5, "a")
+	c.Inc(`quote"back\slash`)
+
+	r := NewRegistry()
+	r.Register(c)
+
+	want := `# HELP events_total Events by type.\nSecond line.
+# TYPE events_total counter
+events_total{type="a"} 2.5
+events_total{type="b"} 1
+events_total{type="quote\"back\\slash"} 1
+`
+	if got := scrape(t, r); got != want {
+		t.Errorf("scrape =\n%s\nwant\n%s", got, want)
+	}
+	if got := c.Value("a"); got != 2.5 {
+		t.Errorf("Value(a) = %v, want 2.5", got)
+	}
+	if got := c.Value("missing"); got != 0 {
+		t.Errorf("Value(missing) = %v, want 0", got)
+	}
+}
+
+func TestHistogramVec(t *testing.T) {
+	h := NewHistogramVec("latency_seconds", "Latency.", []float64{1, 0.1}, "route")
+	for _, v := range []float64{0.05, 0.1, 0.5, 3} {
+		h.Observe(v, "/x")
+	}
+
+	r := NewRegistry()
+	r.Register(h)
+
+	want := `# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/x",le="0.1"} 2
+latency_seconds_bucket{route="/x",le="1"} 3
+latency_seconds_bucket{route="/x",le="+Inf"} 4
+latency_seconds_sum{route="/x"} 3.65
+latency_seconds_count{route="/x"} 4
+`
+	if got := scrape(t, r); got != want {
+		t.Errorf("scrape =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestLabelCount(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Inc with missing label values did not panic")
+		}
+	}()
+	NewCounterVec("x_total", "X.", "a", "b").Inc("a")
+}
+
+func TestInstrument(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /things/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	mux.HandleFunc("GET /panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	// A middleware between Instrument and the mux copies the request
+	type key struct{}
+	copying := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), key{}, true)))
+		})
+	}
+	handler := Instrument(copying(RecordPattern(mux)))
+
+	route := "GET /things/{id}"
+	before := HTTPRequests.Value(route, http.MethodGet, "418")
+	beforeCount := HTTPRequestDuration.Count(route, http.MethodGet)
+	beforeUnmatched := HTTPRequests.Value(unmatchedRoute, http.MethodGet, "404")
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/things/1", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/things/2", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nothing", nil))
+
+	if got := HTTPRequests.Value(route, http.MethodGet, "418") - before; got != 2 {
+		t.Errorf("requests = %v, want 2", got)
+	}
+	if got := HTTPRequestDuration.Count(route, http.MethodGet) - beforeCount; got != 2 {
+		t.Errorf("observations = %v, want 2", got)
+	}
+	if got := HTTPRequests.Value(unmatchedRoute, http.MethodGet, "404") - beforeUnmatched; got != 1 {
+		t.Errorf("unmatched requests = %v, want 1", got)
+	}
+
+	beforePanic := HTTPRequests.Value("GET /panic", http.MethodGet, "500")
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Instrument swallowed the panic")
+			}
+		}()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+	}()
+	if got := HTTPRequests.Value("GET /panic", http.MethodGet, "500") - beforePanic; got != 1 {
+		t.Errorf("panicking requests = %v, want 1", got)
+	}
+}
+
+func TestRecordAITokens(t *testing.T) {
+	before := AITokens.Value("test-model", AITokensInput)
+	RecordAITokens("test-model", AITokensInput, 120)
+	RecordAITokens("test-model", AITokensInput, 0)
+	if got := AITokens.Value("test-model", AITokensInput) - before; got != 120 {
+		t.Errorf("ai_tokens_total grew by %v, want 120", got)
+	}
+	if !strings.Contains(scrape(t, Default), "# TYPE ai_tokens_total counter") {
+		t.Error("ai_tokens_total is not registered")
+	}
+}
@@ -0,0 +1,32 @@
+package metrics
+
+import "github.com/jackc/pgx/v5/pgxpool"
+
+// PoolCollector reports the statistics of a connection pool at scrape time
+func PoolCollector(pool *pgxpool.Pool) Collector {
+	return CollectorFunc(func(w *Writer) {
+		stat := pool.Stat()
+
+		gauge := func(name, help string, value float64) {
+			w.Family(name, TypeGauge, help)
+			w.Sample(name, nil, value)
+		}
+		counter := func(name, help string, value float64) {
+			w.Family(name, TypeCounter, help)
+			w.Sample(name, nil, value)
+		}
+
+		gauge("pgxpool_acquired_conns", "Connections currently in use.", float64(stat.AcquiredConns()))
+		gauge("pgxpool_idle_conns", "Idle connections.", float64(stat.IdleConns()))
+		gauge("pgxpool_constructing_conns", "Connections being established.", float64(stat.ConstructingConns()))
+		gauge("pgxpool_total_conns", "Connections open or being established.", float64(stat.TotalConns()))
+		gauge("pgxpool_max_conns", "Maximum size of the pool.", float64(stat.MaxConns()))
+		counter("pgxpool_acquires_total", "Successful connection acquires.", float64(stat.AcquireCount()))
+		counter("pgxpool_acquire_duration_seconds_total", "Time spent acquiring connections.", stat.AcquireDuration().Seconds())
+		counter("pgxpool_empty_acquires_total", "Acquires that waited for a connection.", float64(stat.EmptyAcquireCount()))
+		counter("pgxpool_canceled_acquires_total", "Acquires canceled by their context.", float64(stat.CanceledAcquireCount()))
+		counter("pgxpool_new_conns_total", "Connections opened.", float64(stat.NewConnsCount()))
+		counter("pgxpool_max_lifetime_destroys_total", "Connections closed for reaching their maximum lifetime.", float64(stat.MaxLifetimeDestroyCount()))
+		counter("pgxpool_max_idle_destroys_total", "Connections closed for reaching their maximum idle time.", float64(stat.MaxIdleDestroyCount()))
+	})
+}
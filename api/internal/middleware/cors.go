@@ -2,59 +2,137 @@ package middleware
 
 import (
 	"net/http"
-	"os"
+	"strconv"
 	"strings"
+
+	"budhapp.com/internal/config"
 )
 
-// allowedOrigins returns the list of allowed CORS origins based on environment
-func allowedOrigins() []string {
-	// Allow custom origins from environment
-	if origins := os.Getenv("CORS_ALLOWED_ORIGINS"); origins != "" {
-		return strings.Split(origins, ",")
-	}
+// originMatcher checks request origins against the configured ones
+type originMatcher struct {
+	any      bool
+	exact    map[string]bool
+	patterns []originPattern
+}
 
-	// Default origins for development
-	env := os.Getenv("ENVIRONMENT")
-	if env == "production" || env == "prod" {
-		// In production, require explicit CORS_ALLOWED_ORIGINS
-		return []string{}
-	}
+// originPattern is an origin with a wildcard first label, split around it:
+// "https://*.example.com" is {"https://", ".example.com"}
+type originPattern struct {
+	prefix string
+	suffix string
+}
 
-	// Development defaults
-	return []string{
-		"http://localhost:3000",
-		"http://localhost:5173",
-		"http://127.0.0.1:3000",
-		"http://127.0.0.1:5173",
+func newOriginMatcher(origins []string) originMatcher {
+	m := originMatcher{exact: make(map[string]bool)}
+	for _, origin := range origins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+		switch {
+		case origin == "*":
+			m.any = true
+		case strings.Contains(origin, "*"):
+			prefix, suffix, _ := strings.Cut(origin, "*")
+			m.patterns = append(m.patterns, originPattern{prefix: prefix, suffix: suffix})
+		default:
+			m.exact[origin] = true
+		}
 	}
+	return m
 }
 
-// isOriginAllowed checks if the origin is in the allowed list
-func isOriginAllowed(origin string, allowed []string) bool {
-	for _, o := range allowed {
-		if strings.TrimSpace(o) == origin {
+// allowed reports whether the origin matches. A wildcard stands for one or
+// more subdomain labels, never for the domain itself.
+func (m originMatcher) allowed(origin string) bool {
+	if m.any {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if m.exact[origin] {
+		return true
+	}
+	for _, p := range m.patterns {
+		if len(origin) <= len(p.prefix)+len(p.suffix) || !strings.HasPrefix(origin, p.prefix) || !strings.HasSuffix(origin, p.suffix) {
+			continue
+		}
+		label := origin[len(p.prefix) : len(origin)-len(p.suffix)]
+		if !strings.ContainsAny(label, "/:@?#") && !strings.HasPrefix(label, ".") && !strings.HasSuffix(label, ".") {
 			return true
 		}
 	}
 	return false
 }
 
-// CORS returns a middleware that handles CORS headers with origin validation
-func CORS(next http.Handler) http.Handler {
-	allowed := allowedOrigins()
-
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		origin := r.Header.Get("Origin")
-		if origin != "" && isOriginAllowed(origin, allowed) {
-			w.Header().Set("Access-Control-Allow-Origin", origin)
-			w.Header().Set("Access-Control-Allow-Credentials", "true")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		}
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-		next.ServeHTTP(w, r)
-	})
+// CORS returns a middleware that answers preflight requests and adds CORS
+// headers to responses for the allowed origins. Preflights from other
+// origins are refused with 403; their other requests get no CORS headers,
+// so browsers block the response.
+func CORS(cfg config.CORSConfig) Constructor {
+	origins := newOriginMatcher(cfg.AllowedOrigins)
+	methods := strings.Join(cfg.AllowedMethods, ", ")
+	exposed := strings.Join(cfg.ExposedHeaders, ", ")
+	anyHeader := false
+	for _, h := range cfg.AllowedHeaders {
+		anyHeader = anyHeader || h == "*"
+	}
+	headers := strings.Join(cfg.AllowedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Std().Seconds()))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The response depends on the origin even when it is refused,
+			// so caches must not share it between origins
+			w.Header().Add("Vary", "Origin")
+
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			allowed := origins.allowed(origin)
+			if preflight {
+				w.Header().Add("Vary", "Access-Control-Request-Method")
+				w.Header().Add("Vary", "Access-Control-Request-Headers")
+				if !allowed {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+			}
+			if !allowed {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Credentials can't be sent to "*", so the origin is echoed
+			if origins.any && !cfg.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+			if cfg.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if !preflight {
+				if exposed != "" {
+					w.Header().Set("Access-Control-Expose-Headers", exposed)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Access-Control-Allow-Methods", methods)
+			if anyHeader {
+				if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+					w.Header().Set("Access-Control-Allow-Headers", requested)
+				}
+			} else if headers != "" {
+				w.Header().Set("Access-Control-Allow-Headers", headers)
+			}
+			if cfg.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", maxAge)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
 }
@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"budhapp.com/internal/config"
+)
+
+func TestOriginMatcher(t *testing.T) {
+	m := newOriginMatcher([]string{"https://app.example.com", "https://*.example.org"})
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://app.example.com", true},
+		{"https://APP.example.com", true},
+		{"http://app.example.com", false},
+		{"https://app.example.com:8443", false},
+		{"https://evil.com", false},
+		{"https://a.example.org", true},
+		{"https://a.b.example.org", true},
+		{"https://example.org", false},
+		{"https://.example.org", false},
+		{"https://evil.com/.example.org", false},
+		{"https://evilexample.org", false},
+	}
+	for _, tt := range tests {
+		if got := m.allowed(tt.origin); got != tt.want {
+			t.Errorf("allowed(%q) = %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+}
+
+func newCORSHandler(cfg config.CORSConfig) http.Handler {
+	return CORS(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+}
+
+var testCORSConfig = config.CORSConfig{
+	AllowedOrigins:   []string{"https://app.example.com"},
+	AllowedMethods:   []string{"GET", "POST"},
+	AllowedHeaders:   []string{"Content-Type", "Authorization"},
+	ExposedHeaders:   []string{"Retry-After"},
+	AllowCredentials: true,
+	MaxAge:           config.Duration(10 * time.Minute),
+}
+
+func TestCORSPreflight(t *testing.T) {
+	handler := newCORSHandler(testCORSConfig)
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/projects/x", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("preflight status = %d, want 204", rec.Code)
+	}
+	want := map[string]string{
+		"Access-Control-Allow-Origin":      "https://app.example.com",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Allow-Methods":     "GET, POST",
+		"Access-Control-Allow-Headers":     "Content-Type, Authorization",
+		"Access-Control-Max-Age":           "600",
+	}
+	for header, value := range want {
+		if got := rec.Header().Get(header); got != value {
+			t.Errorf("%s = %q, want %q", header, got, value)
+		}
+	}
+	if vary := rec.Header().Values("Vary"); len(vary) == 0 || vary[0] != "Origin" {
+		t.Errorf("Vary = %v, want Origin first", vary)
+	}
+
+	req.Header.Set("Origin", "https://evil.com")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("preflight from another origin = %d %v, want 403 without CORS headers", rec.Code, rec.Header())
+	}
+	if rec.Header().Get("Vary") != "Origin" {
+		t.Errorf("refused preflight Vary = %v, want Origin", rec.Header().Values("Vary"))
+	}
+}
+
+func TestCORSRequest(t *testing.T) {
+	handler := newCORSHandler(testCORSConfig)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusTeapot {
+		t.Fatalf("request status = %d, want the handler's", rec.Code)
+	}
+	if rec.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" || rec.Header().Get("Access-Control-Expose-Headers") != "Retry-After" {
+		t.Errorf("request headers = %v", rec.Header())
+	}
+
+	req.Header.Set("Origin", "https://evil.com")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusTeapot || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("request from another origin = %d %v, want the handler's without CORS headers", rec.Code, rec.Header())
+	}
+
+	// OPTIONS without Access-Control-Request-Method is not a preflight
+	req = httptest.NewRequest(http.MethodOptions, "/", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusTeapot {
+		t.Errorf("plain OPTIONS status = %d, want the handler's", rec.Code)
+	}
+}
+
+func TestCORSWildcard(t *testing.T) {
+	cfg := testCORSConfig
+	cfg.AllowedOrigins = []string{"*"}
+	cfg.AllowedHeaders = []string{"*"}
+	cfg.AllowCredentials = false
+
+	req := httptest.NewRequest(http.MethodOptions, "/", nil)
+	req.Header.Set("Origin", "https://anywhere.test")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	req.Header.Set("Access-Control-Request-Headers", "X-Custom")
+	rec := httptest.NewRecorder()
+	newCORSHandler(cfg).ServeHTTP(rec, req)
+
+	if rec.Header().Get("Access-Control-Allow-Origin") != "*" || rec.Header().Get("Access-Control-Allow-Headers") != "X-Custom" {
+		t.Errorf("wildcard preflight headers = %v", rec.Header())
+	}
+	if rec.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Error("wildcard without credentials sent Access-Control-Allow-Credentials")
+	}
+}
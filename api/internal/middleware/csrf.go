@@ -0,0 +1,188 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"budhapp.com/internal/apierror"
+	"budhapp.com/internal/config"
+)
+
+const (
+	// CSRFCookieName is the cookie holding the double-submit token. It is
+	// readable by scripts so clients can echo it back.
+	CSRFCookieName = "csrf_token"
+	// CSRFHeaderName is the header echoing the double-submit token
+	CSRFHeaderName = "X-CSRF-Token"
+	// CSRFFormField is the form field echoing the double-submit token
+	CSRFFormField = "csrf_token"
+)
+
+// pathOrigins are the origins trusted for the routes under a path prefix
+type pathOrigins struct {
+	prefix  string
+	origins originMatcher
+}
+
+// CSRF returns a middleware protecting cookie-authenticated requests against
+// cross-site request forgery. Unsafe requests carrying cookies must come from
+// a trusted origin, as told by their Origin or, failing that, Referer header.
+// With double submit enabled, form posts must also echo the CSRF cookie.
+// Requests without cookies, like bearer-authenticated API calls, and the
+// exempt paths are not checked.
+func CSRF(cfg config.CSRFConfig, trustedOrigins []string, secure bool) Constructor {
+	trusted := newOriginMatcher(normalizeOrigins(trustedOrigins))
+	// Longest prefixes first, so the most specific group is used
+	var groups []pathOrigins
+	for prefix, origins := range cfg.PathOrigins {
+		groups = append(groups, pathOrigins{prefix: prefix, origins: newOriginMatcher(normalizeOrigins(origins))})
+	}
+	sort.Slice(groups, func(i, j int) bool { return len(groups[i].prefix) > len(groups[j].prefix) })
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, prefix := range cfg.ExemptPaths {
+				if strings.HasPrefix(r.URL.Path, prefix) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			if isSafeMethod(r.Method) {
+				if cfg.DoubleSubmit {
+					issueCSRFToken(w, r, secure)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(r.Cookies()) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			origin := requestOrigin(r)
+			if origin == "" {
+				respondForbidden(w, r, "MISSING_OR_NULL_ORIGIN", "Missing or null Origin")
+				return
+			}
+			if !trusted.allowed(origin) && !groupAllows(groups, r.URL.Path, origin) {
+				respondForbidden(w, r, "INVALID_ORIGIN", "Invalid origin")
+				return
+			}
+
+			if cfg.DoubleSubmit && isFormPost(r) && !validCSRFToken(r) {
+				respondForbidden(w, r, "INVALID_CSRF_TOKEN", "Invalid CSRF token")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// requestOrigin returns the origin of the request, from the Origin header or
+// the Referer when browsers leave it out. It is empty when neither is usable.
+func requestOrigin(r *http.Request) string {
+	if origin := r.Header.Get("Origin"); origin != "" {
+		if origin == "null" {
+			return ""
+		}
+		return origin
+	}
+	return originOf(r.Header.Get("Referer"))
+}
+
+// originOf returns the scheme and host of an absolute URL
+func originOf(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// normalizeOrigins reduces URLs to their origin, so trusted origins may be
+// configured with a path like better-auth allows
+func normalizeOrigins(origins []string) []string {
+	normalized := make([]string, 0, len(origins))
+	for _, origin := range origins {
+		origin = strings.TrimSpace(origin)
+		if origin == "" || origin == "*" {
+			continue
+		}
+		if o := originOf(origin); o != "" {
+			origin = o
+		}
+		normalized = append(normalized, origin)
+	}
+	return normalized
+}
+
+func groupAllows(groups []pathOrigins, path, origin string) bool {
+	for _, g := range groups {
+		if strings.HasPrefix(path, g.prefix) {
+			return g.origins.allowed(origin)
+		}
+	}
+	return false
+}
+
+func isFormPost(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data"
+}
+
+// validCSRFToken compares the CSRF cookie with the header or, for URL-encoded
+// forms, the form field. Multipart bodies are left for the handler to parse
+// within its own size limit, so they must use the header.
+func validCSRFToken(r *http.Request) bool {
+	cookie, err := r.Cookie(CSRFCookieName)
+	if err != nil || cookie.Value == "" {
+		return false
+	}
+	token := r.Header.Get(CSRFHeaderName)
+	if token == "" && !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+		token = r.PostFormValue(CSRFFormField)
+	}
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(cookie.Value)) == 1
+}
+
+// issueCSRFToken sets the CSRF cookie when the request doesn't carry one
+func issueCSRFToken(w http.ResponseWriter, r *http.Request, secure bool) {
+	if cookie, err := r.Cookie(CSRFCookieName); err == nil && cookie.Value != "" {
+		return
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     CSRFCookieName,
+		Value:    base64.RawURLEncoding.EncodeToString(b),
+		Path:     "/",
+		Secure:   secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func respondForbidden(w http.ResponseWriter, r *http.Request, code, message string) {
+	apierror.Write(w, r, apierror.New(http.StatusForbidden, code, message))
+}
@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"budhapp.com/internal/config"
+)
+
+var testCSRFConfig = config.CSRFConfig{
+	ExemptPaths: []string{"/api/webhooks/"},
+	PathOrigins: map[string][]string{
+		"/api/share/": {"https://review.example.net"},
+	},
+}
+
+func newCSRFHandler(cfg config.CSRFConfig) http.Handler {
+	trusted := []string{"https://auth.example.com", "https://app.example.com/callback", "https://*.example.org"}
+	return CSRF(cfg, trusted, true)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+}
+
+func TestCSRFOrigin(t *testing.T) {
+	handler := newCSRFHandler(testCSRFConfig)
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		origin  string
+		referer string
+		cookie  bool
+		want    int
+	}{
+		{"safe method", http.MethodGet, "/api/projects", "https://evil.com", "", true, http.StatusTeapot},
+		{"no cookies", http.MethodPost, "/api/projects", "https://evil.com", "", false, http.StatusTeapot},
+		{"trusted origin", http.MethodPost, "/api/projects", "https://auth.example.com", "", true, http.StatusTeapot},
+		{"trusted origin with path", http.MethodDelete, "/api/projects/x", "https://app.example.com", "", true, http.StatusTeapot},
+		{"wildcard origin", http.MethodPost, "/api/projects", "https://a.example.org", "", true, http.StatusTeapot},
+		{"untrusted origin", http.MethodPost, "/api/projects", "https://evil.com", "", true, http.StatusForbidden},
+		{"null origin", http.MethodPost, "/api/projects", "null", "", true, http.StatusForbidden},
+		{"missing origin", http.MethodPost, "/api/projects", "", "", true, http.StatusForbidden},
+		{"trusted referer", http.MethodPost, "/api/projects", "", "https://auth.example.com/sign-in?x=1", true, http.StatusTeapot},
+		{"untrusted referer", http.MethodPost, "/api/projects", "", "https://evil.com/auth.example.com", true, http.StatusForbidden},
+		{"origin wins over referer", http.MethodPost, "/api/projects", "https://evil.com", "https://auth.example.com/", true, http.StatusForbidden},
+		{"route group origin", http.MethodPost, "/api/share/token", "https://review.example.net", "", true, http.StatusTeapot},
+		{"route group origin elsewhere", http.MethodPost, "/api/projects", "https://review.example.net", "", true, http.StatusForbidden},
+		{"exempt path", http.MethodPost, "/api/webhooks/polar", "https://evil.com", "", true, http.StatusTeapot},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.referer != "" {
+				req.Header.Set("Referer", tt.referer)
+			}
+			if tt.cookie {
+				req.AddCookie(&http.Cookie{Name: "better-auth.session_token", Value: "x"})
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSRFDoubleSubmit(t *testing.T) {
+	cfg := testCSRFConfig
+	cfg.DoubleSubmit = true
+	handler := newCSRFHandler(cfg)
+
+	// Safe requests get the token cookie
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sign-in", nil))
+	var token *http.Cookie
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == CSRFCookieName {
+			token = c
+		}
+	}
+	if token == nil || token.Value == "" || token.HttpOnly || !token.Secure {
+		t.Fatalf("token cookie = %+v, want a readable secure cookie", token)
+	}
+
+	post := func(form url.Values, header string, contentType string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/projects", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("Origin", "https://auth.example.com")
+		req.AddCookie(token)
+		if header != "" {
+			req.Header.Set(CSRFHeaderName, header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	const form = "application/x-www-form-urlencoded"
+	if code := post(url.Values{CSRFFormField: {token.Value}}, "", form); code != http.StatusTeapot {
+		t.Errorf("form field: status = %d, want %d", code, http.StatusTeapot)
+	}
+	if code := post(nil, token.Value, form); code != http.StatusTeapot {
+		t.Errorf("header: status = %d, want %d", code, http.StatusTeapot)
+	}
+	if code := post(url.Values{CSRFFormField: {"wrong"}}, "", form); code != http.StatusForbidden {
+		t.Errorf("wrong token: status = %d, want %d", code, http.StatusForbidden)
+	}
+	if code := post(nil, "", form); code != http.StatusForbidden {
+		t.Errorf("missing token: status = %d, want %d", code, http.StatusForbidden)
+	}
+	if code := post(url.Values{CSRFFormField: {token.Value}}, "", "multipart/form-data; boundary=x"); code != http.StatusForbidden {
+		t.Errorf("multipart field: status = %d, want %d", code, http.StatusForbidden)
+	}
+	// JSON requests can't be sent cross-site without CORS, so they only
+	// need a trusted origin
+	if code := post(nil, "", "application/json"); code != http.StatusTeapot {
+		t.Errorf("json: status = %d, want %d", code, http.StatusTeapot)
+	}
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// RealIP returns a middleware replacing the remote address of requests sent
+// by a trusted proxy with the client address from X-Forwarded-For, so rate
+// limits, sessions and logs see the client rather than the proxy. The header
+// is read from the right, skipping trusted proxies: entries further left
+// were written by the client and can't be trusted. The port of the rewritten
+// address is 0.
+func RealIP(trusted []netip.Prefix) Constructor {
+	return func(next http.Handler) http.Handler {
+		if len(trusted) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if client, ok := clientAddr(r, trusted); ok {
+				// Shallow copy, so the caller's request is unchanged
+				r = r.WithContext(r.Context())
+				r.RemoteAddr = netip.AddrPortFrom(client, 0).String()
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientAddr returns the first untrusted address of the forwarding chain of
+// a request from a trusted proxy
+func clientAddr(r *http.Request, trusted []netip.Prefix) (netip.Addr, bool) {
+	remote, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil || !isTrusted(remote.Addr(), trusted) {
+		return netip.Addr{}, false
+	}
+
+	var hops []string
+	for _, value := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+
+	client, found := netip.Addr{}, false
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, ok := parseHop(hops[i])
+		if !ok {
+			// The chain is broken: stop at the last proxy it vouched for
+			break
+		}
+		client, found = addr, true
+		if !isTrusted(addr, trusted) {
+			break
+		}
+	}
+	return client, found
+}
+
+// parseHop parses an X-Forwarded-For entry, which some proxies write with
+// a port
+func parseHop(hop string) (netip.Addr, bool) {
+	hop = strings.TrimSpace(hop)
+	if addr, err := netip.ParseAddr(hop); err == nil {
+		return addr.Unmap(), true
+	}
+	if addrPort, err := netip.ParseAddrPort(hop); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+	return netip.Addr{}, false
+}
+
+func isTrusted(addr netip.Addr, trusted []netip.Prefix) bool {
+	addr = addr.Unmap()
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestRealIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("172.16.0.0/12"), netip.MustParsePrefix("::1/128")}
+
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded []string
+		want      string
+	}{
+		{"untrusted remote", "203.0.113.7:5000", []string{"198.51.100.1"}, "203.0.113.7:5000"},
+		{"trusted proxy", "172.18.0.3:5000", []string{"198.51.100.1"}, "198.51.100.1:0"},
+		{"spoofed entries are skipped", "172.18.0.3:5000", []string{"10.0.0.1, 198.51.100.1"}, "198.51.100.1:0"},
+		{"chain of proxies", "172.18.0.3:5000", []string{"198.51.100.1", "172.18.0.9"}, "198.51.100.1:0"},
+		{"entry with port", "[::1]:5000", []string{"[2001:db8::1]:443"}, "[2001:db8::1]:0"},
+		{"all trusted", "172.18.0.3:5000", []string{"172.18.0.2"}, "172.18.0.2:0"},
+		{"broken chain", "172.18.0.3:5000", []string{"198.51.100.1, garbage, 172.18.0.9"}, "172.18.0.9:0"},
+		{"no header", "172.18.0.3:5000", nil, "172.18.0.3:5000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := RealIP(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.RemoteAddr
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remote
+			for _, value := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Errorf("RemoteAddr = %q, want %q", got, tt.want)
+			}
+			if req.RemoteAddr != tt.remote {
+				t.Error("the caller's request was modified")
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the id that ties a request to its logs, traces and
+// audit entries
+const RequestIDHeader = "X-Request-Id"
+
+// maxRequestIDLength bounds the ids accepted from callers
+const maxRequestIDLength = 128
+
+type requestIDContextKey struct{}
+
+// RequestIDFromContext returns the id of the request
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey{}).(string)
+	return id
+}
+
+// RequestID is a middleware giving each request an id, available from
+// RequestIDFromContext and echoed in the response header. An id sent by the
+// caller, such as the proxy in front of the API, is kept when it is a short
+// token; otherwise a random UUID is used.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		ctx := context.WithValue(r.Context(), requestIDContextKey{}, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// validRequestID reports whether id is safe to log and echo: letters,
+// digits and -_.: only
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range []byte(id) {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
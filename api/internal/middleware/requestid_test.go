@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{"none", "", false},
+		{"kept", "proxy-7f3a:42", true},
+		{"too long", strings.Repeat("a", maxRequestIDLength+1), false},
+		{"unsafe characters", "id\nwith newline", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = RequestIDFromContext(r.Context())
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if got == "" || rec.Header().Get(RequestIDHeader) != got {
+				t.Fatalf("id = %q, response header %q", got, rec.Header().Get(RequestIDHeader))
+			}
+			if (got == tt.incoming) != tt.keep {
+				t.Errorf("id = %q, keep incoming %v", got, tt.keep)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"budhapp.com/internal/config"
+)
+
+var testSecurityConfig = config.SecurityConfig{
+	HSTSMaxAge:            config.Duration(365 * 24 * time.Hour),
+	HSTSIncludeSubdomains: true,
+	ReferrerPolicy:        "strict-origin-when-cross-origin",
+	FrameOptions:          "DENY",
+	PermissionsPolicy:     "camera=()",
+	ContentSecurityPolicy: "default-src 'none'; script-src 'nonce-{nonce}'",
+}
+
+// requestNonce serves a request and returns its nonce
+func requestNonce() string {
+	var nonce string
+	handler := SecurityHeaders(testSecurityConfig, false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nonce = NonceFromContext(r.Context())
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	return nonce
+}
+
+func TestSecurityHeadersByEnvironment(t *testing.T) {
+	tests := []struct {
+		environment string
+		hsts        string
+	}{
+		{"dev", ""},
+		{"staging", ""},
+		{"production", "max-age=31536000; includeSubDomains"},
+		{"prod", "max-age=31536000; includeSubDomains"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.environment, func(t *testing.T) {
+			cfg := config.Config{Environment: tt.environment, Security: testSecurityConfig}
+			var nonce string
+			handler := SecurityHeaders(cfg.Security, cfg.IsProduction())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				nonce = NonceFromContext(r.Context())
+			}))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if nonce == "" {
+				t.Fatal("no nonce in context")
+			}
+			want := map[string]string{
+				"Strict-Transport-Security": tt.hsts,
+				"X-Content-Type-Options":    "nosniff",
+				"Referrer-Policy":           "strict-origin-when-cross-origin",
+				"X-Frame-Options":           "DENY",
+				"Permissions-Policy":        "camera=()",
+				"Content-Security-Policy":   "default-src 'none'; script-src 'nonce-" + nonce + "'; frame-ancestors 'none'",
+			}
+			for name, value := range want {
+				if got := rec.Header().Get(name); got != value {
+					t.Errorf("%s = %q, want %q", name, got, value)
+				}
+			}
+		})
+	}
+}
+
+func TestSecurityHeadersNonce(t *testing.T) {
+	first, second := requestNonce(), requestNonce()
+	if first == "" || first == second {
+		t.Errorf("nonces %q and %q, want distinct nonces", first, second)
+	}
+}
+
+func TestSecurityHeadersEmpty(t *testing.T) {
+	handler := SecurityHeaders(config.SecurityConfig{HSTSMaxAge: config.Duration(time.Hour)}, true)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got := rec.Header().Get("Strict-Transport-Security"); got != "max-age=3600" {
+		t.Errorf("Strict-Transport-Security = %q, want %q", got, "max-age=3600")
+	}
+	for _, name := range []string{"Referrer-Policy", "X-Frame-Options", "Permissions-Policy", "Content-Security-Policy"} {
+		if got := rec.Header().Get(name); got != "" {
+			t.Errorf("%s = %q, want none", name, got)
+		}
+	}
+}
+
+func TestFrameAncestors(t *testing.T) {
+	tests := []struct {
+		csp, frameOptions, want string
+	}{
+		{"default-src 'none'", "SAMEORIGIN", "default-src 'none'; frame-ancestors 'self'"},
+		{"default-src 'none';", "DENY", "default-src 'none'; frame-ancestors 'none'"},
+		{"frame-ancestors https://a.example.com", "DENY", "frame-ancestors https://a.example.com"},
+		{"", "DENY", "frame-ancestors 'none'"},
+		{"default-src 'none'", "", "default-src 'none'"},
+	}
+	for _, tt := range tests {
+		if got := withFrameAncestors(tt.csp, tt.frameOptions); got != tt.want {
+			t.Errorf("withFrameAncestors(%q, %q) = %q, want %q", tt.csp, tt.frameOptions, got, tt.want)
+		}
+	}
+}
+
+func TestOverrideHeader(t *testing.T) {
+	handler := New(
+		SecurityHeaders(testSecurityConfig, true),
+		OverrideHeader("Content-Security-Policy", "style-src 'nonce-{nonce}'; sandbox"),
+		OverrideHeader("Permissions-Policy", ""),
+	).ThenFunc(func(w http.ResponseWriter, r *http.Request) {})
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	csp := rec.Header().Get("Content-Security-Policy")
+	if !strings.HasPrefix(csp, "style-src 'nonce-") || !strings.HasSuffix(csp, "'; sandbox") || strings.Contains(csp, nonceToken) {
+		t.Errorf("Content-Security-Policy = %q, want the override with a nonce", csp)
+	}
+	if got := rec.Header().Get("Permissions-Policy"); got != "" {
+		t.Errorf("Permissions-Policy = %q, want none", got)
+	}
+	if got := rec.Header().Get("X-Frame-Options"); got != "DENY" {
+		t.Errorf("X-Frame-Options = %q, want DENY", got)
+	}
+}
@@ -0,0 +1,136 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"golang.org/x/oauth2"
+)
+
+const githubAPIURL = "https://api.github.com"
+
+// GitHubProvider signs users in with GitHub. GitHub is not an OpenID Connect
+// provider, so the profile and verified email come from the REST API.
+type GitHubProvider struct {
+	config *oauth2.Config
+	client *http.Client
+	apiURL string
+}
+
+func NewGitHubProvider(clientID, clientSecret string, scopes []string, redirectURL string, client *http.Client) *GitHubProvider {
+	if len(scopes) == 0 {
+		scopes = []string{"read:user", "user:email"}
+	}
+
+	return &GitHubProvider{
+		config: &oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			Scopes:       scopes,
+			RedirectURL:  redirectURL,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  "https://github.com/login/oauth/authorize",
+				TokenURL: "https://github.com/login/oauth/access_token",
+			},
+		},
+		client: client,
+		apiURL: githubAPIURL,
+	}
+}
+
+func (p *GitHubProvider) ID() string {
+	return "github"
+}
+
+func (p *GitHubProvider) AuthCodeURL(_ context.Context, state, verifier, _ string) (string, error) {
+	return p.config.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier)), nil
+}
+
+func (p *GitHubProvider) Exchange(ctx context.Context, code, verifier string) (*Tokens, error) {
+	return exchange(ctx, p.config, p.client, code, verifier)
+}
+
+// Refresh only applies to GitHub Apps with expiring user tokens; OAuth App
+// tokens do not expire and have no refresh token
+func (p *GitHubProvider) Refresh(ctx context.Context, refreshToken string) (*Tokens, error) {
+	return refresh(ctx, p.config, p.client, refreshToken)
+}
+
+type githubUser struct {
+	ID        int64  `json:"id"`
+	Login     string `json:"login"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+type githubEmail struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+func (p *GitHubProvider) UserInfo(ctx context.Context, tokens *Tokens, _ string) (*UserInfo, error) {
+	var user githubUser
+	if err := p.get(ctx, tokens.AccessToken, "/user", &user); err != nil {
+		return nil, err
+	}
+
+	var emails []githubEmail
+	if err := p.get(ctx, tokens.AccessToken, "/user/emails", &emails); err != nil {
+		return nil, err
+	}
+
+	info := &UserInfo{
+		ID:    strconv.FormatInt(user.ID, 10),
+		Name:  user.Name,
+		Image: user.AvatarURL,
+	}
+	if info.Name == "" {
+		info.Name = user.Login
+	}
+
+	// Prefer the primary address; the public profile email may be unverified
+	for _, e := range emails {
+		if e.Primary {
+			info.Email, info.EmailVerified = e.Email, e.Verified
+			break
+		}
+	}
+	if info.Email == "" {
+		for _, e := range emails {
+			if e.Email == user.Email {
+				info.Email, info.EmailVerified = e.Email, e.Verified
+			}
+		}
+	}
+	if info.Email == "" {
+		return nil, ErrNoEmail
+	}
+
+	return info, nil
+}
+
+func (p *GitHubProvider) get(ctx context.Context, accessToken, path string, dst any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.apiURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("github request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("github %s returned status %d", path, resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(dst)
+}
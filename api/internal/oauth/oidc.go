@@ -0,0 +1,252 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/lestrrat-go/jwx/v3/jwk"
+	"github.com/lestrrat-go/jwx/v3/jws"
+	"github.com/lestrrat-go/jwx/v3/jwt"
+	"golang.org/x/oauth2"
+)
+
+const GoogleIssuer = "https://accounts.google.com"
+
+// discoveryDocument is the subset of the OpenID Provider Metadata we use
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// OIDCProvider is a generic OpenID Connect provider configured through
+// discovery. Discovery runs lazily on first use so an unreachable issuer does
+// not prevent the server from starting.
+type OIDCProvider struct {
+	id           string
+	issuer       string
+	clientID     string
+	clientSecret string
+	scopes       []string
+	redirectURL  string
+	client       *http.Client
+
+	mu        sync.Mutex
+	discovery *discoveryDocument
+	config    *oauth2.Config
+}
+
+func NewOIDCProvider(id, issuer, clientID, clientSecret string, scopes []string, redirectURL string, client *http.Client) *OIDCProvider {
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	}
+
+	return &OIDCProvider{
+		id:           id,
+		issuer:       strings.TrimSuffix(issuer, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		scopes:       scopes,
+		redirectURL:  redirectURL,
+		client:       client,
+	}
+}
+
+func (p *OIDCProvider) ID() string {
+	return p.id
+}
+
+// discover fetches and caches the provider metadata
+func (p *OIDCProvider) discover(ctx context.Context) (*discoveryDocument, *oauth2.Config, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, p.config, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("oidc discovery failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("oidc discovery returned status %d", resp.StatusCode)
+	}
+
+	var doc discoveryDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, nil, fmt.Errorf("invalid oidc discovery document: %w", err)
+	}
+	if strings.TrimSuffix(doc.Issuer, "/") != p.issuer {
+		return nil, nil, fmt.Errorf("oidc issuer mismatch: got %q, want %q", doc.Issuer, p.issuer)
+	}
+
+	p.discovery = &doc
+	p.config = &oauth2.Config{
+		ClientID:     p.clientID,
+		ClientSecret: p.clientSecret,
+		Scopes:       p.scopes,
+		RedirectURL:  p.redirectURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  doc.AuthorizationEndpoint,
+			TokenURL: doc.TokenEndpoint,
+		},
+	}
+
+	return p.discovery, p.config, nil
+}
+
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, state, verifier, nonce string) (string, error) {
+	_, cfg, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return cfg.AuthCodeURL(state,
+		oauth2.S256ChallengeOption(verifier),
+		oauth2.SetAuthURLParam("nonce", nonce),
+	), nil
+}
+
+func (p *OIDCProvider) Exchange(ctx context.Context, code, verifier string) (*Tokens, error) {
+	_, cfg, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return exchange(ctx, cfg, p.client, code, verifier)
+}
+
+func (p *OIDCProvider) Refresh(ctx context.Context, refreshToken string) (*Tokens, error) {
+	_, cfg, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return refresh(ctx, cfg, p.client, refreshToken)
+}
+
+// oidcClaims are the standard claims read from the id token and userinfo endpoint
+type oidcClaims struct {
+	Subject       string `json:"sub"`
+	Email         string `json:"email"`
+	EmailVerified any    `json:"email_verified"`
+	Name          string `json:"name"`
+	Picture       string `json:"picture"`
+}
+
+// emailVerified handles providers that encode the claim as a string
+func (c oidcClaims) emailVerified() bool {
+	switch v := c.EmailVerified.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
+
+func (p *OIDCProvider) UserInfo(ctx context.Context, tokens *Tokens, nonce string) (*UserInfo, error) {
+	doc, _, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if tokens.IDToken == "" {
+		return nil, fmt.Errorf("%w: missing id_token", ErrInvalidIDToken)
+	}
+
+	claims, err := p.verifyIDToken(ctx, doc, tokens.IDToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	// Some providers only put the profile in the userinfo response
+	if claims.Email == "" && doc.UserinfoEndpoint != "" {
+		var userinfo oidcClaims
+		if err := p.fetchUserinfo(ctx, doc.UserinfoEndpoint, tokens.AccessToken, &userinfo); err != nil {
+			return nil, err
+		}
+		if userinfo.Subject != claims.Subject {
+			return nil, fmt.Errorf("%w: userinfo subject mismatch", ErrInvalidIDToken)
+		}
+		claims = userinfo
+	}
+
+	if claims.Email == "" {
+		return nil, ErrNoEmail
+	}
+
+	return &UserInfo{
+		ID:            claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.emailVerified(),
+		Name:          claims.Name,
+		Image:         claims.Picture,
+	}, nil
+}
+
+// verifyIDToken checks signature, issuer, audience, expiry and nonce
+func (p *OIDCProvider) verifyIDToken(ctx context.Context, doc *discoveryDocument, idToken, nonce string) (oidcClaims, error) {
+	keys, err := jwk.Fetch(ctx, doc.JWKSURI, jwk.WithHTTPClient(p.client))
+	if err != nil {
+		return oidcClaims{}, fmt.Errorf("failed to fetch provider jwks: %w", err)
+	}
+
+	token, err := jwt.Parse([]byte(idToken),
+		jwt.WithKeySet(keys, jws.WithInferAlgorithmFromKey(true)),
+		jwt.WithIssuer(doc.Issuer),
+		jwt.WithAudience(p.clientID),
+	)
+	if err != nil {
+		return oidcClaims{}, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	var tokenNonce string
+	_ = token.Get("nonce", &tokenNonce)
+	if nonce != "" && tokenNonce != nonce {
+		return oidcClaims{}, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	var claims oidcClaims
+	claims.Subject, _ = token.Subject()
+	_ = token.Get("email", &claims.Email)
+	_ = token.Get("name", &claims.Name)
+	_ = token.Get("picture", &claims.Picture)
+	_ = token.Get("email_verified", &claims.EmailVerified)
+
+	return claims, nil
+}
+
+func (p *OIDCProvider) fetchUserinfo(ctx context.Context, endpoint, accessToken string, dst *oidcClaims) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("userinfo request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("userinfo returned status %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(dst)
+}
@@ -0,0 +1,192 @@
+package oauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v3/jwa"
+	"github.com/lestrrat-go/jwx/v3/jwk"
+	"github.com/lestrrat-go/jwx/v3/jwt"
+)
+
+// fakeOIDC is a minimal OpenID provider issuing id tokens for a fixed subject
+type fakeOIDC struct {
+	t        *testing.T
+	server   *httptest.Server
+	key      jwk.Key
+	audience string
+	nonce    string
+	email    string
+}
+
+func newFakeOIDC(t *testing.T) *fakeOIDC {
+	t.Helper()
+
+	raw, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := jwk.Import(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key.Set(jwk.KeyIDKey, "test-key")
+	key.Set(jwk.AlgorithmKey, jwa.ES256())
+
+	f := &fakeOIDC{t: t, key: key, audience: "client-id", email: "Jane@Example.com"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 f.server.URL,
+			"authorization_endpoint": f.server.URL + "/authorize",
+			"token_endpoint":         f.server.URL + "/token",
+			"userinfo_endpoint":      f.server.URL + "/userinfo",
+			"jwks_uri":               f.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		public, _ := key.PublicKey()
+		set := jwk.NewSet()
+		set.AddKey(public)
+		json.NewEncoder(w).Encode(set)
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("code") != "good-code" || r.Form.Get("code_verifier") == "" {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"scope":        "openid email profile",
+			"id_token":     f.idToken(),
+		})
+	})
+
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeOIDC) idToken() string {
+	tok, err := jwt.NewBuilder().
+		Issuer(f.server.URL).
+		Audience([]string{f.audience}).
+		Subject("subject-1").
+		IssuedAt(time.Now()).
+		Expiration(time.Now().Add(time.Hour)).
+		Claim("nonce", f.nonce).
+		Claim("email", f.email).
+		Claim("email_verified", true).
+		Claim("name", "Jane").
+		Build()
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	signed, err := jwt.Sign(tok, jwt.WithKey(jwa.ES256(), f.key))
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return string(signed)
+}
+
+func TestOIDCProvider(t *testing.T) {
+	tests := []struct {
+		name     string
+		audience string
+		nonce    string
+		wantErr  error
+	}{
+		{name: "valid id token", audience: "client-id", nonce: "nonce-1"},
+		{name: "wrong audience", audience: "other-client", nonce: "nonce-1", wantErr: ErrInvalidIDToken},
+		{name: "nonce mismatch", audience: "client-id", nonce: "replayed", wantErr: ErrInvalidIDToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			fake := newFakeOIDC(t)
+			fake.audience = tt.audience
+			fake.nonce = tt.nonce
+
+			p := NewOIDCProvider("test", fake.server.URL, "client-id", "secret", nil, "http://localhost/callback", fake.server.Client())
+
+			authURL, err := p.AuthCodeURL(ctx, "state-1", GenerateVerifier(), "nonce-1")
+			if err != nil {
+				t.Fatalf("AuthCodeURL() error = %v", err)
+			}
+			u, _ := url.Parse(authURL)
+			q := u.Query()
+			if q.Get("state") != "state-1" || q.Get("nonce") != "nonce-1" || q.Get("code_challenge_method") != "S256" {
+				t.Errorf("AuthCodeURL() = %s, missing state, nonce or PKCE challenge", authURL)
+			}
+
+			tokens, err := p.Exchange(ctx, "good-code", GenerateVerifier())
+			if err != nil {
+				t.Fatalf("Exchange() error = %v", err)
+			}
+
+			info, err := p.UserInfo(ctx, tokens, "nonce-1")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("UserInfo() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UserInfo() error = %v", err)
+			}
+			if info.ID != "subject-1" || info.Email != "Jane@Example.com" || !info.EmailVerified || info.Name != "Jane" {
+				t.Errorf("UserInfo() = %+v", info)
+			}
+		})
+	}
+}
+
+func TestOIDCProviderRejectsBadCode(t *testing.T) {
+	fake := newFakeOIDC(t)
+	p := NewOIDCProvider("test", fake.server.URL, "client-id", "secret", nil, "http://localhost/callback", fake.server.Client())
+
+	if _, err := p.Exchange(context.Background(), "bad-code", GenerateVerifier()); err == nil {
+		t.Fatal("Exchange() with invalid code succeeded")
+	}
+}
+
+func TestGitHubProviderUserInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/user":
+			json.NewEncoder(w).Encode(map[string]any{"id": 42, "login": "jane", "email": "public@example.com"})
+		case "/user/emails":
+			json.NewEncoder(w).Encode([]map[string]any{
+				{"email": "public@example.com", "primary": false, "verified": false},
+				{"email": "jane@example.com", "primary": true, "verified": true},
+			})
+		}
+	}))
+	defer server.Close()
+
+	p := NewGitHubProvider("client-id", "secret", nil, "http://localhost/callback", server.Client())
+	p.apiURL = server.URL
+
+	info, err := p.UserInfo(context.Background(), &Tokens{AccessToken: "access"}, "")
+	if err != nil {
+		t.Fatalf("UserInfo() error = %v", err)
+	}
+	if info.ID != "42" || info.Email != "jane@example.com" || !info.EmailVerified || info.Name != "jane" {
+		t.Errorf("UserInfo() = %+v", info)
+	}
+}
@@ -0,0 +1,107 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+var (
+	ErrProviderNotFound = errors.New("oauth provider not found")
+	ErrNoEmail          = errors.New("provider did not return an email address")
+	ErrInvalidIDToken   = errors.New("invalid id token")
+)
+
+// UserInfo is the normalized profile returned by a provider
+type UserInfo struct {
+	ID            string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Image         string
+}
+
+// Tokens are the credentials returned by a provider's token endpoint
+type Tokens struct {
+	AccessToken           string
+	RefreshToken          string
+	IDToken               string
+	Scope                 string
+	AccessTokenExpiresAt  *time.Time
+	RefreshTokenExpiresAt *time.Time
+}
+
+// Provider is an OAuth 2.0 identity provider using the authorization code flow with PKCE
+type Provider interface {
+	// ID is the provider id stored in account.providerId
+	ID() string
+	// AuthCodeURL returns the URL the user is redirected to for consent
+	AuthCodeURL(ctx context.Context, state, verifier, nonce string) (string, error)
+	// Exchange trades an authorization code for tokens
+	Exchange(ctx context.Context, code, verifier string) (*Tokens, error)
+	// UserInfo fetches the profile of the token owner
+	UserInfo(ctx context.Context, tokens *Tokens, nonce string) (*UserInfo, error)
+	// Refresh obtains a new access token with a refresh token
+	Refresh(ctx context.Context, refreshToken string) (*Tokens, error)
+}
+
+// GenerateVerifier returns a new PKCE code verifier
+func GenerateVerifier() string {
+	return oauth2.GenerateVerifier()
+}
+
+// tokensFromOAuth2 converts an oauth2.Token, reading the provider specific extras
+func tokensFromOAuth2(t *oauth2.Token) *Tokens {
+	tokens := &Tokens{
+		AccessToken:  t.AccessToken,
+		RefreshToken: t.RefreshToken,
+	}
+
+	if !t.Expiry.IsZero() {
+		expiry := t.Expiry
+		tokens.AccessTokenExpiresAt = &expiry
+	}
+	if idToken, ok := t.Extra("id_token").(string); ok {
+		tokens.IDToken = idToken
+	}
+	if scope, ok := t.Extra("scope").(string); ok {
+		tokens.Scope = scope
+	}
+
+	// GitHub apps return the refresh token lifetime in seconds
+	switch v := t.Extra("refresh_token_expires_in").(type) {
+	case float64:
+		expiry := time.Now().Add(time.Duration(v) * time.Second)
+		tokens.RefreshTokenExpiresAt = &expiry
+	}
+
+	return tokens
+}
+
+// exchange runs the code exchange with the PKCE verifier using client for HTTP calls
+func exchange(ctx context.Context, cfg *oauth2.Config, client *http.Client, code, verifier string) (*Tokens, error) {
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, client)
+
+	token, err := cfg.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, err
+	}
+
+	return tokensFromOAuth2(token), nil
+}
+
+// refresh runs the refresh token grant. Providers that do not rotate refresh
+// tokens omit it from the response, in which case the old one is kept.
+func refresh(ctx context.Context, cfg *oauth2.Config, client *http.Client, refreshToken string) (*Tokens, error) {
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, client)
+
+	token, err := cfg.TokenSource(ctx, &oauth2.Token{RefreshToken: refreshToken}).Token()
+	if err != nil {
+		return nil, err
+	}
+
+	return tokensFromOAuth2(token), nil
+}
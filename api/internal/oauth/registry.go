@@ -0,0 +1,69 @@
+package oauth
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"budhapp.com/internal/config"
+	"budhapp.com/internal/tracing"
+)
+
+// Registry holds the configured social providers by id
+type Registry struct {
+	providers map[string]Provider
+}
+
+// NewRegistry builds the providers from the auth configuration. The callback
+// URL of each provider is {baseURL}/api/auth/callback/{id}.
+func NewRegistry(cfg config.AuthConfig) (*Registry, error) {
+	client := &http.Client{Timeout: 10 * time.Second, Transport: tracing.Transport(nil)}
+	registry := &Registry{providers: make(map[string]Provider)}
+
+	for id, p := range cfg.SocialProviders {
+		redirectURL := strings.TrimSuffix(cfg.BaseURL, "/") + "/api/auth/callback/" + id
+
+		switch {
+		case id == "github":
+			registry.providers[id] = NewGitHubProvider(p.ClientID, p.ClientSecret, p.Scopes, redirectURL, client)
+		case id == "google":
+			issuer := p.Issuer
+			if issuer == "" {
+				issuer = GoogleIssuer
+			}
+			registry.providers[id] = NewOIDCProvider(id, issuer, p.ClientID, p.ClientSecret, p.Scopes, redirectURL, client)
+		case p.Issuer != "":
+			registry.providers[id] = NewOIDCProvider(id, p.Issuer, p.ClientID, p.ClientSecret, p.Scopes, redirectURL, client)
+		default:
+			return nil, fmt.Errorf("social provider %q requires an issuer", id)
+		}
+	}
+
+	return registry, nil
+}
+
+// Register adds or replaces a provider
+func (r *Registry) Register(p Provider) {
+	r.providers[p.ID()] = p
+}
+
+// Get returns the provider with the given id
+func (r *Registry) Get(id string) (Provider, error) {
+	p, ok := r.providers[id]
+	if !ok {
+		return nil, ErrProviderNotFound
+	}
+	return p, nil
+}
+
+// IDs returns the configured provider ids in sorted order
+func (r *Registry) IDs() []string {
+	ids := make([]string, 0, len(r.providers))
+	for id := range r.providers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
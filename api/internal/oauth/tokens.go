@@ -0,0 +1,252 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"budhapp.com/internal/encryption"
+	"budhapp.com/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrAccountNotFound          = errors.New("no linked account for provider")
+	ErrReauthenticationRequired = errors.New("access token expired and cannot be refreshed")
+	ErrTokenStorageUnavailable  = errors.New("token encryption is not configured")
+)
+
+// refreshSkew refreshes access tokens slightly before they expire so callers
+// never receive a token that expires mid-request
+const refreshSkew = time.Minute
+
+// AccountStore is the subset of repository.Queries used by the TokenService
+type AccountStore interface {
+	GetAccountByUserIdAndProvider(ctx context.Context, arg repository.GetAccountByUserIdAndProviderParams) (repository.Account, error)
+	UpdateAccountTokens(ctx context.Context, arg repository.UpdateAccountTokensParams) (repository.Account, error)
+}
+
+// StoredTokens are provider tokens ready to be written to the account table,
+// with accessToken, refreshToken and idToken encrypted
+type StoredTokens struct {
+	AccessToken           *string
+	RefreshToken          *string
+	IDToken               *string
+	Scope                 *string
+	AccessTokenExpiresAt  *time.Time
+	RefreshTokenExpiresAt *time.Time
+}
+
+// TokenService encrypts provider tokens at rest and hands out valid access
+// tokens, refreshing them when they are about to expire.
+type TokenService struct {
+	store     AccountStore
+	keyring   *encryption.Keyring
+	providers *Registry
+	logger    *slog.Logger
+	now       func() time.Time
+
+	// locks serializes refreshes per account so concurrent callers do not
+	// spend a rotating refresh token twice
+	locks sync.Map
+}
+
+// NewTokenService creates a TokenService. With a nil keyring tokens are not
+// stored and GetAccessToken fails with ErrTokenStorageUnavailable.
+func NewTokenService(store AccountStore, keyring *encryption.Keyring, providers *Registry, logger *slog.Logger) *TokenService {
+	return &TokenService{
+		store:     store,
+		keyring:   keyring,
+		providers: providers,
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+// Seal encrypts tokens for storage. Without a keyring only the scope and
+// expiry are kept; tokens are never written in plaintext.
+func (s *TokenService) Seal(tokens *Tokens) (StoredTokens, error) {
+	stored := StoredTokens{
+		Scope:                 nullable(tokens.Scope),
+		AccessTokenExpiresAt:  tokens.AccessTokenExpiresAt,
+		RefreshTokenExpiresAt: tokens.RefreshTokenExpiresAt,
+	}
+	if s.keyring == nil {
+		return stored, nil
+	}
+
+	var err error
+	if stored.AccessToken, err = s.seal(tokens.AccessToken); err != nil {
+		return StoredTokens{}, err
+	}
+	if stored.RefreshToken, err = s.seal(tokens.RefreshToken); err != nil {
+		return StoredTokens{}, err
+	}
+	if stored.IDToken, err = s.seal(tokens.IDToken); err != nil {
+		return StoredTokens{}, err
+	}
+
+	return stored, nil
+}
+
+// GetAccessToken returns a valid access token for the user's account with the
+// provider, refreshing and storing new tokens when the current one expires
+// within refreshSkew.
+func (s *TokenService) GetAccessToken(ctx context.Context, userID uuid.UUID, providerID string) (string, error) {
+	if s.keyring == nil {
+		return "", ErrTokenStorageUnavailable
+	}
+
+	lock, _ := s.locks.LoadOrStore(userID.String()+":"+providerID, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	account, err := s.store.GetAccountByUserIdAndProvider(ctx, repository.GetAccountByUserIdAndProviderParams{
+		UserId:     userID,
+		ProviderId: providerID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrAccountNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	tokens, err := s.open(account)
+	if err != nil {
+		return "", err
+	}
+
+	now := s.now()
+	if tokens.AccessToken != "" && (tokens.AccessTokenExpiresAt == nil || now.Add(refreshSkew).Before(*tokens.AccessTokenExpiresAt)) {
+		if s.needsRotation(account) {
+			if params, err := s.updateParams(account.ID, tokens); err == nil {
+				s.store.UpdateAccountTokens(ctx, params) //nolint:errcheck // Retried on next access
+			}
+		}
+		return tokens.AccessToken, nil
+	}
+
+	if tokens.RefreshToken == "" || (tokens.RefreshTokenExpiresAt != nil && !now.Before(*tokens.RefreshTokenExpiresAt)) {
+		return "", ErrReauthenticationRequired
+	}
+
+	provider, err := s.providers.Get(providerID)
+	if err != nil {
+		return "", err
+	}
+
+	refreshed, err := provider.Refresh(ctx, tokens.RefreshToken)
+	if err != nil {
+		s.logger.Warn("oauth token refresh failed", "provider", providerID, "user_id", userID, "error", err)
+		return "", fmt.Errorf("%w: %v", ErrReauthenticationRequired, err)
+	}
+
+	// Providers omit values that did not change
+	if refreshed.RefreshToken == "" {
+		refreshed.RefreshToken = tokens.RefreshToken
+		refreshed.RefreshTokenExpiresAt = tokens.RefreshTokenExpiresAt
+	}
+	if refreshed.IDToken == "" {
+		refreshed.IDToken = tokens.IDToken
+	}
+	if refreshed.Scope == "" {
+		refreshed.Scope = tokens.Scope
+	}
+
+	params, err := s.updateParams(account.ID, refreshed)
+	if err != nil {
+		return "", err
+	}
+	if _, err := s.store.UpdateAccountTokens(ctx, params); err != nil {
+		return "", err
+	}
+
+	return refreshed.AccessToken, nil
+}
+
+func (s *TokenService) updateParams(accountID uuid.UUID, tokens *Tokens) (repository.UpdateAccountTokensParams, error) {
+	stored, err := s.Seal(tokens)
+	if err != nil {
+		return repository.UpdateAccountTokensParams{}, err
+	}
+
+	return repository.UpdateAccountTokensParams{
+		ID:                    accountID,
+		AccessToken:           stored.AccessToken,
+		RefreshToken:          stored.RefreshToken,
+		IdToken:               stored.IDToken,
+		AccessTokenExpiresAt:  stored.AccessTokenExpiresAt,
+		RefreshTokenExpiresAt: stored.RefreshTokenExpiresAt,
+		Scope:                 stored.Scope,
+	}, nil
+}
+
+// open decrypts the tokens of an account. Values without a key version were
+// stored before encryption was introduced and are read as plaintext.
+func (s *TokenService) open(account repository.Account) (*Tokens, error) {
+	tokens := &Tokens{
+		AccessTokenExpiresAt:  account.AccessTokenExpiresAt,
+		RefreshTokenExpiresAt: account.RefreshTokenExpiresAt,
+	}
+	if account.Scope != nil {
+		tokens.Scope = *account.Scope
+	}
+
+	var err error
+	if tokens.AccessToken, err = s.unseal(account.AccessToken); err != nil {
+		return nil, err
+	}
+	if tokens.RefreshToken, err = s.unseal(account.RefreshToken); err != nil {
+		return nil, err
+	}
+	if tokens.IDToken, err = s.unseal(account.IdToken); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// needsRotation reports whether any token is plaintext or sealed with a
+// previous key
+func (s *TokenService) needsRotation(account repository.Account) bool {
+	for _, v := range []*string{account.AccessToken, account.RefreshToken, account.IdToken} {
+		if v != nil && s.keyring.NeedsRotation(*v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *TokenService) seal(value string) (*string, error) {
+	if value == "" {
+		return nil, nil
+	}
+	sealed, err := s.keyring.EncryptString(value)
+	if err != nil {
+		return nil, err
+	}
+	return &sealed, nil
+}
+
+func (s *TokenService) unseal(value *string) (string, error) {
+	if value == nil {
+		return "", nil
+	}
+	if !encryption.IsVersioned(*value) {
+		return *value, nil
+	}
+	return s.keyring.DecryptString(*value)
+}
+
+// nullable maps an empty string to NULL
+func nullable(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,160 @@
+package oauth
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"budhapp.com/internal/config"
+	"budhapp.com/internal/encryption"
+	"budhapp.com/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type fakeAccountStore struct {
+	account *repository.Account
+	updates int
+}
+
+func (s *fakeAccountStore) GetAccountByUserIdAndProvider(_ context.Context, arg repository.GetAccountByUserIdAndProviderParams) (repository.Account, error) {
+	if s.account == nil || s.account.UserId != arg.UserId || s.account.ProviderId != arg.ProviderId {
+		return repository.Account{}, pgx.ErrNoRows
+	}
+	return *s.account, nil
+}
+
+func (s *fakeAccountStore) UpdateAccountTokens(_ context.Context, arg repository.UpdateAccountTokensParams) (repository.Account, error) {
+	s.updates++
+	s.account.AccessToken = arg.AccessToken
+	s.account.RefreshToken = arg.RefreshToken
+	s.account.IdToken = arg.IdToken
+	s.account.AccessTokenExpiresAt = arg.AccessTokenExpiresAt
+	s.account.RefreshTokenExpiresAt = arg.RefreshTokenExpiresAt
+	s.account.Scope = arg.Scope
+	return *s.account, nil
+}
+
+// fakeRefresher is a provider that only supports the refresh grant
+type fakeRefresher struct {
+	Provider
+	refreshed []string
+}
+
+func (p *fakeRefresher) ID() string { return "test" }
+
+func (p *fakeRefresher) Refresh(_ context.Context, refreshToken string) (*Tokens, error) {
+	p.refreshed = append(p.refreshed, refreshToken)
+	expiry := time.Now().Add(time.Hour)
+	return &Tokens{AccessToken: "new-access", AccessTokenExpiresAt: &expiry}, nil
+}
+
+func newTestTokenService(t *testing.T) (*TokenService, *fakeAccountStore, *fakeRefresher) {
+	t.Helper()
+
+	keyring, err := encryption.NewKeyring(config.EncryptionConfig{
+		Key:        base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef")),
+		KeyVersion: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	provider := &fakeRefresher{}
+	registry := &Registry{providers: map[string]Provider{}}
+	registry.Register(provider)
+
+	store := &fakeAccountStore{}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewTokenService(store, keyring, registry, logger), store, provider
+}
+
+func TestTokenServiceGetAccessToken(t *testing.T) {
+	userID := uuid.New()
+	soon := time.Now().Add(30 * time.Second)
+	later := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name        string
+		expiresAt   *time.Time
+		refresh     string
+		want        string
+		wantErr     error
+		wantRefresh bool
+	}{
+		{name: "valid token", expiresAt: &later, refresh: "refresh", want: "access"},
+		{name: "no expiry", expiresAt: nil, want: "access"},
+		{name: "near expiry refreshes", expiresAt: &soon, refresh: "refresh", want: "new-access", wantRefresh: true},
+		{name: "expired without refresh token", expiresAt: &soon, wantErr: ErrReauthenticationRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store, provider := newTestTokenService(t)
+
+			stored, err := s.Seal(&Tokens{AccessToken: "access", RefreshToken: tt.refresh, AccessTokenExpiresAt: tt.expiresAt})
+			if err != nil {
+				t.Fatal(err)
+			}
+			store.account = &repository.Account{
+				ID:                   uuid.New(),
+				UserId:               userID,
+				ProviderId:           "test",
+				AccessToken:          stored.AccessToken,
+				RefreshToken:         stored.RefreshToken,
+				AccessTokenExpiresAt: stored.AccessTokenExpiresAt,
+			}
+
+			got, err := s.GetAccessToken(context.Background(), userID, "test")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetAccessToken() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GetAccessToken() = %q, want %q", got, tt.want)
+			}
+			if tt.wantRefresh != (len(provider.refreshed) == 1) {
+				t.Errorf("refreshed = %v, want refresh %v", provider.refreshed, tt.wantRefresh)
+			}
+			if tt.wantRefresh {
+				if provider.refreshed[0] != "refresh" {
+					t.Errorf("Refresh() called with %q, want decrypted refresh token", provider.refreshed[0])
+				}
+				// The refresh token was not rotated, the stored one must be kept
+				if refresh, _ := s.unseal(store.account.RefreshToken); refresh != "refresh" {
+					t.Errorf("stored refresh token = %q, want %q", refresh, "refresh")
+				}
+			}
+			if strings.Contains(*store.account.AccessToken, "access") {
+				t.Error("access token stored in plaintext")
+			}
+		})
+	}
+}
+
+func TestTokenServiceEncryptsLegacyPlaintext(t *testing.T) {
+	s, store, _ := newTestTokenService(t)
+	userID := uuid.New()
+	plaintext := "legacy-access"
+	store.account = &repository.Account{ID: uuid.New(), UserId: userID, ProviderId: "test", AccessToken: &plaintext}
+
+	got, err := s.GetAccessToken(context.Background(), userID, "test")
+	if err != nil || got != plaintext {
+		t.Fatalf("GetAccessToken() = %q, %v", got, err)
+	}
+	if store.updates != 1 || !encryption.IsVersioned(*store.account.AccessToken) {
+		t.Errorf("plaintext token was not re-encrypted: %q", *store.account.AccessToken)
+	}
+}
+
+func TestTokenServiceAccountNotFound(t *testing.T) {
+	s, _, _ := newTestTokenService(t)
+
+	if _, err := s.GetAccessToken(context.Background(), uuid.New(), "test"); !errors.Is(err, ErrAccountNotFound) {
+		t.Errorf("GetAccessToken() error = %v, want %v", err, ErrAccountNotFound)
+	}
+}
@@ -0,0 +1,290 @@
+package passkey
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"budhapp.com/internal/config"
+	"budhapp.com/internal/repository"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrPasskeyNotFound     = errors.New("passkey not found")
+	ErrClonedAuthenticator = errors.New("authenticator sign count went backwards")
+)
+
+// Device types stored in passkey.deviceType, as in better-auth
+const (
+	deviceSingle = "singleDevice"
+	deviceMulti  = "multiDevice"
+)
+
+// Store is the subset of repository.Queries used by the Service
+type Store interface {
+	ListPasskeysByUserId(ctx context.Context, userID uuid.UUID) ([]repository.Passkey, error)
+	GetPasskeyByCredentialId(ctx context.Context, credentialID string) (repository.Passkey, error)
+	UpdatePasskeyCounter(ctx context.Context, arg repository.UpdatePasskeyCounterParams) error
+}
+
+// Service runs the WebAuthn registration and authentication ceremonies.
+// Ceremony state is returned as opaque JSON for the caller to store between
+// the two steps of each ceremony.
+type Service struct {
+	webauthn *webauthn.WebAuthn
+	store    Store
+}
+
+// New creates a Service. The relying party id defaults to the host of the
+// base URL and every trusted origin is accepted as a client origin.
+func New(store Store, cfg config.AuthConfig) (*Service, error) {
+	base, err := url.Parse(cfg.BaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base url: %w", err)
+	}
+
+	rpID := cfg.PasskeyRPID
+	if rpID == "" {
+		rpID = base.Hostname()
+	}
+
+	origins := []string{base.Scheme + "://" + base.Host}
+	origins = append(origins, cfg.TrustedOrigins...)
+
+	w, err := webauthn.New(&webauthn.Config{
+		RPID:          rpID,
+		RPDisplayName: cfg.AppName,
+		RPOrigins:     origins,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementPreferred,
+			UserVerification: protocol.VerificationPreferred,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{webauthn: w, store: store}, nil
+}
+
+// user adapts a user and their passkeys to webauthn.User. The user handle is
+// the user id string, like better-auth.
+type user struct {
+	id          uuid.UUID
+	name        string
+	displayName string
+	credentials []webauthn.Credential
+}
+
+func (u *user) WebAuthnID() []byte                         { return []byte(u.id.String()) }
+func (u *user) WebAuthnName() string                       { return u.name }
+func (u *user) WebAuthnDisplayName() string                { return u.displayName }
+func (u *user) WebAuthnCredentials() []webauthn.Credential { return u.credentials }
+
+// BeginRegistration returns the creation options for the browser and the
+// ceremony state. Existing passkeys are excluded so an authenticator is not
+// registered twice.
+func (s *Service) BeginRegistration(ctx context.Context, u repository.User, attachment protocol.AuthenticatorAttachment) (*protocol.CredentialCreation, []byte, error) {
+	wu, err := s.loadUser(ctx, u)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	selection := s.webauthn.Config.AuthenticatorSelection
+	selection.AuthenticatorAttachment = attachment
+
+	creation, session, err := s.webauthn.BeginRegistration(wu,
+		webauthn.WithExclusions(webauthn.Credentials(wu.credentials).CredentialDescriptors()),
+		webauthn.WithAuthenticatorSelection(selection),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	state, err := json.Marshal(session)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return creation, state, nil
+}
+
+// FinishRegistration verifies the attestation response and returns the
+// passkey row to store
+func (s *Service) FinishRegistration(ctx context.Context, u repository.User, state, response []byte, name string) (repository.CreatePasskeyParams, error) {
+	var session webauthn.SessionData
+	if err := json.Unmarshal(state, &session); err != nil {
+		return repository.CreatePasskeyParams{}, err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(response)
+	if err != nil {
+		return repository.CreatePasskeyParams{}, err
+	}
+
+	wu, err := s.loadUser(ctx, u)
+	if err != nil {
+		return repository.CreatePasskeyParams{}, err
+	}
+
+	credential, err := s.webauthn.CreateCredential(wu, session, parsed)
+	if err != nil {
+		return repository.CreatePasskeyParams{}, err
+	}
+
+	return newPasskeyParams(u.ID, name, credential), nil
+}
+
+// BeginLogin returns request options for a discoverable credential, so the
+// user does not have to enter an email first
+func (s *Service) BeginLogin() (*protocol.CredentialAssertion, []byte, error) {
+	assertion, session, err := s.webauthn.BeginDiscoverableLogin()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	state, err := json.Marshal(session)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return assertion, state, nil
+}
+
+// FinishLogin verifies the assertion and returns the passkey used, after
+// storing its new sign count
+func (s *Service) FinishLogin(ctx context.Context, state, response []byte) (repository.Passkey, error) {
+	var session webauthn.SessionData
+	if err := json.Unmarshal(state, &session); err != nil {
+		return repository.Passkey{}, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		return repository.Passkey{}, err
+	}
+
+	var passkey repository.Passkey
+	handler := func(rawID, userHandle []byte) (webauthn.User, error) {
+		p, err := s.store.GetPasskeyByCredentialId(ctx, base64.RawURLEncoding.EncodeToString(rawID))
+		if err != nil || string(userHandle) != p.UserId.String() {
+			return nil, ErrPasskeyNotFound
+		}
+		passkey = p
+
+		credential, err := toCredential(passkey)
+		if err != nil {
+			return nil, err
+		}
+		return &user{id: passkey.UserId, credentials: []webauthn.Credential{credential}}, nil
+	}
+
+	credential, err := s.webauthn.ValidateDiscoverableLogin(handler, session, parsed)
+	if err != nil {
+		return repository.Passkey{}, err
+	}
+	if credential.Authenticator.CloneWarning {
+		return repository.Passkey{}, ErrClonedAuthenticator
+	}
+
+	if err := s.store.UpdatePasskeyCounter(ctx, repository.UpdatePasskeyCounterParams{
+		ID:       passkey.ID,
+		Counter:  int32(credential.Authenticator.SignCount),
+		BackedUp: credential.Flags.BackupState,
+	}); err != nil {
+		return repository.Passkey{}, err
+	}
+
+	return passkey, nil
+}
+
+func (s *Service) loadUser(ctx context.Context, u repository.User) (*user, error) {
+	passkeys, err := s.store.ListPasskeysByUserId(ctx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	wu := &user{id: u.ID, name: u.Email, displayName: u.Name}
+	for _, p := range passkeys {
+		credential, err := toCredential(p)
+		if err != nil {
+			return nil, err
+		}
+		wu.credentials = append(wu.credentials, credential)
+	}
+
+	return wu, nil
+}
+
+// newPasskeyParams encodes a credential the way the better-auth passkey plugin
+// stores it: base64url id and COSE public key, comma separated transports
+func newPasskeyParams(userID uuid.UUID, name string, c *webauthn.Credential) repository.CreatePasskeyParams {
+	deviceType := deviceSingle
+	if c.Flags.BackupEligible {
+		deviceType = deviceMulti
+	}
+
+	transports := make([]string, len(c.Transport))
+	for i, t := range c.Transport {
+		transports[i] = string(t)
+	}
+
+	params := repository.CreatePasskeyParams{
+		PublicKey:    base64.RawURLEncoding.EncodeToString(c.PublicKey),
+		UserId:       userID,
+		CredentialID: base64.RawURLEncoding.EncodeToString(c.ID),
+		Counter:      int32(c.Authenticator.SignCount),
+		DeviceType:   deviceType,
+		BackedUp:     c.Flags.BackupState,
+	}
+	if name != "" {
+		params.Name = &name
+	}
+	if len(transports) > 0 {
+		joined := strings.Join(transports, ",")
+		params.Transports = &joined
+	}
+	if aaguid, err := uuid.FromBytes(c.Authenticator.AAGUID); err == nil {
+		s := aaguid.String()
+		params.Aaguid = &s
+	}
+
+	return params
+}
+
+func toCredential(p repository.Passkey) (webauthn.Credential, error) {
+	id, err := base64.RawURLEncoding.DecodeString(p.CredentialID)
+	if err != nil {
+		return webauthn.Credential{}, fmt.Errorf("invalid credential id: %w", err)
+	}
+	publicKey, err := base64.RawURLEncoding.DecodeString(p.PublicKey)
+	if err != nil {
+		return webauthn.Credential{}, fmt.Errorf("invalid public key: %w", err)
+	}
+
+	credential := webauthn.Credential{
+		ID:        id,
+		PublicKey: publicKey,
+		Flags: webauthn.CredentialFlags{
+			BackupEligible: p.DeviceType == deviceMulti,
+			BackupState:    p.BackedUp,
+		},
+		Authenticator: webauthn.Authenticator{
+			SignCount: uint32(p.Counter),
+		},
+	}
+	if p.Transports != nil {
+		for _, t := range strings.Split(*p.Transports, ",") {
+			credential.Transport = append(credential.Transport, protocol.AuthenticatorTransport(t))
+		}
+	}
+
+	return credential, nil
+}
@@ -0,0 +1,276 @@
+package passkey
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"budhapp.com/internal/config"
+	"budhapp.com/internal/repository"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const testOrigin = "http://localhost:3001"
+
+type fakeStore struct {
+	passkeys []repository.Passkey
+}
+
+func (s *fakeStore) ListPasskeysByUserId(_ context.Context, userID uuid.UUID) ([]repository.Passkey, error) {
+	var out []repository.Passkey
+	for _, p := range s.passkeys {
+		if p.UserId == userID {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (s *fakeStore) GetPasskeyByCredentialId(_ context.Context, credentialID string) (repository.Passkey, error) {
+	for _, p := range s.passkeys {
+		if p.CredentialID == credentialID {
+			return p, nil
+		}
+	}
+	return repository.Passkey{}, pgx.ErrNoRows
+}
+
+func (s *fakeStore) UpdatePasskeyCounter(_ context.Context, arg repository.UpdatePasskeyCounterParams) error {
+	for i := range s.passkeys {
+		if s.passkeys[i].ID == arg.ID {
+			s.passkeys[i].Counter = arg.Counter
+			s.passkeys[i].BackedUp = arg.BackedUp
+		}
+	}
+	return nil
+}
+
+func (s *fakeStore) create(arg repository.CreatePasskeyParams) {
+	s.passkeys = append(s.passkeys, repository.Passkey{
+		ID:           uuid.New(),
+		Name:         arg.Name,
+		PublicKey:    arg.PublicKey,
+		UserId:       arg.UserId,
+		CredentialID: arg.CredentialID,
+		Counter:      arg.Counter,
+		DeviceType:   arg.DeviceType,
+		BackedUp:     arg.BackedUp,
+		Transports:   arg.Transports,
+		Aaguid:       arg.Aaguid,
+		CreatedAt:    time.Now(),
+	})
+}
+
+// softAuthenticator is a software WebAuthn authenticator with a single
+// ES256 credential and "none" attestation
+type softAuthenticator struct {
+	t          *testing.T
+	rpID       string
+	key        *ecdsa.PrivateKey
+	id         []byte
+	userHandle []byte
+	signCount  uint32
+}
+
+func newSoftAuthenticator(t *testing.T, rpID string) *softAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := make([]byte, 16)
+	rand.Read(id)
+	return &softAuthenticator{t: t, rpID: rpID, key: key, id: id}
+}
+
+func (a *softAuthenticator) authData(attested bool) []byte {
+	rpHash := sha256.Sum256([]byte(a.rpID))
+	// UP | UV | BE | BS, plus AT when attesting
+	flags := byte(0x01 | 0x04 | 0x08 | 0x10)
+	if attested {
+		flags |= 0x40
+	}
+
+	data := append(rpHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	if !attested {
+		return data
+	}
+
+	coseKey, err := webauthncbor.Marshal(map[int]any{
+		1:  2,  // kty: EC2
+		3:  -7, // alg: ES256
+		-1: 1,  // crv: P-256
+		-2: a.key.X.FillBytes(make([]byte, 32)),
+		-3: a.key.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		a.t.Fatal(err)
+	}
+
+	data = append(data, make([]byte, 16)...) // aaguid
+	data = binary.BigEndian.AppendUint16(data, uint16(len(a.id)))
+	data = append(data, a.id...)
+	return append(data, coseKey...)
+}
+
+func clientData(t *testing.T, ceremony, challenge string) []byte {
+	data, err := json.Marshal(map[string]string{
+		"type":      ceremony,
+		"challenge": challenge,
+		"origin":    testOrigin,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func (a *softAuthenticator) create(challenge string, userHandle []byte) []byte {
+	a.userHandle = userHandle
+	attestation, err := webauthncbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": a.authData(true),
+	})
+	if err != nil {
+		a.t.Fatal(err)
+	}
+
+	return a.marshal(map[string]any{
+		"clientDataJSON":    b64(clientData(a.t, "webauthn.create", challenge)),
+		"attestationObject": b64(attestation),
+		"transports":        []string{"internal"},
+	})
+}
+
+func (a *softAuthenticator) get(challenge string) []byte {
+	a.signCount++
+	authData := a.authData(false)
+	cdj := clientData(a.t, "webauthn.get", challenge)
+
+	cdjHash := sha256.Sum256(cdj)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), cdjHash[:]...))
+	sig, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		a.t.Fatal(err)
+	}
+
+	return a.marshal(map[string]any{
+		"clientDataJSON":    b64(cdj),
+		"authenticatorData": b64(authData),
+		"signature":         b64(sig),
+		"userHandle":        b64(a.userHandle),
+	})
+}
+
+func (a *softAuthenticator) marshal(response map[string]any) []byte {
+	data, err := json.Marshal(map[string]any{
+		"id":       b64(a.id),
+		"rawId":    b64(a.id),
+		"type":     "public-key",
+		"response": response,
+	})
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	return data
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func TestRegistrationAndLogin(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeStore{}
+	s, err := New(store, config.AuthConfig{AppName: "budhapp", BaseURL: testOrigin})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u := repository.User{ID: uuid.New(), Email: "jane@example.com", Name: "Jane"}
+	authenticator := newSoftAuthenticator(t, "localhost")
+
+	creation, state, err := s.BeginRegistration(ctx, u, "")
+	if err != nil {
+		t.Fatalf("BeginRegistration() error = %v", err)
+	}
+	if id, _ := creation.Response.User.ID.(protocol.URLEncodedBase64); string(id) != u.ID.String() {
+		t.Errorf("user handle = %v, want user id", creation.Response.User.ID)
+	}
+
+	params, err := s.FinishRegistration(ctx, u, state, authenticator.create(creation.Response.Challenge.String(), []byte(u.ID.String())), "Laptop")
+	if err != nil {
+		t.Fatalf("FinishRegistration() error = %v", err)
+	}
+	if params.DeviceType != deviceMulti || !params.BackedUp || *params.Transports != "internal" || *params.Name != "Laptop" {
+		t.Errorf("FinishRegistration() = %+v", params)
+	}
+	store.create(params)
+
+	for i := range 2 {
+		assertion, state, err := s.BeginLogin()
+		if err != nil {
+			t.Fatalf("BeginLogin() error = %v", err)
+		}
+
+		passkey, err := s.FinishLogin(ctx, state, authenticator.get(assertion.Response.Challenge.String()))
+		if err != nil {
+			t.Fatalf("FinishLogin() #%d error = %v", i, err)
+		}
+		if passkey.UserId != u.ID {
+			t.Errorf("FinishLogin() user = %s, want %s", passkey.UserId, u.ID)
+		}
+		if store.passkeys[0].Counter != int32(authenticator.signCount) {
+			t.Errorf("stored counter = %d, want %d", store.passkeys[0].Counter, authenticator.signCount)
+		}
+	}
+}
+
+func TestLoginRejectsWrongChallengeAndUnknownCredential(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeStore{}
+	s, err := New(store, config.AuthConfig{AppName: "budhapp", BaseURL: testOrigin})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u := repository.User{ID: uuid.New(), Email: "jane@example.com", Name: "Jane"}
+	authenticator := newSoftAuthenticator(t, "localhost")
+	authenticator.userHandle = []byte(u.ID.String())
+
+	_, state, err := s.BeginLogin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Not registered
+	if _, err := s.FinishLogin(ctx, state, authenticator.get("unused")); err == nil {
+		t.Error("FinishLogin() with unknown credential succeeded")
+	}
+
+	creation, regState, _ := s.BeginRegistration(ctx, u, "")
+	params, err := s.FinishRegistration(ctx, u, regState, authenticator.create(creation.Response.Challenge.String(), []byte(u.ID.String())), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.create(params)
+
+	// Replayed against a different challenge
+	if _, err := s.FinishLogin(ctx, state, authenticator.get(b64([]byte("wrong challenge")))); err == nil {
+		t.Error("FinishLogin() with wrong challenge succeeded")
+	} else if errors.Is(err, ErrPasskeyNotFound) {
+		t.Errorf("FinishLogin() error = %v, want challenge mismatch", err)
+	}
+}
@@ -0,0 +1,73 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	ErrInvalidHash = errors.New("invalid password hash")
+)
+
+// scrypt parameters used by better-auth
+const (
+	scryptN      = 16384
+	scryptR      = 16
+	scryptP      = 1
+	scryptKeyLen = 64
+)
+
+// Hash hashes a password the way better-auth does: "<hex salt>:<hex scrypt key>"
+func Hash(password string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	saltHex := hex.EncodeToString(salt)
+
+	key, err := deriveKey(password, saltHex)
+	if err != nil {
+		return "", err
+	}
+
+	return saltHex + ":" + hex.EncodeToString(key), nil
+}
+
+// Verify checks a password against a better-auth scrypt hash or a bcrypt hash
+func Verify(hash, password string) (bool, error) {
+	if strings.HasPrefix(hash, "$2") {
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	saltHex, keyHex, found := strings.Cut(hash, ":")
+	if !found {
+		return false, ErrInvalidHash
+	}
+	want, err := hex.DecodeString(keyHex)
+	if err != nil || len(want) != scryptKeyLen {
+		return false, ErrInvalidHash
+	}
+
+	got, err := deriveKey(password, saltHex)
+	if err != nil {
+		return false, err
+	}
+
+	return subtle.ConstantTimeCompare(got, want) == 1, nil
+}
+
+// deriveKey uses the hex salt string itself as salt, as better-auth does
+func deriveKey(password, salt string) ([]byte, error) {
+	return scrypt.Key([]byte(norm.NFKC.String(password)), []byte(salt), scryptN, scryptR, scryptP, scryptKeyLen)
+}
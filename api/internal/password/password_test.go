@@ -0,0 +1,46 @@
+package password
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// betterAuthHash was produced by better-auth's hashPassword (node scrypt)
+const betterAuthHash = "0123456789abcdef0123456789abcdef:97c07817272163f9b23e52645dc2bde66450d231fdd2620a70c94806260537c0bba3321f6e7f5c67431646e726fa5cfec1644cb5d438a7012fe61c7514e4b95b"
+
+func TestHashVerify(t *testing.T) {
+	hash, err := Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bcryptHash, _ := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+
+	tests := []struct {
+		name     string
+		hash     string
+		password string
+		want     bool
+		wantErr  bool
+	}{
+		{"scrypt match", hash, "correct horse", true, false},
+		{"scrypt mismatch", hash, "wrong", false, false},
+		{"bcrypt match", string(bcryptHash), "correct horse", true, false},
+		{"bcrypt mismatch", string(bcryptHash), "wrong", false, false},
+		{"better-auth vector", betterAuthHash, "pässword", true, false},
+		{"malformed", "not-a-hash", "correct horse", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Verify(tt.hash, tt.password)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+// Package polar calls the Polar API. Webhooks from Polar are handled in the
+// handlers package.
+package polar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"budhapp.com/internal/config"
+	"budhapp.com/internal/tracing"
+)
+
+const (
+	productionAPIURL = "https://api.polar.sh"
+	sandboxAPIURL    = "https://sandbox-api.polar.sh"
+
+	// requestTimeout bounds a single call to the API
+	requestTimeout = 10 * time.Second
+)
+
+// errorAlreadyCanceled is the error type of a subscription that was already
+// canceled
+const errorAlreadyCanceled = "AlreadyCanceledSubscription"
+
+var ErrNotConfigured = errors.New("polar access token not configured")
+
+// Client calls the Polar API with an organization access token
+type Client struct {
+	accessToken string
+	apiURL      string
+	client      *http.Client
+}
+
+func NewClient(cfg config.PolarConfig) *Client {
+	apiURL := productionAPIURL
+	if cfg.Server == "sandbox" {
+		apiURL = sandboxAPIURL
+	}
+
+	return &Client{
+		accessToken: cfg.AccessToken,
+		apiURL:      apiURL,
+		client:      &http.Client{Timeout: requestTimeout, Transport: tracing.Transport(nil)},
+	}
+}
+
+// Enabled reports whether an access token is configured
+func (c *Client) Enabled() bool {
+	return c.accessToken != ""
+}
+
+// RevokeSubscription ends a subscription immediately, without waiting for the
+// end of the billing period. Subscriptions that don't exist or are already
+// canceled are left as they are.
+func (c *Client) RevokeSubscription(ctx context.Context, id string) error {
+	if !c.Enabled() {
+		return ErrNotConfigured
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, c.apiURL+"/v1/subscriptions/"+url.PathEscape(id), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("polar: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 400 || resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	var apiErr struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(body, &apiErr) == nil && apiErr.Error == errorAlreadyCanceled {
+		return nil
+	}
+	return fmt.Errorf("polar: status %d: %s", resp.StatusCode, body)
+}
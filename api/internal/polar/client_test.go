@@ -0,0 +1,54 @@
+package polar
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"budhapp.com/internal/config"
+)
+
+func TestRevokeSubscription(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr bool
+	}{
+		{"revoked", http.StatusOK, `{"id":"sub_1","status":"canceled"}`, false},
+		{"not found", http.StatusNotFound, `{"error":"ResourceNotFound"}`, false},
+		{"already canceled", http.StatusForbidden, `{"error":"AlreadyCanceledSubscription"}`, false},
+		{"unauthorized", http.StatusUnauthorized, `{"error":"invalid_token"}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodDelete || r.URL.Path != "/v1/subscriptions/sub_1" || r.Header.Get("Authorization") != "Bearer test-token" {
+					t.Errorf("request = %s %s %s", r.Method, r.URL.Path, r.Header.Get("Authorization"))
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			c := NewClient(config.PolarConfig{AccessToken: "test-token"})
+			c.apiURL = srv.URL
+			if err := c.RevokeSubscription(context.Background(), "sub_1"); (err != nil) != tt.wantErr {
+				t.Errorf("RevokeSubscription() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRevokeSubscriptionNotConfigured(t *testing.T) {
+	c := NewClient(config.PolarConfig{Server: "sandbox"})
+	if c.apiURL != sandboxAPIURL {
+		t.Errorf("apiURL = %q, want %q", c.apiURL, sandboxAPIURL)
+	}
+	if err := c.RevokeSubscription(context.Background(), "sub_1"); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("RevokeSubscription() error = %v, want ErrNotConfigured", err)
+	}
+}
@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"budhapp.com/internal/config"
+)
+
+// Lockout guards sign-ins by account. Each account has its own token bucket
+// of attempts, and after threshold consecutive failures it is locked out
+// for a duration that doubles with every further failure, up to a maximum.
+// A successful sign-in clears the failures.
+type Lockout struct {
+	store       Store
+	logger      *slog.Logger
+	attempts    Limit
+	threshold   int
+	duration    time.Duration
+	maxDuration time.Duration
+}
+
+func NewLockout(store Store, logger *slog.Logger, cfg config.RateLimitConfig) *Lockout {
+	return &Lockout{
+		store:       store,
+		logger:      logger,
+		attempts:    NewLimit(cfg.Account),
+		threshold:   cfg.Lockout.Threshold,
+		duration:    cfg.Lockout.Duration.Std(),
+		maxDuration: cfg.Lockout.MaxDuration.Std(),
+	}
+}
+
+// Check takes an attempt for the account and returns how long it must wait,
+// zero when the sign-in may proceed. Store failures let the attempt through.
+func (l *Lockout) Check(ctx context.Context, account string, now time.Time) time.Duration {
+	if l.threshold > 0 {
+		failures, err := l.store.Failures(ctx, "lockout:"+account)
+		if err != nil {
+			l.logger.Error("failed to load sign-in failures", "error", err)
+		} else if wait := l.lockedFor(failures, now); wait > 0 {
+			return wait
+		}
+	}
+
+	if l.attempts.Requests > 0 {
+		result, err := l.store.Take(ctx, "account:"+account, l.attempts, now)
+		if err != nil {
+			l.logger.Error("failed to take sign-in attempt", "error", err)
+		} else if !result.Allowed {
+			return result.RetryAfter
+		}
+	}
+	return 0
+}
+
+// Fail counts a failed sign-in of the account
+func (l *Lockout) Fail(ctx context.Context, account string, now time.Time) {
+	if l.threshold <= 0 {
+		return
+	}
+	if _, err := l.store.Fail(ctx, "lockout:"+account, now, idleTimeout); err != nil {
+		l.logger.Error("failed to count sign-in failure", "error", err)
+	}
+}
+
+// Succeed clears the failures of the account
+func (l *Lockout) Succeed(ctx context.Context, account string) {
+	if l.threshold <= 0 {
+		return
+	}
+	if err := l.store.Reset(ctx, "lockout:"+account); err != nil {
+		l.logger.Error("failed to reset sign-in failures", "error", err)
+	}
+}
+
+// lockedFor returns the time left in the lockout the failures earned
+func (l *Lockout) lockedFor(failures Failures, now time.Time) time.Duration {
+	if failures.Count < l.threshold {
+		return 0
+	}
+
+	lock := l.duration
+	for range failures.Count - l.threshold {
+		if lock >= l.maxDuration {
+			break
+		}
+		lock *= 2
+	}
+	lock = min(lock, l.maxDuration)
+	return max(failures.Last.Add(lock).Sub(now), 0)
+}
@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps buckets in the memory of a single instance
+type MemoryStore struct {
+	mu       sync.Mutex
+	buckets  map[string]bucket
+	failures map[string]Failures
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:  make(map[string]bucket),
+		failures: make(map[string]Failures),
+	}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, result := s.buckets[key].take(limit, now)
+	s.buckets[key] = b
+	return result, nil
+}
+
+func (s *MemoryStore) Fail(ctx context.Context, key string, now time.Time, resetAfter time.Duration) (Failures, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f := s.failures[key]
+	if now.Sub(f.Last) > resetAfter {
+		f.Count = 0
+	}
+	f.Count++
+	f.Last = now
+	s.failures[key] = f
+	return f, nil
+}
+
+func (s *MemoryStore) Failures(ctx context.Context, key string) (Failures, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.failures[key], nil
+}
+
+func (s *MemoryStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.failures, key)
+	return nil
+}
+
+func (s *MemoryStore) Sweep(ctx context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, b := range s.buckets {
+		if b.updatedAt.Before(before) {
+			delete(s.buckets, key)
+		}
+	}
+	for key, f := range s.failures {
+		if f.Last.Before(before) {
+			delete(s.failures, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"budhapp.com/internal/apierror"
+	"budhapp.com/internal/middleware"
+	"budhapp.com/internal/session"
+)
+
+// KeyFunc returns the key a request is limited by. Requests with an empty
+// key are not limited.
+type KeyFunc func(r *http.Request) string
+
+// ByIP limits each client IP address
+func ByIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return "ip:" + host
+	}
+	return "ip:" + r.RemoteAddr
+}
+
+// ByUser limits each user authenticated by the JWT middleware, and each IP
+// address for anonymous requests
+func ByUser(r *http.Request) string {
+	if user, ok := session.UserFromContext(r.Context()); ok && user != nil {
+		return "user:" + user.ID
+	}
+	return ByIP(r)
+}
+
+// ByRoute limits each route pattern, whoever calls it
+func ByRoute(r *http.Request) string {
+	return "route:" + r.Pattern
+}
+
+// Limiter applies token bucket limits to requests
+type Limiter struct {
+	store  Store
+	logger *slog.Logger
+}
+
+func NewLimiter(store Store, logger *slog.Logger) *Limiter {
+	return &Limiter{store: store, logger: logger}
+}
+
+// Limit returns a middleware allowing limit requests per key. Buckets are
+// named so limits sharing a key don't share tokens. Every response carries
+// the X-RateLimit-* headers; rejected requests get 429 with Retry-After.
+// When the store fails the request is let through.
+func (l *Limiter) Limit(name string, limit Limit, key KeyFunc) middleware.Constructor {
+	return func(next http.Handler) http.Handler {
+		if limit.Requests <= 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			k := key(r)
+			if k == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			result, err := l.store.Take(r.Context(), name+":"+k, limit, time.Now())
+			if err != nil {
+				l.logger.Error("failed to take rate limit token", "limit", name, "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+			if !result.Allowed {
+				RespondTooManyRequests(w, r, result.RetryAfter)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Run forgets idle buckets and failure counts every interval until ctx is
+// done
+func (l *Limiter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := l.store.Sweep(ctx, time.Now().Add(-idleTimeout)); err != nil {
+				l.logger.Error("failed to sweep rate limits", "error", err)
+			}
+		}
+	}
+}
+
+// RespondTooManyRequests writes a 429 error with the seconds to wait in
+// Retry-After
+func RespondTooManyRequests(w http.ResponseWriter, r *http.Request, retryAfter time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(max(ceilSeconds(retryAfter), 1)))
+	apierror.Write(w, r, apierror.New(http.StatusTooManyRequests, "TOO_MANY_REQUESTS", "Too many requests. Please try again later."))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
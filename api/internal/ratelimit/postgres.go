@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"budhapp.com/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresStore keeps buckets in the rateLimit and lockout tables, so every
+// instance of the API shares the same limits
+type PostgresStore struct {
+	queries *repository.Queries
+	pool    *pgxpool.Pool
+}
+
+func NewPostgresStore(queries *repository.Queries, pool *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{queries: queries, pool: pool}
+}
+
+// Take locks the bucket row so concurrent requests take tokens one at a time
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return Result{}, err
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // No-op after commit
+	q := s.queries.WithTx(tx)
+
+	if err := q.CreateRateLimit(ctx, repository.CreateRateLimitParams{
+		Key:       key,
+		Tokens:    float64(limit.Requests),
+		UpdatedAt: now,
+	}); err != nil {
+		return Result{}, err
+	}
+	row, err := q.GetRateLimitForUpdate(ctx, key)
+	if err != nil {
+		return Result{}, err
+	}
+
+	b, result := bucket{tokens: row.Tokens, updatedAt: row.UpdatedAt}.take(limit, now)
+	if err := q.UpdateRateLimit(ctx, repository.UpdateRateLimitParams{
+		Key:       key,
+		Tokens:    b.tokens,
+		UpdatedAt: b.updatedAt,
+	}); err != nil {
+		return Result{}, err
+	}
+
+	return result, tx.Commit(ctx)
+}
+
+func (s *PostgresStore) Fail(ctx context.Context, key string, now time.Time, resetAfter time.Duration) (Failures, error) {
+	row, err := s.queries.RecordLockoutFailure(ctx, repository.RecordLockoutFailureParams{
+		Key:         key,
+		FailedAt:    now,
+		ResetBefore: now.Add(-resetAfter),
+	})
+	if err != nil {
+		return Failures{}, err
+	}
+	return Failures{Count: int(row.Failures), Last: row.LastFailureAt}, nil
+}
+
+func (s *PostgresStore) Failures(ctx context.Context, key string) (Failures, error) {
+	row, err := s.queries.GetLockout(ctx, key)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Failures{}, nil
+	}
+	if err != nil {
+		return Failures{}, err
+	}
+	return Failures{Count: int(row.Failures), Last: row.LastFailureAt}, nil
+}
+
+func (s *PostgresStore) Reset(ctx context.Context, key string) error {
+	return s.queries.DeleteLockout(ctx, key)
+}
+
+func (s *PostgresStore) Sweep(ctx context.Context, before time.Time) error {
+	if _, err := s.queries.DeleteIdleRateLimits(ctx, before); err != nil {
+		return err
+	}
+	_, err := s.queries.DeleteIdleLockouts(ctx, before)
+	return err
+}
@@ -0,0 +1,115 @@
+// Package ratelimit limits requests with token buckets and locks accounts out
+// after repeated failed sign-ins. Buckets live in memory, or in PostgreSQL to
+// be shared between instances.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"budhapp.com/internal/config"
+	"budhapp.com/internal/repository"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// idleTimeout is how long an untouched bucket or failure count is kept. It
+// must be longer than any limit window and lockout.
+const idleTimeout = 24 * time.Hour
+
+// Limit allows Requests per Window, in bursts of up to Requests
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// NewLimit converts a configured limit
+func NewLimit(cfg config.RateLimit) Limit {
+	return Limit{Requests: cfg.Requests, Window: cfg.Window.Std()}
+}
+
+// rate is the number of tokens added per second
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Window.Seconds()
+}
+
+// Result is the outcome of taking a token
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter is how long until a token is available, when not allowed
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+}
+
+// Failures counts consecutive failures of a key
+type Failures struct {
+	Count int
+	Last  time.Time
+}
+
+// Store keeps token buckets and failure counts by key
+type Store interface {
+	// Take removes a token from the bucket of key, which starts full
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+	// Fail counts a failure of key. The count restarts when the last
+	// failure is older than resetAfter.
+	Fail(ctx context.Context, key string, now time.Time, resetAfter time.Duration) (Failures, error)
+	// Failures returns the failure count of key
+	Failures(ctx context.Context, key string) (Failures, error)
+	// Reset clears the failure count of key
+	Reset(ctx context.Context, key string) error
+	// Sweep forgets the buckets and failures untouched since before
+	Sweep(ctx context.Context, before time.Time) error
+}
+
+// NewStore returns the store selected by the configuration. The pool is
+// only used by the postgres backend.
+func NewStore(cfg config.RateLimitConfig, queries *repository.Queries, pool *pgxpool.Pool) (Store, error) {
+	switch cfg.Backend {
+	case "memory":
+		return NewMemoryStore(), nil
+	case "postgres":
+		return NewPostgresStore(queries, pool), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend: %s", cfg.Backend)
+	}
+}
+
+// bucket is the state of a token bucket
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// take refills the bucket for the time elapsed since it was last updated,
+// then removes a token if one is left. A zero bucket starts full.
+func (b bucket) take(limit Limit, now time.Time) (bucket, Result) {
+	capacity := float64(limit.Requests)
+	rate := limit.rate()
+
+	tokens := capacity
+	if !b.updatedAt.IsZero() {
+		elapsed := max(now.Sub(b.updatedAt).Seconds(), 0)
+		tokens = min(capacity, b.tokens+elapsed*rate)
+	}
+
+	result := Result{Limit: limit.Requests}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	result.Remaining = int(math.Floor(tokens))
+	result.Reset = seconds((capacity - tokens) / rate)
+
+	return bucket{tokens: tokens, updatedAt: now}, result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
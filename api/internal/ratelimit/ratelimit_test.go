@@ -0,0 +1,198 @@
+package ratelimit
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"budhapp.com/internal/config"
+	"budhapp.com/internal/session"
+)
+
+func TestBucketTake(t *testing.T) {
+	limit := Limit{Requests: 3, Window: 3 * time.Second}
+	now := time.Now()
+
+	var b bucket
+	var result Result
+	for i := range 3 {
+		b, result = b.take(limit, now)
+		if !result.Allowed || result.Remaining != 2-i {
+			t.Fatalf("take %d = %+v, want allowed with %d remaining", i, result, 2-i)
+		}
+	}
+
+	b, result = b.take(limit, now)
+	if result.Allowed || result.RetryAfter != time.Second || result.Reset != 3*time.Second {
+		t.Fatalf("take on empty bucket = %+v, want denied, retry after 1s, reset in 3s", result)
+	}
+
+	// One token is added per second
+	b, result = b.take(limit, now.Add(time.Second))
+	if !result.Allowed || result.Remaining != 0 {
+		t.Fatalf("take after 1s = %+v, want allowed with 0 remaining", result)
+	}
+
+	// The bucket never holds more than its capacity
+	_, result = b.take(limit, now.Add(time.Hour))
+	if !result.Allowed || result.Remaining != 2 {
+		t.Fatalf("take after 1h = %+v, want allowed with 2 remaining", result)
+	}
+}
+
+func TestMemoryStoreFailures(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Now()
+
+	for i := range 3 {
+		f, _ := store.Fail(ctx, "a", now.Add(time.Duration(i)*time.Minute), time.Hour)
+		if f.Count != i+1 {
+			t.Fatalf("Fail() count = %d, want %d", f.Count, i+1)
+		}
+	}
+	if f, _ := store.Fail(ctx, "a", now.Add(3*time.Hour), time.Hour); f.Count != 1 {
+		t.Errorf("Fail() after the reset window count = %d, want 1", f.Count)
+	}
+
+	store.Reset(ctx, "a")
+	if f, _ := store.Failures(ctx, "a"); f.Count != 0 {
+		t.Errorf("Failures() after Reset = %d, want 0", f.Count)
+	}
+
+	store.Take(ctx, "old", Limit{Requests: 1, Window: time.Minute}, now)
+	store.Take(ctx, "new", Limit{Requests: 1, Window: time.Minute}, now.Add(time.Hour))
+	store.Sweep(ctx, now.Add(time.Minute))
+	if _, ok := store.buckets["old"]; ok {
+		t.Error("Sweep() kept an idle bucket")
+	}
+	if _, ok := store.buckets["new"]; !ok {
+		t.Error("Sweep() dropped a recent bucket")
+	}
+}
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestLimiterMiddleware(t *testing.T) {
+	limiter := NewLimiter(NewMemoryStore(), testLogger())
+	handler := limiter.Limit("test", Limit{Requests: 2, Window: time.Minute}, ByIP)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	)
+
+	request := func(addr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = addr
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i := range 2 {
+		rec := request("192.0.2.1:1234")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("request %d = %d, want 200", i, rec.Code)
+		}
+		if rec.Header().Get("X-RateLimit-Limit") != "2" || rec.Header().Get("X-RateLimit-Remaining") != []string{"1", "0"}[i] {
+			t.Errorf("request %d headers = %v", i, rec.Header())
+		}
+	}
+
+	rec := request("192.0.2.1:5678")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("third request = %d, want 429", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") != "30" || rec.Header().Get("X-RateLimit-Reset") != "60" {
+		t.Errorf("429 headers = %v, want Retry-After 30 and X-RateLimit-Reset 60", rec.Header())
+	}
+
+	if rec := request("192.0.2.2:1234"); rec.Code != http.StatusOK {
+		t.Errorf("request from another IP = %d, want 200", rec.Code)
+	}
+}
+
+func TestLimiterDisabled(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	handler := NewLimiter(NewMemoryStore(), testLogger()).Limit("off", Limit{}, ByIP)(next)
+	for range 5 {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Limit") != "" {
+			t.Fatalf("disabled limit = %d %v, want 200 without headers", rec.Code, rec.Header())
+		}
+	}
+}
+
+func TestKeyFuncs(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "[2001:db8::1]:443"
+	if got := ByIP(req); got != "ip:2001:db8::1" {
+		t.Errorf("ByIP() = %q", got)
+	}
+	if got := ByUser(req); got != "ip:2001:db8::1" {
+		t.Errorf("ByUser() without a user = %q", got)
+	}
+
+	ctx := context.WithValue(req.Context(), session.UserContextKey, &session.UserInfo{ID: "u1"})
+	if got := ByUser(req.WithContext(ctx)); got != "user:u1" {
+		t.Errorf("ByUser() = %q, want user:u1", got)
+	}
+}
+
+func TestLockout(t *testing.T) {
+	ctx := context.Background()
+	lockout := NewLockout(NewMemoryStore(), testLogger(), config.RateLimitConfig{
+		Account: config.RateLimit{Requests: 100, Window: config.Duration(time.Minute)},
+		Lockout: config.LockoutConfig{
+			Threshold:   3,
+			Duration:    config.Duration(time.Minute),
+			MaxDuration: config.Duration(5 * time.Minute),
+		},
+	})
+	now := time.Now()
+
+	for range 2 {
+		lockout.Fail(ctx, "a@example.com", now)
+	}
+	if wait := lockout.Check(ctx, "a@example.com", now); wait != 0 {
+		t.Fatalf("Check() below the threshold = %v, want 0", wait)
+	}
+
+	// Each failure past the threshold doubles the lockout, up to the maximum
+	for i, want := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute} {
+		lockout.Fail(ctx, "a@example.com", now)
+		if wait := lockout.Check(ctx, "a@example.com", now); wait != want {
+			t.Errorf("Check() after %d failures = %v, want %v", i+3, wait, want)
+		}
+	}
+	if wait := lockout.Check(ctx, "a@example.com", now.Add(5*time.Minute)); wait != 0 {
+		t.Errorf("Check() after the lockout = %v, want 0", wait)
+	}
+	if wait := lockout.Check(ctx, "b@example.com", now); wait != 0 {
+		t.Errorf("Check() of another account = %v, want 0", wait)
+	}
+
+	lockout.Succeed(ctx, "a@example.com")
+	if wait := lockout.Check(ctx, "a@example.com", now); wait != 0 {
+		t.Errorf("Check() after Succeed = %v, want 0", wait)
+	}
+}
+
+func TestLockoutAttempts(t *testing.T) {
+	ctx := context.Background()
+	lockout := NewLockout(NewMemoryStore(), testLogger(), config.RateLimitConfig{
+		Account: config.RateLimit{Requests: 2, Window: config.Duration(2 * time.Minute)},
+	})
+	now := time.Now()
+
+	lockout.Check(ctx, "a@example.com", now)
+	lockout.Check(ctx, "a@example.com", now)
+	if wait := lockout.Check(ctx, "a@example.com", now); wait != time.Minute {
+		t.Errorf("Check() past the attempt limit = %v, want 1m", wait)
+	}
+}
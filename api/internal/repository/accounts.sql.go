@@ -7,10 +7,22 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+const countAccountsByUserId = `-- name: CountAccountsByUserId :one
+SELECT COUNT(*) FROM "account" WHERE "userId" = $1
+`
+
+func (q *Queries) CountAccountsByUserId(ctx context.Context, userid uuid.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countAccountsByUserId, userid)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createAccount = `-- name: CreateAccount :one
 INSERT INTO
     "account" (
@@ -106,6 +118,67 @@ func (q *Queries) CreateAccountWithId(ctx context.Context, arg CreateAccountWith
 	return i, err
 }
 
+const createOAuthAccount = `-- name: CreateOAuthAccount :one
+INSERT INTO
+    "account" (
+        "userId",
+        "accountId",
+        "providerId",
+        "accessToken",
+        "refreshToken",
+        "idToken",
+        "accessTokenExpiresAt",
+        "refreshTokenExpiresAt",
+        scope
+    )
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING
+    id, "userId", "accountId", "providerId", "accessToken", "refreshToken", "accessTokenExpiresAt", "refreshTokenExpiresAt", scope, "idToken", password, "createdAt", "updatedAt"
+`
+
+type CreateOAuthAccountParams struct {
+	UserId                uuid.UUID  `json:"userId"`
+	AccountId             string     `json:"accountId"`
+	ProviderId            string     `json:"providerId"`
+	AccessToken           *string    `json:"accessToken"`
+	RefreshToken          *string    `json:"refreshToken"`
+	IdToken               *string    `json:"idToken"`
+	AccessTokenExpiresAt  *time.Time `json:"accessTokenExpiresAt"`
+	RefreshTokenExpiresAt *time.Time `json:"refreshTokenExpiresAt"`
+	Scope                 *string    `json:"scope"`
+}
+
+func (q *Queries) CreateOAuthAccount(ctx context.Context, arg CreateOAuthAccountParams) (Account, error) {
+	row := q.db.QueryRow(ctx, createOAuthAccount,
+		arg.UserId,
+		arg.AccountId,
+		arg.ProviderId,
+		arg.AccessToken,
+		arg.RefreshToken,
+		arg.IdToken,
+		arg.AccessTokenExpiresAt,
+		arg.RefreshTokenExpiresAt,
+		arg.Scope,
+	)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.UserId,
+		&i.AccountId,
+		&i.ProviderId,
+		&i.AccessToken,
+		&i.RefreshToken,
+		&i.AccessTokenExpiresAt,
+		&i.RefreshTokenExpiresAt,
+		&i.Scope,
+		&i.IdToken,
+		&i.Password,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const deleteAccount = `-- name: DeleteAccount :one
 DELETE FROM "account" WHERE id = $1 RETURNING id, "userId", "accountId", "providerId", "accessToken", "refreshToken", "accessTokenExpiresAt", "refreshTokenExpiresAt", scope, "idToken", password, "createdAt", "updatedAt"
 `
@@ -156,6 +229,36 @@ func (q *Queries) GetAccountById(ctx context.Context, id uuid.UUID) (Account, er
 	return i, err
 }
 
+const getAccountByProviderIdAndAccountId = `-- name: GetAccountByProviderIdAndAccountId :one
+SELECT id, "userId", "accountId", "providerId", "accessToken", "refreshToken", "accessTokenExpiresAt", "refreshTokenExpiresAt", scope, "idToken", password, "createdAt", "updatedAt" FROM "account" WHERE "providerId" = $1 AND "accountId" = $2
+`
+
+type GetAccountByProviderIdAndAccountIdParams struct {
+	ProviderId string `json:"providerId"`
+	AccountId  string `json:"accountId"`
+}
+
+func (q *Queries) GetAccountByProviderIdAndAccountId(ctx context.Context, arg GetAccountByProviderIdAndAccountIdParams) (Account, error) {
+	row := q.db.QueryRow(ctx, getAccountByProviderIdAndAccountId, arg.ProviderId, arg.AccountId)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.UserId,
+		&i.AccountId,
+		&i.ProviderId,
+		&i.AccessToken,
+		&i.RefreshToken,
+		&i.AccessTokenExpiresAt,
+		&i.RefreshTokenExpiresAt,
+		&i.Scope,
+		&i.IdToken,
+		&i.Password,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const getAccountByUserIdAndProvider = `-- name: GetAccountByUserIdAndProvider :one
 SELECT id, "userId", "accountId", "providerId", "accessToken", "refreshToken", "accessTokenExpiresAt", "refreshTokenExpiresAt", scope, "idToken", password, "createdAt", "updatedAt" FROM "account" WHERE "userId" = $1 AND "providerId" = $2
 `
@@ -185,3 +288,120 @@ func (q *Queries) GetAccountByUserIdAndProvider(ctx context.Context, arg GetAcco
 	)
 	return i, err
 }
+
+const listAccountsByUserId = `-- name: ListAccountsByUserId :many
+SELECT id, "userId", "accountId", "providerId", "accessToken", "refreshToken", "accessTokenExpiresAt", "refreshTokenExpiresAt", scope, "idToken", password, "createdAt", "updatedAt" FROM "account" WHERE "userId" = $1 ORDER BY "createdAt"
+`
+
+func (q *Queries) ListAccountsByUserId(ctx context.Context, userid uuid.UUID) ([]Account, error) {
+	rows, err := q.db.Query(ctx, listAccountsByUserId, userid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Account
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserId,
+			&i.AccountId,
+			&i.ProviderId,
+			&i.AccessToken,
+			&i.RefreshToken,
+			&i.AccessTokenExpiresAt,
+			&i.RefreshTokenExpiresAt,
+			&i.Scope,
+			&i.IdToken,
+			&i.Password,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateAccountIdByUserIdAndProvider = `-- name: UpdateAccountIdByUserIdAndProvider :execrows
+UPDATE "account"
+SET
+    "accountId" = $3,
+    "updatedAt" = CURRENT_TIMESTAMP
+WHERE
+    "userId" = $1
+    AND "providerId" = $2
+`
+
+type UpdateAccountIdByUserIdAndProviderParams struct {
+	UserId     uuid.UUID `json:"userId"`
+	ProviderId string    `json:"providerId"`
+	AccountId  string    `json:"accountId"`
+}
+
+func (q *Queries) UpdateAccountIdByUserIdAndProvider(ctx context.Context, arg UpdateAccountIdByUserIdAndProviderParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateAccountIdByUserIdAndProvider, arg.UserId, arg.ProviderId, arg.AccountId)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateAccountTokens = `-- name: UpdateAccountTokens :one
+UPDATE "account"
+SET
+    "accessToken" = $2,
+    "refreshToken" = $3,
+    "idToken" = $4,
+    "accessTokenExpiresAt" = $5,
+    "refreshTokenExpiresAt" = $6,
+    scope = $7,
+    "updatedAt" = CURRENT_TIMESTAMP
+WHERE
+    id = $1
+RETURNING
+    id, "userId", "accountId", "providerId", "accessToken", "refreshToken", "accessTokenExpiresAt", "refreshTokenExpiresAt", scope, "idToken", password, "createdAt", "updatedAt"
+`
+
+type UpdateAccountTokensParams struct {
+	ID                    uuid.UUID  `json:"id"`
+	AccessToken           *string    `json:"accessToken"`
+	RefreshToken          *string    `json:"refreshToken"`
+	IdToken               *string    `json:"idToken"`
+	AccessTokenExpiresAt  *time.Time `json:"accessTokenExpiresAt"`
+	RefreshTokenExpiresAt *time.Time `json:"refreshTokenExpiresAt"`
+	Scope                 *string    `json:"scope"`
+}
+
+func (q *Queries) UpdateAccountTokens(ctx context.Context, arg UpdateAccountTokensParams) (Account, error) {
+	row := q.db.QueryRow(ctx, updateAccountTokens,
+		arg.ID,
+		arg.AccessToken,
+		arg.RefreshToken,
+		arg.IdToken,
+		arg.AccessTokenExpiresAt,
+		arg.RefreshTokenExpiresAt,
+		arg.Scope,
+	)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.UserId,
+		&i.AccountId,
+		&i.ProviderId,
+		&i.AccessToken,
+		&i.RefreshToken,
+		&i.AccessTokenExpiresAt,
+		&i.RefreshTokenExpiresAt,
+		&i.Scope,
+		&i.IdToken,
+		&i.Password,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
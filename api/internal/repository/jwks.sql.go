@@ -12,6 +12,44 @@ import (
 	"github.com/google/uuid"
 )
 
+const createJwksWithId = `-- name: CreateJwksWithId :one
+INSERT INTO
+    jwks (
+        id,
+        "publicKey",
+        "privateKey",
+        "expiresAt"
+    )
+VALUES ($1, $2, $3, $4)
+RETURNING
+    id, "publicKey", "privateKey", "createdAt", "expiresAt"
+`
+
+type CreateJwksWithIdParams struct {
+	ID         uuid.UUID  `json:"id"`
+	PublicKey  string     `json:"publicKey"`
+	PrivateKey string     `json:"privateKey"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+}
+
+func (q *Queries) CreateJwksWithId(ctx context.Context, arg CreateJwksWithIdParams) (Jwk, error) {
+	row := q.db.QueryRow(ctx, createJwksWithId,
+		arg.ID,
+		arg.PublicKey,
+		arg.PrivateKey,
+		arg.ExpiresAt,
+	)
+	var i Jwk
+	err := row.Scan(
+		&i.ID,
+		&i.PublicKey,
+		&i.PrivateKey,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
 const getJwksSets = `-- name: GetJwksSets :many
 SELECT
     id,
@@ -55,3 +93,38 @@ func (q *Queries) GetJwksSets(ctx context.Context) ([]GetJwksSetsRow, error) {
 	}
 	return items, nil
 }
+
+const listJwks = `-- name: ListJwks :many
+SELECT id, "publicKey", "privateKey", "createdAt", "expiresAt"
+FROM jwks
+WHERE
+    "expiresAt" IS NULL
+    OR "expiresAt" > NOW()
+ORDER BY "createdAt" DESC
+`
+
+func (q *Queries) ListJwks(ctx context.Context) ([]Jwk, error) {
+	rows, err := q.db.Query(ctx, listJwks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Jwk
+	for rows.Next() {
+		var i Jwk
+		if err := rows.Scan(
+			&i.ID,
+			&i.PublicKey,
+			&i.PrivateKey,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
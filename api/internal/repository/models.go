@@ -26,6 +26,52 @@ type Account struct {
 	UpdatedAt             time.Time  `json:"updatedAt"`
 }
 
+type Asset struct {
+	ID          uuid.UUID `json:"id"`
+	UserId      uuid.UUID `json:"userId"`
+	Purpose     string    `json:"purpose"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"contentType"`
+	Size        int64     `json:"size"`
+	Sha256      string    `json:"sha256"`
+	Width       *int32    `json:"width"`
+	Height      *int32    `json:"height"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type AuditLog struct {
+	ID         uuid.UUID  `json:"id"`
+	Action     string     `json:"action"`
+	ActorId    *uuid.UUID `json:"actorId"`
+	TargetType *string    `json:"targetType"`
+	TargetId   *string    `json:"targetId"`
+	IpAddress  *string    `json:"ipAddress"`
+	UserAgent  *string    `json:"userAgent"`
+	RequestId  *string    `json:"requestId"`
+	Diff       []byte     `json:"diff"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+type Document struct {
+	ID        uuid.UUID  `json:"id"`
+	ProjectId uuid.UUID  `json:"projectId"`
+	Title     string     `json:"title"`
+	Content   string     `json:"content"`
+	CreatedBy *uuid.UUID `json:"createdBy"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+type DocumentComment struct {
+	ID          uuid.UUID  `json:"id"`
+	DocumentId  uuid.UUID  `json:"documentId"`
+	UserId      *uuid.UUID `json:"userId"`
+	ShareLinkId *uuid.UUID `json:"shareLinkId"`
+	AuthorName  string     `json:"authorName"`
+	Body        string     `json:"body"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
 type Event struct {
 	ID        uuid.UUID `json:"id"`
 	UserId    uuid.UUID `json:"userId"`
@@ -35,6 +81,17 @@ type Event struct {
 	UpdatedAt time.Time `json:"updatedAt"`
 }
 
+type Invitation struct {
+	ID             uuid.UUID `json:"id"`
+	OrganizationId uuid.UUID `json:"organizationId"`
+	Email          string    `json:"email"`
+	Role           string    `json:"role"`
+	Status         string    `json:"status"`
+	ExpiresAt      time.Time `json:"expiresAt"`
+	InviterId      uuid.UUID `json:"inviterId"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
 type Jwk struct {
 	ID         uuid.UUID  `json:"id"`
 	PublicKey  string     `json:"publicKey"`
@@ -43,27 +100,96 @@ type Jwk struct {
 	ExpiresAt  *time.Time `json:"expiresAt"`
 }
 
+type Lockout struct {
+	Key           string    `json:"key"`
+	Failures      int32     `json:"failures"`
+	LastFailureAt time.Time `json:"lastFailureAt"`
+}
+
+type Member struct {
+	ID             uuid.UUID `json:"id"`
+	OrganizationId uuid.UUID `json:"organizationId"`
+	UserId         uuid.UUID `json:"userId"`
+	Role           string    `json:"role"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+type Organization struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	Logo      *string   `json:"logo"`
+	Metadata  *string   `json:"metadata"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type Passkey struct {
+	ID           uuid.UUID `json:"id"`
+	Name         *string   `json:"name"`
+	PublicKey    string    `json:"publicKey"`
+	UserId       uuid.UUID `json:"userId"`
+	CredentialID string    `json:"credentialID"`
+	Counter      int32     `json:"counter"`
+	DeviceType   string    `json:"deviceType"`
+	BackedUp     bool      `json:"backedUp"`
+	Transports   *string   `json:"transports"`
+	Aaguid       *string   `json:"aaguid"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
 type Project struct {
-	ID          uuid.UUID `json:"id"`
-	UserId      uuid.UUID `json:"userId"`
-	Name        string    `json:"name"`
-	Slug        string    `json:"slug"`
-	Description *string   `json:"description"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	ID             uuid.UUID  `json:"id"`
+	UserId         *uuid.UUID `json:"userId"`
+	Name           string     `json:"name"`
+	Slug           string     `json:"slug"`
+	Description    *string    `json:"description"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+	OrganizationId *uuid.UUID `json:"organizationId"`
 }
 
-type Session struct {
+type ProjectMember struct {
 	ID        uuid.UUID `json:"id"`
+	ProjectId uuid.UUID `json:"projectId"`
 	UserId    uuid.UUID `json:"userId"`
-	Token     string    `json:"token"`
-	ExpiresAt time.Time `json:"expiresAt"`
-	IpAddress *string   `json:"ipAddress"`
-	UserAgent *string   `json:"userAgent"`
+	Role      string    `json:"role"`
 	CreatedAt time.Time `json:"createdAt"`
+}
+
+type RateLimit struct {
+	Key       string    `json:"key"`
+	Tokens    float64   `json:"tokens"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
 
+type Session struct {
+	ID                   uuid.UUID  `json:"id"`
+	UserId               uuid.UUID  `json:"userId"`
+	Token                string     `json:"token"`
+	ExpiresAt            time.Time  `json:"expiresAt"`
+	IpAddress            *string    `json:"ipAddress"`
+	UserAgent            *string    `json:"userAgent"`
+	CreatedAt            time.Time  `json:"createdAt"`
+	UpdatedAt            time.Time  `json:"updatedAt"`
+	ActiveOrganizationId *uuid.UUID `json:"activeOrganizationId"`
+	ImpersonatedBy       *uuid.UUID `json:"impersonatedBy"`
+}
+
+type ShareLink struct {
+	ID           uuid.UUID  `json:"id"`
+	ProjectId    uuid.UUID  `json:"projectId"`
+	DocumentId   *uuid.UUID `json:"documentId"`
+	Token        string     `json:"token"`
+	Role         string     `json:"role"`
+	PasswordHash *string    `json:"passwordHash"`
+	ExpiresAt    *time.Time `json:"expiresAt"`
+	MaxUses      *int32     `json:"maxUses"`
+	UseCount     int32      `json:"useCount"`
+	CreatedBy    uuid.UUID  `json:"createdBy"`
+	RevokedAt    *time.Time `json:"revokedAt"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
 type Subscription struct {
 	ID                  uuid.UUID  `json:"id"`
 	UserId              uuid.UUID  `json:"userId"`
@@ -76,14 +202,28 @@ type Subscription struct {
 	UpdatedAt           time.Time  `json:"updatedAt"`
 }
 
+type TwoFactor struct {
+	ID           uuid.UUID `json:"id"`
+	UserId       uuid.UUID `json:"userId"`
+	Secret       string    `json:"secret"`
+	BackupCodes  string    `json:"backupCodes"`
+	LastUsedStep int64     `json:"lastUsedStep"`
+}
+
 type User struct {
-	ID            uuid.UUID `json:"id"`
-	Name          string    `json:"name"`
-	Email         string    `json:"email"`
-	EmailVerified bool      `json:"emailVerified"`
-	Image         *string   `json:"image"`
-	CreatedAt     time.Time `json:"createdAt"`
-	UpdatedAt     time.Time `json:"updatedAt"`
+	ID                  uuid.UUID  `json:"id"`
+	Name                string     `json:"name"`
+	Email               string     `json:"email"`
+	EmailVerified       bool       `json:"emailVerified"`
+	Image               *string    `json:"image"`
+	CreatedAt           time.Time  `json:"createdAt"`
+	UpdatedAt           time.Time  `json:"updatedAt"`
+	TwoFactorEnabled    bool       `json:"twoFactorEnabled"`
+	Role                string     `json:"role"`
+	Banned              bool       `json:"banned"`
+	BanReason           *string    `json:"banReason"`
+	BanExpires          *time.Time `json:"banExpires"`
+	DeletionScheduledAt *time.Time `json:"deletionScheduledAt"`
 }
 
 type Verification struct {
@@ -11,6 +11,98 @@ import (
 	"github.com/google/uuid"
 )
 
+const countProjectsSolelyOwnedByUser = `-- name: CountProjectsSolelyOwnedByUser :one
+SELECT COUNT(*)
+FROM "project" p
+WHERE
+    p."organizationId" IS NOT NULL
+    AND (
+        EXISTS (
+            SELECT 1
+            FROM "member" m
+            WHERE
+                m."organizationId" = p."organizationId"
+                AND m."userId" = $1
+                AND m.role = 'owner'
+        )
+        OR EXISTS (
+            SELECT 1
+            FROM "projectMember" pm
+            WHERE
+                pm."projectId" = p.id
+                AND pm."userId" = $1
+                AND pm.role = 'owner'
+        )
+    )
+    AND NOT EXISTS (
+        SELECT 1
+        FROM "member" m
+        WHERE
+            m."organizationId" = p."organizationId"
+            AND m."userId" <> $1
+            AND m.role = 'owner'
+    )
+    AND NOT EXISTS (
+        SELECT 1
+        FROM "projectMember" pm
+        WHERE
+            pm."projectId" = p.id
+            AND pm."userId" <> $1
+            AND pm.role = 'owner'
+    )
+`
+
+// Organization projects on which the user is the only owner, through the
+// organization role or a project grant. Deleting the user would leave them
+// without one.
+func (q *Queries) CountProjectsSolelyOwnedByUser(ctx context.Context, userid uuid.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countProjectsSolelyOwnedByUser, userid)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createOrganizationProject = `-- name: CreateOrganizationProject :one
+INSERT INTO
+    "project" (
+        "organizationId",
+        name,
+        slug,
+        description
+    )
+VALUES ($1, $2, $3, $4)
+RETURNING
+    id, "userId", name, slug, description, "createdAt", "updatedAt", "organizationId"
+`
+
+type CreateOrganizationProjectParams struct {
+	OrganizationId *uuid.UUID `json:"organizationId"`
+	Name           string     `json:"name"`
+	Slug           string     `json:"slug"`
+	Description    *string    `json:"description"`
+}
+
+func (q *Queries) CreateOrganizationProject(ctx context.Context, arg CreateOrganizationProjectParams) (Project, error) {
+	row := q.db.QueryRow(ctx, createOrganizationProject,
+		arg.OrganizationId,
+		arg.Name,
+		arg.Slug,
+		arg.Description,
+	)
+	var i Project
+	err := row.Scan(
+		&i.ID,
+		&i.UserId,
+		&i.Name,
+		&i.Slug,
+		&i.Description,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.OrganizationId,
+	)
+	return i, err
+}
+
 const createProject = `-- name: CreateProject :one
 INSERT INTO
     "project" (
@@ -21,14 +113,14 @@ INSERT INTO
     )
 VALUES ($1, $2, $3, $4)
 RETURNING
-    id, "userId", name, slug, description, "createdAt", "updatedAt"
+    id, "userId", name, slug, description, "createdAt", "updatedAt", "organizationId"
 `
 
 type CreateProjectParams struct {
-	UserId      uuid.UUID `json:"userId"`
-	Name        string    `json:"name"`
-	Slug        string    `json:"slug"`
-	Description *string   `json:"description"`
+	UserId      *uuid.UUID `json:"userId"`
+	Name        string     `json:"name"`
+	Slug        string     `json:"slug"`
+	Description *string    `json:"description"`
 }
 
 func (q *Queries) CreateProject(ctx context.Context, arg CreateProjectParams) (Project, error) {
@@ -47,6 +139,7 @@ func (q *Queries) CreateProject(ctx context.Context, arg CreateProjectParams) (P
 		&i.Description,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.OrganizationId,
 	)
 	return i, err
 }
@@ -62,15 +155,15 @@ INSERT INTO
     )
 VALUES ($1, $2, $3, $4, $5)
 RETURNING
-    id, "userId", name, slug, description, "createdAt", "updatedAt"
+    id, "userId", name, slug, description, "createdAt", "updatedAt", "organizationId"
 `
 
 type CreateProjectWithIdParams struct {
-	ID          uuid.UUID `json:"id"`
-	UserId      uuid.UUID `json:"userId"`
-	Name        string    `json:"name"`
-	Slug        string    `json:"slug"`
-	Description *string   `json:"description"`
+	ID          uuid.UUID  `json:"id"`
+	UserId      *uuid.UUID `json:"userId"`
+	Name        string     `json:"name"`
+	Slug        string     `json:"slug"`
+	Description *string    `json:"description"`
 }
 
 func (q *Queries) CreateProjectWithId(ctx context.Context, arg CreateProjectWithIdParams) (Project, error) {
@@ -90,12 +183,13 @@ func (q *Queries) CreateProjectWithId(ctx context.Context, arg CreateProjectWith
 		&i.Description,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.OrganizationId,
 	)
 	return i, err
 }
 
 const deleteProject = `-- name: DeleteProject :one
-DELETE FROM "project" WHERE id = $1 RETURNING id, "userId", name, slug, description, "createdAt", "updatedAt"
+DELETE FROM "project" WHERE id = $1 RETURNING id, "userId", name, slug, description, "createdAt", "updatedAt", "organizationId"
 `
 
 func (q *Queries) DeleteProject(ctx context.Context, id uuid.UUID) (Project, error) {
@@ -109,6 +203,7 @@ func (q *Queries) DeleteProject(ctx context.Context, id uuid.UUID) (Project, err
 		&i.Description,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.OrganizationId,
 	)
 	return i, err
 }
@@ -117,13 +212,13 @@ const deleteProjectsByUserID = `-- name: DeleteProjectsByUserID :exec
 DELETE FROM "project" WHERE "userId" = $1
 `
 
-func (q *Queries) DeleteProjectsByUserID(ctx context.Context, userid uuid.UUID) error {
+func (q *Queries) DeleteProjectsByUserID(ctx context.Context, userid *uuid.UUID) error {
 	_, err := q.db.Exec(ctx, deleteProjectsByUserID, userid)
 	return err
 }
 
 const getProjectByID = `-- name: GetProjectByID :one
-SELECT id, "userId", name, slug, description, "createdAt", "updatedAt" FROM "project" WHERE id = $1
+SELECT id, "userId", name, slug, description, "createdAt", "updatedAt", "organizationId" FROM "project" WHERE id = $1
 `
 
 func (q *Queries) GetProjectByID(ctx context.Context, id uuid.UUID) (Project, error) {
@@ -137,17 +232,43 @@ func (q *Queries) GetProjectByID(ctx context.Context, id uuid.UUID) (Project, er
 		&i.Description,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.OrganizationId,
+	)
+	return i, err
+}
+
+const getProjectByOrganizationIDAndSlug = `-- name: GetProjectByOrganizationIDAndSlug :one
+SELECT id, "userId", name, slug, description, "createdAt", "updatedAt", "organizationId" FROM "project" WHERE "organizationId" = $1 AND slug = $2
+`
+
+type GetProjectByOrganizationIDAndSlugParams struct {
+	OrganizationId *uuid.UUID `json:"organizationId"`
+	Slug           string     `json:"slug"`
+}
+
+func (q *Queries) GetProjectByOrganizationIDAndSlug(ctx context.Context, arg GetProjectByOrganizationIDAndSlugParams) (Project, error) {
+	row := q.db.QueryRow(ctx, getProjectByOrganizationIDAndSlug, arg.OrganizationId, arg.Slug)
+	var i Project
+	err := row.Scan(
+		&i.ID,
+		&i.UserId,
+		&i.Name,
+		&i.Slug,
+		&i.Description,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.OrganizationId,
 	)
 	return i, err
 }
 
 const getProjectByUserIDAndSlug = `-- name: GetProjectByUserIDAndSlug :one
-SELECT id, "userId", name, slug, description, "createdAt", "updatedAt" FROM "project" WHERE "userId" = $1 AND slug = $2
+SELECT id, "userId", name, slug, description, "createdAt", "updatedAt", "organizationId" FROM "project" WHERE "userId" = $1 AND slug = $2
 `
 
 type GetProjectByUserIDAndSlugParams struct {
-	UserId uuid.UUID `json:"userId"`
-	Slug   string    `json:"slug"`
+	UserId *uuid.UUID `json:"userId"`
+	Slug   string     `json:"slug"`
 }
 
 func (q *Queries) GetProjectByUserIDAndSlug(ctx context.Context, arg GetProjectByUserIDAndSlugParams) (Project, error) {
@@ -161,19 +282,57 @@ func (q *Queries) GetProjectByUserIDAndSlug(ctx context.Context, arg GetProjectB
 		&i.Description,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.OrganizationId,
 	)
 	return i, err
 }
 
+const listProjectsByOrganizationID = `-- name: ListProjectsByOrganizationID :many
+SELECT id, "userId", name, slug, description, "createdAt", "updatedAt", "organizationId"
+FROM "project"
+WHERE
+    "organizationId" = $1
+ORDER BY "createdAt" DESC
+`
+
+func (q *Queries) ListProjectsByOrganizationID(ctx context.Context, organizationid *uuid.UUID) ([]Project, error) {
+	rows, err := q.db.Query(ctx, listProjectsByOrganizationID, organizationid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Project
+	for rows.Next() {
+		var i Project
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserId,
+			&i.Name,
+			&i.Slug,
+			&i.Description,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.OrganizationId,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listProjectsByUserID = `-- name: ListProjectsByUserID :many
-SELECT id, "userId", name, slug, description, "createdAt", "updatedAt"
+SELECT id, "userId", name, slug, description, "createdAt", "updatedAt", "organizationId"
 FROM "project"
 WHERE
     "userId" = $1
 ORDER BY "createdAt" DESC
 `
 
-func (q *Queries) ListProjectsByUserID(ctx context.Context, userid uuid.UUID) ([]Project, error) {
+func (q *Queries) ListProjectsByUserID(ctx context.Context, userid *uuid.UUID) ([]Project, error) {
 	rows, err := q.db.Query(ctx, listProjectsByUserID, userid)
 	if err != nil {
 		return nil, err
@@ -190,6 +349,7 @@ func (q *Queries) ListProjectsByUserID(ctx context.Context, userid uuid.UUID) ([
 			&i.Description,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.OrganizationId,
 		); err != nil {
 			return nil, err
 		}
@@ -201,6 +361,39 @@ func (q *Queries) ListProjectsByUserID(ctx context.Context, userid uuid.UUID) ([
 	return items, nil
 }
 
+const moveProjectToOrganization = `-- name: MoveProjectToOrganization :one
+UPDATE "project"
+SET
+    "userId" = NULL,
+    "organizationId" = $2,
+    "updatedAt" = CURRENT_TIMESTAMP
+WHERE
+    id = $1
+RETURNING
+    id, "userId", name, slug, description, "createdAt", "updatedAt", "organizationId"
+`
+
+type MoveProjectToOrganizationParams struct {
+	ID             uuid.UUID  `json:"id"`
+	OrganizationId *uuid.UUID `json:"organizationId"`
+}
+
+func (q *Queries) MoveProjectToOrganization(ctx context.Context, arg MoveProjectToOrganizationParams) (Project, error) {
+	row := q.db.QueryRow(ctx, moveProjectToOrganization, arg.ID, arg.OrganizationId)
+	var i Project
+	err := row.Scan(
+		&i.ID,
+		&i.UserId,
+		&i.Name,
+		&i.Slug,
+		&i.Description,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.OrganizationId,
+	)
+	return i, err
+}
+
 const updateProject = `-- name: UpdateProject :one
 UPDATE "project"
 SET
@@ -211,7 +404,7 @@ SET
 WHERE
     id = $1
 RETURNING
-    id, "userId", name, slug, description, "createdAt", "updatedAt"
+    id, "userId", name, slug, description, "createdAt", "updatedAt", "organizationId"
 `
 
 type UpdateProjectParams struct {
@@ -237,6 +430,7 @@ func (q *Queries) UpdateProject(ctx context.Context, arg UpdateProjectParams) (P
 		&i.Description,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.OrganizationId,
 	)
 	return i, err
 }
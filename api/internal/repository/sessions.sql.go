@@ -12,6 +12,55 @@ import (
 	"github.com/google/uuid"
 )
 
+const createImpersonationSession = `-- name: CreateImpersonationSession :one
+INSERT INTO
+    "session" (
+        token,
+        "userId",
+        "expiresAt",
+        "ipAddress",
+        "userAgent",
+        "impersonatedBy"
+    )
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING
+    id, "userId", token, "expiresAt", "ipAddress", "userAgent", "createdAt", "updatedAt", "activeOrganizationId", "impersonatedBy"
+`
+
+type CreateImpersonationSessionParams struct {
+	Token          string     `json:"token"`
+	UserId         uuid.UUID  `json:"userId"`
+	ExpiresAt      time.Time  `json:"expiresAt"`
+	IpAddress      *string    `json:"ipAddress"`
+	UserAgent      *string    `json:"userAgent"`
+	ImpersonatedBy *uuid.UUID `json:"impersonatedBy"`
+}
+
+func (q *Queries) CreateImpersonationSession(ctx context.Context, arg CreateImpersonationSessionParams) (Session, error) {
+	row := q.db.QueryRow(ctx, createImpersonationSession,
+		arg.Token,
+		arg.UserId,
+		arg.ExpiresAt,
+		arg.IpAddress,
+		arg.UserAgent,
+		arg.ImpersonatedBy,
+	)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.UserId,
+		&i.Token,
+		&i.ExpiresAt,
+		&i.IpAddress,
+		&i.UserAgent,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ActiveOrganizationId,
+		&i.ImpersonatedBy,
+	)
+	return i, err
+}
+
 const createSession = `-- name: CreateSession :one
 
 
@@ -25,7 +74,7 @@ INSERT INTO
     )
 VALUES ($1, $2, $3, $4, $5)
 RETURNING
-    id, "userId", token, "expiresAt", "ipAddress", "userAgent", "createdAt", "updatedAt"
+    id, "userId", token, "expiresAt", "ipAddress", "userAgent", "createdAt", "updatedAt", "activeOrganizationId", "impersonatedBy"
 `
 
 type CreateSessionParams struct {
@@ -73,6 +122,8 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		&i.UserAgent,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ActiveOrganizationId,
+		&i.ImpersonatedBy,
 	)
 	return i, err
 }
@@ -89,7 +140,7 @@ INSERT INTO
     )
 VALUES ($1, $2, $3, $4, $5, $6)
 RETURNING
-    id, "userId", token, "expiresAt", "ipAddress", "userAgent", "createdAt", "updatedAt"
+    id, "userId", token, "expiresAt", "ipAddress", "userAgent", "createdAt", "updatedAt", "activeOrganizationId", "impersonatedBy"
 `
 
 type CreateSessionWithIdParams struct {
@@ -120,12 +171,14 @@ func (q *Queries) CreateSessionWithId(ctx context.Context, arg CreateSessionWith
 		&i.UserAgent,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ActiveOrganizationId,
+		&i.ImpersonatedBy,
 	)
 	return i, err
 }
 
 const deleteSession = `-- name: DeleteSession :one
-DELETE FROM "session" WHERE id = $1 RETURNING id, "userId", token, "expiresAt", "ipAddress", "userAgent", "createdAt", "updatedAt"
+DELETE FROM "session" WHERE id = $1 RETURNING id, "userId", token, "expiresAt", "ipAddress", "userAgent", "createdAt", "updatedAt", "activeOrganizationId", "impersonatedBy"
 `
 
 func (q *Queries) DeleteSession(ctx context.Context, id uuid.UUID) (Session, error) {
@@ -140,6 +193,8 @@ func (q *Queries) DeleteSession(ctx context.Context, id uuid.UUID) (Session, err
 		&i.UserAgent,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ActiveOrganizationId,
+		&i.ImpersonatedBy,
 	)
 	return i, err
 }
@@ -154,7 +209,7 @@ func (q *Queries) DeleteUserSessions(ctx context.Context, userid uuid.UUID) erro
 }
 
 const getSession = `-- name: GetSession :one
-SELECT id, "userId", token, "expiresAt", "ipAddress", "userAgent", "createdAt", "updatedAt" FROM "session" WHERE id = $1
+SELECT id, "userId", token, "expiresAt", "ipAddress", "userAgent", "createdAt", "updatedAt", "activeOrganizationId", "impersonatedBy" FROM "session" WHERE id = $1
 `
 
 func (q *Queries) GetSession(ctx context.Context, id uuid.UUID) (Session, error) {
@@ -169,12 +224,14 @@ func (q *Queries) GetSession(ctx context.Context, id uuid.UUID) (Session, error)
 		&i.UserAgent,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ActiveOrganizationId,
+		&i.ImpersonatedBy,
 	)
 	return i, err
 }
 
 const getSessionByToken = `-- name: GetSessionByToken :one
-SELECT id, "userId", token, "expiresAt", "ipAddress", "userAgent", "createdAt", "updatedAt" FROM "session" WHERE token = $1
+SELECT id, "userId", token, "expiresAt", "ipAddress", "userAgent", "createdAt", "updatedAt", "activeOrganizationId", "impersonatedBy" FROM "session" WHERE token = $1
 `
 
 func (q *Queries) GetSessionByToken(ctx context.Context, token string) (Session, error) {
@@ -189,6 +246,82 @@ func (q *Queries) GetSessionByToken(ctx context.Context, token string) (Session,
 		&i.UserAgent,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ActiveOrganizationId,
+		&i.ImpersonatedBy,
+	)
+	return i, err
+}
+
+const listSessionsByUserId = `-- name: ListSessionsByUserId :many
+SELECT id, "userId", token, "expiresAt", "ipAddress", "userAgent", "createdAt", "updatedAt", "activeOrganizationId", "impersonatedBy"
+FROM "session"
+WHERE
+    "userId" = $1
+    AND "expiresAt" > CURRENT_TIMESTAMP
+ORDER BY "createdAt" DESC
+`
+
+func (q *Queries) ListSessionsByUserId(ctx context.Context, userid uuid.UUID) ([]Session, error) {
+	rows, err := q.db.Query(ctx, listSessionsByUserId, userid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Session
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserId,
+			&i.Token,
+			&i.ExpiresAt,
+			&i.IpAddress,
+			&i.UserAgent,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ActiveOrganizationId,
+			&i.ImpersonatedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setSessionActiveOrganization = `-- name: SetSessionActiveOrganization :one
+UPDATE "session"
+SET
+    "activeOrganizationId" = $2,
+    "updatedAt" = CURRENT_TIMESTAMP
+WHERE
+    id = $1
+RETURNING
+    id, "userId", token, "expiresAt", "ipAddress", "userAgent", "createdAt", "updatedAt", "activeOrganizationId", "impersonatedBy"
+`
+
+type SetSessionActiveOrganizationParams struct {
+	ID                   uuid.UUID  `json:"id"`
+	ActiveOrganizationId *uuid.UUID `json:"activeOrganizationId"`
+}
+
+func (q *Queries) SetSessionActiveOrganization(ctx context.Context, arg SetSessionActiveOrganizationParams) (Session, error) {
+	row := q.db.QueryRow(ctx, setSessionActiveOrganization, arg.ID, arg.ActiveOrganizationId)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.UserId,
+		&i.Token,
+		&i.ExpiresAt,
+		&i.IpAddress,
+		&i.UserAgent,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ActiveOrganizationId,
+		&i.ImpersonatedBy,
 	)
 	return i, err
 }
@@ -205,7 +338,7 @@ SET
 WHERE
     id = $1
 RETURNING
-    id, "userId", token, "expiresAt", "ipAddress", "userAgent", "createdAt", "updatedAt"
+    id, "userId", token, "expiresAt", "ipAddress", "userAgent", "createdAt", "updatedAt", "activeOrganizationId", "impersonatedBy"
 `
 
 type UpdateSessionParams struct {
@@ -236,6 +369,8 @@ func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) (S
 		&i.UserAgent,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ActiveOrganizationId,
+		&i.ImpersonatedBy,
 	)
 	return i, err
 }
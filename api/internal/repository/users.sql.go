@@ -7,10 +7,103 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+const banUser = `-- name: BanUser :one
+UPDATE "user"
+SET
+    banned = TRUE,
+    "banReason" = $2,
+    "banExpires" = $3,
+    "updatedAt" = CURRENT_TIMESTAMP
+WHERE
+    id = $1
+RETURNING
+    id, name, email, "emailVerified", image, "createdAt", "updatedAt", "twoFactorEnabled", role, banned, "banReason", "banExpires", "deletionScheduledAt"
+`
+
+type BanUserParams struct {
+	ID         uuid.UUID  `json:"id"`
+	BanReason  *string    `json:"banReason"`
+	BanExpires *time.Time `json:"banExpires"`
+}
+
+func (q *Queries) BanUser(ctx context.Context, arg BanUserParams) (User, error) {
+	row := q.db.QueryRow(ctx, banUser, arg.ID, arg.BanReason, arg.BanExpires)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.EmailVerified,
+		&i.Image,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TwoFactorEnabled,
+		&i.Role,
+		&i.Banned,
+		&i.BanReason,
+		&i.BanExpires,
+		&i.DeletionScheduledAt,
+	)
+	return i, err
+}
+
+const cancelUserDeletion = `-- name: CancelUserDeletion :one
+UPDATE "user"
+SET
+    "deletionScheduledAt" = NULL,
+    "updatedAt" = CURRENT_TIMESTAMP
+WHERE
+    id = $1
+RETURNING
+    id, name, email, "emailVerified", image, "createdAt", "updatedAt", "twoFactorEnabled", role, banned, "banReason", "banExpires", "deletionScheduledAt"
+`
+
+func (q *Queries) CancelUserDeletion(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRow(ctx, cancelUserDeletion, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.EmailVerified,
+		&i.Image,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TwoFactorEnabled,
+		&i.Role,
+		&i.Banned,
+		&i.BanReason,
+		&i.BanExpires,
+		&i.DeletionScheduledAt,
+	)
+	return i, err
+}
+
+const countUsers = `-- name: CountUsers :one
+SELECT COUNT(*)
+FROM "user"
+WHERE
+    email ILIKE $1::text
+    AND name ILIKE $2::text
+`
+
+type CountUsersParams struct {
+	EmailPattern string `json:"emailPattern"`
+	NamePattern  string `json:"namePattern"`
+}
+
+func (q *Queries) CountUsers(ctx context.Context, arg CountUsersParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countUsers, arg.EmailPattern, arg.NamePattern)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO
     "user" (
@@ -21,7 +114,7 @@ INSERT INTO
     )
 VALUES ($1, $2, $3, $4)
 RETURNING
-    id, name, email, "emailVerified", image, "createdAt", "updatedAt"
+    id, name, email, "emailVerified", image, "createdAt", "updatedAt", "twoFactorEnabled", role, banned, "banReason", "banExpires", "deletionScheduledAt"
 `
 
 type CreateUserParams struct {
@@ -47,6 +140,12 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Image,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TwoFactorEnabled,
+		&i.Role,
+		&i.Banned,
+		&i.BanReason,
+		&i.BanExpires,
+		&i.DeletionScheduledAt,
 	)
 	return i, err
 }
@@ -62,7 +161,7 @@ INSERT INTO
     )
 VALUES ($1, $2, $3, $4, $5)
 RETURNING
-    id, name, email, "emailVerified", image, "createdAt", "updatedAt"
+    id, name, email, "emailVerified", image, "createdAt", "updatedAt", "twoFactorEnabled", role, banned, "banReason", "banExpires", "deletionScheduledAt"
 `
 
 type CreateUserWithIdParams struct {
@@ -90,12 +189,18 @@ func (q *Queries) CreateUserWithId(ctx context.Context, arg CreateUserWithIdPara
 		&i.Image,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TwoFactorEnabled,
+		&i.Role,
+		&i.Banned,
+		&i.BanReason,
+		&i.BanExpires,
+		&i.DeletionScheduledAt,
 	)
 	return i, err
 }
 
 const deleteUser = `-- name: DeleteUser :one
-DELETE FROM "user" WHERE id = $1 RETURNING id, name, email, "emailVerified", image, "createdAt", "updatedAt"
+DELETE FROM "user" WHERE id = $1 RETURNING id, name, email, "emailVerified", image, "createdAt", "updatedAt", "twoFactorEnabled", role, banned, "banReason", "banExpires", "deletionScheduledAt"
 `
 
 func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) (User, error) {
@@ -109,12 +214,18 @@ func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.Image,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TwoFactorEnabled,
+		&i.Role,
+		&i.Banned,
+		&i.BanReason,
+		&i.BanExpires,
+		&i.DeletionScheduledAt,
 	)
 	return i, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, name, email, "emailVerified", image, "createdAt", "updatedAt" FROM "user" WHERE email = $1
+SELECT id, name, email, "emailVerified", image, "createdAt", "updatedAt", "twoFactorEnabled", role, banned, "banReason", "banExpires", "deletionScheduledAt" FROM "user" WHERE email = $1
 `
 
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
@@ -128,12 +239,18 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Image,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TwoFactorEnabled,
+		&i.Role,
+		&i.Banned,
+		&i.BanReason,
+		&i.BanExpires,
+		&i.DeletionScheduledAt,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, name, email, "emailVerified", image, "createdAt", "updatedAt" FROM "user" WHERE id = $1
+SELECT id, name, email, "emailVerified", image, "createdAt", "updatedAt", "twoFactorEnabled", role, banned, "banReason", "banExpires", "deletionScheduledAt" FROM "user" WHERE id = $1
 `
 
 func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
@@ -147,16 +264,42 @@ func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.Image,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TwoFactorEnabled,
+		&i.Role,
+		&i.Banned,
+		&i.BanReason,
+		&i.BanExpires,
+		&i.DeletionScheduledAt,
 	)
 	return i, err
 }
 
 const listUsers = `-- name: ListUsers :many
-SELECT id, name, email, "emailVerified", image, "createdAt", "updatedAt" FROM "user"
+SELECT id, name, email, "emailVerified", image, "createdAt", "updatedAt", "twoFactorEnabled", role, banned, "banReason", "banExpires", "deletionScheduledAt"
+FROM "user"
+WHERE
+    email ILIKE $1::text
+    AND name ILIKE $2::text
+ORDER BY "createdAt" DESC
+LIMIT $3
+OFFSET
+    $4
 `
 
-func (q *Queries) ListUsers(ctx context.Context) ([]User, error) {
-	rows, err := q.db.Query(ctx, listUsers)
+type ListUsersParams struct {
+	EmailPattern string `json:"emailPattern"`
+	NamePattern  string `json:"namePattern"`
+	RowLimit     int32  `json:"rowLimit"`
+	RowOffset    int32  `json:"rowOffset"`
+}
+
+func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error) {
+	rows, err := q.db.Query(ctx, listUsers,
+		arg.EmailPattern,
+		arg.NamePattern,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -172,6 +315,12 @@ func (q *Queries) ListUsers(ctx context.Context) ([]User, error) {
 			&i.Image,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.TwoFactorEnabled,
+			&i.Role,
+			&i.Banned,
+			&i.BanReason,
+			&i.BanExpires,
+			&i.DeletionScheduledAt,
 		); err != nil {
 			return nil, err
 		}
@@ -183,6 +332,194 @@ func (q *Queries) ListUsers(ctx context.Context) ([]User, error) {
 	return items, nil
 }
 
+const listUsersDueForDeletion = `-- name: ListUsersDueForDeletion :many
+SELECT id, name, email, "emailVerified", image, "createdAt", "updatedAt", "twoFactorEnabled", role, banned, "banReason", "banExpires", "deletionScheduledAt"
+FROM "user"
+WHERE
+    "deletionScheduledAt" <= CURRENT_TIMESTAMP
+ORDER BY "deletionScheduledAt"
+LIMIT $1
+`
+
+func (q *Queries) ListUsersDueForDeletion(ctx context.Context, limit int32) ([]User, error) {
+	rows, err := q.db.Query(ctx, listUsersDueForDeletion, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Email,
+			&i.EmailVerified,
+			&i.Image,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.TwoFactorEnabled,
+			&i.Role,
+			&i.Banned,
+			&i.BanReason,
+			&i.BanExpires,
+			&i.DeletionScheduledAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const scheduleUserDeletion = `-- name: ScheduleUserDeletion :one
+UPDATE "user"
+SET
+    "deletionScheduledAt" = $2,
+    "updatedAt" = CURRENT_TIMESTAMP
+WHERE
+    id = $1
+RETURNING
+    id, name, email, "emailVerified", image, "createdAt", "updatedAt", "twoFactorEnabled", role, banned, "banReason", "banExpires", "deletionScheduledAt"
+`
+
+type ScheduleUserDeletionParams struct {
+	ID                  uuid.UUID  `json:"id"`
+	DeletionScheduledAt *time.Time `json:"deletionScheduledAt"`
+}
+
+func (q *Queries) ScheduleUserDeletion(ctx context.Context, arg ScheduleUserDeletionParams) (User, error) {
+	row := q.db.QueryRow(ctx, scheduleUserDeletion, arg.ID, arg.DeletionScheduledAt)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.EmailVerified,
+		&i.Image,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TwoFactorEnabled,
+		&i.Role,
+		&i.Banned,
+		&i.BanReason,
+		&i.BanExpires,
+		&i.DeletionScheduledAt,
+	)
+	return i, err
+}
+
+const setUserRole = `-- name: SetUserRole :one
+UPDATE "user"
+SET
+    role = $2,
+    "updatedAt" = CURRENT_TIMESTAMP
+WHERE
+    id = $1
+RETURNING
+    id, name, email, "emailVerified", image, "createdAt", "updatedAt", "twoFactorEnabled", role, banned, "banReason", "banExpires", "deletionScheduledAt"
+`
+
+type SetUserRoleParams struct {
+	ID   uuid.UUID `json:"id"`
+	Role string    `json:"role"`
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) (User, error) {
+	row := q.db.QueryRow(ctx, setUserRole, arg.ID, arg.Role)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.EmailVerified,
+		&i.Image,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TwoFactorEnabled,
+		&i.Role,
+		&i.Banned,
+		&i.BanReason,
+		&i.BanExpires,
+		&i.DeletionScheduledAt,
+	)
+	return i, err
+}
+
+const setUserTwoFactorEnabled = `-- name: SetUserTwoFactorEnabled :one
+UPDATE "user"
+SET
+    "twoFactorEnabled" = $2,
+    "updatedAt" = CURRENT_TIMESTAMP
+WHERE
+    id = $1
+RETURNING
+    id, name, email, "emailVerified", image, "createdAt", "updatedAt", "twoFactorEnabled", role, banned, "banReason", "banExpires", "deletionScheduledAt"
+`
+
+type SetUserTwoFactorEnabledParams struct {
+	ID               uuid.UUID `json:"id"`
+	TwoFactorEnabled bool      `json:"twoFactorEnabled"`
+}
+
+func (q *Queries) SetUserTwoFactorEnabled(ctx context.Context, arg SetUserTwoFactorEnabledParams) (User, error) {
+	row := q.db.QueryRow(ctx, setUserTwoFactorEnabled, arg.ID, arg.TwoFactorEnabled)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.EmailVerified,
+		&i.Image,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TwoFactorEnabled,
+		&i.Role,
+		&i.Banned,
+		&i.BanReason,
+		&i.BanExpires,
+		&i.DeletionScheduledAt,
+	)
+	return i, err
+}
+
+const unbanUser = `-- name: UnbanUser :one
+UPDATE "user"
+SET
+    banned = FALSE,
+    "banReason" = NULL,
+    "banExpires" = NULL,
+    "updatedAt" = CURRENT_TIMESTAMP
+WHERE
+    id = $1
+RETURNING
+    id, name, email, "emailVerified", image, "createdAt", "updatedAt", "twoFactorEnabled", role, banned, "banReason", "banExpires", "deletionScheduledAt"
+`
+
+func (q *Queries) UnbanUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRow(ctx, unbanUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.EmailVerified,
+		&i.Image,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TwoFactorEnabled,
+		&i.Role,
+		&i.Banned,
+		&i.BanReason,
+		&i.BanExpires,
+		&i.DeletionScheduledAt,
+	)
+	return i, err
+}
+
 const updateUser = `-- name: UpdateUser :one
 UPDATE "user"
 SET
@@ -194,7 +531,7 @@ SET
 WHERE
     id = $1
 RETURNING
-    id, name, email, "emailVerified", image, "createdAt", "updatedAt"
+    id, name, email, "emailVerified", image, "createdAt", "updatedAt", "twoFactorEnabled", role, banned, "banReason", "banExpires", "deletionScheduledAt"
 `
 
 type UpdateUserParams struct {
@@ -222,6 +559,50 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Image,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TwoFactorEnabled,
+		&i.Role,
+		&i.Banned,
+		&i.BanReason,
+		&i.BanExpires,
+		&i.DeletionScheduledAt,
+	)
+	return i, err
+}
+
+const updateUserEmail = `-- name: UpdateUserEmail :one
+UPDATE "user"
+SET
+    email = $2,
+    "emailVerified" = TRUE,
+    "updatedAt" = CURRENT_TIMESTAMP
+WHERE
+    id = $1
+RETURNING
+    id, name, email, "emailVerified", image, "createdAt", "updatedAt", "twoFactorEnabled", role, banned, "banReason", "banExpires", "deletionScheduledAt"
+`
+
+type UpdateUserEmailParams struct {
+	ID    uuid.UUID `json:"id"`
+	Email string    `json:"email"`
+}
+
+func (q *Queries) UpdateUserEmail(ctx context.Context, arg UpdateUserEmailParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserEmail, arg.ID, arg.Email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.EmailVerified,
+		&i.Image,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TwoFactorEnabled,
+		&i.Role,
+		&i.Banned,
+		&i.BanReason,
+		&i.BanExpires,
+		&i.DeletionScheduledAt,
 	)
 	return i, err
 }
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// live reports that the process serves requests. It checks no dependency,
+// so an outage doesn't get every instance restarted.
+func (s *Server) live(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("OK"))
+}
+
+// ready reports whether the server should get traffic. It fails once
+// shutdown starts, so load balancers stop routing to the instance while its
+// requests drain, and when a critical dependency check fails. Check errors
+// are only shown to admins.
+func (s *Server) ready(w http.ResponseWriter, r *http.Request) {
+	if s.draining.Load() {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"status": "draining"})
+		return
+	}
+
+	report := s.health.Check(r.Context())
+	checks := make(map[string]string, len(report.Checks))
+	for _, check := range report.Checks {
+		checks[check.Name] = string(check.Status)
+	}
+
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, map[string]any{
+		"status": report.Status,
+		"checks": checks,
+	})
+}
+
+// healthReport returns the detailed results of the dependency checks
+func (s *Server) healthReport(w http.ResponseWriter, r *http.Request) {
+	report := s.health.Check(r.Context())
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status":   report.Status,
+		"draining": s.draining.Load(),
+		"checks":   report.Checks,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data) //nolint:errcheck // Best-effort encoding
+}
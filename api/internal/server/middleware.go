@@ -2,28 +2,23 @@ package server
 
 import (
 	"context"
+	"crypto/subtle"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
+	"budhapp.com/internal/authz"
+	"budhapp.com/internal/handlers"
+	"budhapp.com/internal/logging"
+	"budhapp.com/internal/middleware"
+	"budhapp.com/internal/repository"
 	"budhapp.com/internal/session"
-	"github.com/lestrrat-go/jwx/v3/jwt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
-func (s *Server) logRequest(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		var (
-			ip     = r.RemoteAddr
-			proto  = r.Proto
-			method = r.Method
-			uri    = r.URL.RequestURI()
-		)
-
-		s.logger.Info("received request", "ip", ip, "proto", proto, "method", method, "uri", uri)
-
-		next.ServeHTTP(w, r)
-	})
-}
-
 func (s *Server) recoverPanic(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
@@ -42,7 +37,7 @@ func (s *Server) requireAuthentication(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		isAuthenticated, ok := r.Context().Value(session.IsAuthenticatedContextKey).(bool)
 		if !ok || !isAuthenticated {
-			s.clientError(w, http.StatusUnauthorized)
+			s.clientError(w, r, http.StatusUnauthorized)
 			return
 		}
 
@@ -50,15 +45,39 @@ func (s *Server) requireAuthentication(next http.Handler) http.Handler {
 	})
 }
 
+// requireMetricsToken checks the bearer token of scrapers when one is
+// configured
+func (s *Server) requireMetricsToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.config.Metrics.Token != "" {
+			token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(token), []byte(s.config.Metrics.Token)) != 1 {
+				s.clientError(w, r, http.StatusUnauthorized)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (s *Server) authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		token, err := jwt.ParseRequest(r, jwt.WithVerify(false))
+		token, err := s.keys.ParseRequest(r)
 		if err != nil {
 			ctx := context.WithValue(r.Context(), session.IsAuthenticatedContextKey, false)
 			next.ServeHTTP(w, r.WithContext(ctx))
 			return
 		}
 
+		// Anonymous share sessions only open the /api/shared routes
+		var shareLinkID string
+		if token.Get(handlers.ShareLinkClaim, &shareLinkID) == nil {
+			ctx := context.WithValue(r.Context(), session.IsAuthenticatedContextKey, false)
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
 		userID, exists := token.Subject()
 		if !exists {
 			ctx := context.WithValue(r.Context(), session.IsAuthenticatedContextKey, false)
@@ -79,6 +98,7 @@ func (s *Server) authenticate(next http.Handler) http.Handler {
 		}
 
 		// Create a new context with the user info
+		logging.AddAttrs(r.Context(), "userId", userID)
 		ctx := context.WithValue(r.Context(), session.UserContextKey, &userInfo)
 		ctx = context.WithValue(ctx, session.IsAuthenticatedContextKey, true)
 
@@ -86,3 +106,165 @@ func (s *Server) authenticate(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// authorizeProject loads the project named by the {slug} route parameter,
+// resolves the caller's role on it and rejects the request unless the role
+// grants permission. Routes with an {organization} parameter resolve the slug
+// among that organization's projects, others among the caller's own. The
+// project and permission set are stored in the request context.
+func (s *Server) authorizeProject(permission authz.Permission) middleware.Constructor {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := session.UserFromContext(r.Context())
+			if !ok {
+				s.clientError(w, r, http.StatusUnauthorized)
+				return
+			}
+			userID, err := uuid.Parse(user.ID)
+			if err != nil {
+				s.clientError(w, r, http.StatusUnauthorized)
+				return
+			}
+
+			project, role, err := s.projectRole(r, userID)
+			if errors.Is(err, pgx.ErrNoRows) {
+				s.clientError(w, r, http.StatusNotFound)
+				return
+			}
+			if err != nil {
+				s.serverError(w, r, err)
+				return
+			}
+
+			// Callers without any role don't learn that the project exists
+			if role == "" {
+				s.clientError(w, r, http.StatusNotFound)
+				return
+			}
+
+			permissions := authz.Permissions(role)
+			if !permissions.Has(permission) {
+				s.clientError(w, r, http.StatusForbidden)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), session.ProjectContextKey, &project)
+			ctx = context.WithValue(ctx, session.PermissionsContextKey, permissions)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// projectRole loads the route's project and returns the caller's highest role
+// on it, or an empty role when they have none
+func (s *Server) projectRole(r *http.Request, userID uuid.UUID) (repository.Project, authz.Role, error) {
+	ctx := r.Context()
+	slug := r.PathValue("slug")
+
+	orgSlug := r.PathValue("organization")
+	if orgSlug == "" {
+		project, err := s.queries.GetProjectByUserIDAndSlug(ctx, repository.GetProjectByUserIDAndSlugParams{
+			UserId: &userID,
+			Slug:   slug,
+		})
+		return project, authz.RoleOwner, err
+	}
+
+	org, err := s.queries.GetOrganizationBySlug(ctx, orgSlug)
+	if err != nil {
+		return repository.Project{}, "", err
+	}
+	project, err := s.queries.GetProjectByOrganizationIDAndSlug(ctx, repository.GetProjectByOrganizationIDAndSlugParams{
+		OrganizationId: &org.ID,
+		Slug:           slug,
+	})
+	if err != nil {
+		return repository.Project{}, "", err
+	}
+
+	var roles []authz.Role
+	member, err := s.queries.GetMemberByOrganizationIdAndUserId(ctx, repository.GetMemberByOrganizationIdAndUserIdParams{
+		OrganizationId: org.ID,
+		UserId:         userID,
+	})
+	if err == nil {
+		if role, ok := authz.OrganizationRole(member.Role); ok {
+			roles = append(roles, role)
+		}
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return repository.Project{}, "", err
+	}
+
+	grant, err := s.queries.GetProjectMember(ctx, repository.GetProjectMemberParams{
+		ProjectId: project.ID,
+		UserId:    userID,
+	})
+	if err == nil {
+		roles = append(roles, authz.Role(grant.Role))
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return repository.Project{}, "", err
+	}
+
+	role, _ := authz.Resolve(roles...)
+	return project, role, nil
+}
+
+// authorizeShare admits anonymous share sessions issued by the share link
+// handler. The link is reloaded on every request so revoking or expiring it
+// takes effect immediately, and every request is recorded as an event.
+func (s *Server) authorizeShare(permission authz.Permission) middleware.Constructor {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, err := s.keys.ParseRequestFor(r, handlers.ShareAudience)
+			if err != nil {
+				s.clientError(w, r, http.StatusUnauthorized)
+				return
+			}
+			var claim string
+			if err := token.Get(handlers.ShareLinkClaim, &claim); err != nil {
+				s.clientError(w, r, http.StatusUnauthorized)
+				return
+			}
+			linkID, err := uuid.Parse(claim)
+			if err != nil {
+				s.clientError(w, r, http.StatusUnauthorized)
+				return
+			}
+
+			link, err := s.queries.GetShareLinkByID(r.Context(), linkID)
+			if errors.Is(err, pgx.ErrNoRows) {
+				s.clientError(w, r, http.StatusUnauthorized)
+				return
+			}
+			if err != nil {
+				s.serverError(w, r, err)
+				return
+			}
+			if link.RevokedAt != nil || (link.ExpiresAt != nil && link.ExpiresAt.Before(time.Now())) {
+				s.clientError(w, r, http.StatusUnauthorized)
+				return
+			}
+
+			permissions := authz.Permissions(authz.Role(link.Role))
+			if !permissions.Has(permission) {
+				s.clientError(w, r, http.StatusForbidden)
+				return
+			}
+
+			project, err := s.queries.GetProjectByID(r.Context(), link.ProjectId)
+			if err != nil {
+				s.serverError(w, r, err)
+				return
+			}
+
+			if err := handlers.RecordShareAccess(r, s.queries, &link, handlers.EventShareLinkAccessed); err != nil {
+				logging.FromContext(r.Context()).Error("failed to record share link access", "error", err)
+			}
+
+			ctx := context.WithValue(r.Context(), session.ProjectContextKey, &project)
+			ctx = context.WithValue(ctx, session.PermissionsContextKey, permissions)
+			ctx = context.WithValue(ctx, session.ShareLinkContextKey, &link)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -2,24 +2,181 @@ package server
 
 import (
 	"net/http"
+	"strings"
 
+	"budhapp.com/internal/apierror"
+	"budhapp.com/internal/authz"
+	"budhapp.com/internal/logging"
+	"budhapp.com/internal/metrics"
 	"budhapp.com/internal/middleware"
+	"budhapp.com/internal/ratelimit"
+	"budhapp.com/internal/tracing"
+	"budhapp.com/internal/validate"
 )
 
+// polarWebhookMaxBodySize bounds the webhook payloads read from Polar
+const polarWebhookMaxBodySize = 2 << 20
+
 func (s *Server) initRoutes() http.Handler {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("GET /api/ping", s.handlers.Ping)
 
+	// Endpoints that check credentials get a stricter limit by IP address.
+	// Sign-ins and password confirmations are also limited by account, see
+	// ratelimit.Lockout.
+	credentials := middleware.New(s.limiter.Limit("auth", ratelimit.NewLimit(s.config.RateLimit.Auth), ratelimit.ByIP))
+
+	// Auth
+	mux.Handle("POST /api/auth/sign-in/email", credentials.ThenFunc(s.handlers.Auth.SignInEmail))
+	mux.HandleFunc("GET /api/auth/token", s.handlers.Auth.Token)
+	mux.HandleFunc("GET /api/auth/jwks", s.handlers.Auth.JWKS)
+	mux.HandleFunc("POST /api/auth/sign-out", s.handlers.Auth.SignOut)
+	mux.HandleFunc("POST /api/auth/revoke-session", s.handlers.Auth.RevokeSession)
+	mux.HandleFunc("GET /api/auth/security-activity", s.handlers.Audit.SecurityActivity)
+	mux.HandleFunc("POST /api/auth/update-user", s.handlers.User.UpdateUser)
+	mux.Handle("POST /api/auth/change-email", credentials.ThenFunc(s.handlers.User.ChangeEmail))
+	mux.HandleFunc("GET /api/auth/verify-email", s.handlers.User.VerifyEmail)
+	mux.Handle("POST /api/auth/delete-user", credentials.ThenFunc(s.handlers.Account.DeleteUser))
+	mux.HandleFunc("GET /api/auth/delete-user/callback", s.handlers.Account.DeleteUserCallback)
+	mux.HandleFunc("POST /api/auth/sign-in/social", s.handlers.Social.SignIn)
+	mux.HandleFunc("GET /api/auth/callback/{provider}", s.handlers.Social.Callback)
+	mux.HandleFunc("POST /api/auth/link-social", s.handlers.Social.LinkSocial)
+	mux.HandleFunc("GET /api/auth/list-accounts", s.handlers.Social.ListAccounts)
+	mux.HandleFunc("POST /api/auth/unlink-account", s.handlers.Social.UnlinkAccount)
+
+	// Passwordless
+	mux.Handle("POST /api/auth/sign-in/magic-link", credentials.ThenFunc(s.handlers.Passwordless.SignInMagicLink))
+	mux.Handle("GET /api/auth/magic-link/verify", credentials.ThenFunc(s.handlers.Passwordless.VerifyMagicLink))
+	mux.Handle("POST /api/auth/email-otp/send-verification-otp", credentials.ThenFunc(s.handlers.Passwordless.SendVerificationOTP))
+	mux.Handle("POST /api/auth/sign-in/email-otp", credentials.ThenFunc(s.handlers.Passwordless.SignInEmailOTP))
+
+	// Two factor
+	mux.Handle("POST /api/auth/two-factor/enable", credentials.ThenFunc(s.handlers.TwoFactor.Enable))
+	mux.Handle("POST /api/auth/two-factor/get-totp-uri", credentials.ThenFunc(s.handlers.TwoFactor.TOTPURI))
+	mux.Handle("POST /api/auth/two-factor/verify-totp", credentials.ThenFunc(s.handlers.TwoFactor.VerifyTOTP))
+	mux.Handle("POST /api/auth/two-factor/disable", credentials.ThenFunc(s.handlers.TwoFactor.Disable))
+	mux.Handle("POST /api/auth/two-factor/generate-backup-codes", credentials.ThenFunc(s.handlers.TwoFactor.GenerateBackupCodes))
+	mux.Handle("POST /api/auth/two-factor/verify-backup-code", credentials.ThenFunc(s.handlers.TwoFactor.VerifyBackupCode))
+
+	// Passkeys
+	mux.HandleFunc("GET /api/auth/passkey/generate-register-options", s.handlers.Passkey.GenerateRegisterOptions)
+	mux.HandleFunc("POST /api/auth/passkey/verify-registration", s.handlers.Passkey.VerifyRegistration)
+	mux.HandleFunc("POST /api/auth/passkey/generate-authenticate-options", s.handlers.Passkey.GenerateAuthenticateOptions)
+	mux.Handle("POST /api/auth/passkey/verify-authentication", credentials.ThenFunc(s.handlers.Passkey.VerifyAuthentication))
+	mux.HandleFunc("GET /api/auth/passkey/list-user-passkeys", s.handlers.Passkey.ListUserPasskeys)
+	mux.HandleFunc("POST /api/auth/passkey/update-passkey", s.handlers.Passkey.UpdatePasskey)
+	mux.HandleFunc("POST /api/auth/passkey/delete-passkey", s.handlers.Passkey.DeletePasskey)
+
+	// Organizations
+	mux.HandleFunc("POST /api/auth/organization/create", s.handlers.Organization.Create)
+	mux.HandleFunc("GET /api/auth/organization/list", s.handlers.Organization.List)
+	mux.HandleFunc("POST /api/auth/organization/update", s.handlers.Organization.Update)
+	mux.HandleFunc("POST /api/auth/organization/set-active", s.handlers.Organization.SetActive)
+	mux.HandleFunc("GET /api/auth/organization/get-full-organization", s.handlers.Organization.GetFull)
+	mux.HandleFunc("POST /api/auth/organization/invite-member", s.handlers.Organization.InviteMember)
+	mux.HandleFunc("POST /api/auth/organization/accept-invitation", s.handlers.Organization.AcceptInvitation)
+	mux.HandleFunc("POST /api/auth/organization/reject-invitation", s.handlers.Organization.RejectInvitation)
+	mux.HandleFunc("POST /api/auth/organization/cancel-invitation", s.handlers.Organization.CancelInvitation)
+	mux.HandleFunc("POST /api/auth/organization/update-member-role", s.handlers.Organization.UpdateMemberRole)
+
+	// Account
+	mux.HandleFunc("POST /api/account/cancel-deletion", s.handlers.Account.CancelDeletion)
+	mux.HandleFunc("GET /api/account/export", s.handlers.Account.Export)
+
+	// Admin
+	admin := middleware.New(s.handlers.Admin.RequireAdmin)
+	mux.Handle("GET /api/auth/admin/list-users", admin.ThenFunc(s.handlers.Admin.ListUsers))
+	mux.Handle("POST /api/auth/admin/set-role", admin.ThenFunc(s.handlers.Admin.SetRole))
+	mux.Handle("POST /api/auth/admin/ban-user", admin.ThenFunc(s.handlers.Admin.BanUser))
+	mux.Handle("POST /api/auth/admin/unban-user", admin.ThenFunc(s.handlers.Admin.UnbanUser))
+	mux.Handle("POST /api/auth/admin/list-user-sessions", admin.ThenFunc(s.handlers.Admin.ListUserSessions))
+	mux.Handle("POST /api/auth/admin/revoke-user-session", admin.ThenFunc(s.handlers.Admin.RevokeUserSession))
+	mux.Handle("POST /api/auth/admin/revoke-user-sessions", admin.ThenFunc(s.handlers.Admin.RevokeUserSessions))
+	mux.Handle("POST /api/auth/admin/impersonate-user", admin.ThenFunc(s.handlers.Admin.ImpersonateUser))
+	mux.Handle("GET /api/auth/admin/audit-logs", admin.ThenFunc(s.handlers.Audit.ListAuditLogs))
+	mux.Handle("GET /api/auth/admin/health", admin.ThenFunc(s.healthReport))
+	mux.HandleFunc("POST /api/auth/admin/stop-impersonating", s.handlers.Admin.StopImpersonating)
+
 	dynamic := middleware.New(s.authenticate)
 	protected := dynamic.Append(s.requireAuthentication)
 
 	mux.Handle("GET /api/protected/ping", protected.ThenFunc(s.handlers.Ping))
 	mux.Handle("GET /api/secured/ping", dynamic.ThenFunc(s.handlers.Auth.UserFromRequest))
 
-	// Webhooks
-	mux.HandleFunc("POST /api/webhooks/polar", s.handlers.Polar.HandleWebhook)
+	// Projects, owned by the caller or by an organization
+	for _, prefix := range []string{"/api/projects/{slug}", "/api/organizations/{organization}/projects/{slug}"} {
+		mux.Handle("GET "+prefix, protected.Append(s.authorizeProject(authz.ProjectRead)).ThenFunc(s.handlers.Project.Get))
+		mux.Handle("PATCH "+prefix, protected.Append(s.authorizeProject(authz.ProjectUpdate)).ThenFunc(s.handlers.Project.Update))
+		mux.Handle("DELETE "+prefix, protected.Append(s.authorizeProject(authz.ProjectDelete)).ThenFunc(s.handlers.Project.Delete))
+		mux.Handle("GET "+prefix+"/members", protected.Append(s.authorizeProject(authz.ProjectRead)).ThenFunc(s.handlers.Project.ListMembers))
+		mux.Handle("PUT "+prefix+"/members", protected.Append(s.authorizeProject(authz.ProjectShare)).ThenFunc(s.handlers.Project.SetMember))
+		mux.Handle("DELETE "+prefix+"/members/{userId}", protected.Append(s.authorizeProject(authz.ProjectShare)).ThenFunc(s.handlers.Project.RemoveMember))
+		mux.Handle("POST "+prefix+"/share-links", protected.Append(s.authorizeProject(authz.ProjectShare)).ThenFunc(s.handlers.ShareLink.Create))
+		mux.Handle("GET "+prefix+"/share-links", protected.Append(s.authorizeProject(authz.ProjectShare)).ThenFunc(s.handlers.ShareLink.List))
+		mux.Handle("DELETE "+prefix+"/share-links/{id}", protected.Append(s.authorizeProject(authz.ProjectShare)).ThenFunc(s.handlers.ShareLink.Revoke))
+		mux.Handle("GET "+prefix+"/documents", protected.Append(s.authorizeProject(authz.DocumentRead)).ThenFunc(s.handlers.Document.List))
+		mux.Handle("POST "+prefix+"/documents", protected.Append(s.authorizeProject(authz.DocumentCreate)).ThenFunc(s.handlers.Document.Create))
+		mux.Handle("GET "+prefix+"/documents/{documentId}", protected.Append(s.authorizeProject(authz.DocumentRead)).ThenFunc(s.handlers.Document.Get))
+		mux.Handle("PATCH "+prefix+"/documents/{documentId}", protected.Append(s.authorizeProject(authz.DocumentUpdate)).ThenFunc(s.handlers.Document.Update))
+		mux.Handle("DELETE "+prefix+"/documents/{documentId}", protected.Append(s.authorizeProject(authz.DocumentDelete)).ThenFunc(s.handlers.Document.Delete))
+		mux.Handle("GET "+prefix+"/documents/{documentId}/comments", protected.Append(s.authorizeProject(authz.DocumentRead)).ThenFunc(s.handlers.Document.ListComments))
+		mux.Handle("POST "+prefix+"/documents/{documentId}/comments", protected.Append(s.authorizeProject(authz.DocumentComment)).ThenFunc(s.handlers.Document.CreateComment))
+	}
+	mux.Handle("GET /api/organizations/{organization}/projects", protected.ThenFunc(s.handlers.Project.ListOrganizationProjects))
+	mux.Handle("POST /api/organizations/{organization}/projects", protected.ThenFunc(s.handlers.Project.CreateOrganizationProject))
+	// Moving a personal project into an organization gives up ownership
+	mux.Handle("POST /api/projects/{slug}/transfer", protected.Append(s.authorizeProject(authz.ProjectDelete)).ThenFunc(s.handlers.Project.Transfer))
+
+	// Uploads, downloaded through signed URLs
+	mux.Handle("POST /api/uploads", protected.Append(validate.LimitBody(s.handlers.Upload.MaxBodySize())).ThenFunc(s.handlers.Upload.Upload))
+	mux.Handle("GET /api/uploads", protected.ThenFunc(s.handlers.Upload.List))
+	mux.Handle("DELETE /api/uploads/{id}", protected.ThenFunc(s.handlers.Upload.Delete))
+	// User content is sandboxed, so a file opened directly can't run
+	// scripts on the API origin
+	files := middleware.New(middleware.OverrideHeader("Content-Security-Policy", "default-src 'none'; sandbox"))
+	mux.Handle("GET /api/files/{id}/{variant}", files.ThenFunc(s.handlers.Upload.Download))
+	mux.Handle("GET /api/avatars/{id}", files.ThenFunc(s.handlers.Upload.Avatar))
+
+	// Share links: anonymous sessions for reviewers without an account
+	mux.Handle("POST /api/share/{token}", credentials.ThenFunc(s.handlers.ShareLink.Resolve))
+	mux.Handle("GET /api/shared/project", middleware.New(s.authorizeShare(authz.ProjectRead)).ThenFunc(s.handlers.Project.Get))
+	mux.Handle("GET /api/shared/documents", middleware.New(s.authorizeShare(authz.DocumentRead)).ThenFunc(s.handlers.Document.List))
+	mux.Handle("GET /api/shared/documents/{documentId}", middleware.New(s.authorizeShare(authz.DocumentRead)).ThenFunc(s.handlers.Document.Get))
+	mux.Handle("GET /api/shared/documents/{documentId}/comments", middleware.New(s.authorizeShare(authz.DocumentRead)).ThenFunc(s.handlers.Document.ListComments))
+	mux.Handle("POST /api/shared/documents/{documentId}/comments", middleware.New(s.authorizeShare(authz.DocumentComment)).ThenFunc(s.handlers.Document.CreateComment))
+
+	// Webhooks. Polar payloads embed the full subscription or order, so they
+	// get more room than the default request body limit.
+	mux.Handle("POST /api/webhooks/polar", middleware.New(validate.LimitBody(polarWebhookMaxBodySize)).ThenFunc(s.handlers.Polar.HandleWebhook))
+
+	global := s.limiter.Limit("global", ratelimit.NewLimit(s.config.RateLimit.Global), ratelimit.ByIP)
+	csrf := middleware.CSRF(s.config.CSRF, s.csrfTrustedOrigins(), strings.HasPrefix(s.config.Auth.BaseURL, "https://"))
+	// CORS runs before the rate limit so browsers can read 429 responses
+	security := middleware.SecurityHeaders(s.config.Security, s.config.IsProduction())
+	// Proxies were validated with the configuration
+	proxies, _ := s.config.HTTP.TrustedProxyPrefixes()
+	standard := middleware.New(s.recoverPanic, middleware.RealIP(proxies), metrics.Instrument, tracing.Middleware, middleware.RequestID, logging.Requests(s.logger), validate.MaxBodySize(validate.DefaultMaxBodySize), security, middleware.CORS(s.config.CORS), csrf, global)
+
+	// Probes and scrapes skip logging and rate limits, as they come often
+	// and from the same addresses
+	root := http.NewServeMux()
+	root.Handle("GET /healthz", middleware.New(s.recoverPanic).ThenFunc(s.live))
+	root.Handle("GET /readyz", middleware.New(s.recoverPanic).ThenFunc(s.ready))
+	if s.config.Metrics.Enabled {
+		root.Handle("GET /metrics", middleware.New(s.recoverPanic, s.requireMetricsToken).Then(metrics.Default))
+	}
+	root.Handle("/", standard.Then(tracing.Route(metrics.RecordPattern(apierror.RouteErrors(mux)))))
+	return root
+}
 
-	standard := middleware.New(s.recoverPanic, s.logRequest)
-	return standard.Then(mux)
+// csrfTrustedOrigins returns the origins allowed to send cookie-authenticated
+// mutations: the auth server itself, its trusted origins and the CORS origins
+// allowed to send credentials
+func (s *Server) csrfTrustedOrigins() []string {
+	origins := append([]string{s.config.Auth.BaseURL}, s.config.Auth.TrustedOrigins...)
+	if s.config.CORS.AllowCredentials {
+		origins = append(origins, s.config.CORS.AllowedOrigins...)
+	}
+	return origins
 }
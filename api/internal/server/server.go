@@ -2,43 +2,102 @@ package server
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
+	"budhapp.com/db"
 	"budhapp.com/internal/config"
+	"budhapp.com/internal/email"
+	"budhapp.com/internal/encryption"
 	"budhapp.com/internal/handlers"
+	"budhapp.com/internal/health"
+	"budhapp.com/internal/jwks"
+	"budhapp.com/internal/metrics"
+	"budhapp.com/internal/oauth"
+	"budhapp.com/internal/passkey"
+	"budhapp.com/internal/polar"
+	"budhapp.com/internal/ratelimit"
 	"budhapp.com/internal/repository"
+	"budhapp.com/internal/storage"
+	"budhapp.com/internal/tracing"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/lestrrat-go/jwx/v3/jwk"
+)
+
+const (
+	// jwksRefreshInterval is how often the key set is reloaded and rotation checked
+	jwksRefreshInterval = 5 * time.Minute
+	// accountPurgeInterval is how often accounts past their deletion grace
+	// period are deleted
+	accountPurgeInterval = time.Hour
+	// rateLimitSweepInterval is how often idle rate limit buckets are dropped
+	rateLimitSweepInterval = 10 * time.Minute
 )
 
 // Server represents the HTTP server
 type Server struct {
-	config                 config.Config
-	logger                 *slog.Logger
-	pool                   *pgxpool.Pool
-	queries                *repository.Queries
-	handlers               *handlers.Handlers
-	authVerificationKeyset *jwk.Set
+	config   config.Config
+	logger   *slog.Logger
+	pool     *pgxpool.Pool
+	queries  *repository.Queries
+	handlers *handlers.Handlers
+	keys     *jwks.Manager
+	tokens   *oauth.TokenService
+	limiter  *ratelimit.Limiter
+	health   *health.Registry
+	http     *http.Server
+	// flushTraces exports the buffered spans and stops tracing
+	flushTraces func(ctx context.Context) error
+	// workers are the background jobs, stopped after requests are drained
+	workers sync.WaitGroup
+	// draining fails readiness once shutdown starts
+	draining atomic.Bool
 }
 
 // New creates a new Server with the given configuration
 func New(cfg config.Config) *Server {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	// Code outside requests logs with the default logger
+	slog.SetDefault(logger)
 
 	return &Server{
 		config: cfg,
 		logger: logger,
+		health: health.NewRegistry(cfg.Health.CacheTTL.Std(), cfg.Health.Timeout.Std()),
 	}
 }
 
-// Start initializes the database connection and starts the HTTP server
+// Start initializes the database connection and serves HTTP until SIGINT or
+// SIGTERM, then shuts down gracefully. A second signal exits immediately.
 func (s *Server) Start() error {
-	ctx := context.Background()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Background jobs outlive the signal until requests are drained
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
 
-	// Connect to database
-	pool, err := pgxpool.New(ctx, s.config.Database.ConnectionString)
+	flushTraces, err := tracing.Setup(ctx, s.config.Tracing, s.config.Environment)
+	if err != nil {
+		s.logger.Error("failed to configure tracing", "error", err)
+		return err
+	}
+	s.flushTraces = flushTraces
+
+	// Connect to database, tracing each query
+	poolConfig, err := pgxpool.ParseConfig(s.config.Database.ConnectionString)
+	if err != nil {
+		s.logger.Error("failed to parse database connection string", "error", err)
+		return err
+	}
+	poolConfig.ConnConfig.Tracer = tracing.QueryTracer{}
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
 		s.logger.Error("failed to connect to database", "error", err)
 		return err
@@ -53,14 +112,173 @@ func (s *Server) Start() error {
 	}
 	s.logger.Info("connected to database")
 
+	migration, err := db.LatestMigration()
+	if err != nil {
+		return err
+	}
+	s.health.Register("database", true, health.Database(pool))
+	s.health.Register("migrations", true, health.Migrations(pool, migration))
+	metrics.Default.Register(metrics.PoolCollector(pool))
+
 	// Create repository queries
 	s.queries = repository.New(pool)
 
+	// Load JWT signing keys. Without an encryption key private keys cannot be
+	// stored, so the server only verifies tokens issued elsewhere.
+	keyring, err := encryption.NewKeyring(s.config.Encryption)
+	if err != nil && !errors.Is(err, encryption.ErrMissingKey) {
+		return err
+	}
+	if keyring == nil {
+		s.logger.Warn("encryption key not configured, jwt signing and oauth token storage disabled")
+	}
+	s.keys = jwks.NewManager(s.queries, keyring, s.config.Auth, s.logger)
+	if err := s.keys.EnsureSigningKey(ctx); err != nil {
+		s.logger.Error("failed to load jwks", "error", err)
+		return err
+	}
+	s.runWorker(workerCtx, func(ctx context.Context) { s.keys.Run(ctx, jwksRefreshInterval) })
+	// Without an encryption key there may be no keys yet, which only
+	// matters for verifying tokens issued elsewhere
+	s.health.Register("jwks", keyring != nil, s.keys.Check)
+
+	// Social sign-in providers
+	providers, err := oauth.NewRegistry(s.config.Auth)
+	if err != nil {
+		s.logger.Error("failed to configure social providers", "error", err)
+		return err
+	}
+
+	s.tokens = oauth.NewTokenService(s.queries, keyring, providers, s.logger)
+
+	passkeys, err := passkey.New(s.queries, s.config.Auth)
+	if err != nil {
+		s.logger.Error("failed to configure passkeys", "error", err)
+		return err
+	}
+
+	emails, err := email.NewEmailProvider(s.config.Email, s.logger)
+	if err != nil {
+		s.logger.Error("failed to configure email provider", "error", err)
+		return err
+	}
+	if s.config.Email.Provider == "" {
+		s.logger.Warn("email provider not configured, emails are logged instead of sent")
+	}
+	// Emails are sent in the background and retried by users, so an
+	// unreachable provider degrades the service without failing readiness
+	if checker, ok := emails.(email.HealthChecker); ok {
+		s.health.Register("email", false, checker.Check)
+	}
+
+	billing := polar.NewClient(s.config.Polar)
+	if !billing.Enabled() {
+		s.logger.Warn("polar access token not configured, subscriptions are not revoked when accounts are deleted")
+	}
+
+	store, err := storage.New(s.config.Storage)
+	if err != nil {
+		s.logger.Error("failed to configure storage", "error", err)
+		return err
+	}
+	signer, err := storage.NewSigner(s.config.Auth.Secret)
+	if err != nil {
+		s.logger.Error("failed to configure download url signing", "error", err)
+		return err
+	}
+
+	limits, err := ratelimit.NewStore(s.config.RateLimit, s.queries, s.pool)
+	if err != nil {
+		s.logger.Error("failed to configure rate limits", "error", err)
+		return err
+	}
+	s.limiter = ratelimit.NewLimiter(limits, s.logger)
+	lockout := ratelimit.NewLockout(limits, s.logger, s.config.RateLimit)
+	s.runWorker(workerCtx, func(ctx context.Context) { s.limiter.Run(ctx, rateLimitSweepInterval) })
+
 	// Create handlers (pass pool for transaction support)
-	s.handlers = handlers.New(s.queries, s.logger, s.config)
+	s.handlers = handlers.New(s.queries, s.pool, s.config, s.keys, providers, s.tokens, keyring, passkeys, emails, billing, store, signer, lockout)
+	s.runWorker(workerCtx, func(ctx context.Context) { s.handlers.Account.Run(ctx, accountPurgeInterval) })
 
 	// Setup routes
 	handler := s.initRoutes()
 
-	return http.ListenAndServe(":8080", handler)
+	s.http = &http.Server{
+		Addr:              s.config.Address,
+		Handler:           handler,
+		ReadTimeout:       s.config.HTTP.ReadTimeout.Std(),
+		ReadHeaderTimeout: s.config.HTTP.ReadHeaderTimeout.Std(),
+		WriteTimeout:      s.config.HTTP.WriteTimeout.Std(),
+		IdleTimeout:       s.config.HTTP.IdleTimeout.Std(),
+		ErrorLog:          slog.NewLogLogger(s.logger.Handler(), slog.LevelWarn),
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- s.http.ListenAndServe()
+	}()
+	s.logger.Info("listening", "address", s.config.Address)
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+	// Restore the default handling, so a second signal kills the process
+	stop()
+
+	return s.shutdown(stopWorkers)
+}
+
+// runWorker starts a background job, stopped on shutdown once requests are
+// drained
+func (s *Server) runWorker(ctx context.Context, run func(ctx context.Context)) {
+	s.workers.Add(1)
+	go func() {
+		defer s.workers.Done()
+		run(ctx)
+	}()
+}
+
+// shutdown fails readiness for the drain delay so load balancers stop
+// sending traffic, then waits for in-flight requests, background jobs and
+// emails, in that order, and flushes their spans within the shutdown
+// timeout. The database pool is closed last, by Start.
+func (s *Server) shutdown(stopWorkers context.CancelFunc) error {
+	s.logger.Info("shutting down", "drainDelay", s.config.HTTP.DrainDelay.Std(), "timeout", s.config.HTTP.ShutdownTimeout.Std())
+	s.draining.Store(true)
+	s.http.SetKeepAlivesEnabled(false)
+	time.Sleep(s.config.HTTP.DrainDelay.Std())
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.HTTP.ShutdownTimeout.Std())
+	defer cancel()
+
+	err := s.http.Shutdown(ctx)
+	if err != nil {
+		s.logger.Error("failed to drain requests", "error", err)
+		s.http.Close()
+	}
+
+	stopWorkers()
+	workersDone := make(chan struct{})
+	go func() {
+		s.workers.Wait()
+		close(workersDone)
+	}()
+	select {
+	case <-workersDone:
+	case <-ctx.Done():
+		s.logger.Error("background jobs did not stop in time")
+	}
+
+	if err := handlers.WaitForEmails(ctx); err != nil {
+		s.logger.Error("emails still sending at shutdown", "error", err)
+	}
+
+	if err := s.flushTraces(ctx); err != nil {
+		s.logger.Error("failed to flush traces", "error", err)
+	}
+
+	s.logger.Info("server stopped")
+	return err
 }
@@ -1,17 +1,32 @@
 package server
 
-import "net/http"
+import (
+	"net/http"
+
+	"budhapp.com/internal/apierror"
+	"budhapp.com/internal/logging"
+	"budhapp.com/internal/middleware"
+)
 
 func (s *Server) serverError(w http.ResponseWriter, r *http.Request, err error) {
 	var (
 		method = r.Method
 		uri    = r.URL.RequestURI()
+		logger = logging.FromContext(r.Context())
 	)
 
-	s.logger.Error(err.Error(), "method", method, "uri", uri)
-	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	// Panics are recovered outside the request scope, after the request id
+	// was set on the response
+	if middleware.RequestIDFromContext(r.Context()) == "" {
+		if id := w.Header().Get(middleware.RequestIDHeader); id != "" {
+			logger = logger.With("requestId", id)
+		}
+	}
+
+	logger.Error(err.Error(), "method", method, "uri", uri)
+	apierror.Write(w, r, apierror.Internal(err))
 }
 
-func (s *Server) clientError(w http.ResponseWriter, status int) {
-	http.Error(w, http.StatusText(status), status)
+func (s *Server) clientError(w http.ResponseWriter, r *http.Request, status int) {
+	apierror.Write(w, r, apierror.FromStatus(status))
 }
@@ -2,12 +2,18 @@ package session
 
 import (
 	"context"
+
+	"budhapp.com/internal/authz"
+	"budhapp.com/internal/repository"
 )
 
 type contextKey string
 
 const IsAuthenticatedContextKey = contextKey("isAuthenticated")
 const UserContextKey = contextKey("userSession")
+const ProjectContextKey = contextKey("project")
+const PermissionsContextKey = contextKey("permissions")
+const ShareLinkContextKey = contextKey("shareLink")
 
 type UserInfo struct {
 	ID    string `json:"id"`
@@ -20,3 +26,23 @@ func UserFromContext(ctx context.Context) (*UserInfo, bool) {
 	user, ok := ctx.Value(UserContextKey).(*UserInfo)
 	return user, ok
 }
+
+// ProjectFromContext returns the project loaded from the {slug} route
+// parameter
+func ProjectFromContext(ctx context.Context) (*repository.Project, bool) {
+	project, ok := ctx.Value(ProjectContextKey).(*repository.Project)
+	return project, ok
+}
+
+// PermissionsFromContext returns the caller's permissions on the project
+// loaded from the route
+func PermissionsFromContext(ctx context.Context) (authz.PermissionSet, bool) {
+	permissions, ok := ctx.Value(PermissionsContextKey).(authz.PermissionSet)
+	return permissions, ok
+}
+
+// ShareLinkFromContext returns the share link of an anonymous share session
+func ShareLinkFromContext(ctx context.Context) (*repository.ShareLink, bool) {
+	link, ok := ctx.Value(ShareLinkContextKey).(*repository.ShareLink)
+	return link, ok
+}
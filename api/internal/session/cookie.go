@@ -0,0 +1,77 @@
+package session
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Cookie names used by better-auth. Over HTTPS better-auth prefixes them with
+// "__Secure-".
+const (
+	SessionCookieName      = "better-auth.session_token"
+	TwoFactorCookieName    = "better-auth.two_factor"
+	TrustDeviceCookieName  = "better-auth.trust_device"
+	PasskeyCookieName      = "better-auth.passkey"
+	AdminSessionCookieName = "better-auth.admin_session"
+	SecureCookiePrefix     = "__Secure-"
+)
+
+// SignCookieValue signs a session token the way better-auth does:
+// "<token>.<base64(HMAC-SHA256(secret, token))>", URL encoded
+func SignCookieValue(token, secret string) string {
+	return url.QueryEscape(token + "." + signature(token, secret))
+}
+
+// VerifyCookieValue checks a signed cookie value and returns the session token
+func VerifyCookieValue(value, secret string) (string, bool) {
+	if secret == "" {
+		return "", false
+	}
+
+	decoded, err := url.QueryUnescape(value)
+	if err != nil {
+		return "", false
+	}
+
+	i := strings.LastIndex(decoded, ".")
+	if i < 1 {
+		return "", false
+	}
+	token, sig := decoded[:i], decoded[i+1:]
+
+	if !hmac.Equal([]byte(sig), []byte(signature(token, secret))) {
+		return "", false
+	}
+
+	return token, true
+}
+
+// TokenFromRequest returns the verified session token from the request cookies
+func TokenFromRequest(r *http.Request, secret string) (string, bool) {
+	return SignedCookieFromRequest(r, SessionCookieName, secret)
+}
+
+// SignedCookieFromRequest returns the verified value of a signed cookie,
+// with or without the secure prefix
+func SignedCookieFromRequest(r *http.Request, name, secret string) (string, bool) {
+	for _, name := range []string{SecureCookiePrefix + name, name} {
+		cookie, err := r.Cookie(name)
+		if err != nil {
+			continue
+		}
+		if token, ok := VerifyCookieValue(cookie.Value, secret); ok {
+			return token, true
+		}
+	}
+	return "", false
+}
+
+func signature(token, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(token))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"budhapp.com/internal/config"
+	"budhapp.com/internal/tracing"
+)
+
+// requestTimeout bounds calls that don't transfer a blob body
+const requestTimeout = 30 * time.Second
+
+// S3Store keeps blobs in an S3 compatible bucket. Requests are signed with
+// AWS Signature Version 4.
+type S3Store struct {
+	endpoint        string
+	region          string
+	bucket          string
+	accessKeyID     string
+	secretAccessKey string
+	usePathStyle    bool
+	client          *http.Client
+	now             func() time.Time
+}
+
+// NewS3Store returns a store for the bucket. A nil client uses a client
+// without timeout, as bodies are streamed, that traces each request.
+func NewS3Store(cfg config.S3Config, client *http.Client) *S3Store {
+	if client == nil {
+		client = &http.Client{Transport: tracing.Transport(nil)}
+	}
+	endpoint := strings.TrimSuffix(cfg.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+
+	return &S3Store{
+		endpoint:        endpoint,
+		region:          cfg.Region,
+		bucket:          cfg.Bucket,
+		accessKeyID:     cfg.AccessKeyID,
+		secretAccessKey: cfg.SecretAccessKey,
+		usePathStyle:    cfg.UsePathStyle,
+		client:          client,
+		now:             time.Now,
+	}
+}
+
+// objectURL addresses the key in the bucket, in the path or the host
+func (s *S3Store) objectURL(key string) string {
+	if s.usePathStyle {
+		return s.endpoint + "/" + uriEncode(s.bucket, false) + "/" + uriEncode(key, true)
+	}
+	scheme, host, _ := strings.Cut(s.endpoint, "://")
+	return scheme + "://" + s.bucket + "." + host + "/" + uriEncode(key, true)
+}
+
+// do signs and sends a request for the object
+func (s *S3Store) do(ctx context.Context, method, key string, body io.Reader, size int64, contentType string) (*http.Response, error) {
+	if err := validKey(key); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key), body)
+	if err != nil {
+		return nil, err
+	}
+	payloadHash := emptyPayloadHash
+	if body != nil {
+		// The body is streamed, so its hash is not part of the signature
+		payloadHash = unsignedPayload
+		req.ContentLength = size
+		req.Header.Set("Content-Length", strconv.FormatInt(size, 10))
+		req.Header.Set("Content-Type", contentType)
+	}
+	signV4(req, payloadHash, s.accessKeyID, s.secretAccessKey, s.region, s.now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3: %w", err)
+	}
+	return resp, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	resp, err := s.do(ctx, http.MethodPut, key, r, size, contentType)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return responseError(resp)
+	}
+	return nil
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil, 0, "")
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ErrNotFound
+	case resp.StatusCode >= 300:
+		defer resp.Body.Close()
+		return nil, responseError(resp)
+	}
+	return resp.Body, nil
+}
+
+func (s *S3Store) Exists(ctx context.Context, key string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	resp, err := s.do(ctx, http.MethodHead, key, nil, 0, "")
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case resp.StatusCode >= 300:
+		return false, responseError(resp)
+	}
+	return true, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, 0, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNotFound {
+		return responseError(resp)
+	}
+	return nil
+}
+
+// responseError reads the S3 error document of a failed request
+func responseError(resp *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("s3: status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+}
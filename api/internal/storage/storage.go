@@ -0,0 +1,51 @@
+// Package storage keeps blobs by key in a local directory or an S3
+// compatible bucket, and signs short-lived URLs to download them.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"budhapp.com/internal/config"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// Store keeps blobs by key. Keys are slash separated paths such as
+// "blobs/ab/cdef".
+type Store interface {
+	// Put stores size bytes from r under key, replacing any blob there
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the blob. It returns ErrNotFound when there is none.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Exists reports whether a blob is stored under key
+	Exists(ctx context.Context, key string) (bool, error)
+	// Delete removes the blob. Missing blobs are not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// New returns the store selected by the configuration
+func New(cfg config.StorageConfig) (Store, error) {
+	switch cfg.Backend {
+	case "local":
+		return NewLocalStore(cfg.LocalPath)
+	case "s3":
+		return NewS3Store(cfg.S3, nil), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", cfg.Backend)
+	}
+}
+
+// validKey rejects keys that could escape the store, like "../x" or "/x"
+func validKey(key string) error {
+	if !fs.ValidPath(key) || key == "." {
+		return ErrInvalidKey
+	}
+	return nil
+}
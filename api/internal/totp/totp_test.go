@@ -0,0 +1,84 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 key from RFC 6238 appendix B
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCodeRFC6238Vectors(t *testing.T) {
+	// Last six digits of the RFC's eight digit SHA1 codes
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("Code(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code, _ := Code(rfcSecret, now)
+	previous, _ := Code(rfcSecret, now.Add(-Period))
+	stale, _ := Code(rfcSecret, now.Add(-3*Period))
+
+	tests := []struct {
+		name string
+		code string
+		want bool
+	}{
+		{"current period", code, true},
+		{"previous period", previous, true},
+		{"stale code", stale, false},
+		{"wrong length", "12345", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Validate(rfcSecret, tt.code, now); got != tt.want {
+				t.Errorf("Validate(%q) = %v, want %v", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStep(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := now.Unix() / int64(Period.Seconds())
+	code, _ := Code(rfcSecret, now)
+	previous, _ := Code(rfcSecret, now.Add(-Period))
+	if step, ok := Step(rfcSecret, code, now); !ok || step != current {
+		t.Errorf("Step(current) = %d, %v, want %d, true", step, ok, current)
+	}
+	if step, ok := Step(rfcSecret, previous, now); !ok || step != current-1 {
+		t.Errorf("Step(previous) = %d, %v, want %d, true", step, ok, current-1)
+	}
+	stale, _ := Code(rfcSecret, now.Add(-3*Period))
+	if _, ok := Step(rfcSecret, stale, now); ok {
+		t.Error("Step(stale) accepted the code")
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("SECRET", "budhapp", "jane@example.com")
+	if !strings.HasPrefix(uri, "otpauth://totp/budhapp:jane@example.com?") || !strings.Contains(uri, "secret=SECRET") {
+		t.Errorf("URI() = %s", uri)
+	}
+}
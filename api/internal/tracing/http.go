@@ -0,0 +1,171 @@
+package tracing
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware starts a server span for each request, continuing the trace of
+// an incoming traceparent header. The span is named after the method until
+// Route renames it after the matched pattern.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer().Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+				semconv.UserAgentOriginal(r.UserAgent()),
+				semconv.ClientAddress(clientAddress(r.RemoteAddr)),
+			),
+		)
+		rec := &statusRecorder{ResponseWriter: w}
+
+		defer func() {
+			status := rec.status
+			pv := recover()
+			switch {
+			case pv != nil && status == 0:
+				// The panic is answered with 500 upstream
+				status = http.StatusInternalServerError
+			case status == 0:
+				status = http.StatusOK
+			}
+			span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+			span.End()
+			if pv != nil {
+				panic(pv)
+			}
+		}()
+
+		next.ServeHTTP(rec, r.WithContext(ctx))
+	})
+}
+
+// Route wraps the mux to name the server span after the matched pattern, as
+// in "GET /api/auth/session". Unmatched requests keep the method as name so
+// unknown paths don't create span names.
+func Route(mux http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if r.Pattern == "" {
+				return
+			}
+			method, route, ok := strings.Cut(r.Pattern, " ")
+			if !ok {
+				method, route = r.Method, r.Pattern
+			}
+			span := trace.SpanFromContext(r.Context())
+			span.SetName(method + " " + route)
+			span.SetAttributes(semconv.HTTPRoute(route))
+		}()
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// Transport returns a round tripper creating a client span for each request
+// and sending the trace context in the traceparent header. A nil base uses
+// http.DefaultTransport. The span ends when the response headers arrive.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base}
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	attrs := []attribute.KeyValue{
+		semconv.HTTPRequestMethodKey.String(req.Method),
+		// The query is left out, as it may hold credentials
+		semconv.URLFull(req.URL.Scheme + "://" + req.URL.Host + req.URL.Path),
+		semconv.ServerAddress(req.URL.Hostname()),
+	}
+	if port := serverPort(req); port != 0 {
+		attrs = append(attrs, semconv.ServerPort(port))
+	}
+	ctx, span := tracer().Start(req.Context(), req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	defer span.End()
+
+	// A round tripper must not modify the caller's request
+	req = req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+	return resp, nil
+}
+
+// serverPort returns the port of the request URL, defaulting by scheme
+func serverPort(req *http.Request) int {
+	if port, err := strconv.Atoi(req.URL.Port()); err == nil {
+		return port
+	}
+	switch req.URL.Scheme {
+	case "https":
+		return 443
+	case "http":
+		return 80
+	}
+	return 0
+}
+
+// clientAddress strips the port from a remote address
+func clientAddress(remoteAddr string) string {
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return host
+	}
+	return remoteAddr
+}
+
+// statusRecorder remembers the status code written by the handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,77 @@
+// Package tracing sets up OpenTelemetry tracing: server spans for incoming
+// requests, client spans for outbound HTTP calls and database queries, and
+// W3C trace context propagation. Spans are created with the global tracer
+// provider, so they cost next to nothing while tracing is disabled.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"budhapp.com/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies the spans created by this package
+const instrumentationName = "budhapp.com/internal/tracing"
+
+// Setup installs the tracer provider selected by the configuration and
+// returns a function flushing and stopping it. The trace context propagator
+// is installed even without an exporter, so traces started upstream still
+// reach the services the API calls.
+func Setup(ctx context.Context, cfg config.TracingConfig, environment string) (func(context.Context) error, error) {
+	// Baggage isn't propagated: outbound calls go to third parties
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var (
+		exporter sdktrace.SpanExporter
+		err      error
+	)
+	switch cfg.Exporter {
+	case "":
+		return func(context.Context) error { return nil }, nil
+	case "otlp":
+		exporter, err = otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter: %s", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %w", cfg.Exporter, err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceName(cfg.ServiceName),
+			semconv.DeploymentEnvironmentName(environment),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// tracer returns the tracer of the global provider, looked up on each use
+// so spans follow the provider installed by Setup
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// TraceID returns the trace ID of the span in the context, or an empty
+// string, for correlating logs with traces
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
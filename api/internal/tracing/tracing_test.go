@@ -0,0 +1,154 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// record installs a provider recording spans for the test
+func record(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+	return recorder
+}
+
+func attr(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestQueryName(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{"-- name: GetUserByID :one\nSELECT id FROM \"user\" WHERE id = $1", "GetUserByID"},
+		{"\n-- name: DeleteExpiredSessions :exec\nDELETE FROM session", "DeleteExpiredSessions"},
+		{"select 1", "SELECT"},
+		{"  ", "query"},
+	}
+	for _, tt := range tests {
+		if got := queryName(tt.sql); got != tt.want {
+			t.Errorf("queryName(%q) = %q, want %q", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestMiddlewareRoute(t *testing.T) {
+	recorder := record(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /things/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	mux.HandleFunc("/any", func(w http.ResponseWriter, r *http.Request) {})
+	// A middleware between Middleware and the mux copies the request
+	type key struct{}
+	copying := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), key{}, true)))
+		})
+	}
+	handler := Middleware(copying(Route(mux)))
+
+	parent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	req := httptest.NewRequest(http.MethodGet, "/things/1", nil)
+	req.Header.Set("traceparent", parent)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/any", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nothing", nil))
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want 3", len(spans))
+	}
+
+	span := spans[0]
+	if span.Name() != "GET /things/{id}" || span.SpanKind() != trace.SpanKindServer {
+		t.Errorf("span = %q (%s), want the server span of the pattern", span.Name(), span.SpanKind())
+	}
+	if got := span.Parent().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" || !span.Parent().IsRemote() {
+		t.Errorf("parent trace = %s, want the incoming traceparent", got)
+	}
+	if got := attr(span, semconv.HTTPRouteKey).AsString(); got != "/things/{id}" {
+		t.Errorf("http.route = %q", got)
+	}
+	if got := attr(span, semconv.HTTPResponseStatusCodeKey).AsInt64(); got != http.StatusTeapot {
+		t.Errorf("status = %d, want 418", got)
+	}
+
+	if got := spans[1].Name(); got != "POST /any" {
+		t.Errorf("pattern without method named %q, want POST /any", got)
+	}
+	if got := spans[2].Name(); got != http.MethodGet {
+		t.Errorf("unmatched request named %q, want GET", got)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	recorder := record(t)
+
+	var traceparent string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer upstream.Close()
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, upstream.URL+"/path?token=secret", nil)
+	client := &http.Client{Transport: Transport(nil)}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	parent.End()
+
+	if req.Header.Get("traceparent") != "" {
+		t.Error("the caller's request was modified")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	span := spans[0]
+	if span.SpanKind() != trace.SpanKindClient || span.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("span = %s with parent %s, want a client span of the parent", span.SpanKind(), span.Parent().SpanID())
+	}
+	want := "00-" + span.SpanContext().TraceID().String() + "-" + span.SpanContext().SpanID().String() + "-01"
+	if traceparent != want {
+		t.Errorf("traceparent = %q, want %q", traceparent, want)
+	}
+	if got := attr(span, semconv.URLFullKey).AsString(); got != upstream.URL+"/path" {
+		t.Errorf("url.full = %q, want the URL without query", got)
+	}
+	if span.Status().Code != codes.Error {
+		t.Errorf("status = %v, want an error for 502", span.Status())
+	}
+}
@@ -0,0 +1,188 @@
+package validate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"budhapp.com/internal/apierror"
+	"budhapp.com/internal/middleware"
+)
+
+// DefaultMaxBodySize bounds request bodies of routes without their own limit
+const DefaultMaxBodySize = 1 << 20
+
+type limitContextKey struct{}
+
+// bodyContextKey holds the request body before any limit, so a route can
+// replace the default limit with its own
+type bodyContextKey struct{}
+
+// MaxBodySize returns a middleware limiting every request body to n bytes,
+// unless the route sets its own limit with LimitBody. Reading past the limit
+// fails with an *http.MaxBytesError.
+func MaxBodySize(n int64) middleware.Constructor {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), limitContextKey{}, n)
+			ctx = context.WithValue(ctx, bodyContextKey{}, r.Body)
+			r = r.WithContext(ctx)
+			r.Body = http.MaxBytesReader(w, r.Body, n)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// LimitBody returns a middleware setting the body limit of a route, for
+// routes needing more or less than the limit of MaxBodySize
+func LimitBody(n int64) middleware.Constructor {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), limitContextKey{}, n)
+			r = r.WithContext(ctx)
+			if body, ok := ctx.Value(bodyContextKey{}).(io.ReadCloser); ok {
+				r.Body = body
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, n)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// maxBodySize returns the body limit of the request
+func maxBodySize(r *http.Request) int64 {
+	if n, ok := r.Context().Value(limitContextKey{}).(int64); ok {
+		return n
+	}
+	return DefaultMaxBodySize
+}
+
+// ReadBody reads the request body up to the limit of the route, for
+// handlers needing the raw bytes, such as webhooks checking a signature.
+// A larger body is answered with 413.
+func ReadBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	limit := maxBodySize(r)
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, apierror.New(http.StatusRequestEntityTooLarge, "BODY_TOO_LARGE",
+				"Request body must not exceed "+strconv.FormatInt(limit, 10)+" bytes").With("limit", limit)
+		}
+		return nil, apierror.New(http.StatusBadRequest, "INVALID_BODY", "Failed to read request body").Wrap(err)
+	}
+	return body, nil
+}
+
+// Option changes how a body is decoded
+type Option func(*options)
+
+type options struct {
+	allowUnknownFields bool
+	allowEmpty         bool
+}
+
+// AllowUnknownFields accepts fields missing from the target type, for
+// payloads of third parties that add fields over time
+func AllowUnknownFields() Option {
+	return func(o *options) {
+		o.allowUnknownFields = true
+	}
+}
+
+// AllowEmptyBody accepts an empty body as a zero T, which is then validated,
+// for endpoints whose fields are all optional
+func AllowEmptyBody() Option {
+	return func(o *options) {
+		o.allowEmpty = true
+	}
+}
+
+// Decode reads a JSON request body into a T and validates it. Bodies over
+// the route limit, malformed JSON, unknown fields, values of the wrong type
+// and fields failing their rules are answered with apierror errors, so
+// handlers write any error as is:
+//
+//	req, err := validate.Decode[createProjectRequest](w, r)
+//	if err != nil {
+//		respondErr(w, r, err)
+//		return
+//	}
+func Decode[T any](w http.ResponseWriter, r *http.Request, opts ...Option) (T, error) {
+	body, err := ReadBody(w, r)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return Unmarshal[T](body, opts...)
+}
+
+// Unmarshal decodes and validates a JSON document, like Decode
+func Unmarshal[T any](data []byte, opts ...Option) (T, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var v T
+	if o.allowEmpty && len(bytes.TrimSpace(data)) == 0 {
+		return v, Struct(&v)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if !o.allowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(&v); err != nil {
+		return v, decodeError(err)
+	}
+	if dec.More() {
+		return v, invalidBody("Request body must be a single JSON value", nil)
+	}
+
+	if err := Struct(&v); err != nil {
+		return v, err
+	}
+	return v, nil
+}
+
+// decodeError converts a JSON decoding error, reporting the field when the
+// decoder names it
+func decodeError(err error) *apierror.Error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return Errors(FieldError{Field: typeErr.Field, Rule: "type", Message: "must be " + jsonType(typeErr.Type.Kind().String())})
+	}
+	// The decoder doesn't type this error
+	if field, ok := bytes.CutPrefix([]byte(err.Error()), []byte("json: unknown field ")); ok {
+		name, _ := strconv.Unquote(string(field))
+		return Errors(FieldError{Field: name, Rule: "unknown", Message: "is not a known field"})
+	}
+	if errors.Is(err, io.EOF) {
+		return invalidBody("Request body is empty", err)
+	}
+	return invalidBody("Request body is not valid JSON", err)
+}
+
+func invalidBody(message string, err error) *apierror.Error {
+	return apierror.New(http.StatusBadRequest, "INVALID_BODY", message).Wrap(err)
+}
+
+// jsonType names a Go kind the way JSON clients know it
+func jsonType(kind string) string {
+	switch kind {
+	case "string":
+		return "a string"
+	case "bool":
+		return "a boolean"
+	case "slice", "array":
+		return "an array"
+	case "map", "struct":
+		return "an object"
+	}
+	return "a number"
+}
@@ -0,0 +1,167 @@
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Password length bounds, the defaults of better-auth
+const (
+	MinPasswordLength = 8
+	MaxPasswordLength = 128
+)
+
+// maxEmailLength is the longest address SMTP allows
+const maxEmailLength = 254
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
+// IsSlug reports whether s is lowercase letters and digits in groups joined
+// by single hyphens, like "my-project-2"
+func IsSlug(s string) bool {
+	return slugPattern.MatchString(s)
+}
+
+// IsEmail reports whether s is a bare email address, without display name
+// or angle brackets
+func IsEmail(s string) bool {
+	if len(s) > maxEmailLength {
+		return false
+	}
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s && strings.Contains(addr.Address[strings.LastIndex(addr.Address, "@"):], ".")
+}
+
+// PasswordProblem returns why a password is too weak, or "": it must be
+// 8 to 128 characters and mix letters with digits or symbols
+func PasswordProblem(password string) string {
+	n := utf8.RuneCountInString(password)
+	if n < MinPasswordLength || n > MaxPasswordLength {
+		return fmt.Sprintf("must be between %d and %d characters", MinPasswordLength, MaxPasswordLength)
+	}
+	var letter, other bool
+	for _, r := range password {
+		if unicode.IsLetter(r) {
+			letter = true
+		} else if !unicode.IsSpace(r) {
+			other = true
+		}
+	}
+	if !letter || !other {
+		return "must contain letters and digits or symbols"
+	}
+	return ""
+}
+
+// parseRule returns the check of a rule of the validate tag
+func parseRule(name, arg string) (rule, error) {
+	switch name {
+	case "email":
+		return stringRule(func(s string) string {
+			if !IsEmail(s) {
+				return "must be a valid email address"
+			}
+			return ""
+		}), nil
+	case "slug":
+		return stringRule(func(s string) string {
+			if !IsSlug(s) {
+				return "must be lowercase letters, digits and single hyphens"
+			}
+			return ""
+		}), nil
+	case "password":
+		return stringRule(PasswordProblem), nil
+	case "uuid":
+		return stringRule(func(s string) string {
+			if _, err := uuid.Parse(s); err != nil {
+				return "must be a UUID"
+			}
+			return ""
+		}), nil
+	case "url":
+		return stringRule(func(s string) string {
+			u, err := url.Parse(s)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return "must be an http or https URL"
+			}
+			return ""
+		}), nil
+	case "oneof":
+		values := strings.Fields(arg)
+		if len(values) == 0 {
+			return nil, fmt.Errorf("oneof needs values")
+		}
+		return stringRule(func(s string) string {
+			if !slices.Contains(values, s) {
+				return "must be one of " + strings.Join(values, ", ")
+			}
+			return ""
+		}), nil
+	case "min", "max":
+		limit, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s needs a number: %w", name, err)
+		}
+		return boundRule(name == "min", limit), nil
+	}
+	return nil, fmt.Errorf("unknown rule %q", name)
+}
+
+// stringRule applies check to string values; other kinds always pass
+func stringRule(check func(s string) string) rule {
+	return func(v reflect.Value) string {
+		if v.Kind() != reflect.String {
+			return ""
+		}
+		return check(v.String())
+	}
+}
+
+// boundRule bounds the length of strings, slices and maps, counting
+// characters of strings, and the value of numbers
+func boundRule(isMin bool, limit float64) rule {
+	return func(v reflect.Value) string {
+		var n float64
+		unit := ""
+		switch v.Kind() {
+		case reflect.String:
+			n, unit = float64(utf8.RuneCountInString(v.String())), " characters"
+		case reflect.Slice, reflect.Array, reflect.Map:
+			n, unit = float64(v.Len()), " items"
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n = float64(v.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n = float64(v.Uint())
+		case reflect.Float32, reflect.Float64:
+			n = v.Float()
+		default:
+			return ""
+		}
+
+		bound := strconv.FormatFloat(limit, 'f', -1, 64)
+		switch {
+		case isMin && n < limit:
+			if unit != "" {
+				return "must have at least " + bound + unit
+			}
+			return "must be at least " + bound
+		case !isMin && n > limit:
+			if unit != "" {
+				return "must have at most " + bound + unit
+			}
+			return "must be at most " + bound
+		}
+		return ""
+	}
+}
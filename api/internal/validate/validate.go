@@ -0,0 +1,190 @@
+// Package validate decodes request bodies and checks them against rules in
+// struct tags, reporting every invalid field at once in the standard error
+// response.
+//
+// Rules are listed in the validate tag, separated by commas:
+//
+//	type createProjectRequest struct {
+//		Name  string  `json:"name" validate:"required,max=255"`
+//		Slug  string  `json:"slug" validate:"required,slug"`
+//		Email *string `json:"email" validate:"email"`
+//	}
+//
+// Rules other than required are skipped for nil pointers and empty strings,
+// so optional fields are only checked when present. Nested structs and
+// slices of structs are validated too; their fields are reported as
+// "owner.email" or "members[2].role".
+package validate
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"budhapp.com/internal/apierror"
+)
+
+// CodeValidation is the error code of requests failing validation
+const CodeValidation = "VALIDATION_ERROR"
+
+// FieldError is an invalid field of a request
+type FieldError struct {
+	// Field is the JSON path of the field, such as "members[2].role"
+	Field string `json:"field"`
+	// Rule is the failed rule, such as "required" or "max"
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Errors returns the error answering a request with invalid fields, with
+// the fields in the "fields" details
+func Errors(fields ...FieldError) *apierror.Error {
+	return apierror.New(http.StatusBadRequest, CodeValidation, "Invalid request body").With("fields", fields)
+}
+
+// rule checks a non-empty value; it returns the message of a failure, or ""
+type rule func(v reflect.Value) string
+
+type fieldRule struct {
+	name  string
+	check rule
+}
+
+type field struct {
+	index    int
+	name     string
+	required bool
+	rules    []fieldRule
+}
+
+// fields caches the parsed rules of struct types
+var fields sync.Map
+
+// Struct checks the validate tags of a struct, or a pointer to one. It
+// returns nil or the error listing the invalid fields. Malformed tags panic,
+// as they are programming errors.
+func Struct(v any) error {
+	errs := check(reflect.ValueOf(v), "", nil)
+	if len(errs) > 0 {
+		return Errors(errs...)
+	}
+	return nil
+}
+
+func check(v reflect.Value, prefix string, errs []FieldError) []FieldError {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return errs
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		for _, f := range typeFields(v.Type()) {
+			errs = checkField(v.Field(f.index), prefix+f.name, f, errs)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			errs = check(v.Index(i), strings.TrimSuffix(prefix, ".")+"["+strconv.Itoa(i)+"].", errs)
+		}
+	}
+	return errs
+}
+
+func checkField(v reflect.Value, path string, f field, errs []FieldError) []FieldError {
+	if isEmpty(v) {
+		if f.required {
+			errs = append(errs, FieldError{Field: path, Rule: "required", Message: "is required"})
+		}
+		return errs
+	}
+
+	elem := v
+	for elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+	for _, r := range f.rules {
+		if msg := r.check(elem); msg != "" {
+			errs = append(errs, FieldError{Field: path, Rule: r.name, Message: msg})
+		}
+	}
+
+	switch elem.Kind() {
+	case reflect.Struct:
+		errs = check(elem, path+".", errs)
+	case reflect.Slice, reflect.Array:
+		if t := elem.Type().Elem(); t.Kind() == reflect.Struct || (t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Struct) {
+			for i := range elem.Len() {
+				errs = check(elem.Index(i), path+"["+strconv.Itoa(i)+"].", errs)
+			}
+		}
+	}
+	return errs
+}
+
+// isEmpty reports whether a value counts as missing: nil, zero or a blank
+// string
+func isEmpty(v reflect.Value) bool {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return true
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(v.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	case reflect.Struct:
+		return false
+	}
+	return v.IsZero()
+}
+
+// typeFields returns the rules of the exported fields of a struct type
+func typeFields(t reflect.Type) []field {
+	if cached, ok := fields.Load(t); ok {
+		return cached.([]field)
+	}
+
+	var result []field
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+
+		f := field{index: i, name: name}
+		tag := sf.Tag.Get("validate")
+		if tag != "" {
+			for _, spec := range strings.Split(tag, ",") {
+				if spec == "required" {
+					f.required = true
+					continue
+				}
+				ruleName, arg, _ := strings.Cut(spec, "=")
+				check, err := parseRule(ruleName, arg)
+				if err != nil {
+					panic(fmt.Sprintf("validate: %s.%s: %v", t.Name(), sf.Name, err))
+				}
+				f.rules = append(f.rules, fieldRule{name: ruleName, check: check})
+			}
+		}
+		result = append(result, f)
+	}
+
+	fields.Store(t, result)
+	return result
+}
@@ -0,0 +1,215 @@
+package validate
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"budhapp.com/internal/apierror"
+)
+
+type member struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"oneof=owner admin member"`
+}
+
+type createRequest struct {
+	Name     string   `json:"name" validate:"required,max=8"`
+	Slug     *string  `json:"slug" validate:"slug"`
+	Password string   `json:"password" validate:"password"`
+	Seats    int      `json:"seats" validate:"min=1,max=10"`
+	Owner    member   `json:"owner"`
+	Members  []member `json:"members"`
+	Ignored  string   `json:"-"`
+}
+
+// fieldErrors returns the field errors of a validation error
+func fieldErrors(t *testing.T, err error) []FieldError {
+	t.Helper()
+	var e *apierror.Error
+	if !errors.As(err, &e) || e.Code != CodeValidation || e.Status != http.StatusBadRequest {
+		t.Fatalf("err = %v, want a validation error", err)
+	}
+	fields, _ := e.Details["fields"].([]FieldError)
+	return fields
+}
+
+func TestStruct(t *testing.T) {
+	slug := "Not A Slug"
+	req := createRequest{
+		Name:     "too long name",
+		Slug:     &slug,
+		Password: "letters",
+		Seats:    11,
+		Owner:    member{Email: "nope"},
+		Members:  []member{{Email: "a@example.com", Role: "owner"}, {Email: "b@example.com", Role: "guest"}},
+	}
+
+	got := fieldErrors(t, Struct(&req))
+	var paths []string
+	for _, f := range got {
+		paths = append(paths, f.Field+":"+f.Rule)
+	}
+	want := []string{"name:max", "slug:slug", "password:password", "seats:max", "owner.email:email", "members[1].role:oneof"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("fields = %v, want %v", paths, want)
+	}
+}
+
+func TestStructOptional(t *testing.T) {
+	// Only required fields must be present; the others are checked when set
+	req := createRequest{Name: "ok", Owner: member{Email: "owner@example.com"}, Seats: 1}
+	if err := Struct(&req); err != nil {
+		t.Errorf("Struct = %v, want nil", err)
+	}
+
+	got := fieldErrors(t, Struct(&createRequest{Name: "  ", Seats: 1}))
+	if len(got) != 2 || got[0].Field != "name" || got[1].Field != "owner.email" || got[0].Rule != "required" {
+		t.Errorf("fields = %+v, want name and owner.email required", got)
+	}
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name string
+		ok   func(string) bool
+		in   string
+		want bool
+	}{
+		{"email", IsEmail, "user@example.com", true},
+		{"email with name", IsEmail, "User <user@example.com>", false},
+		{"email without dot", IsEmail, "user@localhost", false},
+		{"email too long", IsEmail, strings.Repeat("a", 250) + "@example.com", false},
+		{"slug", IsSlug, "my-project-2", true},
+		{"slug uppercase", IsSlug, "My-Project", false},
+		{"slug double dash", IsSlug, "my--project", false},
+		{"slug trailing dash", IsSlug, "project-", false},
+		{"password", func(s string) bool { return PasswordProblem(s) == "" }, "correct horse 1", true},
+		{"password short", func(s string) bool { return PasswordProblem(s) == "" }, "ab1", false},
+		{"password letters only", func(s string) bool { return PasswordProblem(s) == "" }, "lettersonly", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.ok(tt.in); got != tt.want {
+				t.Errorf("%q = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+type webhook struct {
+	Type string `json:"type" validate:"required"`
+	Data struct {
+		ID int `json:"id"`
+	} `json:"data"`
+}
+
+func TestUnmarshal(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		opts   []Option
+		code   string
+		fields []string
+	}{
+		{"valid", `{"type":"order.paid","data":{"id":1}}`, nil, "", nil},
+		{"unknown field", `{"type":"order.paid","extra":true}`, nil, CodeValidation, []string{"extra:unknown"}},
+		{"unknown field allowed", `{"type":"order.paid","extra":true}`, []Option{AllowUnknownFields()}, "", nil},
+		{"wrong type", `{"type":"order.paid","data":{"id":"1"}}`, nil, CodeValidation, []string{"data.id:type"}},
+		{"missing required", `{"data":{"id":1}}`, nil, CodeValidation, []string{"type:required"}},
+		{"empty", ``, nil, "INVALID_BODY", nil},
+		{"malformed", `{"type":`, nil, "INVALID_BODY", nil},
+		{"trailing value", `{"type":"a"} {"type":"b"}`, nil, "INVALID_BODY", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Unmarshal[webhook]([]byte(tt.body), tt.opts...)
+			if tt.code == "" {
+				if err != nil {
+					t.Fatalf("Unmarshal = %v, want nil", err)
+				}
+				return
+			}
+			var e *apierror.Error
+			if !errors.As(err, &e) || e.Code != tt.code || e.Status != http.StatusBadRequest {
+				t.Fatalf("err = %v, want %s", err, tt.code)
+			}
+			if tt.fields == nil {
+				return
+			}
+			if got := fieldNames(t, err); !reflect.DeepEqual(got, tt.fields) {
+				t.Errorf("fields = %v, want %v", got, tt.fields)
+			}
+		})
+	}
+}
+
+func TestDecodeLimit(t *testing.T) {
+	var decodeErr error
+	handler := LimitBody(16)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, decodeErr = Decode[webhook](w, r)
+	}))
+
+	body := `{"type":"` + strings.Repeat("a", 32) + `"}`
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	var e *apierror.Error
+	if !errors.As(decodeErr, &e) || e.Status != http.StatusRequestEntityTooLarge || e.Details["limit"] != int64(16) {
+		t.Fatalf("err = %v, want 413 with the limit", decodeErr)
+	}
+
+	// Without a route limit the default applies
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	if _, err := Decode[webhook](rec, req); err != nil {
+		t.Errorf("Decode = %v, want nil under the default limit", err)
+	}
+}
+
+func TestMaxBodySize(t *testing.T) {
+	var decodeErr error
+	decode := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, decodeErr = Decode[webhook](w, r)
+	})
+	body := `{"type":"` + strings.Repeat("a", 32) + `"}`
+
+	MaxBodySize(16)(decode).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	var e *apierror.Error
+	if !errors.As(decodeErr, &e) || e.Status != http.StatusRequestEntityTooLarge || e.Details["limit"] != int64(16) {
+		t.Fatalf("err = %v, want 413 with the global limit", decodeErr)
+	}
+
+	// A route limit replaces the global one, even when it is larger
+	MaxBodySize(16)(LimitBody(64)(decode)).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	if decodeErr != nil {
+		t.Errorf("Decode = %v, want nil under the route limit", decodeErr)
+	}
+}
+
+func TestAllowEmptyBody(t *testing.T) {
+	if _, err := Unmarshal[webhook](nil, AllowEmptyBody()); !reflect.DeepEqual(fieldNames(t, err), []string{"type:required"}) {
+		t.Errorf("err = %v, want the empty body validated", err)
+	}
+
+	type optional struct {
+		CallbackURL string `json:"callbackURL" validate:"url"`
+	}
+	if _, err := Unmarshal[optional]([]byte(" \n"), AllowEmptyBody()); err != nil {
+		t.Errorf("Unmarshal = %v, want nil for an empty body", err)
+	}
+	if _, err := Unmarshal[optional]([]byte(`{"callbackURL":"ftp://x"}`), AllowEmptyBody()); !reflect.DeepEqual(fieldNames(t, err), []string{"callbackURL:url"}) {
+		t.Errorf("err = %v, want a non-empty body validated", err)
+	}
+}
+
+// fieldNames returns the field and rule of each field error of err
+func fieldNames(t *testing.T, err error) []string {
+	t.Helper()
+	var got []string
+	for _, f := range fieldErrors(t, err) {
+		got = append(got, f.Field+":"+f.Rule)
+	}
+	return got
+}
@@ -12,6 +12,23 @@ const EXCLUDED_RESPONSE_HEADERS = new Set([
     'content-encoding'
 ]);
 
+// W3C trace context: version 00, 32 hex trace id, 16 hex parent id, flags
+const TRACEPARENT = /^00-(?!0{32})[0-9a-f]{32}-(?!0{16})[0-9a-f]{16}-[0-9a-f]{2}$/;
+
+function randomHex(bytes: number): string {
+    return Array.from(crypto.getRandomValues(new Uint8Array(bytes)), (b) => b.toString(16).padStart(2, '0')).join('');
+}
+
+// Continue the caller's trace, or start a sampled one so the Go API spans of
+// this request share a trace id with the proxy logs
+function traceparent(request: Request): string {
+    const incoming = request.headers.get('traceparent')?.trim().toLowerCase();
+    if (incoming && TRACEPARENT.test(incoming)) {
+        return incoming;
+    }
+    return `00-${randomHex(16)}-${randomHex(8)}-01`;
+}
+
 async function handleRequest(event: Parameters<RequestHandler>[0]): Promise<Response> {
     const { request, params } = event;
 
@@ -25,6 +42,8 @@ async function handleRequest(event: Parameters<RequestHandler>[0]): Promise<Resp
         body = await request.text();
     }
 
+    const trace = traceparent(request);
+
     try {
         // Get JWT token from better-auth
         const { token } = await auth.api.getToken({
@@ -36,12 +55,26 @@ async function handleRequest(event: Parameters<RequestHandler>[0]): Promise<Resp
 
         // Forward relevant headers from the original request
         for (const [key, value] of request.headers.entries()) {
-            // Skip hop-by-hop headers and host
-            if (!['host', 'connection', 'keep-alive', 'transfer-encoding'].includes(key.toLowerCase())) {
+            // Skip hop-by-hop headers and host, and the trace context set below
+            if (!['host', 'connection', 'keep-alive', 'transfer-encoding', 'traceparent', 'tracestate'].includes(key.toLowerCase())) {
                 headers.set(key, value);
             }
         }
 
+        // Name the client for the API, which trusts this header from the
+        // proxies in TRUSTED_PROXIES only
+        const forwardedFor = request.headers.get('x-forwarded-for');
+        const clientAddress = event.getClientAddress();
+        headers.set('x-forwarded-for', forwardedFor ? `${forwardedFor}, ${clientAddress}` : clientAddress);
+
+        // Propagate the trace context; tracestate only belongs to the
+        // caller's trace
+        headers.set('traceparent', trace);
+        const tracestate = request.headers.get('tracestate');
+        if (tracestate && trace === request.headers.get('traceparent')?.trim().toLowerCase()) {
+            headers.set('tracestate', tracestate);
+        }
+
         // Set the Authorization header with JWT token
         if (token) {
             headers.set('Authorization', `Bearer ${token}`);
@@ -69,10 +102,13 @@ async function handleRequest(event: Parameters<RequestHandler>[0]): Promise<Resp
             headers: responseHeaders
         });
     } catch (error) {
-        console.error('Proxy error:', error);
+        console.error('Proxy error:', { traceId: trace.split('-')[1], error });
+        // Same shape as the errors of the Go API
         return new Response(JSON.stringify({
-            error: 'Bad Gateway',
-            message: 'Failed to proxy request to Go API'
+            error: {
+                code: 'BAD_GATEWAY',
+                message: 'Failed to proxy request to Go API'
+            }
         }), {
             status: 502,
             headers: { 'Content-Type': 'application/json' }